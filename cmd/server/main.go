@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
@@ -9,12 +10,14 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	mcp "github.com/daltoniam/switchboard"
 	"github.com/daltoniam/switchboard/browser"
+	"github.com/daltoniam/switchboard/compact"
 	"github.com/daltoniam/switchboard/config"
 	"github.com/daltoniam/switchboard/daemon"
 	acpInt "github.com/daltoniam/switchboard/integrations/acp"
@@ -69,6 +72,8 @@ import (
 	"github.com/daltoniam/switchboard/project"
 	"github.com/daltoniam/switchboard/registry"
 	"github.com/daltoniam/switchboard/server"
+	"github.com/daltoniam/switchboard/telemetry"
+	"github.com/daltoniam/switchboard/tokenizer"
 	"github.com/daltoniam/switchboard/version"
 	wasmmod "github.com/daltoniam/switchboard/wasm"
 	"github.com/daltoniam/switchboard/web"
@@ -79,6 +84,14 @@ func main() {
 		handleDaemon(os.Args[2:])
 		return
 	}
+	if len(os.Args) > 1 && os.Args[1] == "project" {
+		handleProject(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "compact" {
+		handleCompact(os.Args[2:])
+		return
+	}
 
 	stdioMode := flag.Bool("stdio", false, "Run MCP server over stdio transport (default is HTTP)")
 	port := flag.Int("port", 3847, "Port for the HTTP server")
@@ -197,6 +210,190 @@ Options:
 	}
 }
 
+func handleProject(args []string) {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, `Usage: switchboard project <command> [options]
+
+Commands:
+  init        Scaffold a project definition from a template
+  templates   List the built-in templates
+
+Run 'switchboard project init -h' for init options.
+`)
+	}
+	if len(args) == 0 {
+		usage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "templates":
+		for _, t := range project.Templates() {
+			fmt.Printf("%-18s %s\n", t.Name, t.Description)
+		}
+	case "init":
+		projectInit(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown project command: %s\n", args[0])
+		usage()
+		os.Exit(1)
+	}
+}
+
+func projectInit(args []string) {
+	fs := flag.NewFlagSet("project init", flag.ExitOnError)
+	tmplName := fs.String("template", "backend-service", "Template to start from (see 'switchboard project templates')")
+	name := fs.String("name", "", "Project name (default: the repository directory name)")
+	dir := fs.String("dir", ".", "Directory inside the project's git repository")
+	dryRun := fs.Bool("dry-run", false, "Print the definition without writing it")
+	_ = fs.Parse(args)
+
+	tmpl, ok := project.FindTemplate(*tmplName)
+	if !ok {
+		log.Fatalf("Unknown template %q (run 'switchboard project templates')", *tmplName)
+	}
+
+	opts := project.ScaffoldOptions{Name: *name}
+	repo, err := project.DetectRepo(*dir)
+	if err != nil {
+		log.Printf("WARN: %v; repo, branch and github defaults left empty", err)
+	} else {
+		opts.Repo = repo
+	}
+	if cfgMgr, err := config.NewManager(); err != nil {
+		log.Printf("WARN: failed to load config, proposing all template integrations: %v", err)
+	} else {
+		opts.Enabled = cfgMgr.EnabledIntegrations()
+	}
+
+	def, err := project.Scaffold(tmpl, opts)
+	if err != nil {
+		log.Fatalf("Scaffold failed: %v", err)
+	}
+
+	data, err := json.MarshalIndent(def, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode project: %v", err)
+	}
+	if *dryRun {
+		fmt.Println(string(data))
+		return
+	}
+
+	configDir := project.DefaultConfigDir()
+	store := project.NewStore(configDir)
+	if err := store.Load(); err != nil {
+		log.Fatalf("Failed to load project definitions: %v", err)
+	}
+	if err := store.Create(def); err != nil {
+		log.Fatalf("Create failed: %v", err)
+	}
+	fmt.Println(string(data))
+	fmt.Printf("\nCreated %s\n", filepath.Join(configDir, "projects", def.Name+".project.json"))
+	fmt.Printf("Connect agents to http://localhost:3847/mcp/%s\n", def.Name)
+}
+
+func handleCompact(args []string) {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, `Usage: switchboard compact <command> [options]
+
+Commands:
+  suggest <tool>   Suggest a compaction spec from recorded responses
+
+Record responses by running the server with %s=<dir>.
+Run 'switchboard compact suggest -h' for suggest options.
+`, compact.EnvRecordDir)
+	}
+	if len(args) == 0 {
+		usage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "suggest":
+		compactSuggest(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown compact command: %s\n", args[0])
+		usage()
+		os.Exit(1)
+	}
+}
+
+func compactSuggest(args []string) {
+	fs := flag.NewFlagSet("compact suggest", flag.ExitOnError)
+	samplesDir := fs.String("samples", os.Getenv(compact.EnvRecordDir), "Directory of recorded responses (default $"+compact.EnvRecordDir+")")
+	write := fs.Bool("write", false, "Merge the spec into the overlay directory instead of only printing it")
+	overlayDir := fs.String("dir", os.Getenv(compact.EnvOverrideDir), "Overlay directory to write to (default $"+compact.EnvOverrideDir+")")
+	adapter := fs.String("adapter", "", "Overlay file name (default: the tool name up to its first underscore)")
+	maxDepth := fs.Int("max-depth", 0, "Drop fields nested deeper than this many keys (default 4)")
+	truncateAbove := fs.Int("truncate-above", 0, "Truncate string fields averaging more characters than this (default 1000)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: switchboard compact suggest <tool> [options]\n\n")
+		fs.PrintDefaults()
+	}
+
+	// Accept the tool before or after the flags.
+	var tool string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		tool, args = args[0], args[1:]
+	}
+	_ = fs.Parse(args)
+	if tool == "" {
+		tool = fs.Arg(0)
+	}
+	if tool == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *samplesDir == "" {
+		log.Fatalf("No samples directory: run the server with %s=<dir> to record responses, then pass -samples <dir>", compact.EnvRecordDir)
+	}
+
+	samples, err := compact.LoadSamples(*samplesDir, mcp.ToolName(tool))
+	if err != nil {
+		log.Fatalf("Failed to load samples: %v", err)
+	}
+	sug, err := compact.Suggest(mcp.ToolName(tool), samples, compact.SuggestOptions{MaxDepth: *maxDepth, TruncateAbove: *truncateAbove})
+	if err != nil {
+		log.Fatalf("Suggest failed: %v", err)
+	}
+
+	fmt.Printf("Profiled %d responses for %s:\n\n", sug.Samples, tool)
+	for _, f := range sug.Fields {
+		status := "keep"
+		switch {
+		case f.Spec == "":
+			status = "drop: " + f.Reason
+		case f.Reason != "":
+			status = "keep (" + f.Reason + ")"
+		}
+		fmt.Printf("  %-40s %3d/%-3d %8d bytes  %s\n", f.Path, f.Present, sug.Samples, f.Bytes, status)
+	}
+	fmt.Printf("\nProjected: %d → %d bytes (%d%% smaller)\n\n", sug.BytesBefore, sug.BytesAfter, sug.SavingsPct())
+
+	out, err := sug.YAML()
+	if err != nil {
+		log.Fatalf("Failed to encode spec: %v", err)
+	}
+	if !*write {
+		fmt.Print(string(out))
+		return
+	}
+
+	if *overlayDir == "" {
+		log.Fatalf("No overlay directory: set %s or pass -dir", compact.EnvOverrideDir)
+	}
+	name := *adapter
+	if name == "" {
+		name, _, _ = strings.Cut(tool, "_")
+	}
+	path, err := compact.WriteOverlay(*overlayDir, name, sug)
+	if err != nil {
+		log.Fatalf("Write failed: %v", err)
+	}
+	fmt.Printf("Wrote %s spec to %s\n", tool, path)
+}
+
 func runServer(stdioMode bool, port int, discoverAll bool) {
 	cfgMgr, err := config.NewManager()
 	if err != nil {
@@ -307,8 +504,114 @@ func runServer(stdioMode bool, port int, discoverAll bool) {
 		}
 	}()
 
-	// Create WASM runtime and loader (always — needed for live-reload from web UI).
 	cfg := cfgMgr.Get()
+	services.Metrics.SetHistoryRetention(time.Duration(cfg.MetricsHistoryDays) * 24 * time.Hour)
+
+	shutdownTracing, err := telemetry.SetupTracing(ctx, cfg.Telemetry, version.String())
+	if err != nil {
+		log.Printf("WARN: tracing disabled: %v", err)
+	} else {
+		defer func() {
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(flushCtx); err != nil {
+				log.Printf("WARN: failed to flush traces: %v", err)
+			}
+		}()
+	}
+	// Vocabularies take a moment to load; measure once they are ready.
+	go func() {
+		if err := tokenizer.Configure(services.Metrics, cfg.Tokenizer); err != nil {
+			log.Printf("WARN: token measurement disabled: %v", err)
+		}
+	}()
+	var prom *telemetry.Prometheus
+	if cfg.Telemetry != nil && cfg.Telemetry.Prometheus {
+		prom = telemetry.NewPrometheus()
+		services.Metrics.SetObserver(prom)
+	}
+
+	// Initialize plugin marketplace.
+	var mpCfg marketplace.Config
+	if cfg.Marketplace != nil {
+		mpCfg = marketplace.Config{
+			AutoUpdate:    cfg.Marketplace.AutoUpdate,
+			CheckInterval: cfg.Marketplace.CheckInterval,
+			PluginDir:     cfg.Marketplace.PluginDir,
+			LastCheck:     cfg.Marketplace.LastCheck,
+		}
+		for _, src := range cfg.Marketplace.ManifestSources {
+			mpCfg.ManifestSources = append(mpCfg.ManifestSources, marketplace.ManifestSource{
+				URL:        src.URL,
+				Name:       src.Name,
+				Enabled:    src.Enabled,
+				PublicKeys: src.PublicKeys,
+			})
+		}
+		for _, ip := range cfg.Marketplace.InstalledPlugins {
+			mpCfg.InstalledPlugins = append(mpCfg.InstalledPlugins, marketplace.InstalledPlugin{
+				Name:          ip.Name,
+				Version:       ip.Version,
+				ManifestURL:   ip.ManifestURL,
+				InstalledAt:   ip.InstalledAt,
+				Path:          ip.Path,
+				SHA256:        ip.SHA256,
+				AutoUpdate:    ip.AutoUpdate,
+				LatestVersion: ip.LatestVersion,
+
+				ApprovedCapabilities: ip.ApprovedCapabilities,
+				PendingCapabilities:  ip.PendingCapabilities,
+				NeedsApproval:        ip.NeedsApproval,
+				Trust:                ip.Trust,
+				TrustOverride:        ip.TrustOverride,
+			})
+		}
+	}
+	mp := marketplace.NewManager(mpCfg, "", func(c marketplace.Config) error {
+		mc := &mcp.MarketplaceConfig{
+			AutoUpdate:    c.AutoUpdate,
+			CheckInterval: c.CheckInterval,
+			PluginDir:     c.PluginDir,
+			LastCheck:     c.LastCheck,
+		}
+		for _, src := range c.ManifestSources {
+			mc.ManifestSources = append(mc.ManifestSources, mcp.MarketplaceManifestSource{
+				URL:        src.URL,
+				Name:       src.Name,
+				Enabled:    src.Enabled,
+				PublicKeys: src.PublicKeys,
+			})
+		}
+		for _, ip := range c.InstalledPlugins {
+			mc.InstalledPlugins = append(mc.InstalledPlugins, mcp.MarketplaceInstalledPlugin{
+				Name:          ip.Name,
+				Version:       ip.Version,
+				ManifestURL:   ip.ManifestURL,
+				InstalledAt:   ip.InstalledAt,
+				Path:          ip.Path,
+				SHA256:        ip.SHA256,
+				AutoUpdate:    ip.AutoUpdate,
+				LatestVersion: ip.LatestVersion,
+
+				ApprovedCapabilities: ip.ApprovedCapabilities,
+				PendingCapabilities:  ip.PendingCapabilities,
+				NeedsApproval:        ip.NeedsApproval,
+				Trust:                ip.Trust,
+				TrustOverride:        ip.TrustOverride,
+			})
+		}
+		cfgNow := cfgMgr.Get()
+		cfgNow.Marketplace = mc
+		return cfgMgr.Update(cfgNow)
+	}, marketplace.WithTokenFunc(marketplace.GitHubTokenFunc(func() string {
+		ic, ok := cfgMgr.GetIntegration("github")
+		if !ok || ic == nil {
+			return ""
+		}
+		return ic.Credentials["token"]
+	})))
+
+	// Create WASM runtime and loader (always — needed for live-reload from web UI).
 	wasmCtx := context.Background()
 	wasmRT, err := wasmmod.NewRuntime(wasmCtx)
 	if err != nil {
@@ -318,21 +621,28 @@ func runServer(stdioMode bool, port int, discoverAll bool) {
 	wasmLoader := wasmmod.NewLoader(wasmRT, reg, cfgMgr)
 
 	// Load WASM modules from config + marketplace installed plugins.
+	// Operator-configured modules are trusted; marketplace plugins load only
+	// once the capabilities their binary requests have been approved.
 	allWasmModules := make([]mcp.WasmModuleConfig, len(cfg.WasmModules))
 	copy(allWasmModules, cfg.WasmModules)
-	if cfg.Marketplace != nil {
-		seen := make(map[string]bool)
-		for _, wm := range allWasmModules {
-			seen[wm.Path] = true
-		}
-		for _, ip := range cfg.Marketplace.InstalledPlugins {
-			if ip.Path != "" && !seen[ip.Path] {
-				allWasmModules = append(allWasmModules, mcp.WasmModuleConfig{Path: ip.Path})
-				seen[ip.Path] = true
-			}
+	marketplaceByPath := make(map[string]string)
+	seen := make(map[string]bool)
+	for _, wm := range allWasmModules {
+		seen[wm.Path] = true
+	}
+	for _, ip := range mp.InstalledPlugins() {
+		if ip.Path != "" && !seen[ip.Path] {
+			allWasmModules = append(allWasmModules, mcp.WasmModuleConfig{Path: ip.Path})
+			marketplaceByPath[ip.Path] = ip.Name
+			seen[ip.Path] = true
 		}
 	}
 	for _, wc := range allWasmModules {
+		if name, ok := marketplaceByPath[wc.Path]; ok {
+			if !capabilitiesApproved(wasmCtx, wasmLoader, mp, name, wc.Path) {
+				continue
+			}
+		}
 		if err := wasmLoader.LoadPlugin(wasmCtx, wc.Path, wc.Name); err != nil {
 			log.Printf("WARN: %v", err)
 		}
@@ -355,10 +665,18 @@ func runServer(stdioMode bool, port int, discoverAll bool) {
 	if discoverAll {
 		serverOpts = append(serverOpts, server.WithDiscoverAll(true))
 	}
-	if cfg.SessionStore == "file" {
+	switch cfg.SessionStore {
+	case "file":
 		serverOpts = append(serverOpts, server.WithSessionStore(
 			server.NewFileSessionStore(server.DefaultSessionDir(), server.DefaultSessionTTL),
 		))
+	case "sqlite":
+		store, err := server.NewSQLiteSessionStore(server.DefaultSessionDBPath(), server.DefaultSessionTTL)
+		if err != nil {
+			log.Fatalf("Failed to open session database: %v", err)
+		}
+		defer func() { _ = store.Close() }()
+		serverOpts = append(serverOpts, server.WithSessionStore(store))
 	}
 	srv := server.New(services, serverOpts...)
 
@@ -384,85 +702,22 @@ func runServer(stdioMode bool, port int, discoverAll bool) {
 		log.Printf("Loaded %d project(s): %v", len(names), names)
 	}
 
-	projectRouter := server.NewProjectRouter(services, projectStore, "", srv.SearchIndex())
+	projectRouter := server.NewProjectRouter(srv, projectStore, "")
 
 	mux := http.NewServeMux()
 
 	mux.Handle("/mcp", srv.Handler())
 	mux.Handle("/mcp/{project}", projectRouter.Handler())
-
-	// Initialize plugin marketplace.
-	var mpCfg marketplace.Config
-	if cfg.Marketplace != nil {
-		mpCfg = marketplace.Config{
-			AutoUpdate:    cfg.Marketplace.AutoUpdate,
-			CheckInterval: cfg.Marketplace.CheckInterval,
-			PluginDir:     cfg.Marketplace.PluginDir,
-			LastCheck:     cfg.Marketplace.LastCheck,
-		}
-		for _, src := range cfg.Marketplace.ManifestSources {
-			mpCfg.ManifestSources = append(mpCfg.ManifestSources, marketplace.ManifestSource{
-				URL:     src.URL,
-				Name:    src.Name,
-				Enabled: src.Enabled,
-			})
-		}
-		for _, ip := range cfg.Marketplace.InstalledPlugins {
-			mpCfg.InstalledPlugins = append(mpCfg.InstalledPlugins, marketplace.InstalledPlugin{
-				Name:          ip.Name,
-				Version:       ip.Version,
-				ManifestURL:   ip.ManifestURL,
-				InstalledAt:   ip.InstalledAt,
-				Path:          ip.Path,
-				SHA256:        ip.SHA256,
-				AutoUpdate:    ip.AutoUpdate,
-				LatestVersion: ip.LatestVersion,
-			})
-		}
+	if prom != nil {
+		mux.Handle("/metrics", prom.Handler())
 	}
-	mp := marketplace.NewManager(mpCfg, "", func(c marketplace.Config) error {
-		mc := &mcp.MarketplaceConfig{
-			AutoUpdate:    c.AutoUpdate,
-			CheckInterval: c.CheckInterval,
-			PluginDir:     c.PluginDir,
-			LastCheck:     c.LastCheck,
-		}
-		for _, src := range c.ManifestSources {
-			mc.ManifestSources = append(mc.ManifestSources, mcp.MarketplaceManifestSource{
-				URL:     src.URL,
-				Name:    src.Name,
-				Enabled: src.Enabled,
-			})
-		}
-		for _, ip := range c.InstalledPlugins {
-			mc.InstalledPlugins = append(mc.InstalledPlugins, mcp.MarketplaceInstalledPlugin{
-				Name:          ip.Name,
-				Version:       ip.Version,
-				ManifestURL:   ip.ManifestURL,
-				InstalledAt:   ip.InstalledAt,
-				Path:          ip.Path,
-				SHA256:        ip.SHA256,
-				AutoUpdate:    ip.AutoUpdate,
-				LatestVersion: ip.LatestVersion,
-			})
-		}
-		cfgNow := cfgMgr.Get()
-		cfgNow.Marketplace = mc
-		return cfgMgr.Update(cfgNow)
-	}, marketplace.WithTokenFunc(marketplace.GitHubTokenFunc(func() string {
-		ic, ok := cfgMgr.GetIntegration("github")
-		if !ok || ic == nil {
-			return ""
-		}
-		return ic.Credentials["token"]
-	})))
 
 	switchboardInt.SetMarketplace(switchboardIntegration, mp)
 
 	cancelAutoUpdate := mp.StartAutoUpdateLoop(ctx)
 	defer cancelAutoUpdate()
 
-	ws := web.New(services, port, mp, wasmLoader, web.WithConfigChangeHook(srv.RefreshSearchIndex))
+	ws := web.New(services, port, mp, wasmLoader, web.WithConfigChangeHook(srv.RefreshSearchIndex), web.WithProjectStore(projectStore))
 	mux.Handle("/", ws.Handler())
 
 	addr := fmt.Sprintf(":%d", port)
@@ -470,6 +725,9 @@ func runServer(stdioMode bool, port int, discoverAll bool) {
 	fmt.Fprintf(os.Stderr, "  Web UI:  http://localhost:%d/\n", port)
 	fmt.Fprintf(os.Stderr, "  MCP:     http://localhost:%d/mcp\n", port)
 	fmt.Fprintf(os.Stderr, "  Project: http://localhost:%d/mcp/{project}\n", port)
+	if prom != nil {
+		fmt.Fprintf(os.Stderr, "  Metrics: http://localhost:%d/metrics\n", port)
+	}
 
 	httpServer := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
 	go func() {
@@ -561,3 +819,23 @@ func startMetricsFlusher(ctx context.Context, metrics *mcp.Metrics, interval tim
 		}
 	}()
 }
+
+// capabilitiesApproved inspects a marketplace plugin's binary and reports
+// whether the capabilities it requests match the approved set. Plugins
+// awaiting approval are skipped at startup and surfaced on the web UI's
+// plugin page instead.
+func capabilitiesApproved(ctx context.Context, loader *wasmmod.Loader, mp *marketplace.Manager, name, path string) bool {
+	meta, err := loader.InspectPlugin(ctx, path)
+	if err != nil {
+		log.Printf("WARN: %v", err)
+		return false
+	}
+	approved, err := mp.ReviewCapabilities(name, meta.CapabilityList())
+	if err != nil {
+		log.Printf("WARN: record capability review for %q: %v", name, err)
+	}
+	if !approved {
+		log.Printf("WARN: plugin %q requests capabilities that have not been approved; approve them on the Plugins page to load it", name)
+	}
+	return approved
+}
@@ -12,15 +12,16 @@ import (
 // CompactField is a parsed field compaction spec — parse once via ParseCompactSpecs,
 // then pass to CompactJSON on each request.
 type CompactField struct {
-	path        []string // e.g. ["user", "login"] or ["labels[]", "name"]
-	outputKey   string   // top-level key in the compacted output
-	arrayIdx    int      // index of the "[]" segment in path, -1 if none
-	arrayKey    string   // path[arrayIdx] without "[]", empty if arrayIdx == -1
-	childPath   []string // path[arrayIdx+1:], nil if arrayIdx == -1
-	objectRoot  string   // first path segment for non-array multi-segment specs, empty otherwise
-	exclude     bool     // true for "-field" exclusion specs
-	wildcard    bool     // true for "parent.*" wildcard specs
-	globPattern string   // non-empty for glob exclusion specs like "-*_url"
+	path        []string         // e.g. ["user", "login"] or ["labels[]", "name"]
+	outputKey   string           // top-level key in the compacted output
+	arrayIdx    int              // index of the "[]" segment in path, -1 if none
+	arrayKey    string           // path[arrayIdx] without "[]", empty if arrayIdx == -1
+	childPath   []string         // path[arrayIdx+1:], nil if arrayIdx == -1
+	objectRoot  string           // first path segment for non-array multi-segment specs, empty otherwise
+	exclude     bool             // true for "-field" exclusion specs
+	wildcard    bool             // true for "parent.*" wildcard specs
+	globPattern string           // non-empty for glob exclusion specs like "-*_url"
+	transforms  []valueTransform // "|truncate:500" etc., applied to the selected value
 }
 
 // fieldPlan holds pre-computed groupings for a set of CompactField specs.
@@ -33,6 +34,7 @@ type fieldPlan struct {
 	excludes     map[string]bool           // top-level keys to exclude (exact match)
 	globExcludes []string                  // glob patterns to exclude (e.g. "*_url")
 	hasIncludes  bool                      // true if any non-exclude specs exist
+	stats        *TransformStats           // accumulates transform results; may be nil
 }
 
 // buildFieldPlan pre-computes groupings from a slice of CompactFields.
@@ -105,6 +107,7 @@ func buildFieldPlan(fields []CompactField) *fieldPlan {
 			if err != nil {
 				continue
 			}
+			cf.transforms = f.transforms
 			childFields = append(childFields, cf)
 		}
 		plan.childPlans[root] = childFields
@@ -136,6 +139,10 @@ func (p *fieldPlan) isExcluded(key string) bool {
 //   - "-parent.child"  → exclusion spec, removes entire parent object from output
 //   - "field:alias"    → field renaming, output key = alias
 //   - "parent.*"       → wildcard, keeps entire sub-object under parent key
+//   - "field|truncate:500", "labels[]|limit:5", "created_at|date",
+//     "body|strip_html", "diff|lines:200"
+//     → value transforms, applied in order to the value the spec selects;
+//     "parent[]" with a transform selects the whole array
 func ParseCompactSpecs(specs []string) ([]CompactField, error) {
 	fields := make([]CompactField, 0, len(specs))
 	for _, s := range specs {
@@ -153,6 +160,22 @@ func parseCompactSpec(spec string) (CompactField, error) {
 		return CompactField{}, fmt.Errorf("compact spec: empty string")
 	}
 
+	// Split off value transforms ("body|truncate:500").
+	var transforms []valueTransform
+	if head, steps, ok := strings.Cut(spec, "|"); ok {
+		if strings.HasPrefix(head, "-") {
+			return CompactField{}, fmt.Errorf("compact spec: exclusion cannot use transforms %q", spec)
+		}
+		if head == "" {
+			return CompactField{}, fmt.Errorf("compact spec: transforms without a field in %q", spec)
+		}
+		ts, err := parseTransforms(spec, strings.Split(steps, "|"))
+		if err != nil {
+			return CompactField{}, err
+		}
+		transforms, spec = ts, head
+	}
+
 	// Handle exclusion specs.
 	var exclude bool
 	if strings.HasPrefix(spec, "-") {
@@ -237,8 +260,8 @@ func parseCompactSpec(spec string) (CompactField, error) {
 		if exclude {
 			return CompactField{}, fmt.Errorf("compact spec: exclusion cannot use array syntax %q", "-"+spec)
 		}
-		if i == len(parts)-1 {
-			return CompactField{}, fmt.Errorf("compact spec: %q ends with [] (need a child field)", spec)
+		if i == len(parts)-1 && len(transforms) == 0 {
+			return CompactField{}, fmt.Errorf("compact spec: %q ends with [] (need a child field or a transform)", spec)
 		}
 		if arrayIdx >= 0 {
 			continue // nested [] handled at extraction time
@@ -290,6 +313,7 @@ func parseCompactSpec(spec string) (CompactField, error) {
 		objectRoot: objectRoot,
 		exclude:    exclude,
 		wildcard:   isWildcard,
+		transforms: transforms,
 	}, nil
 }
 
@@ -299,10 +323,23 @@ func parseCompactSpec(spec string) (CompactField, error) {
 // Exclusion specs (prefixed with "-") preserve all unlisted fields.
 // Null values, empty objects, and empty strings are omitted from output.
 func CompactAny(v any, fields []CompactField) any {
+	out, _ := CompactAnyWithStats(v, fields)
+	return out
+}
+
+// CompactAnyWithStats is CompactAny that also reports what the specs' value
+// transforms changed, for compaction metrics.
+func CompactAnyWithStats(v any, fields []CompactField) (any, TransformStats) {
+	var st TransformStats
+	return compactAny(v, fields, &st), st
+}
+
+func compactAny(v any, fields []CompactField, st *TransformStats) any {
 	if len(fields) == 0 || v == nil {
 		return v
 	}
 	plan := buildFieldPlan(fields)
+	plan.stats = st
 	switch val := v.(type) {
 	case map[string]any:
 		return compactObject(val, fields, plan)
@@ -360,7 +397,11 @@ func compactObject(obj map[string]any, fields []CompactField, plan *fieldPlan) m
 	// Scalar fields (simple + single-member object groups).
 	for _, f := range plan.scalars {
 		val, ok := extractField(obj, f)
-		if !ok || isEmptyValue(val) {
+		if !ok {
+			continue
+		}
+		val = applyTransforms(val, f.transforms, plan.stats)
+		if isEmptyValue(val) {
 			continue
 		}
 		out[f.outputKey] = val
@@ -369,7 +410,7 @@ func compactObject(obj map[string]any, fields []CompactField, plan *fieldPlan) m
 	// Object groups: 2+ specs sharing a root → nested sub-object.
 	for root, group := range plan.objectGroups {
 		childFields := plan.childPlans[root]
-		if sub := compactSubObject(obj, root, group, childFields); len(sub) > 0 {
+		if sub := compactSubObject(obj, root, group, childFields, plan.stats); len(sub) > 0 {
 			out[root] = sub
 		}
 	}
@@ -378,10 +419,13 @@ func compactObject(obj map[string]any, fields []CompactField, plan *fieldPlan) m
 	for key, group := range plan.arrayGroups {
 		var val any
 		var ok bool
-		if len(group) == 1 {
+		if len(group) == 1 && !hasNestedArray(group[0].childPath) {
 			val, ok = extractArrayField(obj, group[0])
+			if ok {
+				val = applyTransforms(val, group[0].transforms, plan.stats)
+			}
 		} else {
-			val, ok = extractArrayFieldGroup(obj, group)
+			val, ok = extractArrayFieldGroup(obj, group, plan.stats)
 		}
 		if !ok {
 			continue
@@ -445,12 +489,13 @@ func isEmptyValue(v any) bool {
 
 // compactSubObject builds a compacted nested object from specs sharing a root.
 // Uses pre-parsed child fields instead of re-parsing on every call.
-func compactSubObject(obj map[string]any, root string, _ []CompactField, childFields []CompactField) map[string]any {
+func compactSubObject(obj map[string]any, root string, _ []CompactField, childFields []CompactField, st *TransformStats) map[string]any {
 	parentObj, ok := obj[root].(map[string]any)
 	if !ok {
 		return nil
 	}
 	childPlan := buildFieldPlan(childFields)
+	childPlan.stats = st
 	return compactObject(parentObj, childFields, childPlan)
 }
 
@@ -685,13 +730,17 @@ func navigateToArray(obj map[string]any, f CompactField) ([]any, bool) {
 // For childPaths containing [], delegates to group extraction.
 func extractArrayField(obj map[string]any, f CompactField) (any, bool) {
 	if hasNestedArray(f.childPath) {
-		return extractArrayFieldGroup(obj, []CompactField{f})
+		return extractArrayFieldGroup(obj, []CompactField{f}, nil)
 	}
 
 	arr, ok := navigateToArray(obj, f)
 	if !ok {
 		return nil, false
 	}
+	if len(f.childPath) == 0 {
+		// "labels[]|limit:5" selects the array itself.
+		return arr, true
+	}
 
 	result := make([]any, 0, len(arr))
 	for _, elem := range arr {
@@ -713,13 +762,28 @@ func extractArrayField(obj map[string]any, f CompactField) (any, bool) {
 
 // extractArrayFieldGroup handles multiple specs on the same array parent
 // (e.g. steps[].name + steps[].conclusion) — produces sub-objects per element.
-// Also handles nested array specs (e.g. items[].labels[].name).
-func extractArrayFieldGroup(obj map[string]any, fields []CompactField) (any, bool) {
+// Also handles nested array specs (e.g. items[].labels[].name). Each spec's
+// transforms apply to its value in every element; a bare "steps[]" spec in
+// the group applies its transforms (typically limit) to the array as a whole.
+func extractArrayFieldGroup(obj map[string]any, fields []CompactField, st *TransformStats) (any, bool) {
 	arr, ok := navigateToArray(obj, fields[0])
 	if !ok {
 		return nil, false
 	}
 
+	var whole []valueTransform
+	members := make([]CompactField, 0, len(fields))
+	for _, f := range fields {
+		if len(f.childPath) == 0 {
+			whole = append(whole, f.transforms...)
+			continue
+		}
+		members = append(members, f)
+	}
+	if len(members) == 0 {
+		return applyTransforms(arr, whole, st), true
+	}
+
 	result := make([]any, 0, len(arr))
 	for _, elem := range arr {
 		elemObj, ok := elem.(map[string]any)
@@ -728,10 +792,14 @@ func extractArrayFieldGroup(obj map[string]any, fields []CompactField) (any, boo
 			result = append(result, elem)
 			continue
 		}
-		sub := make(map[string]any, len(fields))
-		for _, f := range fields {
+		sub := make(map[string]any, len(members))
+		for _, f := range members {
 			key, val, ok := extractChildValue(elemObj, f.childPath)
-			if ok && !isEmptyValue(val) {
+			if !ok {
+				continue
+			}
+			val = applyTransforms(val, f.transforms, st)
+			if !isEmptyValue(val) {
 				sub[key] = val
 			}
 		}
@@ -740,7 +808,7 @@ func extractArrayFieldGroup(obj map[string]any, fields []CompactField) (any, boo
 		}
 	}
 
-	return result, true
+	return applyTransforms(result, whole, st), true
 }
 
 // extractChildValue extracts a value from an object following a child path.
@@ -777,8 +845,12 @@ func extractChildValue(obj map[string]any, childPath []string) (string, any, boo
 			return "", nil, false
 		}
 
-		// Extract from each element using the remaining path.
+		// Extract from each element using the remaining path; a trailing
+		// "labels[]" selects the array itself.
 		remaining := childPath[i+1:]
+		if len(remaining) == 0 {
+			return arrayKey, arr, true
+		}
 		extracted := make([]any, 0, len(arr))
 		for _, elem := range arr {
 			elemObj, ok := elem.(map[string]any)
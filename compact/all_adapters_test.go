@@ -35,8 +35,8 @@ func TestAllAdapterYAMLsLoadStrict(t *testing.T) {
 			if err != nil {
 				t.Fatalf("strict load failed: %v", err)
 			}
-			if len(res.Specs) == 0 {
-				t.Fatalf("%s loaded but produced 0 specs", path)
+			if len(res.Specs) == 0 && len(res.Untrusted) == 0 {
+				t.Fatalf("%s loaded but produced 0 specs and 0 untrusted declarations", path)
 			}
 		})
 	}
@@ -31,11 +31,15 @@ type Options struct {
 //
 // Views holds the multi-view detail, populated only for tools that use
 // the `views:` form. Pipeline code with view dispatch reads this map.
+//
+// Untrusted holds the untrusted-content paths of tools that declare them;
+// adapters surface it through mcp.UntrustedContentIntegration.
 type Result struct {
-	Specs    map[mcp.ToolName][]mcp.CompactField
-	MaxBytes map[mcp.ToolName]int
-	Views    map[mcp.ToolName]ViewSet
-	Warnings []error
+	Specs     map[mcp.ToolName][]mcp.CompactField
+	MaxBytes  map[mcp.ToolName]int
+	Views     map[mcp.ToolName]ViewSet
+	Untrusted map[mcp.ToolName][]string
+	Warnings  []error
 }
 
 // Load parses a compact.yaml byte slice into a Result.
@@ -50,9 +54,10 @@ func Load(data []byte, opts Options) (Result, error) {
 		return Result{}, fmt.Errorf("compact: unsupported version %d (want 1)", sf.Version)
 	}
 	res := Result{
-		Specs:    make(map[mcp.ToolName][]mcp.CompactField, len(sf.Tools)),
-		MaxBytes: make(map[mcp.ToolName]int),
-		Views:    make(map[mcp.ToolName]ViewSet),
+		Specs:     make(map[mcp.ToolName][]mcp.CompactField, len(sf.Tools)),
+		MaxBytes:  make(map[mcp.ToolName]int),
+		Views:     make(map[mcp.ToolName]ViewSet),
+		Untrusted: make(map[mcp.ToolName][]string),
 	}
 	for name, cfg := range sf.Tools {
 		toolName := mcp.ToolName(name)
@@ -70,16 +75,34 @@ func Load(data []byte, opts Options) (Result, error) {
 // On error it leaves res unchanged for that tool (caller decides
 // strict-vs-lenient).
 func loadOneTool(res *Result, name mcp.ToolName, cfg ToolConfig, opts Options) error {
+	for _, p := range cfg.Untrusted {
+		if err := mcp.ValidateUntrustedPath(p); err != nil {
+			return fmt.Errorf("compact: tool %q: %w", name, err)
+		}
+	}
 	hasSpec := len(cfg.Spec) > 0
 	hasViews := len(cfg.Views) > 0
+	var err error
 	switch {
 	case hasSpec && hasViews:
 		return fmt.Errorf("compact: tool %q: cannot set both `spec` and `views`; pick one", name)
 	case hasViews:
-		return loadMultiViewTool(res, name, cfg, opts)
+		err = loadMultiViewTool(res, name, cfg, opts)
+	case !hasSpec && len(cfg.Untrusted) > 0:
+		// Untrusted-only: an empty spec here would strip every field.
+		if cfg.MaxBytes != 0 {
+			return fmt.Errorf("compact: tool %q: max_bytes needs a `spec`", name)
+		}
 	default:
-		return loadFlatTool(res, name, cfg)
+		err = loadFlatTool(res, name, cfg)
 	}
+	if err != nil {
+		return err
+	}
+	if len(cfg.Untrusted) > 0 {
+		res.Untrusted[name] = cfg.Untrusted
+	}
+	return nil
 }
 
 // loadFlatTool handles the today-form: one spec, optional max_bytes.
@@ -93,6 +93,14 @@ func TestLoad_RejectsBadInput(t *testing.T) {
 tools:
   bad_tool:
     spec: [""]
+`,
+		},
+		{
+			name: "unknown transform in strict mode",
+			yaml: `version: 1
+tools:
+  t:
+    spec: ["body|shorten:10"]
 `,
 		},
 		{
@@ -464,7 +472,7 @@ tools:
 `,
 		},
 		{
-			name: "format with no framework default and no custom renderer",
+			name: "unknown format and no custom renderer",
 			yaml: `version: 1
 tools:
   bad:
@@ -472,7 +480,7 @@ tools:
       v:
         spec:
           - a.b
-        formats: [json, text]
+        formats: [json, yaml]
     default:
       view: v
       format: json
@@ -595,6 +603,147 @@ tools:
 	}
 }
 
+// frameworkRenderer loads a one-view tool declaring format and returns the
+// framework default renderer the loader resolved for it.
+func frameworkRenderer(t *testing.T, format compact.Format) compact.Renderer {
+	t.Helper()
+	data := []byte(`version: 1
+tools:
+  t:
+    views:
+      v:
+        spec: [a]
+        formats: [` + string(format) + `]
+    default:
+      view: v
+      format: ` + string(format) + `
+`)
+	res, err := compact.Load(data, compact.Options{Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := res.Views[mcp.ToolName("t")].Renderers["v"][format]
+	if r == nil {
+		t.Fatalf("no framework renderer for %s", format)
+	}
+	return r
+}
+
+func TestFrameworkRenderer_Text(t *testing.T) {
+	r := frameworkRenderer(t, compact.FormatText)
+
+	cases := []struct {
+		name  string
+		input any
+		want  string
+	}{
+		{"scalar", "hello", "hello\n"},
+		{"flat map sorted, nil dropped", map[string]any{"title": "Foo", "id": float64(1000000), "gone": nil}, "id: 1000000\ntitle: Foo\n"},
+		{"nested map indents", map[string]any{"user": map[string]any{"login": "octo"}}, "user:\n  login: octo\n"},
+		{"scalar list", map[string]any{"labels": []any{"bug", "ui"}}, "labels:\n  - bug\n  - ui\n"},
+		{
+			"list of objects",
+			[]any{map[string]any{"id": float64(1), "state": "open"}, map[string]any{"id": float64(2), "state": "closed"}},
+			"- id: 1\n  state: open\n- id: 2\n  state: closed\n",
+		},
+		{"multi-line string", map[string]any{"body": "a\nb"}, "body: a\n  b\n"},
+		{"empty collections", map[string]any{"a": []any{}, "b": map[string]any{}}, "a: []\nb: {}\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := r(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", out, tc.want)
+			}
+		})
+	}
+}
+
+func TestFrameworkRenderer_CSVAndTSV(t *testing.T) {
+	input := []any{
+		map[string]any{"id": float64(1), "title": "Fix, then ship", "draft": true},
+		map[string]any{"id": float64(2), "title": "Plain"},
+	}
+
+	out, err := frameworkRenderer(t, compact.FormatCSV)(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "draft,id,title\ntrue,1,\"Fix, then ship\"\n,2,Plain\n"; string(out) != want {
+		t.Errorf("csv: got %q, want %q", out, want)
+	}
+
+	out, err = frameworkRenderer(t, compact.FormatTSV)(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "draft\tid\ttitle\ntrue\t1\tFix, then ship\n\t2\tPlain\n"; string(out) != want {
+		t.Errorf("tsv: got %q, want %q", out, want)
+	}
+
+	out, err = frameworkRenderer(t, compact.FormatCSV)([]any{"a", float64(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "value\na\n2\n"; string(out) != want {
+		t.Errorf("csv scalars: got %q, want %q", out, want)
+	}
+
+	for _, bad := range []any{map[string]any{"a": "b"}, []any{map[string]any{"a": "b"}, "c"}, "scalar"} {
+		if _, err := frameworkRenderer(t, compact.FormatCSV)(bad); err == nil {
+			t.Errorf("csv of %v: want error, got nil", bad)
+		}
+	}
+}
+
+func TestFrameworkRenderer_TOON(t *testing.T) {
+	r := frameworkRenderer(t, compact.FormatTOON)
+
+	cases := []struct {
+		name  string
+		input any
+		want  string
+	}{
+		{
+			"uniform objects become a table",
+			map[string]any{
+				"total": float64(2),
+				"issues": []any{
+					map[string]any{"id": float64(1), "title": "Fix login"},
+					map[string]any{"id": float64(2), "title": "Crash on save, again"},
+				},
+			},
+			"issues[2]{id,title}:\n  1,Fix login\n  2,\"Crash on save, again\"\ntotal: 2\n",
+		},
+		{"scalar array inline", map[string]any{"labels": []any{"bug", "ui"}}, "labels[2]: bug,ui\n"},
+		{"root array table", []any{map[string]any{"a": float64(1)}, map[string]any{"a": float64(2)}}, "[2]{a}:\n  1\n  2\n"},
+		{"ambiguous strings are quoted", map[string]any{"a": "true", "b": "42", "c": "", "d": "x: y", "e": "ok"}, "a: \"true\"\nb: \"42\"\nc: \"\"\nd: \"x: y\"\ne: ok\n"},
+		{"null", map[string]any{"a": nil}, "a: null\n"},
+		{"nested object", map[string]any{"user": map[string]any{"login": "octo"}}, "user:\n  login: octo\n"},
+		{
+			"non-uniform objects become list items",
+			map[string]any{"items": []any{map[string]any{"a": float64(1), "b": "x"}, map[string]any{"a": float64(2)}}},
+			"items[2]:\n  - a: 1\n    b: x\n  - a: 2\n",
+		},
+		{"empty array", map[string]any{"items": []any{}}, "items[0]:\n"},
+		{"odd keys are quoted", map[string]any{"a b": float64(1)}, "\"a b\": 1\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := r(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", out, tc.want)
+			}
+		})
+	}
+}
+
 func TestLoadWithOverlay_MultiView_WholeToolReplacement(t *testing.T) {
 	dir := t.TempDir()
 	overlay := `version: 1
@@ -682,3 +831,132 @@ tools:
 		t.Fatal("expected a warning for the bad view")
 	}
 }
+
+func TestLoad_Untrusted(t *testing.T) {
+	data := []byte(`version: 1
+tools:
+  slack_history:
+    spec:
+      - messages[].text
+      - messages[].user
+    untrusted:
+      - messages[].text
+  notion_page:
+    views:
+      full:
+        spec: [page.id, page.content]
+        formats: [json]
+    default:
+      view: full
+      format: json
+    untrusted: ["."]
+  web_fetch:
+    untrusted: ["."]
+`)
+	res, err := compact.Load(data, compact.Options{Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[mcp.ToolName][]string{
+		"slack_history": {"messages[].text"},
+		"notion_page":   {"."},
+		"web_fetch":     {"."},
+	}
+	if !reflect.DeepEqual(res.Untrusted, want) {
+		t.Fatalf("Untrusted: want %v, got %v", want, res.Untrusted)
+	}
+	if _, ok := res.Specs[mcp.ToolName("web_fetch")]; ok {
+		t.Fatal("untrusted-only tool must not register a spec (an empty spec strips every field)")
+	}
+	if len(res.Specs[mcp.ToolName("slack_history")]) != 2 {
+		t.Fatalf("slack_history spec: want 2 fields, got %d", len(res.Specs[mcp.ToolName("slack_history")]))
+	}
+}
+
+func TestLoad_UntrustedRejectsBadInput(t *testing.T) {
+	cases := map[string]string{
+		"empty path": `version: 1
+tools:
+  foo:
+    spec: [a]
+    untrusted: [""]
+`,
+		"bracketed index": `version: 1
+tools:
+  foo:
+    untrusted: ["items[0].body"]
+`,
+		"empty segment": `version: 1
+tools:
+  foo:
+    untrusted: ["items..body"]
+`,
+		"max_bytes without spec": `version: 1
+tools:
+  foo:
+    max_bytes: 1000
+    untrusted: ["."]
+`,
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := compact.Load([]byte(data), compact.Options{Strict: true}); err == nil {
+				t.Fatal("want error, got nil")
+			}
+			res, _ := compact.Load([]byte(data), compact.Options{Strict: false})
+			if _, ok := res.Untrusted[mcp.ToolName("foo")]; ok {
+				t.Fatal("lenient load should skip the bad tool")
+			}
+			if len(res.Warnings) != 1 {
+				t.Fatalf("want 1 warning, got %v", res.Warnings)
+			}
+		})
+	}
+}
+
+func TestLoadWithOverlay_UntrustedMerge(t *testing.T) {
+	embedded := []byte(`version: 1
+tools:
+  foo:
+    spec: [a, b]
+    untrusted: [b]
+  bar:
+    spec: [x]
+  web_fetch:
+    untrusted: ["."]
+`)
+	dir := t.TempDir()
+	overlay := []byte(`version: 1
+tools:
+  foo:
+    spec: [a, b]
+  bar:
+    untrusted: ["."]
+  web_fetch:
+    untrusted: [body]
+`)
+	if err := os.WriteFile(filepath.Join(dir, "linear.yaml"), overlay, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SWITCHBOARD_COMPACT_DIR", dir)
+	res, err := compact.LoadWithOverlay("linear", embedded, compact.Options{Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := res.Untrusted[mcp.ToolName("foo")]; ok {
+		t.Fatal("foo: overlay omitting untrusted should clear the embedded paths")
+	}
+	// Whole-tool replacement: an untrusted-only overlay drops the embedded spec.
+	if _, ok := res.Specs[mcp.ToolName("bar")]; ok {
+		t.Fatal("bar: untrusted-only overlay should replace the embedded spec")
+	}
+	if got := res.Untrusted[mcp.ToolName("bar")]; !reflect.DeepEqual(got, []string{"."}) {
+		t.Fatalf("bar untrusted: got %v", got)
+	}
+	if got := res.Untrusted[mcp.ToolName("web_fetch")]; !reflect.DeepEqual(got, []string{"body"}) {
+		t.Fatalf("web_fetch untrusted: got %v", got)
+	}
+	if len(res.Warnings) != 0 {
+		t.Fatalf("want 0 warnings (every overlay tool has an embedded counterpart), got %v", res.Warnings)
+	}
+}
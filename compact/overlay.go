@@ -6,6 +6,7 @@ import (
 	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 )
 
 // EnvOverrideDir is the environment variable that points to a directory
@@ -67,22 +68,33 @@ func absorbOverlayErr(base Result, opts Options, err error) (Result, error) {
 // Tools present only in overlay (no embedded counterpart) are accepted with a warning.
 //
 // Granularity is whole-tool: if the overlay defines a tool, that tool's
-// entire config (spec, max_bytes, and any multi-view detail) is replaced.
-// Per-view merging (override one view, keep others embedded) is not
-// supported — authors copy the whole tool's YAML out of the source tree
-// when they want to override.
+// entire config (spec, max_bytes, any multi-view detail and untrusted
+// paths) is replaced. Per-view merging (override one view, keep others
+// embedded) is not supported — authors copy the whole tool's YAML out of
+// the source tree when they want to override.
 func mergeOverlay(base *Result, overlay Result) {
-	for name, fields := range overlay.Specs {
-		if _, present := base.Specs[name]; !present {
+	names := slices.Collect(maps.Keys(overlay.Specs))
+	for name := range overlay.Untrusted {
+		if _, ok := overlay.Specs[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		_, hasSpec := base.Specs[name]
+		_, hasUntrusted := base.Untrusted[name]
+		if !hasSpec && !hasUntrusted {
 			base.Warnings = append(base.Warnings,
 				fmt.Errorf("compact: overlay tool %q has no embedded counterpart (possible typo)", name))
 		}
-		base.Specs[name] = fields
-		// Clear any prior per-tool max_bytes and view config; overlay re-applies below if set.
+		// Clear the prior per-tool config; overlay re-applies below if set.
+		delete(base.Specs, name)
 		delete(base.MaxBytes, name)
 		delete(base.Views, name)
+		delete(base.Untrusted, name)
 	}
+	maps.Copy(base.Specs, overlay.Specs)
 	maps.Copy(base.MaxBytes, overlay.MaxBytes)
 	maps.Copy(base.Views, overlay.Views)
+	maps.Copy(base.Untrusted, overlay.Untrusted)
 	base.Warnings = append(base.Warnings, overlay.Warnings...)
 }
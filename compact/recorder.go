@@ -0,0 +1,145 @@
+package compact
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	mcp "github.com/daltoniam/switchboard"
+)
+
+// EnvRecordDir is the environment variable that turns on the dev-mode
+// response recorder. When set, the server appends raw tool responses to
+// <dir>/<tool>.jsonl so `switchboard compact suggest` can profile them.
+const EnvRecordDir = "SWITCHBOARD_COMPACT_RECORD"
+
+const (
+	// DefaultMaxSamples caps how many responses are kept per tool. A few
+	// dozen calls cover a tool's shape; more only slows profiling.
+	DefaultMaxSamples = 25
+	// maxSampleBytes skips responses too large to be worth re-reading.
+	maxSampleBytes = 2 << 20
+)
+
+var sampleToolNameRE = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Recorder captures raw JSON tool responses as samples for spec suggestion.
+// It is safe for concurrent use. A nil *Recorder records nothing.
+type Recorder struct {
+	dir        string
+	maxSamples int
+
+	mu     sync.Mutex
+	counts map[mcp.ToolName]int
+}
+
+// NewRecorder returns a recorder writing up to maxSamples responses per tool
+// under dir. maxSamples <= 0 means DefaultMaxSamples.
+func NewRecorder(dir string, maxSamples int) *Recorder {
+	if maxSamples <= 0 {
+		maxSamples = DefaultMaxSamples
+	}
+	return &Recorder{dir: dir, maxSamples: maxSamples, counts: make(map[mcp.ToolName]int)}
+}
+
+// RecorderFromEnv returns a recorder for $SWITCHBOARD_COMPACT_RECORD, or nil
+// when the variable is unset.
+func RecorderFromEnv() *Recorder {
+	dir := os.Getenv(EnvRecordDir)
+	if dir == "" {
+		return nil
+	}
+	return NewRecorder(dir, 0)
+}
+
+// Dir returns the directory samples are written to.
+func (r *Recorder) Dir() string { return r.dir }
+
+// Record appends data as one sample for tool. Non-JSON responses, oversized
+// responses and tools that already have maxSamples samples are skipped;
+// write failures are logged, never returned, so recording cannot break a
+// tool call.
+func (r *Recorder) Record(tool mcp.ToolName, data []byte) {
+	if r == nil || len(data) > maxSampleBytes || !sampleToolNameRE.MatchString(string(tool)) {
+		return
+	}
+	var line bytes.Buffer
+	if err := json.Compact(&line, data); err != nil {
+		return
+	}
+	if b := line.Bytes(); len(b) == 0 || (b[0] != '{' && b[0] != '[') {
+		return
+	}
+	line.WriteByte('\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	path := samplePath(r.dir, tool)
+	n, ok := r.counts[tool]
+	if !ok {
+		n = countLines(path)
+	}
+	if n >= r.maxSamples {
+		r.counts[tool] = n
+		return
+	}
+	if err := os.MkdirAll(r.dir, 0o700); err != nil {
+		slog.Warn("compact recorder: create dir failed", "dir", r.dir, "err", err)
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) // #nosec G304 -- dir is operator-controlled, tool name is validated above
+	if err != nil {
+		slog.Warn("compact recorder: open failed", "path", path, "err", err)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.Write(line.Bytes()); err != nil {
+		slog.Warn("compact recorder: write failed", "path", path, "err", err)
+		return
+	}
+	r.counts[tool] = n + 1
+}
+
+// LoadSamples reads the samples recorded for tool under dir.
+func LoadSamples(dir string, tool mcp.ToolName) ([][]byte, error) {
+	if !sampleToolNameRE.MatchString(string(tool)) {
+		return nil, fmt.Errorf("compact: invalid tool name %q", tool)
+	}
+	path := samplePath(dir, tool)
+	f, err := os.Open(path) // #nosec G304 -- dir is operator-controlled, tool name is validated above
+	if err != nil {
+		return nil, fmt.Errorf("compact: read samples: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var samples [][]byte
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64<<10), maxSampleBytes+1)
+	for sc.Scan() {
+		if line := bytes.TrimSpace(sc.Bytes()); len(line) > 0 {
+			samples = append(samples, bytes.Clone(line))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("compact: read samples %s: %w", path, err)
+	}
+	return samples, nil
+}
+
+func samplePath(dir string, tool mcp.ToolName) string {
+	return filepath.Join(dir, string(tool)+".jsonl")
+}
+
+func countLines(path string) int {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is built by samplePath
+	if err != nil {
+		return 0
+	}
+	return bytes.Count(data, []byte("\n"))
+}
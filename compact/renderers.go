@@ -1,17 +1,26 @@
 package compact
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 )
 
 // jsonRenderer is the framework default for FormatJSON. Marshals the
-// projected Go value with json.Marshal — same shape as today's
-// processResult output.
+// projected Go value like json.Marshal but without HTML escaping — same
+// shape as today's processResult output, with <, > and & kept readable
+// (untrusted-content envelopes are tags).
 func jsonRenderer(projected any) ([]byte, error) {
-	return json.Marshal(projected)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(projected); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
 }
 
 // markdownRenderer is the framework default for FormatMarkdown. Renders any
@@ -107,6 +116,122 @@ func renderMarkdownTable(sb *strings.Builder, s []any, first map[string]any) {
 	sb.WriteString("\n")
 }
 
+// textRenderer is the framework default for FormatText. Renders the
+// projected value as indented "key: value" lines, YAML-like but without
+// quoting rules to learn:
+//
+//   - map → one "key: value" line per field, nested values indented below
+//     their key
+//   - array → one "- item" line per element
+//   - multi-line strings → continuation lines indented under their key
+//
+// Nil fields are dropped, matching the markdown renderer.
+func textRenderer(projected any) ([]byte, error) {
+	var sb strings.Builder
+	renderTextValue(&sb, projected, 0)
+	out := strings.TrimRight(sb.String(), "\n") + "\n"
+	return []byte(out), nil
+}
+
+func renderTextValue(sb *strings.Builder, v any, depth int) {
+	switch val := v.(type) {
+	case map[string]any:
+		if len(val) == 0 {
+			sb.WriteString(textIndent(depth) + "{}\n")
+			return
+		}
+		for _, k := range sortedKeys(val) {
+			renderTextField(sb, k, val[k], depth)
+		}
+	case []any:
+		if len(val) == 0 {
+			sb.WriteString(textIndent(depth) + "[]\n")
+			return
+		}
+		for _, item := range val {
+			renderTextItem(sb, item, depth)
+		}
+	default:
+		sb.WriteString(textIndent(depth) + textScalar(val, depth) + "\n")
+	}
+}
+
+func renderTextField(sb *strings.Builder, k string, v any, depth int) {
+	switch val := v.(type) {
+	case nil:
+		// skip nil values
+	case map[string]any:
+		if len(val) == 0 {
+			fmt.Fprintf(sb, "%s%s: {}\n", textIndent(depth), k)
+			return
+		}
+		fmt.Fprintf(sb, "%s%s:\n", textIndent(depth), k)
+		renderTextValue(sb, val, depth+1)
+	case []any:
+		if len(val) == 0 {
+			fmt.Fprintf(sb, "%s%s: []\n", textIndent(depth), k)
+			return
+		}
+		fmt.Fprintf(sb, "%s%s:\n", textIndent(depth), k)
+		renderTextValue(sb, val, depth+1)
+	default:
+		fmt.Fprintf(sb, "%s%s: %s\n", textIndent(depth), k, textScalar(val, depth+1))
+	}
+}
+
+// renderTextItem writes one array element. Objects put their first field on
+// the "- " line and indent the rest beneath it.
+func renderTextItem(sb *strings.Builder, v any, depth int) {
+	switch v.(type) {
+	case map[string]any, []any:
+		var inner strings.Builder
+		renderTextValue(&inner, v, depth+1)
+		sb.WriteString(textIndent(depth) + "- " + strings.TrimPrefix(inner.String(), textIndent(depth+1)))
+	default:
+		sb.WriteString(textIndent(depth) + "- " + textScalar(v, depth+1) + "\n")
+	}
+}
+
+func textIndent(depth int) string {
+	return strings.Repeat("  ", depth)
+}
+
+// textScalar formats a scalar, indenting continuation lines of multi-line
+// strings to depth.
+func textScalar(v any, depth int) string {
+	s := scalarString(v)
+	if strings.Contains(s, "\n") {
+		s = strings.ReplaceAll(s, "\n", "\n"+textIndent(depth))
+	}
+	return s
+}
+
+// scalarString formats a decoded JSON scalar for the plain-text renderers.
+// Numbers print without exponents, so 1000000 stays 1000000 rather than
+// %v's 1e+06. Nested values fall back to their JSON encoding.
+func scalarString(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case json.Number:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	case map[string]any, []any:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprintf("%v", val)
+		}
+		return string(b)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
 func sortedKeys(m map[string]any) []string {
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -149,9 +274,15 @@ func resolveRenderer(key RenderKey, opts Options) (Renderer, error) {
 	case FormatMarkdown:
 		return markdownRenderer, nil
 	case FormatText:
-		return nil, fmt.Errorf("format %q has no framework default; provide a custom renderer via Options.Renderers", key.Format)
+		return textRenderer, nil
+	case FormatCSV:
+		return csvRenderer, nil
+	case FormatTSV:
+		return tsvRenderer, nil
+	case FormatTOON:
+		return toonRenderer, nil
 	default:
-		return nil, fmt.Errorf("unknown format %q (known: %s, %s, %s)",
-			key.Format, FormatJSON, FormatMarkdown, FormatText)
+		return nil, fmt.Errorf("unknown format %q (known: %s, %s, %s, %s, %s, %s); provide a custom renderer via Options.Renderers",
+			key.Format, FormatJSON, FormatMarkdown, FormatText, FormatCSV, FormatTSV, FormatTOON)
 	}
 }
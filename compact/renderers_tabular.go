@@ -0,0 +1,274 @@
+package compact
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// csvRenderer is the framework default for FormatCSV. It needs the
+// projected value to be an array: objects become one row each under a
+// header of their keys, scalars a single "value" column. Anything else is a
+// render error rather than a best-effort flattening — a view that declares
+// csv should project to a list.
+func csvRenderer(projected any) ([]byte, error) {
+	return renderDelimited(projected, ',')
+}
+
+// tsvRenderer is the framework default for FormatTSV. Same contract as
+// csvRenderer, tab-separated.
+func tsvRenderer(projected any) ([]byte, error) {
+	return renderDelimited(projected, '\t')
+}
+
+func renderDelimited(projected any, comma rune) ([]byte, error) {
+	header, rows, err := tableOf(projected)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = comma
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, v := range row {
+			cells[i] = scalarString(v)
+		}
+		if err := w.Write(cells); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// tableOf lays an array out as a header and rows. Arrays of objects use the
+// sorted union of their keys, leaving a field an element lacks empty.
+func tableOf(projected any) ([]string, [][]any, error) {
+	s, ok := projected.([]any)
+	if !ok {
+		return nil, nil, fmt.Errorf("tabular formats need an array, got %s", shapeName(projected))
+	}
+	if len(s) == 0 {
+		return []string{}, nil, nil
+	}
+	if _, ok := s[0].(map[string]any); !ok {
+		rows := make([][]any, len(s))
+		for i, item := range s {
+			if _, isMap := item.(map[string]any); isMap {
+				return nil, nil, fmt.Errorf("tabular formats need an array of all objects or all scalars, element %d is an object", i)
+			}
+			rows[i] = []any{item}
+		}
+		return []string{"value"}, rows, nil
+	}
+
+	seen := make(map[string]any)
+	for i, item := range s {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return nil, nil, fmt.Errorf("tabular formats need an array of all objects or all scalars, element %d is %s", i, shapeName(item))
+		}
+		for k := range m {
+			seen[k] = nil
+		}
+	}
+	header := sortedKeys(seen)
+	rows := make([][]any, len(s))
+	for i, item := range s {
+		m := item.(map[string]any)
+		row := make([]any, len(header))
+		for j, k := range header {
+			row[j] = m[k]
+		}
+		rows[i] = row
+	}
+	return header, rows, nil
+}
+
+func shapeName(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "an array"
+	case nil:
+		return "null"
+	default:
+		return "a scalar"
+	}
+}
+
+// toonRenderer is the framework default for FormatTOON, a token-oriented
+// object notation: indentation instead of braces, and arrays declared once
+// with their length so uniform lists of objects collapse into a table.
+//
+//	total: 2
+//	issues[2]{id,state,title}:
+//	  1,open,Fix login
+//	  2,closed,"Crash on save, again"
+//	labels[3]: bug,ui,p1
+//
+// Strings are quoted only when they would otherwise read as another type or
+// contain a delimiter. Arrays whose elements are not uniform objects fall
+// back to "- " list items.
+func toonRenderer(projected any) ([]byte, error) {
+	var sb strings.Builder
+	switch v := projected.(type) {
+	case map[string]any:
+		renderTOONFields(&sb, v, 0)
+	case []any:
+		renderTOONArray(&sb, "", v, 0)
+	default:
+		sb.WriteString(toonScalar(v) + "\n")
+	}
+	out := strings.TrimRight(sb.String(), "\n") + "\n"
+	return []byte(out), nil
+}
+
+func renderTOONFields(sb *strings.Builder, m map[string]any, depth int) {
+	for _, k := range sortedKeys(m) {
+		renderTOONField(sb, toonKey(k), m[k], depth)
+	}
+}
+
+func renderTOONField(sb *strings.Builder, key string, v any, depth int) {
+	switch val := v.(type) {
+	case map[string]any:
+		sb.WriteString(textIndent(depth) + key + ":\n")
+		renderTOONFields(sb, val, depth+1)
+	case []any:
+		renderTOONArray(sb, key, val, depth)
+	default:
+		sb.WriteString(textIndent(depth) + key + ": " + toonScalar(val) + "\n")
+	}
+}
+
+func renderTOONArray(sb *strings.Builder, key string, s []any, depth int) {
+	head := textIndent(depth) + key + "[" + strconv.Itoa(len(s)) + "]"
+	if len(s) == 0 {
+		sb.WriteString(head + ":\n")
+		return
+	}
+	if allTOONScalars(s) {
+		cells := make([]string, len(s))
+		for i, v := range s {
+			cells[i] = toonScalar(v)
+		}
+		sb.WriteString(head + ": " + strings.Join(cells, ",") + "\n")
+		return
+	}
+	if fields, ok := toonTableFields(s); ok {
+		keys := make([]string, len(fields))
+		for i, f := range fields {
+			keys[i] = toonKey(f)
+		}
+		sb.WriteString(head + "{" + strings.Join(keys, ",") + "}:\n")
+		for _, item := range s {
+			m := item.(map[string]any)
+			cells := make([]string, len(fields))
+			for i, f := range fields {
+				cells[i] = toonScalar(m[f])
+			}
+			sb.WriteString(textIndent(depth+1) + strings.Join(cells, ",") + "\n")
+		}
+		return
+	}
+	sb.WriteString(head + ":\n")
+	for _, item := range s {
+		var inner strings.Builder
+		switch val := item.(type) {
+		case map[string]any:
+			if len(val) == 0 {
+				sb.WriteString(textIndent(depth+1) + "-\n")
+				continue
+			}
+			renderTOONFields(&inner, val, depth+2)
+		case []any:
+			renderTOONArray(&inner, "", val, depth+2)
+		default:
+			sb.WriteString(textIndent(depth+1) + "- " + toonScalar(val) + "\n")
+			continue
+		}
+		sb.WriteString(textIndent(depth+1) + "- " + strings.TrimPrefix(inner.String(), textIndent(depth+2)))
+	}
+}
+
+func allTOONScalars(s []any) bool {
+	for _, v := range s {
+		switch v.(type) {
+		case map[string]any, []any:
+			return false
+		}
+	}
+	return true
+}
+
+// toonTableFields reports the shared, sorted keys of s when every element is
+// an object with the same keys and only scalar values.
+func toonTableFields(s []any) ([]string, bool) {
+	first, ok := s[0].(map[string]any)
+	if !ok || len(first) == 0 || !allMapsHomogeneous(s, first) {
+		return nil, false
+	}
+	for _, item := range s {
+		for _, v := range item.(map[string]any) {
+			switch v.(type) {
+			case map[string]any, []any:
+				return nil, false
+			}
+		}
+	}
+	return sortedKeys(first), true
+}
+
+var (
+	toonBareKeyRE   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+	toonNumericRE   = regexp.MustCompile(`^-?\d+(?:\.\d+)?(?:[eE][+-]?\d+)?$`)
+	toonNeedsQuotes = "\",:[]{}\\\n\r\t"
+)
+
+func toonKey(k string) string {
+	if toonBareKeyRE.MatchString(k) {
+		return k
+	}
+	return toonQuote(k)
+}
+
+// toonScalar formats a scalar value. Strings that are empty, padded, look
+// like a literal or number, or contain a delimiter are JSON-quoted.
+func toonScalar(v any) string {
+	s, ok := v.(string)
+	if !ok {
+		if v == nil {
+			return "null"
+		}
+		return scalarString(v)
+	}
+	switch {
+	case s == "", s != strings.TrimSpace(s),
+		s == "true", s == "false", s == "null",
+		toonNumericRE.MatchString(s),
+		strings.HasPrefix(s, "- "),
+		strings.ContainsAny(s, toonNeedsQuotes):
+		return toonQuote(s)
+	}
+	return s
+}
+
+func toonQuote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return strconv.Quote(s)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -11,8 +11,8 @@ type RawSpec string
 type ViewName string
 
 // Format identifies one serialization of a projected tool response
-// (e.g. "json", "markdown", "text"). Reserved in the namespace even when
-// not yet implemented — see Hyrum's law on stable name surfaces.
+// (e.g. "json", "markdown", "csv"). Names are part of the LLM-facing
+// surface — see Hyrum's law on stable name surfaces.
 type Format string
 
 // Known framework-default formats. Adapters may declare additional names
@@ -21,6 +21,9 @@ const (
 	FormatJSON     Format = "json"
 	FormatMarkdown Format = "markdown"
 	FormatText     Format = "text"
+	FormatCSV      Format = "csv"
+	FormatTSV      Format = "tsv"
+	FormatTOON     Format = "toon"
 )
 
 // SpecFile is the top-level structure of a compact.yaml file.
@@ -43,6 +46,11 @@ type SpecFile struct {
 // Spec and Views are mutually exclusive — the loader validates this at parse.
 // Designed to grow: future fields (Script, Compose, FetchFrom) can be added
 // without breaking existing YAML or pipeline code.
+//
+// Untrusted applies to either form: it lists the paths, as they appear
+// after compaction, whose values carry third-party text the server may
+// quarantine ("messages[].text"; "." for the whole result). A tool may
+// declare Untrusted alone, without compaction.
 type ToolConfig struct {
 	// Flat form
 	Spec     []RawSpec `yaml:"spec,omitempty"`
@@ -51,6 +59,8 @@ type ToolConfig struct {
 	// Multi-view form
 	Views   map[string]ViewConfig `yaml:"views,omitempty"`
 	Default *DefaultSelection     `yaml:"default,omitempty"`
+
+	Untrusted []string `yaml:"untrusted,omitempty"`
 }
 
 // ViewConfig is one projection of a tool's output.
@@ -0,0 +1,330 @@
+package compact
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	mcp "github.com/daltoniam/switchboard"
+	"gopkg.in/yaml.v3"
+)
+
+// SuggestOptions tunes Suggest. Zero values pick the defaults.
+type SuggestOptions struct {
+	// MaxDepth drops fields nested deeper than this many keys (default 4).
+	MaxDepth int
+	// TruncateAbove adds a truncate transform to string fields whose
+	// average length exceeds it, in characters (default 1000).
+	TruncateAbove int
+}
+
+const (
+	defaultSuggestMaxDepth      = 4
+	defaultSuggestTruncateAbove = 1000
+)
+
+// FieldProfile describes one leaf field across the sampled responses.
+// Path is in spec notation ("user.login", "labels[].name").
+type FieldProfile struct {
+	Path string
+	// Seen counts samples the field appears in; Present counts those
+	// where it held something other than null, "", [] or {}.
+	Seen, Present int
+	// Bytes is the field's total encoded size across samples.
+	Bytes int
+	// AvgChars is the average length of the field's string values.
+	AvgChars int
+	Depth    int
+	// Spec is the spec line suggested for the field, empty when dropped.
+	Spec RawSpec
+	// Reason explains why the field was dropped or transformed.
+	Reason string
+}
+
+// Suggestion is a proposed compaction spec for one tool, with the savings it
+// would have had on the samples it was built from.
+type Suggestion struct {
+	Tool    mcp.ToolName
+	Spec    []RawSpec
+	Fields  []FieldProfile
+	Samples int
+	// BytesBefore and BytesAfter total the samples' size without and with
+	// the suggested spec applied.
+	BytesBefore, BytesAfter int
+}
+
+// SavingsPct is the share of the sampled bytes the spec removes.
+func (s Suggestion) SavingsPct() int {
+	if s.BytesBefore == 0 {
+		return 0
+	}
+	return 100 - s.BytesAfter*100/s.BytesBefore
+}
+
+// YAML renders the suggestion as a compact.yaml document.
+func (s Suggestion) YAML() ([]byte, error) {
+	return marshalSpecFile(SpecFile{
+		Version: 1,
+		Tools:   map[string]ToolConfig{string(s.Tool): {Spec: s.Spec}},
+	})
+}
+
+// Suggest profiles recorded responses for tool and proposes a spec that
+// keeps every populated field except the usual noise: *_url links, GraphQL
+// node ids, avatars, fields that are always empty, and fields nested deeper
+// than MaxDepth. Long text fields keep a truncate transform. The spec is
+// checked with Load before it is returned.
+func Suggest(tool mcp.ToolName, samples [][]byte, opts SuggestOptions) (Suggestion, error) {
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = defaultSuggestMaxDepth
+	}
+	if opts.TruncateAbove <= 0 {
+		opts.TruncateAbove = defaultSuggestTruncateAbove
+	}
+
+	p := &profiler{fields: make(map[string]*fieldStats)}
+	var (
+		docs  []any
+		sizes []int
+	)
+	for _, raw := range samples {
+		var v any
+		if err := json.Unmarshal(raw, &v); err != nil {
+			continue
+		}
+		p.sample++
+		p.walk(v, "", 0)
+		docs = append(docs, v)
+		sizes = append(sizes, len(raw))
+	}
+	if len(docs) == 0 {
+		return Suggestion{}, fmt.Errorf("compact: no JSON samples recorded for %q", tool)
+	}
+	if len(p.fields) == 0 {
+		return Suggestion{}, fmt.Errorf("compact: samples for %q have no object fields to select", tool)
+	}
+
+	sug := Suggestion{Tool: tool, Samples: len(docs)}
+	for _, path := range slices.Sorted(maps.Keys(p.fields)) {
+		fs := p.fields[path]
+		fp := FieldProfile{
+			Path:    path,
+			Seen:    fs.seen,
+			Present: fs.present,
+			Bytes:   fs.bytes,
+			Depth:   fs.depth,
+		}
+		if fs.strings > 0 {
+			fp.AvgChars = fs.chars / fs.strings
+		}
+		fp.Spec, fp.Reason = suggestField(fp, opts)
+		if fp.Spec != "" {
+			sug.Spec = append(sug.Spec, fp.Spec)
+		}
+		sug.Fields = append(sug.Fields, fp)
+	}
+	if len(sug.Spec) == 0 {
+		return Suggestion{}, fmt.Errorf("compact: every field of %q was dropped as noise; nothing to suggest", tool)
+	}
+
+	out, err := sug.YAML()
+	if err != nil {
+		return Suggestion{}, err
+	}
+	res, err := Load(out, Options{Strict: true})
+	if err != nil {
+		return Suggestion{}, fmt.Errorf("compact: suggested spec for %q does not load: %w", tool, err)
+	}
+	fields := res.Specs[tool]
+	for i, v := range docs {
+		sug.BytesBefore += sizes[i]
+		if b, err := json.Marshal(mcp.CompactAny(v, fields)); err == nil {
+			sug.BytesAfter += len(b)
+		}
+	}
+	return sug, nil
+}
+
+// suggestField decides one field's spec line, or why it has none.
+func suggestField(fp FieldProfile, opts SuggestOptions) (RawSpec, string) {
+	segs := strings.Split(fp.Path, ".")
+	key := strings.TrimSuffix(segs[len(segs)-1], "[]")
+	lower := strings.ToLower(key)
+	switch {
+	case fp.Present == 0:
+		return "", "always empty"
+	case strings.HasSuffix(lower, "_url"):
+		return "", "URL"
+	case lower == "node_id" || strings.HasSuffix(lower, "_node_id"):
+		return "", "node id"
+	case strings.Contains(lower, "avatar"):
+		return "", "avatar"
+	case fp.Depth > opts.MaxDepth:
+		return "", fmt.Sprintf("nested deeper than %d", opts.MaxDepth)
+	case fp.AvgChars > opts.TruncateAbove:
+		return RawSpec(fmt.Sprintf("%s|truncate:%d", fp.Path, opts.TruncateAbove/2)),
+			fmt.Sprintf("long text (avg %d chars)", fp.AvgChars)
+	}
+	return RawSpec(fp.Path), ""
+}
+
+type fieldStats struct {
+	seen, present int
+	bytes         int
+	chars         int
+	strings       int
+	depth         int
+	lastSample    int
+	lastPresent   int
+}
+
+type profiler struct {
+	sample int
+	fields map[string]*fieldStats
+}
+
+// walk records the leaves of v under prefix. Objects and arrays of objects
+// are descended into; scalars, arrays of scalars and empty containers are
+// leaves.
+func (p *profiler) walk(v any, prefix string, depth int) {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			if strings.ContainsAny(k, ".:|[]*") || strings.HasPrefix(k, "-") {
+				continue // not expressible as a spec path segment
+			}
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			p.visit(child, path, depth+1)
+		}
+	case []any:
+		for _, el := range t {
+			p.walk(el, prefix, depth)
+		}
+	}
+}
+
+func (p *profiler) visit(v any, path string, depth int) {
+	switch t := v.(type) {
+	case map[string]any:
+		if len(t) > 0 {
+			p.walk(t, path, depth)
+			return
+		}
+	case []any:
+		if slices.ContainsFunc(t, func(el any) bool { _, ok := el.(map[string]any); return ok }) {
+			p.walk(t, path+"[]", depth)
+			return
+		}
+	}
+	p.leaf(v, path, depth)
+}
+
+func (p *profiler) leaf(v any, path string, depth int) {
+	fs, ok := p.fields[path]
+	if !ok {
+		fs = &fieldStats{depth: depth}
+		p.fields[path] = fs
+	}
+	if fs.lastSample != p.sample {
+		fs.lastSample = p.sample
+		fs.seen++
+	}
+	if !isEmptyValue(v) && fs.lastPresent != p.sample {
+		fs.lastPresent = p.sample
+		fs.present++
+	}
+	if b, err := json.Marshal(v); err == nil {
+		fs.bytes += len(b)
+	}
+	if s, ok := v.(string); ok {
+		fs.strings++
+		fs.chars += utf8.RuneCountInString(s)
+	}
+}
+
+func isEmptyValue(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case []any:
+		return len(t) == 0
+	case map[string]any:
+		return len(t) == 0
+	}
+	return false
+}
+
+// WriteOverlay merges the suggestion into <dir>/<adapter>.yaml, the overlay
+// LoadWithOverlay reads from $SWITCHBOARD_COMPACT_DIR, replacing the spec
+// of any existing entry for the tool and keeping its max_bytes, views and
+// untrusted paths. The merged file is validated with Load
+// before it is written, so a bad merge leaves the old file in place.
+// Comments in an existing overlay are not preserved.
+func WriteOverlay(dir, adapter string, s Suggestion) (string, error) {
+	if !sampleToolNameRE.MatchString(adapter) {
+		return "", fmt.Errorf("compact: invalid adapter name %q", adapter)
+	}
+	path := filepath.Join(dir, adapter+".yaml")
+
+	sf := SpecFile{Version: 1}
+	data, err := os.ReadFile(path) // #nosec G304 -- dir is operator-controlled, adapter name is validated above
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return "", fmt.Errorf("compact: read overlay %s: %w", path, err)
+	default:
+		if err := yaml.Unmarshal(data, &sf); err != nil {
+			return "", fmt.Errorf("compact: parse overlay %s: %w", path, err)
+		}
+	}
+	if sf.Tools == nil {
+		sf.Tools = make(map[string]ToolConfig)
+	}
+	tc := sf.Tools[string(s.Tool)]
+	tc.Spec = s.Spec
+	sf.Tools[string(s.Tool)] = tc
+
+	out, err := marshalSpecFile(sf)
+	if err != nil {
+		return "", err
+	}
+	if _, err := Load(out, Options{Strict: true}); err != nil {
+		return "", fmt.Errorf("compact: merged overlay %s does not load: %w", path, err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("compact: create overlay dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out, 0o644); err != nil { // #nosec G306 -- overlay specs are not secret
+		return "", fmt.Errorf("compact: write overlay: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", fmt.Errorf("compact: write overlay: %w", err)
+	}
+	return path, nil
+}
+
+func marshalSpecFile(sf SpecFile) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(sf); err != nil {
+		return nil, fmt.Errorf("compact: encode spec: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("compact: encode spec: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,195 @@
+package compact_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	mcp "github.com/daltoniam/switchboard"
+
+	"github.com/daltoniam/switchboard/compact"
+)
+
+func TestRecorder_CapsSamplesPerTool(t *testing.T) {
+	dir := t.TempDir()
+	r := compact.NewRecorder(dir, 2)
+
+	r.Record("github_list_issues", []byte("[\n  {\"id\": 1}\n]"))
+	r.Record("github_list_issues", []byte(`not json`))
+	r.Record("github_list_issues", []byte(`"a string"`))
+	r.Record("github_list_issues", []byte(`{"id":2}`))
+	r.Record("github_list_issues", []byte(`{"id":3}`))
+	r.Record("../escape", []byte(`{"id":4}`))
+
+	got, err := compact.LoadSamples(dir, "github_list_issues")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`[{"id":1}]`, `{"id":2}`}
+	if len(got) != len(want) {
+		t.Fatalf("got %d samples, want %d: %q", len(got), len(want), got)
+	}
+	for i := range want {
+		if string(got[i]) != want[i] {
+			t.Errorf("sample %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+
+	// A fresh recorder picks up the existing count instead of appending.
+	compact.NewRecorder(dir, 2).Record("github_list_issues", []byte(`{"id":5}`))
+	if got, _ := compact.LoadSamples(dir, "github_list_issues"); len(got) != 2 {
+		t.Errorf("cap not honored across recorders: %d samples", len(got))
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("want only the github_list_issues sample file, got %d entries", len(entries))
+	}
+}
+
+func TestRecorder_NilIsNoop(t *testing.T) {
+	var r *compact.Recorder
+	r.Record("x", []byte(`{}`)) // must not panic
+	t.Setenv(compact.EnvRecordDir, "")
+	if compact.RecorderFromEnv() != nil {
+		t.Error("RecorderFromEnv with the variable unset should return nil")
+	}
+}
+
+func issueSamples(n int) [][]byte {
+	samples := make([][]byte, n)
+	for i := range samples {
+		samples[i] = []byte(fmt.Sprintf(`[{
+			"id": %d,
+			"node_id": "I_kwDO%d",
+			"title": "Issue %d",
+			"html_url": "https://github.com/o/r/issues/%d",
+			"body": %q,
+			"milestone": null,
+			"user": {"login": "octo", "avatar_url": "https://avatars/1", "gravatar_id": ""},
+			"labels": [{"name": "bug", "url": "https://api/labels/bug"}],
+			"a": {"b": {"c": {"d": {"e": 1}}}}
+		}]`, i, i, i, i, strings.Repeat("x", 3000)))
+	}
+	return samples
+}
+
+func TestSuggest_DropsNoiseAndTruncatesLongText(t *testing.T) {
+	sug, err := compact.Suggest("github_list_issues", issueSamples(3), compact.SuggestOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []compact.RawSpec{
+		"body|truncate:500",
+		"id",
+		"labels[].name",
+		"labels[].url",
+		"title",
+		"user.login",
+	}
+	if !reflect.DeepEqual(sug.Spec, want) {
+		t.Errorf("spec = %q, want %q", sug.Spec, want)
+	}
+
+	reasons := map[string]string{}
+	for _, f := range sug.Fields {
+		reasons[f.Path] = f.Reason
+	}
+	for path, reason := range map[string]string{
+		"node_id":          "node id",
+		"html_url":         "URL",
+		"milestone":        "always empty",
+		"user.avatar_url":  "URL",
+		"user.gravatar_id": "always empty",
+		"a.b.c.d.e":        "nested deeper than 4",
+		"body":             "long text (avg 3000 chars)",
+	} {
+		if reasons[path] != reason {
+			t.Errorf("%s: reason %q, want %q", path, reasons[path], reason)
+		}
+	}
+
+	if sug.Samples != 3 || sug.BytesAfter >= sug.BytesBefore || sug.SavingsPct() < 70 {
+		t.Errorf("unexpected savings: %d samples, %d → %d bytes (%d%%)", sug.Samples, sug.BytesBefore, sug.BytesAfter, sug.SavingsPct())
+	}
+
+	out, err := sug.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := compact.Load(out, compact.Options{Strict: true})
+	if err != nil {
+		t.Fatalf("suggested YAML does not load: %v\n%s", err, out)
+	}
+	if len(res.Specs["github_list_issues"]) != len(want) {
+		t.Errorf("loaded %d fields, want %d", len(res.Specs["github_list_issues"]), len(want))
+	}
+}
+
+func TestSuggest_Errors(t *testing.T) {
+	if _, err := compact.Suggest("t", [][]byte{[]byte(`nope`)}, compact.SuggestOptions{}); err == nil {
+		t.Error("want error for no JSON samples")
+	}
+	if _, err := compact.Suggest("t", [][]byte{[]byte(`[1,2,3]`)}, compact.SuggestOptions{}); err == nil {
+		t.Error("want error for samples without object fields")
+	}
+	if _, err := compact.Suggest("t", [][]byte{[]byte(`{"avatar_url":"x","n":null}`)}, compact.SuggestOptions{}); err == nil {
+		t.Error("want error when every field is noise")
+	}
+}
+
+func TestWriteOverlay_MergesIntoExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	existing := `version: 1
+tools:
+  github_get_issue:
+    spec: [number, title]
+  github_list_issues:
+    spec: [id]
+    max_bytes: 4096
+    untrusted: ["[].body"]
+`
+	if err := os.WriteFile(filepath.Join(dir, "github.yaml"), []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sug, err := compact.Suggest("github_list_issues", issueSamples(1), compact.SuggestOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := compact.WriteOverlay(dir, "github", sug)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := compact.Load(data, compact.Options{Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Specs[mcp.ToolName("github_get_issue")]) != 2 {
+		t.Error("existing tool entry was not preserved")
+	}
+	if len(res.Specs[mcp.ToolName("github_list_issues")]) != len(sug.Spec) {
+		t.Error("suggested spec did not replace the old entry")
+	}
+	if res.MaxBytes[mcp.ToolName("github_list_issues")] != 4096 {
+		t.Error("max_bytes of the replaced entry was dropped")
+	}
+	if got := res.Untrusted[mcp.ToolName("github_list_issues")]; len(got) != 1 || got[0] != "[].body" {
+		t.Errorf("untrusted paths of the replaced entry = %v, want [[].body]", got)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("temporary file left behind")
+	}
+
+	if _, err := compact.WriteOverlay(dir, "../github", sug); err == nil {
+		t.Error("want error for an adapter name with a path separator")
+	}
+}
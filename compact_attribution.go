@@ -0,0 +1,121 @@
+package mcp
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// OtherFieldsPath collects the bytes of fields beyond a tool's attribution
+// cap, so responses keyed by ids cannot grow the table without bound.
+const OtherFieldsPath = "(other)"
+
+// FieldBytes attributes v's approximate encoded size to its leaf fields,
+// keyed by spec-notation path ("user.login", "labels[].name"). A field's
+// bytes include its key, so dropping it drops exactly that much. Objects and
+// arrays of objects are descended into; scalars, arrays of scalars and empty
+// containers are leaves. Elements of a root array share their fields' paths.
+//
+// Sizes are estimates: strings count their UTF-8 bytes plus quotes, without
+// escaping, which is close enough to rank fields.
+func FieldBytes(v any) map[string]int {
+	out := make(map[string]int)
+	addFieldBytes(out, v, "")
+	return out
+}
+
+// CompactedFieldBytes is FieldBytes for out, the result of compacting with
+// fields, with each output key that compaction renamed (aliases, single-field
+// array groups flattened to "labels": [...]) attributed back to its source
+// path, so the result lines up with FieldBytes of the input.
+func CompactedFieldBytes(out any, fields []CompactField) map[string]int {
+	got := FieldBytes(out)
+	for _, f := range fields {
+		if f.exclude {
+			continue
+		}
+		src := strings.Join(f.path, ".")
+		if n, ok := got[f.outputKey]; ok && f.outputKey != src {
+			delete(got, f.outputKey)
+			got[src] += n
+		}
+	}
+	return got
+}
+
+func addFieldBytes(out map[string]int, v any, prefix string) {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			keyBytes := len(k) + 3 // "k":
+			switch c := child.(type) {
+			case map[string]any:
+				if len(c) > 0 {
+					addFieldBytes(out, c, path)
+					continue
+				}
+			case []any:
+				if hasObject(c) {
+					addFieldBytes(out, c, path+"[]")
+					continue
+				}
+			}
+			out[path] += keyBytes + encodedSize(child)
+		}
+	case []any:
+		for _, el := range t {
+			addFieldBytes(out, el, prefix)
+		}
+	}
+}
+
+func hasObject(s []any) bool {
+	for _, el := range s {
+		if _, ok := el.(map[string]any); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// encodedSize estimates the JSON-encoded length of v.
+func encodedSize(v any) int {
+	switch t := v.(type) {
+	case nil:
+		return 4
+	case bool:
+		if t {
+			return 4
+		}
+		return 5
+	case string:
+		if utf8.ValidString(t) {
+			return len(t) + 2
+		}
+		return len(strconv.Quote(t))
+	case float64:
+		return len(strconv.FormatFloat(t, 'g', -1, 64))
+	case int:
+		return len(strconv.Itoa(t))
+	case map[string]any:
+		n := 2 + max(len(t)-1, 0) // braces and commas
+		for k, child := range t {
+			n += len(k) + 3 + encodedSize(child)
+		}
+		return n
+	case []any:
+		n := 2 + max(len(t)-1, 0) // brackets and commas
+		for _, el := range t {
+			n += encodedSize(el)
+		}
+		return n
+	}
+	if s, ok := v.(interface{ String() string }); ok {
+		return len(s.String())
+	}
+	return 0
+}
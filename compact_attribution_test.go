@@ -0,0 +1,46 @@
+package mcp
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldBytes(t *testing.T) {
+	var v any
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"id": 1, "user": {"login": "octo"}, "labels": [{"name": "bug"}], "tags": ["a", 2], "meta": {}},
+		{"id": 22, "user": {"login": "cat"}, "labels": [], "tags": [], "meta": {}}
+	]`), &v))
+
+	got := FieldBytes(v)
+	assert.Equal(t, map[string]int{
+		"id":            len(`"id":1`) + len(`"id":22`),
+		"user.login":    len(`"login":"octo"`) + len(`"login":"cat"`),
+		"labels[].name": len(`"name":"bug"`),
+		"labels":        len(`"labels":[]`),
+		"tags":          len(`"tags":["a",2]`) + len(`"tags":[]`),
+		"meta":          2 * len(`"meta":{}`),
+	}, got)
+}
+
+func TestFieldBytes_DroppedFieldsDisappear(t *testing.T) {
+	var v any
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"id": 1, "body": "long text", "title": "t",
+		"user": {"login": "octo", "avatar_url": "https://x"},
+		"labels": [{"name": "bug", "url": "https://y"}]
+	}`), &v))
+	fields, err := ParseCompactSpecs([]string{"id", "title:name", "user.login", "labels[].name"})
+	require.NoError(t, err)
+
+	before, after := FieldBytes(v), CompactedFieldBytes(CompactAny(v, fields), fields)
+	assert.ElementsMatch(t, []string{"id", "title", "user.login", "labels[].name"}, slices.Collect(maps.Keys(after)),
+		"renamed and flattened outputs are attributed to their source paths")
+	assert.Equal(t, before["id"], after["id"])
+	assert.Greater(t, before["body"], 0)
+}
@@ -0,0 +1,192 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// valueTransform is one "|name" or "|name:n" step of a compaction spec. It
+// shrinks the value the spec selects rather than choosing which fields to
+// keep.
+type valueTransform struct {
+	name string
+	n    int
+}
+
+// transformArgs records, for each known transform, the smallest count it
+// takes, or 0 when it takes no argument. truncate and lines spend one
+// character or line on their marker, so a count of 1 would keep nothing.
+var transformArgs = map[string]int{
+	"truncate":   2, // strings: at most n characters, ending in "…"
+	"limit":      1, // arrays: at most n elements
+	"lines":      2, // strings: at most n lines, the last noting how many were cut
+	"date":       0,
+	"strip_html": 0,
+}
+
+// TransformStats counts the values a compaction's transforms changed and the
+// bytes that removed from the response.
+type TransformStats struct {
+	Values     int
+	BytesSaved int
+}
+
+func (st *TransformStats) record(saved int) {
+	if st == nil {
+		return
+	}
+	st.Values++
+	st.BytesSaved += saved
+}
+
+// parseTransforms parses the "|"-separated steps after a spec's field path.
+func parseTransforms(spec string, steps []string) ([]valueTransform, error) {
+	out := make([]valueTransform, 0, len(steps))
+	for _, step := range steps {
+		name, arg, hasArg := strings.Cut(step, ":")
+		minArg, known := transformArgs[name]
+		takesArg := minArg > 0
+		switch {
+		case !known:
+			return nil, fmt.Errorf("compact spec: unknown transform %q in %q (want truncate, limit, lines, date or strip_html)", name, spec)
+		case takesArg && !hasArg:
+			return nil, fmt.Errorf("compact spec: transform %q needs a count in %q (e.g. %s:100)", name, spec, name)
+		case !takesArg && hasArg:
+			return nil, fmt.Errorf("compact spec: transform %q takes no argument in %q", name, spec)
+		}
+		t := valueTransform{name: name}
+		if takesArg {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < minArg {
+				return nil, fmt.Errorf("compact spec: transform %q needs a count of at least %d in %q, got %q", name, minArg, spec, arg)
+			}
+			t.n = n
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// applyTransforms runs ts over v in order. Strings are transformed directly
+// and arrays element by element, except for limit, which shortens the array
+// itself. Other values pass through unchanged.
+func applyTransforms(v any, ts []valueTransform, st *TransformStats) any {
+	for _, t := range ts {
+		v = t.apply(v, st)
+	}
+	return v
+}
+
+func (t valueTransform) apply(v any, st *TransformStats) any {
+	switch val := v.(type) {
+	case string:
+		// A marker can outweigh what it replaces ("…" is three bytes, so
+		// truncate:3 lengthens "abcd"); such values are left as they were.
+		out := t.applyString(val)
+		if len(out) >= len(val) {
+			return val
+		}
+		st.record(len(val) - len(out))
+		return out
+	case []any:
+		if t.name == "limit" {
+			if len(val) <= t.n {
+				return val
+			}
+			dropped, _ := json.Marshal(val[t.n:])
+			st.record(len(dropped))
+			return val[:t.n:t.n]
+		}
+		out := make([]any, len(val))
+		for i, el := range val {
+			out[i] = t.apply(el, st)
+		}
+		return out
+	}
+	return v
+}
+
+func (t valueTransform) applyString(s string) string {
+	switch t.name {
+	case "truncate":
+		if utf8.RuneCountInString(s) <= t.n {
+			return s
+		}
+		// Cut to n characters including the ellipsis, so a second pass
+		// leaves the value alone.
+		cut, i := 0, 0
+		for i = range s {
+			if cut == t.n-1 {
+				break
+			}
+			cut++
+		}
+		return s[:i] + "…"
+	case "lines":
+		lines := strings.Split(s, "\n")
+		if len(lines) <= t.n {
+			return s
+		}
+		keep := lines[:t.n-1]
+		return strings.Join(append(keep, fmt.Sprintf("… (%d more lines)", len(lines)-len(keep))), "\n")
+	case "date":
+		return formatDate(s)
+	case "strip_html":
+		return stripHTML(s)
+	}
+	return s
+}
+
+// dateLayouts are the timestamp shapes the date transform recognizes.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+}
+
+// formatDate shortens a timestamp to its date in the timestamp's own offset.
+// Strings that are not timestamps are returned unchanged.
+func formatDate(s string) string {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format(time.DateOnly)
+		}
+	}
+	return s
+}
+
+var (
+	htmlSkipRE  = regexp.MustCompile(`(?is)<script\b.*?</script\s*>|<style\b.*?</style\s*>|<!--.*?-->`)
+	htmlBlockRE = regexp.MustCompile(`(?i)<(?:br|/?p|/?div|li|/?ul|/?ol|tr|/?h[1-6]|/?blockquote|/?pre|/?table|hr)\b[^>]*>`)
+	htmlTagRE   = regexp.MustCompile(`<[^>]*>`)
+	spaceRunRE  = regexp.MustCompile(`[ \t\r\f\v]+`)
+	blankRunRE  = regexp.MustCompile(`\n\s*\n\s*\n+`)
+)
+
+// stripHTML reduces HTML to its text: block elements become line breaks,
+// other tags are dropped, entities are decoded and whitespace is collapsed.
+func stripHTML(s string) string {
+	if !strings.ContainsAny(s, "<&") {
+		return s
+	}
+	s = htmlSkipRE.ReplaceAllString(s, "")
+	s = htmlBlockRE.ReplaceAllString(s, "\n")
+	s = htmlTagRE.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = spaceRunRE.ReplaceAllString(s, " ")
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	s = strings.Join(lines, "\n")
+	s = blankRunRE.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,191 @@
+package mcp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCompactSpec_Transforms(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    CompactField
+		wantErr bool
+	}{
+		{
+			name: "truncate",
+			spec: "body|truncate:500",
+			want: CompactField{path: []string{"body"}, outputKey: "body", arrayIdx: -1, transforms: []valueTransform{{name: "truncate", n: 500}}},
+		},
+		{
+			name: "chained with rename",
+			spec: "body_html:body|strip_html|lines:20",
+			want: CompactField{path: []string{"body_html"}, outputKey: "body", arrayIdx: -1, transforms: []valueTransform{{name: "strip_html"}, {name: "lines", n: 20}}},
+		},
+		{
+			name: "bare array with limit",
+			spec: "labels[]|limit:5",
+			want: CompactField{path: []string{"labels[]"}, outputKey: "labels", arrayIdx: 0, arrayKey: "labels", childPath: []string{}, transforms: []valueTransform{{name: "limit", n: 5}}},
+		},
+		{name: "unknown transform", spec: "body|shorten:5", wantErr: true},
+		{name: "missing count", spec: "body|truncate", wantErr: true},
+		{name: "zero count", spec: "body|truncate:0", wantErr: true},
+		{name: "truncate to the marker alone", spec: "body|truncate:1", wantErr: true},
+		{name: "lines to the note alone", spec: "diff|lines:1", wantErr: true},
+		{name: "non-numeric count", spec: "body|limit:many", wantErr: true},
+		{name: "argument on date", spec: "created_at|date:iso", wantErr: true},
+		{name: "empty step", spec: "body|", wantErr: true},
+		{name: "no field", spec: "|date", wantErr: true},
+		{name: "on exclusion", spec: "-body|truncate:5", wantErr: true},
+		{name: "bare array without transform", spec: "labels[]", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCompactSpec(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCompactJSON_Transforms(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		specs []string
+		want  string
+	}{
+		{
+			name:  "truncate string",
+			input: `{"number":1,"body":"abcdefghij"}`,
+			specs: []string{"number", "body|truncate:5"},
+			want:  `{"number":1,"body":"abcd…"}`,
+		},
+		{
+			name:  "truncate counts characters, not bytes",
+			input: `{"title":"héllo wörld"}`,
+			specs: []string{"title|truncate:4"},
+			want:  `{"title":"hél…"}`,
+		},
+		{
+			name:  "short values are unchanged",
+			input: `{"body":"abc","labels":["a"]}`,
+			specs: []string{"body|truncate:5", "labels[]|limit:5"},
+			want:  `{"body":"abc","labels":["a"]}`,
+		},
+		{
+			name:  "limit whole array",
+			input: `{"labels":[{"name":"a"},{"name":"b"},{"name":"c"}]}`,
+			specs: []string{"labels[]|limit:2"},
+			want:  `{"labels":[{"name":"a"},{"name":"b"}]}`,
+		},
+		{
+			name:  "limit projected values",
+			input: `{"labels":[{"name":"a"},{"name":"b"},{"name":"c"}]}`,
+			specs: []string{"labels[].name|limit:2"},
+			want:  `{"labels":["a","b"]}`,
+		},
+		{
+			name:  "bare array limits a projected group",
+			input: `{"steps":[{"name":"a","x":1},{"name":"b","x":2},{"name":"c","x":3}]}`,
+			specs: []string{"steps[].name", "steps[].x", "steps[]|limit:2"},
+			want:  `{"steps":[{"name":"a","x":1},{"name":"b","x":2}]}`,
+		},
+		{
+			name:  "group member transform applies per element",
+			input: `{"comments":[{"id":1,"text":"abcdef"},{"id":2,"text":"xy"}]}`,
+			specs: []string{"comments[].id", "comments[].text|truncate:3"},
+			want:  `{"comments":[{"id":1,"text":"ab…"},{"id":2,"text":"xy"}]}`,
+		},
+		{
+			name:  "nested bare array limits per element",
+			input: `{"items":[{"labels":["a","b","c"]},{"labels":["d"]}]}`,
+			specs: []string{"items[].labels[]|limit:2"},
+			want:  `{"items":[{"labels":["a","b"]},{"labels":["d"]}]}`,
+		},
+		{
+			name:  "date",
+			input: `{"created_at":"2024-03-05T17:04:05Z","due":"2024-03-06 09:00:00","note":"soon"}`,
+			specs: []string{"created_at|date", "due|date", "note|date"},
+			want:  `{"created_at":"2024-03-05","due":"2024-03-06","note":"soon"}`,
+		},
+		{
+			name:  "lines",
+			input: `{"diff":"+ first added line\n+ second added line\n+ third added line\n+ fourth added line\n+ fifth added line"}`,
+			specs: []string{"diff|lines:3"},
+			want:  `{"diff":"+ first added line\n+ second added line\n… (3 more lines)"}`,
+		},
+		{
+			name:  "cuts that would lengthen a value are skipped",
+			input: `{"title":"abcd","diff":"1\n2\n3\n4\n5"}`,
+			specs: []string{"title|truncate:3", "diff|lines:3"},
+			want:  `{"title":"abcd","diff":"1\n2\n3\n4\n5"}`,
+		},
+		{
+			name:  "strip_html",
+			input: `{"description":"<p>Hello &amp; <b>welcome</b></p><script>x()</script><ul><li>one</li><li>two</li></ul>"}`,
+			specs: []string{"description|strip_html"},
+			want:  `{"description":"Hello & welcome\n\none\ntwo"}`,
+		},
+		{
+			name:  "transform on a nested object group",
+			input: `{"user":{"login":"octocat","bio":"long biography"}}`,
+			specs: []string{"user.login", "user.bio|truncate:5"},
+			want:  `{"user":{"login":"octocat","bio":"long…"}}`,
+		},
+		{
+			name:  "transform in array of objects",
+			input: `[{"id":1,"body":"aaaaaa"},{"id":2,"body":"bb"}]`,
+			specs: []string{"id", "body|truncate:3"},
+			want:  `[{"id":1,"body":"aa…"},{"id":2,"body":"bb"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := ParseCompactSpecs(tt.specs)
+			require.NoError(t, err)
+
+			got, err := CompactJSON([]byte(tt.input), fields)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+
+			// Compaction is idempotent, transforms included.
+			again, err := CompactJSON(got, fields)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(again))
+		})
+	}
+}
+
+func TestCompactAnyWithStats(t *testing.T) {
+	fields, err := ParseCompactSpecs([]string{"id", "body|truncate:10", "labels[]|limit:1"})
+	require.NoError(t, err)
+
+	var v any
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"id":1,"body":"`+strings.Repeat("x", 100)+`","labels":["a","b"]},
+		{"id":2,"body":"short","labels":["c"]}
+	]`), &v))
+
+	_, st := CompactAnyWithStats(v, fields)
+	assert.Equal(t, 2, st.Values, "one truncated body and one limited array")
+	assert.Equal(t, 100-len(strings.Repeat("x", 9)+"…")+len(`["b"]`), st.BytesSaved)
+
+	_, st = CompactAnyWithStats(v, []CompactField{})
+	assert.Zero(t, st)
+
+	fields, err = ParseCompactSpecs([]string{"body|truncate:3"})
+	require.NoError(t, err)
+	_, st = CompactAnyWithStats(map[string]any{"body": "abcd"}, fields)
+	assert.Zero(t, st, "a value the marker would lengthen is not counted")
+}
@@ -446,6 +446,12 @@ func (m *manager) Load() error {
 			return fmt.Errorf("config: integration %q: %w", name, err)
 		}
 	}
+	if err := mcp.ValidateRedaction(cfg.Redaction); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	if err := mcp.ValidateQuarantine(cfg.Quarantine); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
 	m.applyEnvOverrides()
 	return nil
 }
@@ -457,6 +463,11 @@ func mergeWithDefaults(file *mcp.Config) *mcp.Config {
 	cfg.SessionStore = file.SessionStore
 	cfg.ShowDollarEstimate = file.ShowDollarEstimate
 	cfg.DollarsPerMTokInput = file.DollarsPerMTokInput
+	cfg.Tokenizer = file.Tokenizer
+	cfg.MetricsHistoryDays = file.MetricsHistoryDays
+	cfg.Redaction = file.Redaction
+	cfg.Quarantine = file.Quarantine
+	cfg.Telemetry = file.Telemetry
 	if file.Integrations == nil {
 		return cfg
 	}
@@ -533,6 +544,12 @@ func (m *manager) Update(cfg *mcp.Config) error {
 			return fmt.Errorf("integration %q: %w", name, err)
 		}
 	}
+	if err := mcp.ValidateRedaction(cfg.Redaction); err != nil {
+		return err
+	}
+	if err := mcp.ValidateQuarantine(cfg.Quarantine); err != nil {
+		return err
+	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.cfg = cfg
@@ -122,6 +122,47 @@ func TestLoad_RejectsInvalidToolGlobs(t *testing.T) {
 	assert.Contains(t, err.Error(), "github")
 }
 
+func TestLoad_Redaction(t *testing.T) {
+	m, path := newTestManager(t)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, os.WriteFile(path, []byte(`{"redaction": {"enabled": true, "rules": [{"type": "phone", "pattern": "\\+1 \\d{3}-\\d{4}", "integrations": ["slack"]}]}}`), 0600))
+
+	require.NoError(t, m.Load())
+	require.NotNil(t, m.cfg.Redaction)
+	assert.True(t, m.cfg.Redaction.Enabled)
+	require.Len(t, m.cfg.Redaction.Rules, 1)
+	assert.Equal(t, []string{"slack"}, m.cfg.Redaction.Rules[0].Integrations)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"redaction": {"enabled": true, "rules": [{"type": "phone", "pattern": "("}]}}`), 0600))
+	err := m.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "redaction rule 0")
+
+	err = m.Update(&mcp.Config{Redaction: &mcp.RedactionConfig{Detectors: []string{"phone"}}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown redaction detector")
+}
+
+func TestLoad_Quarantine(t *testing.T) {
+	m, path := newTestManager(t)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, os.WriteFile(path, []byte(`{"quarantine": {"enabled": true, "mode": "strip", "integrations": {"notion": "off"}}}`), 0600))
+
+	require.NoError(t, m.Load())
+	require.NotNil(t, m.cfg.Quarantine)
+	assert.Equal(t, mcp.QuarantineStrip, m.cfg.Quarantine.ModeFor("slack"))
+	assert.Equal(t, mcp.QuarantineOff, m.cfg.Quarantine.ModeFor("notion"))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"quarantine": {"enabled": true, "mode": "block"}}`), 0600))
+	err := m.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "quarantine mode")
+
+	err = m.Update(&mcp.Config{Quarantine: &mcp.QuarantineConfig{Integrations: map[string]string{"web": "drop"}}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `integration "web"`)
+}
+
 func TestSave(t *testing.T) {
 	m, path := newTestManager(t)
 	m.cfg = defaultConfig()
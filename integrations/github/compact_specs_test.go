@@ -1,6 +1,7 @@
 package github
 
 import (
+	"maps"
 	"testing"
 
 	"github.com/daltoniam/switchboard/compact"
@@ -22,7 +23,22 @@ func TestFieldCompactionSpecs_AllParse(t *testing.T) {
 func TestFieldCompactionSpecs_NoDuplicateTools(t *testing.T) {
 	var sf compact.SpecFile
 	require.NoError(t, yaml.Unmarshal(compactYAML, &sf))
-	assert.Equal(t, len(sf.Tools), len(fieldCompactionSpecs))
+	loaded := maps.Clone(untrustedFields)
+	for name := range fieldCompactionSpecs {
+		loaded[name] = nil
+	}
+	assert.Equal(t, len(sf.Tools), len(loaded))
+}
+
+func TestUntrustedFields_DeclaredForBodies(t *testing.T) {
+	g := &integration{}
+	paths, ok := g.UntrustedFields("github_get_issue")
+	require.True(t, ok)
+	assert.Contains(t, paths, "body")
+	_, compacted := g.CompactSpec("github_get_issue")
+	assert.False(t, compacted, "untrusted-only tools must not gain an empty spec")
+	_, ok = g.UntrustedFields("github_list_labels")
+	assert.False(t, ok)
 }
 
 func TestFieldCompactionSpecs_NoOrphanSpecs(t *testing.T) {
@@ -17,12 +17,14 @@ var compactYAML []byte
 var compactResult = compact.MustLoadWithOverlay("github", compactYAML, compact.Options{Strict: false})
 var fieldCompactionSpecs = compactResult.Specs
 var maxBytesByTool = compactResult.MaxBytes
+var untrustedFields = compactResult.Untrusted
 
 // Compile-time interface assertions.
 var (
 	_ mcp.Integration                        = (*integration)(nil)
 	_ mcp.FieldCompactionIntegration         = (*integration)(nil)
 	_ mcp.ToolMaxBytesIntegration            = (*integration)(nil)
+	_ mcp.UntrustedContentIntegration        = (*integration)(nil)
 	_ mcp.PerToolMaxResponseBytesIntegration = (*integration)(nil)
 )
 
@@ -73,6 +75,11 @@ func (g *integration) MaxBytes(toolName mcp.ToolName) (int, bool) {
 	return n, ok
 }
 
+func (g *integration) UntrustedFields(toolName mcp.ToolName) ([]string, bool) {
+	paths, ok := untrustedFields[toolName]
+	return paths, ok
+}
+
 // MaxResponseBytesForTool raises the integration-wide response cap for tools
 // whose responses are not amenable to compaction or pagination. github_get_pull_diff
 // returns a raw unified diff that has no projection or per-file knob — the only
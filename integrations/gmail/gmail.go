@@ -23,6 +23,7 @@ var compactYAML []byte
 var compactResult = compact.MustLoadWithOverlay("gmail", compactYAML, compact.Options{Strict: false})
 var fieldCompactionSpecs = compactResult.Specs
 var maxBytesByTool = compactResult.MaxBytes
+var untrustedFields = compactResult.Untrusted
 
 type gmail struct {
 	accessToken  string
@@ -36,10 +37,11 @@ type gmail struct {
 }
 
 var (
-	_ mcp.FieldCompactionIntegration = (*gmail)(nil)
-	_ mcp.MarkdownIntegration        = (*gmail)(nil)
-	_ mcp.PlainTextCredentials       = (*gmail)(nil)
-	_ mcp.ToolMaxBytesIntegration    = (*gmail)(nil)
+	_ mcp.FieldCompactionIntegration  = (*gmail)(nil)
+	_ mcp.MarkdownIntegration         = (*gmail)(nil)
+	_ mcp.PlainTextCredentials        = (*gmail)(nil)
+	_ mcp.ToolMaxBytesIntegration     = (*gmail)(nil)
+	_ mcp.UntrustedContentIntegration = (*gmail)(nil)
 )
 
 func (g *gmail) PlainTextKeys() []string {
@@ -106,6 +108,11 @@ func (g *gmail) MaxBytes(toolName mcp.ToolName) (int, bool) {
 	return n, ok
 }
 
+func (g *gmail) UntrustedFields(toolName mcp.ToolName) ([]string, bool) {
+	paths, ok := untrustedFields[toolName]
+	return paths, ok
+}
+
 // --- HTTP helpers ---
 
 func (g *gmail) doRequest(ctx context.Context, method, path string, body any) (json.RawMessage, error) {
@@ -65,6 +65,7 @@ var compactResult = compact.MustLoadWithOverlay("notion", compactYAML, compact.O
 var fieldCompactionSpecs = compactResult.Specs
 var maxBytesByTool = compactResult.MaxBytes
 var viewSets = compactResult.Views
+var untrustedFields = compactResult.Untrusted
 
 // compact_v1.yaml uses the same per-tool schema but matches the
 // public-API response shapes (parent objects, nested properties,
@@ -78,13 +79,15 @@ var compactV1Result = compact.MustLoadWithOverlay("notion_v1", compactV1YAML, co
 var v1FieldCompactionSpecs = compactV1Result.Specs
 var v1MaxBytesByTool = compactV1Result.MaxBytes
 var v1ViewSets = compactV1Result.Views
+var v1UntrustedFields = compactV1Result.Untrusted
 
 var (
-	_ mcp.Integration                = (*notion)(nil)
-	_ mcp.FieldCompactionIntegration = (*notion)(nil)
-	_ mcp.MarkdownIntegration        = (*notion)(nil)
-	_ mcp.ToolMaxBytesIntegration    = (*notion)(nil)
-	_ compact.ToolViewsIntegration   = (*notion)(nil)
+	_ mcp.Integration                 = (*notion)(nil)
+	_ mcp.FieldCompactionIntegration  = (*notion)(nil)
+	_ mcp.MarkdownIntegration         = (*notion)(nil)
+	_ mcp.ToolMaxBytesIntegration     = (*notion)(nil)
+	_ compact.ToolViewsIntegration    = (*notion)(nil)
+	_ mcp.UntrustedContentIntegration = (*notion)(nil)
 )
 
 // notion is the integration's top-level type. It holds the v3 cookie
@@ -251,6 +254,15 @@ func (n *notion) Views(toolName mcp.ToolName) (compact.ViewSet, bool) {
 	return vs, ok
 }
 
+func (n *notion) UntrustedFields(toolName mcp.ToolName) ([]string, bool) {
+	if n.v1 != nil {
+		paths, ok := v1UntrustedFields[toolName]
+		return paths, ok
+	}
+	paths, ok := untrustedFields[toolName]
+	return paths, ok
+}
+
 func (n *notion) Execute(ctx context.Context, toolName mcp.ToolName, args map[string]any) (*mcp.ToolResult, error) {
 	if n.v1 != nil {
 		fn, ok := dispatchV1[toolName]
@@ -22,13 +22,15 @@ var compactYAML []byte
 var compactResult = compact.MustLoadWithOverlay("slack", compactYAML, compact.Options{Strict: false})
 var fieldCompactionSpecs = compactResult.Specs
 var maxBytesByTool = compactResult.MaxBytes
+var untrustedFields = compactResult.Untrusted
 
 // Compile-time interface assertions.
 var (
-	_ mcp.Integration                = (*slackIntegration)(nil)
-	_ mcp.FieldCompactionIntegration = (*slackIntegration)(nil)
-	_ mcp.PlainTextCredentials       = (*slackIntegration)(nil)
-	_ mcp.ToolMaxBytesIntegration    = (*slackIntegration)(nil)
+	_ mcp.Integration                 = (*slackIntegration)(nil)
+	_ mcp.FieldCompactionIntegration  = (*slackIntegration)(nil)
+	_ mcp.PlainTextCredentials        = (*slackIntegration)(nil)
+	_ mcp.ToolMaxBytesIntegration     = (*slackIntegration)(nil)
+	_ mcp.UntrustedContentIntegration = (*slackIntegration)(nil)
 )
 
 func (s *slackIntegration) PlainTextKeys() []string {
@@ -308,6 +310,11 @@ func (s *slackIntegration) MaxBytes(toolName mcp.ToolName) (int, bool) {
 	return n, ok
 }
 
+func (s *slackIntegration) UntrustedFields(toolName mcp.ToolName) ([]string, bool) {
+	paths, ok := untrustedFields[toolName]
+	return paths, ok
+}
+
 func (s *slackIntegration) Execute(ctx context.Context, toolName mcp.ToolName, args map[string]any) (*mcp.ToolResult, error) {
 	fn, ok := dispatch[toolName]
 	if !ok {
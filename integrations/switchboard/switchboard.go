@@ -4,6 +4,7 @@ import (
 	"context"
 	_ "embed"
 	"sort"
+	"time"
 
 	mcp "github.com/daltoniam/switchboard"
 	"github.com/daltoniam/switchboard/compact"
@@ -85,6 +86,7 @@ var dispatch = map[mcp.ToolName]handlerFunc{
 	"switchboard_install_plugin":        installPlugin,
 	"switchboard_uninstall_plugin":      uninstallPlugin,
 	"switchboard_server_info":           serverInfo,
+	"switchboard_latency_report":        latencyReport,
 }
 
 // listIntegrations returns all registered integrations with their status.
@@ -448,6 +450,7 @@ func serverInfo(_ context.Context, s *switchboardInt, _ map[string]any) (*mcp.To
 
 	if s.services.Metrics != nil {
 		snap := s.services.Metrics.Snapshot()
+		snap.SlowCalls = withoutArgs(snap.SlowCalls)
 		info["metrics"] = snap
 	}
 
@@ -463,3 +466,65 @@ func serverInfo(_ context.Context, s *switchboardInt, _ map[string]any) (*mcp.To
 
 	return mcp.JSONResult(info)
 }
+
+// Latency report windows, in minutes. The maximum matches the per-minute
+// metrics history.
+const (
+	defaultLatencyWindow = 15
+	maxLatencyWindow     = 180
+)
+
+// latencyReport returns recent per-integration latency, slowest first, and
+// the slowest logged calls in the same window, without their arguments.
+func latencyReport(_ context.Context, s *switchboardInt, args map[string]any) (*mcp.ToolResult, error) {
+	if s.services.Metrics == nil {
+		return &mcp.ToolResult{
+			Data:    "metrics are not enabled on this server",
+			IsError: true,
+		}, nil
+	}
+	minutes, err := mcp.ArgInt(args, "window_minutes")
+	if err != nil {
+		return mcp.ErrResult(err)
+	}
+	if minutes <= 0 {
+		minutes = defaultLatencyWindow
+	}
+	minutes = min(minutes, maxLatencyWindow)
+	only, err := mcp.ArgStr(args, "integration")
+	if err != nil {
+		return mcp.ErrResult(err)
+	}
+	window := time.Duration(minutes) * time.Minute
+
+	integrations := []mcp.IntegrationLatency{}
+	for _, l := range s.services.Metrics.RecentLatency(window) {
+		if only == "" || string(l.Integration) == only {
+			integrations = append(integrations, l)
+		}
+	}
+	slowCalls := []mcp.SlowCall{}
+	cutoff := time.Now().Add(-window)
+	for _, c := range s.services.Metrics.SlowCalls() {
+		if c.At.After(cutoff) && (only == "" || string(c.Integration) == only) {
+			slowCalls = append(slowCalls, c)
+		}
+	}
+	slowCalls = withoutArgs(slowCalls)
+	return mcp.JSONResult(map[string]any{
+		"window_minutes":      minutes,
+		"integrations":        integrations,
+		"slow_calls":          slowCalls,
+		"slow_call_threshold": mcp.SlowCallThreshold.String(),
+	})
+}
+
+// withoutArgs strips arguments from slow calls shown to an agent. The log
+// spans every session, so even the names of another session's arguments
+// stay on the dashboard.
+func withoutArgs(calls []mcp.SlowCall) []mcp.SlowCall {
+	for i := range calls {
+		calls[i].Args = nil
+	}
+	return calls
+}
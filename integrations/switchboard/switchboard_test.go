@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	mcp "github.com/daltoniam/switchboard"
 	"github.com/daltoniam/switchboard/compact"
@@ -451,6 +452,48 @@ func TestServerInfo(t *testing.T) {
 	assert.Equal(t, float64(2), info["total_tools"])
 }
 
+func TestLatencyReport(t *testing.T) {
+	services := newTestServices()
+	services.Metrics.RecordExecution("github", "github_list_issues", 10*time.Millisecond, false, 0)
+	services.Metrics.RecordExecution("datadog", "datadog_search_logs", 3*time.Second, true, 1)
+	services.Metrics.RecordSlowCall("datadog", "datadog_search_logs", map[string]any{"query": "error", "api_key": "k"}, 3*time.Second, true, 1)
+	s := newTestIntegration(services)
+
+	res, err := latencyReport(context.Background(), s, map[string]any{})
+	require.NoError(t, err)
+	require.False(t, res.IsError, res.Data)
+
+	var report struct {
+		WindowMinutes int                      `json:"window_minutes"`
+		Integrations  []mcp.IntegrationLatency `json:"integrations"`
+		SlowCalls     []mcp.SlowCall           `json:"slow_calls"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(res.Data), &report))
+	assert.Equal(t, defaultLatencyWindow, report.WindowMinutes)
+	require.Len(t, report.Integrations, 2)
+	assert.Equal(t, mcp.IntegrationName("datadog"), report.Integrations[0].Integration, "slowest first")
+	assert.Equal(t, 100.0, report.Integrations[0].ErrorRate)
+	require.Len(t, report.SlowCalls, 1)
+	assert.Nil(t, report.SlowCalls[0].Args, "other sessions' arguments are not shown to agents")
+	assert.NotContains(t, res.Data, `"query"`)
+
+	res, err = latencyReport(context.Background(), s, map[string]any{"integration": "github", "window_minutes": float64(1000)})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(res.Data), &report))
+	assert.Equal(t, maxLatencyWindow, report.WindowMinutes)
+	require.Len(t, report.Integrations, 1)
+	assert.Equal(t, mcp.IntegrationName("github"), report.Integrations[0].Integration)
+	assert.Empty(t, report.SlowCalls)
+}
+
+func TestLatencyReport_NilMetrics(t *testing.T) {
+	services := newTestServices()
+	services.Metrics = nil
+	res, err := latencyReport(context.Background(), newTestIntegration(services), map[string]any{})
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+}
+
 // --- Compact specs tests ---
 
 func TestFieldCompactionSpecs_AllParse(t *testing.T) {
@@ -76,4 +76,15 @@ var tools = []mcp.ToolDefinition{
 			"Use for diagnostics, status checks, and understanding the current server state.",
 		Parameters: map[string]string{},
 	},
+	{
+		Name: "switchboard_latency_report",
+		Description: "Report which integrations are slow right now: per-integration call count, error rate and " +
+			"p50/p95/p99 latency over the last few minutes, slowest first, plus the slowest individual calls " +
+			"(tool, retries, duration). Use before retrying a slow call or to decide which " +
+			"integration to avoid.",
+		Parameters: map[string]string{
+			"window_minutes": "How many recent minutes to cover (default 15, max 180).",
+			"integration":    "Only report this integration.",
+		},
+	},
 }
@@ -2,6 +2,7 @@ package webfetch
 
 import (
 	"context"
+	_ "embed"
 	"fmt"
 	"io"
 	"net"
@@ -12,9 +13,19 @@ import (
 	"time"
 
 	mcp "github.com/daltoniam/switchboard"
+	"github.com/daltoniam/switchboard/compact"
 )
 
-var _ mcp.Integration = (*webfetch)(nil)
+//go:embed compact.yaml
+var compactYAML []byte
+
+var compactResult = compact.MustLoadWithOverlay("web", compactYAML, compact.Options{Strict: false})
+var untrustedFields = compactResult.Untrusted
+
+var (
+	_ mcp.Integration                 = (*webfetch)(nil)
+	_ mcp.UntrustedContentIntegration = (*webfetch)(nil)
+)
 
 const (
 	maxTimeout  = 30
@@ -61,6 +72,11 @@ func (w *webfetch) Execute(ctx context.Context, toolName mcp.ToolName, args map[
 
 func (w *webfetch) Healthy(_ context.Context) bool { return true }
 
+func (w *webfetch) UntrustedFields(toolName mcp.ToolName) ([]string, bool) {
+	paths, ok := untrustedFields[toolName]
+	return paths, ok
+}
+
 type handlerFunc func(ctx context.Context, w *webfetch, args map[string]any) (*mcp.ToolResult, error)
 
 var dispatch = map[mcp.ToolName]handlerFunc{
@@ -29,6 +29,13 @@ func TestHealthy(t *testing.T) {
 	assert.True(t, i.Healthy(context.Background()))
 }
 
+func TestUntrustedFields(t *testing.T) {
+	w := New().(mcp.UntrustedContentIntegration)
+	paths, ok := w.UntrustedFields("web_fetch")
+	require.True(t, ok)
+	assert.Equal(t, []string{mcp.UntrustedWholeResult}, paths)
+}
+
 func TestTools(t *testing.T) {
 	i := New()
 	tools := i.Tools()
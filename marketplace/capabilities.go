@@ -0,0 +1,153 @@
+package marketplace
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// CapabilityHTTP is the legacy capability string that grants a plugin
+// outbound HTTP(S) access to any public host. Plugins should prefer declaring
+// a NetworkCapability, which takes precedence when both are present.
+const CapabilityHTTP = "http"
+
+// CapabilityKV grants a plugin persistent key-value storage through the
+// host_kv_* imports, namespaced to the plugin.
+const CapabilityKV = "kv"
+
+// CapabilitySecrets lets a plugin read its configured credentials on demand
+// and persist updated values (such as refreshed tokens) through the
+// host_secret_* imports. Only keys declared in CredentialKeys can be written.
+const CapabilitySecrets = "secrets"
+
+// NetworkCapability declares the outbound network access a plugin needs.
+// The host enforces it on every host_http_request call; anything not listed
+// here is refused.
+type NetworkCapability struct {
+	// Hosts lists the hosts the plugin may contact. Entries are exact
+	// hostnames ("api.example.com"), wildcard subdomains ("*.example.com"),
+	// or host:port pairs ("localhost:8080"). Loopback, private, and
+	// link-local addresses are only reachable when named exactly here.
+	Hosts []string `json:"hosts,omitempty"`
+	// Schemes lists the allowed URL schemes. Defaults to ["https"].
+	Schemes []string `json:"schemes,omitempty"`
+	// MaxTimeoutSeconds raises the ceiling a plugin may request through the
+	// X-Host-Timeout-Seconds header. Zero keeps the host default.
+	MaxTimeoutSeconds int `json:"max_timeout_seconds,omitempty"`
+}
+
+// EffectiveSchemes returns the declared schemes, lowercased, or the default
+// ["https"] when none are declared.
+func (n *NetworkCapability) EffectiveSchemes() []string {
+	if len(n.Schemes) == 0 {
+		return []string{"https"}
+	}
+	out := make([]string, 0, len(n.Schemes))
+	for _, s := range n.Schemes {
+		out = append(out, strings.ToLower(strings.TrimSpace(s)))
+	}
+	return out
+}
+
+// CapabilityList returns a sorted, human-readable summary of everything the
+// plugin asks the host for. It is what the web UI shows for approval and
+// what gets persisted as the approved set, so two binaries requesting the
+// same access produce identical lists.
+func (pm *PluginMetadata) CapabilityList() []string {
+	if pm == nil {
+		return nil
+	}
+	var caps []string
+	switch {
+	case pm.Network != nil:
+		schemes := strings.Join(pm.Network.EffectiveSchemes(), ",")
+		for _, h := range pm.Network.Hosts {
+			caps = append(caps, fmt.Sprintf("network: %s (%s)", strings.ToLower(h), schemes))
+		}
+		if pm.Network.MaxTimeoutSeconds > 0 {
+			caps = append(caps, fmt.Sprintf("network: requests up to %ds", pm.Network.MaxTimeoutSeconds))
+		}
+	case slices.Contains(pm.Capabilities, CapabilityHTTP):
+		caps = append(caps, "network: any public host (http,https)")
+	}
+	for _, c := range pm.Capabilities {
+		if c != CapabilityHTTP {
+			caps = append(caps, c)
+		}
+	}
+	sort.Strings(caps)
+	return caps
+}
+
+// ReviewCapabilities compares the capabilities requested by an installed
+// plugin's binary against what the user has approved and reports whether the
+// plugin may be loaded. Plugins not tracked by the marketplace are always
+// allowed. When approval is required the requested list is recorded as
+// pending so the web UI can present it.
+//
+// Plugins installed before capability review existed have no approval state;
+// their current request is recorded as the approved baseline. Unsigned
+// plugins stay pending until the user overrides their trust.
+func (m *Manager) ReviewCapabilities(name string, requested []string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	idx := slices.IndexFunc(m.cfg.InstalledPlugins, func(ip InstalledPlugin) bool { return ip.Name == name })
+	if idx < 0 {
+		return true, nil
+	}
+	ip := &m.cfg.InstalledPlugins[idx]
+
+	if !ip.trusted() {
+		if ip.NeedsApproval && slices.Equal(ip.PendingCapabilities, requested) {
+			return false, nil
+		}
+		ip.NeedsApproval = true
+		ip.PendingCapabilities = requested
+		return false, m.saveLocked()
+	}
+	if !ip.NeedsApproval && ip.ApprovedCapabilities == nil {
+		if len(requested) == 0 {
+			return true, nil
+		}
+		ip.ApprovedCapabilities = requested
+		return true, m.saveLocked()
+	}
+	if slices.Equal(ip.ApprovedCapabilities, requested) {
+		if !ip.NeedsApproval && ip.PendingCapabilities == nil {
+			return true, nil
+		}
+		ip.NeedsApproval = false
+		ip.PendingCapabilities = nil
+		return true, m.saveLocked()
+	}
+
+	ip.NeedsApproval = true
+	ip.PendingCapabilities = requested
+	return false, m.saveLocked()
+}
+
+// ApproveCapabilities accepts the pending capability request for an
+// installed plugin so it can be loaded.
+func (m *Manager) ApproveCapabilities(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, ip := range m.cfg.InstalledPlugins {
+		if ip.Name != name {
+			continue
+		}
+		if !ip.NeedsApproval {
+			return fmt.Errorf("plugin %q has no pending capability request", name)
+		}
+		if !ip.trusted() {
+			return fmt.Errorf("plugin %q is unsigned; override trust before approving it", name)
+		}
+		m.cfg.InstalledPlugins[i].ApprovedCapabilities = ip.PendingCapabilities
+		m.cfg.InstalledPlugins[i].PendingCapabilities = nil
+		m.cfg.InstalledPlugins[i].NeedsApproval = false
+		return m.saveLocked()
+	}
+	return fmt.Errorf("plugin %q not installed", name)
+}
@@ -0,0 +1,77 @@
+package marketplace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilityList(t *testing.T) {
+	var nilMeta *PluginMetadata
+	assert.Nil(t, nilMeta.CapabilityList())
+
+	legacy := &PluginMetadata{Capabilities: []string{"http"}}
+	assert.Equal(t, []string{"network: any public host (http,https)"}, legacy.CapabilityList())
+
+	scoped := &PluginMetadata{
+		Capabilities: []string{"http", "kv"},
+		Network: &NetworkCapability{
+			Hosts:             []string{"API.example.com", "*.example.org"},
+			MaxTimeoutSeconds: 120,
+		},
+	}
+	assert.Equal(t, []string{
+		"kv",
+		"network: *.example.org (https)",
+		"network: api.example.com (https)",
+		"network: requests up to 120s",
+	}, scoped.CapabilityList())
+}
+
+func TestReviewCapabilities(t *testing.T) {
+	var saved Config
+	mgr := NewManager(Config{InstalledPlugins: []InstalledPlugin{
+		{Name: "legacy", Path: "/p/legacy.wasm"},
+		{Name: "fresh", Path: "/p/fresh.wasm", NeedsApproval: true},
+	}}, t.TempDir(), func(c Config) error { saved = c; return nil })
+
+	t.Run("untracked plugin is allowed", func(t *testing.T) {
+		ok, err := mgr.ReviewCapabilities("unknown", []string{"network: any public host (http,https)"})
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("legacy install records a baseline", func(t *testing.T) {
+		ok, err := mgr.ReviewCapabilities("legacy", []string{"kv"})
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"kv"}, saved.InstalledPlugins[0].ApprovedCapabilities)
+
+		ok, err = mgr.ReviewCapabilities("legacy", []string{"kv", "network: any public host (http,https)"})
+		require.NoError(t, err)
+		assert.False(t, ok, "a changed binary must be re-approved")
+	})
+
+	t.Run("new install waits for approval", func(t *testing.T) {
+		caps := []string{"network: api.example.com (https)"}
+		ok, err := mgr.ReviewCapabilities("fresh", caps)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, caps, saved.InstalledPlugins[1].PendingCapabilities)
+
+		require.NoError(t, mgr.ApproveCapabilities("fresh"))
+		assert.Equal(t, caps, saved.InstalledPlugins[1].ApprovedCapabilities)
+		assert.False(t, saved.InstalledPlugins[1].NeedsApproval)
+
+		ok, err = mgr.ReviewCapabilities("fresh", caps)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+}
+
+func TestApproveCapabilities_Errors(t *testing.T) {
+	mgr := NewManager(Config{InstalledPlugins: []InstalledPlugin{{Name: "done"}}}, t.TempDir(), nil)
+	assert.Error(t, mgr.ApproveCapabilities("missing"))
+	assert.Error(t, mgr.ApproveCapabilities("done"))
+}
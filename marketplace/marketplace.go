@@ -21,8 +21,16 @@ import (
 )
 
 // ABIVersion is the current plugin ABI version that this build of Switchboard supports.
-// Plugins declare a min/max ABI range; the host only loads plugins whose range includes this value.
-const ABIVersion = 1
+// Plugins declare a min/max ABI range; the host loads plugins whose range
+// overlaps MinABIVersion through ABIVersion.
+//
+// ABI 2 adds the host_kv_*, host_secret_* and host_clock_now imports and the
+// optional render_markdown export. ABI 1 plugins import none of these and
+// keep loading unchanged.
+const ABIVersion = 2
+
+// MinABIVersion is the oldest plugin ABI version this build still loads.
+const MinABIVersion = 1
 
 // Manifest describes a collection of available plugins from a single source.
 type Manifest struct {
@@ -31,6 +39,10 @@ type Manifest struct {
 	Name          string          `json:"name"`
 	Description   string          `json:"description,omitempty"`
 	Plugins       []PluginListing `json:"plugins"`
+
+	// Verified is set when the manifest's detached signature verified
+	// against a publisher key configured for its source.
+	Verified bool `json:"-"`
 }
 
 // PluginListing describes a single plugin available for installation.
@@ -54,22 +66,26 @@ type PluginVersion struct {
 	ReleasedAt string   `json:"released_at,omitempty"`
 	Changelog  string   `json:"changelog,omitempty"`
 	Platforms  []string `json:"platforms,omitempty"` // empty = all platforms
+	// Signature is the base64 ed25519 signature of the binary by one of
+	// the source's publisher keys.
+	Signature string `json:"signature,omitempty"`
 }
 
 // PluginMetadata is embedded in the WASM binary and returned by the `metadata()` export.
 type PluginMetadata struct {
-	Name           string            `json:"name"`
-	Version        string            `json:"version"`
-	ABIVersion     int               `json:"abi_version"`
-	Description    string            `json:"description,omitempty"`
-	Author         string            `json:"author,omitempty"`
-	Homepage       string            `json:"homepage,omitempty"`
-	License        string            `json:"license,omitempty"`
-	Capabilities   []string          `json:"capabilities,omitempty"`
-	CredentialKeys []string          `json:"credential_keys,omitempty"`
-	PlainTextKeys  []string          `json:"plain_text_keys,omitempty"`
-	OptionalKeys   []string          `json:"optional_keys,omitempty"`
-	Placeholders   map[string]string `json:"placeholders,omitempty"`
+	Name           string             `json:"name"`
+	Version        string             `json:"version"`
+	ABIVersion     int                `json:"abi_version"`
+	Description    string             `json:"description,omitempty"`
+	Author         string             `json:"author,omitempty"`
+	Homepage       string             `json:"homepage,omitempty"`
+	License        string             `json:"license,omitempty"`
+	Capabilities   []string           `json:"capabilities,omitempty"`
+	Network        *NetworkCapability `json:"network,omitempty"`
+	CredentialKeys []string           `json:"credential_keys,omitempty"`
+	PlainTextKeys  []string           `json:"plain_text_keys,omitempty"`
+	OptionalKeys   []string           `json:"optional_keys,omitempty"`
+	Placeholders   map[string]string  `json:"placeholders,omitempty"`
 }
 
 // InstalledPlugin tracks a plugin installed via the marketplace.
@@ -82,6 +98,23 @@ type InstalledPlugin struct {
 	SHA256        string `json:"sha256"`
 	AutoUpdate    bool   `json:"auto_update"`
 	LatestVersion string `json:"latest_version,omitempty"`
+
+	// ApprovedCapabilities is the capability list (see
+	// PluginMetadata.CapabilityList) the user accepted for this plugin.
+	ApprovedCapabilities []string `json:"approved_capabilities,omitempty"`
+	// PendingCapabilities is what the installed binary requests while it
+	// awaits approval.
+	PendingCapabilities []string `json:"pending_capabilities,omitempty"`
+	// NeedsApproval is set on install and update; the plugin is not loaded
+	// until its requested capabilities match the approved set.
+	NeedsApproval bool `json:"needs_approval,omitempty"`
+
+	// Trust is TrustVerified or TrustUnsigned for the installed binary;
+	// empty for installs that predate signing.
+	Trust string `json:"trust,omitempty"`
+	// TrustOverride records that the user chose to run this unsigned
+	// binary anyway.
+	TrustOverride bool `json:"trust_override,omitempty"`
 }
 
 // ManifestSource is a configured manifest URL.
@@ -89,6 +122,10 @@ type ManifestSource struct {
 	URL     string `json:"url"`
 	Name    string `json:"name,omitempty"`
 	Enabled bool   `json:"enabled"`
+	// PublicKeys are the publisher keys (see ParsePublicKey) trusted to
+	// sign this source's manifest and binaries. When set, the manifest must
+	// carry a valid detached signature at URL + ".sig".
+	PublicKeys []string `json:"public_keys,omitempty"`
 }
 
 // Config is the marketplace section of the switchboard config.
@@ -161,40 +198,36 @@ func (m *Manager) Config() Config {
 	return m.cfg
 }
 
-// FetchManifest downloads and parses a manifest from a URL.
+// FetchManifest downloads and parses a manifest from a URL. When the
+// manifest's source has publisher keys configured, its detached signature is
+// fetched from URL + ".sig" and must verify.
 func (m *Manager) FetchManifest(ctx context.Context, rawURL string) (*Manifest, error) {
-	effectiveURL, useRawAccept := normalizeGitHubURL(rawURL)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, effectiveURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-	if useRawAccept {
-		req.Header.Set("Accept", "application/vnd.github.raw")
-	} else {
-		req.Header.Set("Accept", "application/json")
-	}
-	req.Header.Set("User-Agent", "Switchboard-Plugin-Manager/1.0")
-	m.applyAuth(req)
-
-	resp, err := m.client.Do(req)
+	body, err := m.fetchDocument(ctx, rawURL, "application/json", 10<<20) // 10MB limit
 	if err != nil {
 		return nil, fmt.Errorf("fetch manifest: %w", err)
 	}
-	defer resp.Body.Close() //nolint:errcheck
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("manifest returned HTTP %d", resp.StatusCode)
-	}
 
-	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20)) // 10MB limit
+	keys, err := m.sourceKeys(rawURL)
 	if err != nil {
-		return nil, fmt.Errorf("read manifest: %w", err)
+		return nil, err
+	}
+	verified := false
+	if len(keys) > 0 {
+		sig, err := m.fetchDocument(ctx, rawURL+manifestSignatureSuffix, "", 4<<10)
+		if err != nil {
+			return nil, fmt.Errorf("fetch manifest signature: %w", err)
+		}
+		if err := verifySignature(keys, body, string(sig)); err != nil {
+			return nil, fmt.Errorf("manifest %s: %w", rawURL, err)
+		}
+		verified = true
 	}
 
 	var manifest Manifest
 	if err := json.Unmarshal(body, &manifest); err != nil {
 		return nil, fmt.Errorf("parse manifest: %w", err)
 	}
+	manifest.Verified = verified
 
 	if manifest.SchemaVersion == 0 {
 		manifest.SchemaVersion = 1
@@ -251,7 +284,7 @@ func (m *Manager) BrowsePlugins(ctx context.Context) ([]BrowseResult, error) {
 	var results []BrowseResult
 	for _, mf := range manifests {
 		for _, pl := range mf.Plugins {
-			best := bestCompatibleVersion(pl.Versions, ABIVersion)
+			best := bestSupportedVersion(pl.Versions)
 			if best == nil {
 				continue
 			}
@@ -263,6 +296,7 @@ func (m *Manager) BrowsePlugins(ctx context.Context) ([]BrowseResult, error) {
 				License:        pl.License,
 				LatestVersion:  best.Version,
 				ManifestSource: mf.Name,
+				Verified:       mf.Verified,
 			}
 			if ip, ok := installed[pl.Name]; ok {
 				br.Installed = true
@@ -291,6 +325,7 @@ type BrowseResult struct {
 	Installed        bool   `json:"installed"`
 	InstalledVersion string `json:"installed_version,omitempty"`
 	UpdateAvailable  bool   `json:"update_available,omitempty"`
+	Verified         bool   `json:"verified,omitempty"` // manifest signature verified
 }
 
 // InstallPlugin downloads and installs a plugin by name from available manifests.
@@ -316,11 +351,11 @@ func (m *Manager) InstallPlugin(ctx context.Context, name string) (*InstalledPlu
 			if pl.Name != name {
 				continue
 			}
-			ver := bestCompatibleVersion(pl.Versions, ABIVersion)
+			ver := bestSupportedVersion(pl.Versions)
 			if ver == nil {
-				return nil, fmt.Errorf("no compatible version of %q for ABI %d", name, ABIVersion)
+				return nil, fmt.Errorf("no compatible version of %q for ABI %d-%d", name, MinABIVersion, ABIVersion)
 			}
-			return m.downloadAndInstall(ctx, pl.Name, ver, src.URL)
+			return m.downloadAndInstall(ctx, pl.Name, ver, src.URL, false)
 		}
 	}
 	return nil, fmt.Errorf("plugin %q not found in any manifest", name)
@@ -347,12 +382,13 @@ func (m *Manager) InstallFromURL(ctx context.Context, url string) (*InstalledPlu
 	}
 
 	ip := InstalledPlugin{
-		Name:        name,
-		Version:     "unknown",
-		InstalledAt: time.Now().UTC().Format(time.RFC3339),
-		Path:        destPath,
-		SHA256:      hash,
-		AutoUpdate:  false,
+		Name:          name,
+		Version:       "unknown",
+		InstalledAt:   time.Now().UTC().Format(time.RFC3339),
+		Path:          destPath,
+		SHA256:        hash,
+		AutoUpdate:    false,
+		NeedsApproval: true,
 	}
 
 	m.mu.Lock()
@@ -383,12 +419,13 @@ func (m *Manager) InstallFromBytes(name string, data []byte) (*InstalledPlugin,
 	}
 
 	ip := InstalledPlugin{
-		Name:        name,
-		Version:     "uploaded",
-		InstalledAt: time.Now().UTC().Format(time.RFC3339),
-		Path:        destPath,
-		SHA256:      hash,
-		AutoUpdate:  false,
+		Name:          name,
+		Version:       "uploaded",
+		InstalledAt:   time.Now().UTC().Format(time.RFC3339),
+		Path:          destPath,
+		SHA256:        hash,
+		AutoUpdate:    false,
+		NeedsApproval: true,
 	}
 
 	m.mu.Lock()
@@ -448,7 +485,7 @@ func (m *Manager) CheckForUpdates(ctx context.Context) ([]UpdateResult, error) {
 	lookupURL := make(map[string]string) // name -> manifest URL
 	for _, mf := range manifests {
 		for _, pl := range mf.Plugins {
-			if best := bestCompatibleVersion(pl.Versions, ABIVersion); best != nil {
+			if best := bestSupportedVersion(pl.Versions); best != nil {
 				lookup[pl.Name] = best
 				for _, src := range sources {
 					if cached, ok := manifestCache[src.URL]; ok && cached == mf {
@@ -500,8 +537,16 @@ type UpdateResult struct {
 	ManifestURL    string `json:"manifest_url,omitempty"`
 }
 
-// UpdatePlugin updates a specific plugin to its latest version.
+// UpdatePlugin updates a specific plugin to its latest version. An unsigned
+// update installs but needs a fresh trust override before it loads.
 func (m *Manager) UpdatePlugin(ctx context.Context, name string) (*InstalledPlugin, error) {
+	return m.updatePlugin(ctx, name, false)
+}
+
+// updatePlugin updates name to its latest version. An unattended update is
+// refused when it would install an unsigned binary from a source with
+// publisher keys, or replace a verified install with an unsigned one.
+func (m *Manager) updatePlugin(ctx context.Context, name string, unattended bool) (*InstalledPlugin, error) {
 	manifests, err := m.FetchAllManifests(ctx)
 	if err != nil {
 		return nil, err
@@ -521,7 +566,7 @@ func (m *Manager) UpdatePlugin(ctx context.Context, name string) (*InstalledPlug
 			if pl.Name != name {
 				continue
 			}
-			ver := bestCompatibleVersion(pl.Versions, ABIVersion)
+			ver := bestSupportedVersion(pl.Versions)
 			if ver == nil {
 				return nil, fmt.Errorf("no compatible version for %q", name)
 			}
@@ -534,7 +579,14 @@ func (m *Manager) UpdatePlugin(ctx context.Context, name string) (*InstalledPlug
 				}
 			}
 
-			ip, err := m.downloadAndInstall(ctx, name, ver, manifestURL)
+			requireVerified := false
+			if unattended {
+				requireVerified, err = m.requiresVerified(name, manifestURL)
+				if err != nil {
+					return nil, err
+				}
+			}
+			ip, err := m.downloadAndInstall(ctx, name, ver, manifestURL, requireVerified)
 			if err != nil {
 				return nil, err
 			}
@@ -545,6 +597,8 @@ func (m *Manager) UpdatePlugin(ctx context.Context, name string) (*InstalledPlug
 }
 
 // UpdateAll updates all installed plugins that have auto_update enabled.
+// Unsigned updates to plugins from signed sources, or to plugins whose
+// installed binary was verified, are skipped and left for a manual update.
 func (m *Manager) UpdateAll(ctx context.Context) ([]InstalledPlugin, error) {
 	updates, err := m.CheckForUpdates(ctx)
 	if err != nil {
@@ -562,7 +616,7 @@ func (m *Manager) UpdateAll(ctx context.Context) ([]InstalledPlugin, error) {
 	var updated []InstalledPlugin
 	for _, u := range updates {
 		if globalAutoUpdate || autoUpdate[u.Name] {
-			ip, err := m.UpdatePlugin(ctx, u.Name)
+			ip, err := m.updatePlugin(ctx, u.Name, true)
 			if err != nil {
 				log.Printf("WARN: failed to update plugin %q: %v", u.Name, err)
 				continue
@@ -697,7 +751,7 @@ func (m *Manager) StartAutoUpdateLoop(ctx context.Context) context.CancelFunc {
 
 // --- internal helpers ---
 
-func (m *Manager) downloadAndInstall(ctx context.Context, name string, ver *PluginVersion, manifestURL string) (*InstalledPlugin, error) {
+func (m *Manager) downloadAndInstall(ctx context.Context, name string, ver *PluginVersion, manifestURL string, requireVerified bool) (*InstalledPlugin, error) {
 	data, err := m.downloadWasm(ctx, ver.URL)
 	if err != nil {
 		return nil, err
@@ -708,6 +762,14 @@ func (m *Manager) downloadAndInstall(ctx context.Context, name string, ver *Plug
 		return nil, fmt.Errorf("SHA256 mismatch for %s@%s: got %s, want %s", name, ver.Version, hash, ver.SHA256)
 	}
 
+	trust, err := m.binaryTrust(manifestURL, ver, data)
+	if err != nil {
+		return nil, err
+	}
+	if requireVerified && trust != TrustVerified {
+		return nil, fmt.Errorf("refusing unattended install of unsigned %s@%s; update it manually to review", name, ver.Version)
+	}
+
 	filename := sanitizeFilename(name) + ".wasm"
 	destPath := filepath.Join(m.pluginDir, filename)
 
@@ -716,19 +778,24 @@ func (m *Manager) downloadAndInstall(ctx context.Context, name string, ver *Plug
 	}
 
 	ip := InstalledPlugin{
-		Name:        name,
-		Version:     ver.Version,
-		ManifestURL: manifestURL,
-		InstalledAt: time.Now().UTC().Format(time.RFC3339),
-		Path:        destPath,
-		SHA256:      hash,
-		AutoUpdate:  m.cfg.AutoUpdate,
+		Name:          name,
+		Version:       ver.Version,
+		ManifestURL:   manifestURL,
+		InstalledAt:   time.Now().UTC().Format(time.RFC3339),
+		Path:          destPath,
+		SHA256:        hash,
+		AutoUpdate:    m.cfg.AutoUpdate,
+		NeedsApproval: true,
+		Trust:         trust,
 	}
 
 	m.mu.Lock()
 	var updated bool
 	for i, existing := range m.cfg.InstalledPlugins {
 		if existing.Name == name {
+			// Keep the prior approval so an update requesting the same
+			// capabilities loads without asking again.
+			ip.ApprovedCapabilities = existing.ApprovedCapabilities
 			m.cfg.InstalledPlugins[i] = ip
 			updated = true
 			break
@@ -746,30 +813,43 @@ func (m *Manager) downloadAndInstall(ctx context.Context, name string, ver *Plug
 }
 
 func (m *Manager) downloadWasm(ctx context.Context, rawURL string) ([]byte, error) {
+	data, err := m.fetchDocument(ctx, rawURL, "", 100<<20) // 100MB limit
+	if err != nil {
+		return nil, fmt.Errorf("download plugin: %w", err)
+	}
+	return data, nil
+}
+
+// fetchDocument GETs rawURL with the marketplace's auth and GitHub URL
+// handling and returns at most limit bytes of the body. accept is sent
+// unless the URL is a GitHub blob that needs the raw media type.
+func (m *Manager) fetchDocument(ctx context.Context, rawURL, accept string, limit int64) ([]byte, error) {
 	effectiveURL, useRawAccept := normalizeGitHubURL(rawURL)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, effectiveURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
-	req.Header.Set("User-Agent", "Switchboard-Plugin-Manager/1.0")
 	if useRawAccept {
 		req.Header.Set("Accept", "application/vnd.github.raw")
+	} else if accept != "" {
+		req.Header.Set("Accept", accept)
 	}
+	req.Header.Set("User-Agent", "Switchboard-Plugin-Manager/1.0")
 	m.applyAuth(req)
 
 	resp, err := m.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("download plugin: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close() //nolint:errcheck
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("download returned HTTP %d", resp.StatusCode)
+		return nil, fmt.Errorf("%s returned HTTP %d", rawURL, resp.StatusCode)
 	}
 
-	data, err := io.ReadAll(io.LimitReader(resp.Body, 100<<20)) // 100MB limit
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit))
 	if err != nil {
-		return nil, fmt.Errorf("read plugin: %w", err)
+		return nil, fmt.Errorf("read %s: %w", rawURL, err)
 	}
 	return data, nil
 }
@@ -853,12 +933,25 @@ func nameFromURL(url string) string {
 	return sanitizeFilename(name)
 }
 
+// bestCompatibleVersion returns the highest version whose ABI range includes abi.
 func bestCompatibleVersion(versions []PluginVersion, abi int) *PluginVersion {
+	return bestVersionInABIRange(versions, abi, abi)
+}
+
+// bestSupportedVersion returns the highest version whose ABI range overlaps
+// the range this build supports, MinABIVersion through ABIVersion.
+func bestSupportedVersion(versions []PluginVersion) *PluginVersion {
+	return bestVersionInABIRange(versions, MinABIVersion, ABIVersion)
+}
+
+// bestVersionInABIRange returns the highest version whose ABI range
+// overlaps [lo, hi].
+func bestVersionInABIRange(versions []PluginVersion, lo, hi int) *PluginVersion {
 	var best *PluginVersion
 	var bestSV *semver.Version
 	for i := range versions {
 		v := &versions[i]
-		if v.ABIMin <= abi && v.ABIMax >= abi {
+		if v.ABIMin <= hi && v.ABIMax >= lo {
 			sv, err := semver.NewVersion(v.Version)
 			if err != nil {
 				if best == nil {
@@ -57,6 +57,21 @@ func testManifest() Manifest {
 	}
 }
 
+func TestBestSupportedVersion(t *testing.T) {
+	versions := []PluginVersion{
+		{Version: "1.0.0", ABIMin: 1, ABIMax: 1},
+		{Version: "1.1.0", ABIMin: 1, ABIMax: 1},
+		{Version: "3.0.0", ABIMin: ABIVersion + 1, ABIMax: ABIVersion + 1},
+	}
+	best := bestSupportedVersion(versions)
+	if assert.NotNil(t, best, "ABI 1 plugins must stay installable") {
+		assert.Equal(t, "1.1.0", best.Version)
+	}
+
+	versions = append(versions, PluginVersion{Version: "2.0.0", ABIMin: ABIVersion, ABIMax: ABIVersion})
+	assert.Equal(t, "2.0.0", bestSupportedVersion(versions).Version)
+}
+
 func TestBestCompatibleVersion(t *testing.T) {
 	tests := []struct {
 		name     string
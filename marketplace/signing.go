@@ -0,0 +1,166 @@
+package marketplace
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Trust states recorded on an InstalledPlugin.
+const (
+	// TrustVerified means the binary was checked against a publisher key
+	// configured for its manifest source: either its own signature verified,
+	// or the manifest listing its SHA256 did.
+	TrustVerified = "verified"
+	// TrustUnsigned means nothing vouches for the binary beyond the
+	// transport it came over. Such plugins load only after an explicit
+	// override.
+	TrustUnsigned = "unsigned"
+)
+
+// ErrSignatureInvalid is wrapped by errors for signatures that are present
+// but do not verify against any trusted key. Unlike a missing signature this
+// is never overridable: the content was altered or signed by someone else.
+var ErrSignatureInvalid = errors.New("marketplace: signature verification failed")
+
+// manifestSignatureSuffix is appended to a manifest URL to fetch its
+// detached signature.
+const manifestSignatureSuffix = ".sig"
+
+const publicKeyPrefix = "ed25519:"
+
+// ParsePublicKey decodes a publisher key: a base64-encoded ed25519 public
+// key, optionally prefixed with "ed25519:".
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), publicKeyPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("decode public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key is %d bytes, want %d", len(raw), ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// FormatPublicKey encodes key in the form ParsePublicKey accepts.
+func FormatPublicKey(key ed25519.PublicKey) string {
+	return publicKeyPrefix + base64.StdEncoding.EncodeToString(key)
+}
+
+// Sign returns the base64 signature of data, in the format used for
+// manifest .sig files and PluginVersion.Signature.
+func Sign(key ed25519.PrivateKey, data []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(key, data))
+}
+
+// verifySignature checks a base64 signature of data against keys.
+func verifySignature(keys []ed25519.PublicKey, data []byte, sig string) error {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(sig))
+	if err != nil || len(raw) != ed25519.SignatureSize {
+		return fmt.Errorf("%w: malformed signature", ErrSignatureInvalid)
+	}
+	for _, k := range keys {
+		if ed25519.Verify(k, data, raw) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no trusted key matches", ErrSignatureInvalid)
+}
+
+// sourceKeys returns the publisher keys configured for the manifest source
+// at url. A source without keys is accepted unverified.
+func (m *Manager) sourceKeys(url string) ([]ed25519.PublicKey, error) {
+	m.mu.RLock()
+	var encoded []string
+	for _, src := range m.cfg.ManifestSources {
+		if src.URL == url {
+			encoded = src.PublicKeys
+			break
+		}
+	}
+	m.mu.RUnlock()
+
+	keys := make([]ed25519.PublicKey, 0, len(encoded))
+	for _, s := range encoded {
+		k, err := ParsePublicKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("manifest source %s: %w", url, err)
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// binaryTrust decides how far a downloaded binary can be trusted. A binary
+// carrying a signature must verify; one without is verified through its
+// manifest when that manifest was signed and pins the binary's SHA256.
+func (m *Manager) binaryTrust(manifestURL string, ver *PluginVersion, data []byte) (string, error) {
+	keys, err := m.sourceKeys(manifestURL)
+	if err != nil {
+		return "", err
+	}
+	if ver.Signature != "" {
+		if len(keys) == 0 {
+			return TrustUnsigned, nil
+		}
+		if err := verifySignature(keys, data, ver.Signature); err != nil {
+			return "", fmt.Errorf("plugin binary %s: %w", ver.URL, err)
+		}
+		return TrustVerified, nil
+	}
+
+	m.mu.RLock()
+	mf := m.manifests[manifestURL]
+	m.mu.RUnlock()
+	if mf != nil && mf.Verified && ver.SHA256 != "" && ver.SHA256 == sha256sum(data) {
+		return TrustVerified, nil
+	}
+	return TrustUnsigned, nil
+}
+
+// requiresVerified reports whether an unattended update of name from
+// manifestURL must install a verified binary: the source has publisher keys,
+// or the installed binary was verified and must not be downgraded.
+func (m *Manager) requiresVerified(name, manifestURL string) (bool, error) {
+	keys, err := m.sourceKeys(manifestURL)
+	if err != nil {
+		return false, err
+	}
+	if len(keys) > 0 {
+		return true, nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, ip := range m.cfg.InstalledPlugins {
+		if ip.Name == name {
+			return ip.Trust == TrustVerified, nil
+		}
+	}
+	return false, nil
+}
+
+// trusted reports whether the plugin may load as far as its provenance is
+// concerned. Only manifest installs carry a trust state; uploads, direct URL
+// installs and installs that predate signing were chosen by the user
+// directly and stay loadable.
+func (ip *InstalledPlugin) trusted() bool {
+	return ip.Trust != TrustUnsigned || ip.TrustOverride
+}
+
+// OverrideTrust records the user's explicit decision to run an unsigned
+// plugin. The override covers only the installed binary; updating the plugin
+// clears it.
+func (m *Manager) OverrideTrust(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	idx := slices.IndexFunc(m.cfg.InstalledPlugins, func(ip InstalledPlugin) bool { return ip.Name == name })
+	if idx < 0 {
+		return fmt.Errorf("plugin %q not installed", name)
+	}
+	m.cfg.InstalledPlugins[idx].TrustOverride = true
+	return m.saveLocked()
+}
@@ -0,0 +1,257 @@
+package marketplace
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return pub, priv
+}
+
+// signedServer serves manifest at /manifest.json with its signature by priv
+// at /manifest.json.sig (when priv is non-nil) and binary at /plugin.wasm.
+// mutate may alter the served manifest bytes after signing.
+func signedServer(t *testing.T, manifest Manifest, binary []byte, priv ed25519.PrivateKey, mutate func([]byte) []byte) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mf := manifest
+		mf.Plugins = append([]PluginListing(nil), manifest.Plugins...)
+		for i := range mf.Plugins {
+			mf.Plugins[i].Versions = append([]PluginVersion(nil), mf.Plugins[i].Versions...)
+			for j := range mf.Plugins[i].Versions {
+				mf.Plugins[i].Versions[j].URL = srv.URL + "/plugin.wasm"
+			}
+		}
+		body, _ := json.Marshal(mf)
+		sig := ""
+		if priv != nil {
+			sig = Sign(priv, body)
+		}
+		if mutate != nil {
+			body = mutate(body)
+		}
+		switch r.URL.Path {
+		case "/manifest.json":
+			w.Write(body) //nolint:errcheck
+		case "/manifest.json.sig":
+			if sig == "" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(sig)) //nolint:errcheck
+		case "/plugin.wasm":
+			w.Write(binary) //nolint:errcheck
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func signedManifest(binary []byte, sig string) Manifest {
+	return Manifest{
+		SchemaVersion: 1,
+		Name:          "signed",
+		Plugins: []PluginListing{{
+			Name: "example",
+			Versions: []PluginVersion{{
+				Version: "1.0.0", ABIMin: 1, ABIMax: 1,
+				SHA256: sha256sum(binary), Signature: sig,
+			}},
+		}},
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	pub, _ := testKey(t)
+
+	got, err := ParsePublicKey(FormatPublicKey(pub))
+	require.NoError(t, err)
+	assert.Equal(t, pub, got)
+
+	got, err = ParsePublicKey(FormatPublicKey(pub)[len(publicKeyPrefix):])
+	require.NoError(t, err, "prefix is optional")
+	assert.Equal(t, pub, got)
+
+	_, err = ParsePublicKey("ed25519:not-base64!")
+	assert.Error(t, err)
+	_, err = ParsePublicKey("ed25519:AAAA")
+	assert.ErrorContains(t, err, "want 32")
+}
+
+func TestVerifySignature(t *testing.T) {
+	pub, priv := testKey(t)
+	other, _ := testKey(t)
+	data := []byte("payload")
+	sig := Sign(priv, data)
+
+	assert.NoError(t, verifySignature([]ed25519.PublicKey{other, pub}, data, sig))
+	assert.ErrorIs(t, verifySignature([]ed25519.PublicKey{other}, data, sig), ErrSignatureInvalid)
+	assert.ErrorIs(t, verifySignature([]ed25519.PublicKey{pub}, []byte("tampered"), sig), ErrSignatureInvalid)
+	assert.ErrorIs(t, verifySignature([]ed25519.PublicKey{pub}, data, "garbage"), ErrSignatureInvalid)
+}
+
+func TestFetchManifest_Signed(t *testing.T) {
+	pub, priv := testKey(t)
+	binary := []byte("wasm bytes")
+	srv := signedServer(t, signedManifest(binary, ""), binary, priv, nil)
+	url := srv.URL + "/manifest.json"
+
+	m := NewManager(Config{ManifestSources: []ManifestSource{
+		{URL: url, Enabled: true, PublicKeys: []string{FormatPublicKey(pub)}},
+	}}, t.TempDir(), nil)
+	mf, err := m.FetchManifest(context.Background(), url)
+	require.NoError(t, err)
+	assert.True(t, mf.Verified)
+
+	unkeyed := NewManager(Config{ManifestSources: []ManifestSource{{URL: url, Enabled: true}}}, t.TempDir(), nil)
+	mf, err = unkeyed.FetchManifest(context.Background(), url)
+	require.NoError(t, err)
+	assert.False(t, mf.Verified, "a source without keys is not verified")
+}
+
+func TestFetchManifest_TamperedRejected(t *testing.T) {
+	pub, priv := testKey(t)
+	binary := []byte("wasm bytes")
+	srv := signedServer(t, signedManifest(binary, ""), binary, priv, func(b []byte) []byte {
+		return append(b, ' ')
+	})
+	url := srv.URL + "/manifest.json"
+
+	m := NewManager(Config{ManifestSources: []ManifestSource{
+		{URL: url, Enabled: true, PublicKeys: []string{FormatPublicKey(pub)}},
+	}}, t.TempDir(), nil)
+	_, err := m.FetchManifest(context.Background(), url)
+	assert.ErrorIs(t, err, ErrSignatureInvalid)
+}
+
+func TestFetchManifest_MissingSignatureRejected(t *testing.T) {
+	pub, _ := testKey(t)
+	binary := []byte("wasm bytes")
+	srv := signedServer(t, signedManifest(binary, ""), binary, nil, nil)
+	url := srv.URL + "/manifest.json"
+
+	m := NewManager(Config{ManifestSources: []ManifestSource{
+		{URL: url, Enabled: true, PublicKeys: []string{FormatPublicKey(pub)}},
+	}}, t.TempDir(), nil)
+	_, err := m.FetchManifest(context.Background(), url)
+	assert.ErrorContains(t, err, "manifest signature")
+}
+
+func TestInstallPlugin_Trust(t *testing.T) {
+	pub, priv := testKey(t)
+	_, otherPriv := testKey(t)
+	binary := []byte("wasm bytes")
+
+	cases := []struct {
+		name      string
+		keyed     bool
+		signer    ed25519.PrivateKey // signs the manifest
+		binarySig string
+		want      string
+		wantErr   error
+	}{
+		{name: "signed manifest pins binary", keyed: true, signer: priv, want: TrustVerified},
+		{name: "binary signature", keyed: true, signer: priv, binarySig: Sign(priv, binary), want: TrustVerified},
+		{name: "binary signed by wrong key", keyed: true, signer: priv, binarySig: Sign(otherPriv, binary), wantErr: ErrSignatureInvalid},
+		{name: "no keys configured", want: TrustUnsigned},
+		{name: "signature without keys", binarySig: Sign(priv, binary), want: TrustUnsigned},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := signedServer(t, signedManifest(binary, tc.binarySig), binary, tc.signer, nil)
+			src := ManifestSource{URL: srv.URL + "/manifest.json", Enabled: true}
+			if tc.keyed {
+				src.PublicKeys = []string{FormatPublicKey(pub)}
+			}
+			m := NewManager(Config{ManifestSources: []ManifestSource{src}}, t.TempDir(), nil)
+
+			ip, err := m.InstallPlugin(context.Background(), "example")
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				assert.Empty(t, m.InstalledPlugins())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, ip.Trust)
+		})
+	}
+}
+
+func TestUpdateAll_RefusesUnsignedFromKeyedSource(t *testing.T) {
+	pub, priv := testKey(t)
+	binary := []byte("wasm bytes v2")
+	mf := signedManifest(binary, "")
+	mf.Plugins[0].Versions[0].Version = "2.0.0"
+	mf.Plugins[0].Versions[0].SHA256 = "" // nothing pins the binary
+	srv := signedServer(t, mf, binary, priv, nil)
+	url := srv.URL + "/manifest.json"
+
+	m := NewManager(Config{
+		ManifestSources: []ManifestSource{{URL: url, Enabled: true, PublicKeys: []string{FormatPublicKey(pub)}}},
+		InstalledPlugins: []InstalledPlugin{
+			{Name: "example", Version: "1.0.0", ManifestURL: url, AutoUpdate: true, Trust: TrustVerified},
+		},
+	}, t.TempDir(), nil)
+
+	updated, err := m.UpdateAll(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, updated)
+	assert.Equal(t, "1.0.0", m.InstalledPlugins()[0].Version)
+
+	ip, err := m.UpdatePlugin(context.Background(), "example")
+	require.NoError(t, err, "a manual update installs and is held for review")
+	assert.Equal(t, TrustUnsigned, ip.Trust)
+}
+
+func TestUpdateAll_RefusesDowngradeToUnsigned(t *testing.T) {
+	binary := []byte("wasm bytes v2")
+	mf := signedManifest(binary, "")
+	mf.Plugins[0].Versions[0].Version = "2.0.0"
+	srv := signedServer(t, mf, binary, nil, nil)
+	url := srv.URL + "/manifest.json"
+
+	m := NewManager(Config{
+		ManifestSources: []ManifestSource{{URL: url, Enabled: true}},
+		InstalledPlugins: []InstalledPlugin{
+			{Name: "example", Version: "1.0.0", ManifestURL: url, AutoUpdate: true, Trust: TrustVerified},
+		},
+	}, t.TempDir(), nil)
+
+	updated, err := m.UpdateAll(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, updated)
+}
+
+func TestReviewCapabilities_UnsignedNeedsOverride(t *testing.T) {
+	m := NewManager(Config{InstalledPlugins: []InstalledPlugin{
+		{Name: "example", Trust: TrustUnsigned, NeedsApproval: true, ApprovedCapabilities: []string{}},
+	}}, t.TempDir(), func(Config) error { return nil })
+
+	ok, err := m.ReviewCapabilities("example", []string{})
+	require.NoError(t, err)
+	assert.False(t, ok, "unsigned plugin must not load even with unchanged capabilities")
+	assert.ErrorContains(t, m.ApproveCapabilities("example"), "unsigned")
+
+	require.NoError(t, m.OverrideTrust("example"))
+	require.NoError(t, m.ApproveCapabilities("example"))
+	ok, err = m.ReviewCapabilities("example", []string{})
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.Error(t, m.OverrideTrust("missing"))
+}
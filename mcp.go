@@ -116,6 +116,15 @@ type WasmModuleConfig struct {
 	Path        string      `json:"path"`
 	Name        string      `json:"name,omitempty"`
 	Credentials Credentials `json:"credentials,omitempty"`
+	// PoolSize caps how many instances of the module may run calls
+	// concurrently. Zero uses the wasm package default.
+	PoolSize int `json:"pool_size,omitempty"`
+	// MemoryLimitMB caps each instance's linear memory in mebibytes. Zero
+	// uses the wasm package default.
+	MemoryLimitMB int `json:"memory_limit_mb,omitempty"`
+	// CallTimeout bounds the wall-clock time of a single guest call, as a
+	// duration string such as "30s". Empty uses the wasm package default.
+	CallTimeout string `json:"call_timeout,omitempty"`
 }
 
 // MarketplaceConfig holds plugin marketplace settings.
@@ -130,9 +139,10 @@ type MarketplaceConfig struct {
 
 // MarketplaceManifestSource is a configured manifest URL.
 type MarketplaceManifestSource struct {
-	URL     string `json:"url"`
-	Name    string `json:"name,omitempty"`
-	Enabled bool   `json:"enabled"`
+	URL        string   `json:"url"`
+	Name       string   `json:"name,omitempty"`
+	Enabled    bool     `json:"enabled"`
+	PublicKeys []string `json:"public_keys,omitempty"`
 }
 
 // MarketplaceInstalledPlugin tracks a plugin installed via the marketplace.
@@ -145,6 +155,31 @@ type MarketplaceInstalledPlugin struct {
 	SHA256        string `json:"sha256"`
 	AutoUpdate    bool   `json:"auto_update"`
 	LatestVersion string `json:"latest_version,omitempty"`
+
+	ApprovedCapabilities []string `json:"approved_capabilities,omitempty"`
+	PendingCapabilities  []string `json:"pending_capabilities,omitempty"`
+	NeedsApproval        bool     `json:"needs_approval,omitempty"`
+
+	Trust         string `json:"trust,omitempty"`
+	TrustOverride bool   `json:"trust_override,omitempty"`
+}
+
+// TelemetryConfig controls export of traces and metrics to external
+// observability systems. Everything is off unless enabled here.
+type TelemetryConfig struct {
+	// Tracing enables OTLP/HTTP trace export.
+	Tracing bool `json:"tracing,omitempty"`
+	// OTLPEndpoint is the collector base URL, e.g. "http://localhost:4318".
+	// Empty falls back to the standard OTEL_EXPORTER_OTLP_* environment
+	// variables.
+	OTLPEndpoint string            `json:"otlp_endpoint,omitempty"`
+	OTLPHeaders  map[string]string `json:"otlp_headers,omitempty"`
+	// SampleRatio is the fraction of root spans kept, in (0, 1]. Zero keeps
+	// every trace.
+	SampleRatio float64 `json:"sample_ratio,omitempty"`
+
+	// Prometheus serves metrics for scraping at /metrics.
+	Prometheus bool `json:"prometheus,omitempty"`
 }
 
 // Config is the top-level configuration containing all integrations.
@@ -152,7 +187,7 @@ type Config struct {
 	Integrations map[string]*IntegrationConfig `json:"integrations"`
 	WasmModules  []WasmModuleConfig            `json:"wasm_modules,omitempty"`
 	Marketplace  *MarketplaceConfig            `json:"marketplace,omitempty"`
-	SessionStore string                        `json:"session_store,omitempty"` // "memory" or "file" (default: "memory")
+	SessionStore string                        `json:"session_store,omitempty"` // "memory", "file" or "sqlite" (default: "memory")
 
 	// ShowDollarEstimate toggles the dashboard's "tokens saved" hero card
 	// from displaying a dollar-equivalent figure. Hidden by default to keep
@@ -162,6 +197,24 @@ type Config struct {
 	// DollarsPerMTokInput is the price per million input tokens used to
 	// compute the dollar estimate. Zero falls back to DefaultInputDollarsPerMTok.
 	DollarsPerMTokInput float64 `json:"dollars_per_mtok_input,omitempty"`
+	// Tokenizer names the BPE vocabulary used to measure a sample of
+	// responses in tokens ("o200k_base", "cl100k_base"). Empty or
+	// "estimate" keeps the CharsPerToken heuristic.
+	Tokenizer string `json:"tokenizer,omitempty"`
+	// MetricsHistoryDays is how many days of hourly metrics history are
+	// kept on disk for the dashboard charts and /api/metrics/history.
+	// Zero keeps DefaultHistoryRetention (7 days).
+	MetricsHistoryDays int `json:"metrics_history_days,omitempty"`
+
+	// Redaction scrubs secrets and personal data from tool results before
+	// they reach the LLM. Nil or disabled leaves results untouched.
+	Redaction *RedactionConfig `json:"redaction,omitempty"`
+	// Quarantine wraps tool result fields declared untrusted in
+	// compact.yaml in envelopes and flags or strips prompt-injection
+	// patterns in them. Nil or disabled leaves results untouched.
+	Quarantine *QuarantineConfig `json:"quarantine,omitempty"`
+
+	Telemetry *TelemetryConfig `json:"telemetry,omitempty"`
 }
 
 // ToolDefinition describes an API operation an integration exposes.
@@ -320,8 +373,8 @@ type MaxResponseBytesIntegration interface {
 // for, and the integration-wide value is used as a fallback for the rest.
 //
 // Distinguished from ToolMaxBytesIntegration: that interface declares a stricter
-// per-tool cap that triggers a response_too_large envelope on the post-compaction
-// JSON body; it cannot raise the cap above the integration-wide check. This
+// per-tool cap on the post-compaction JSON body; it cannot raise the cap above
+// the integration-wide check. This
 // interface raises the integration-wide cap, applies to both JSON and plain-text
 // responses, and runs before the integration-wide cap check.
 type PerToolMaxResponseBytesIntegration interface {
@@ -333,9 +386,11 @@ type PerToolMaxResponseBytesIntegration interface {
 
 // ToolMaxBytesIntegration is an optional interface that integrations can implement
 // to declare per-tool response size caps. When the post-compaction response for a
-// tool exceeds its declared cap, the server replaces the body with a structured
-// error envelope (response_too_large) so the LLM can detect and recover from
-// over-large responses without parsing a truncated JSON document.
+// tool called through execute exceeds its declared cap, the server pins it and
+// returns it in pages of at most the cap. Inside scripts, where a result cannot
+// be paged, the body is replaced with a structured error envelope
+// (response_too_large) so the script can detect and recover from over-large
+// responses without parsing a truncated JSON document.
 //
 // Distinguished from MaxResponseBytesIntegration: that interface returns one cap
 // for the entire integration; this one returns a different cap per tool, sourced
@@ -346,6 +401,17 @@ type ToolMaxBytesIntegration interface {
 	MaxBytes(toolName ToolName) (int, bool)
 }
 
+// UntrustedContentIntegration is an optional interface that integrations can
+// implement to declare which fields of a tool's response carry third-party
+// text (message bodies, issue comments, fetched pages), sourced from
+// compact.yaml's optional untrusted field. The server quarantines those
+// fields when Config.Quarantine is enabled.
+type UntrustedContentIntegration interface {
+	// UntrustedFields returns the tool's untrusted paths, validated with
+	// ValidateUntrustedPath. Returns false for tools without any.
+	UntrustedFields(toolName ToolName) ([]string, bool)
+}
+
 // PlainTextCredentials is an optional interface that integrations can implement
 // to declare which credential keys should be rendered as plain text inputs
 // instead of password fields in the web UI.
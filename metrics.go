@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -46,6 +47,13 @@ type Metrics struct {
 	integrationCalls map[IntegrationName]*integrationMetric
 	circuitBreaks    map[IntegrationName]*atomic.Int64
 
+	// Redacted matches by detector or rule type, and prompt-injection
+	// patterns found in quarantined fields by kind. In memory only, like
+	// the circuit breaks. Guarded by mu.
+	redactions        map[string]int64
+	injectionFindings map[string]int64
+	quarantinedFields int64
+
 	// Rolling-window samples (bounded). Used for percentage averages and
 	// (in the future) recency-weighted stats; counts overflow into the
 	// atomic lifetime totals below so persistence preserves accuracy.
@@ -58,6 +66,26 @@ type Metrics struct {
 	compactionBytesAfter  atomic.Int64
 	compactionSamplesAll  atomic.Int64
 
+	// Value transforms ("body|truncate:500") within compaction. Their bytes
+	// are part of the compaction savings above, not in addition to them.
+	transformValues     atomic.Int64
+	transformBytesSaved atomic.Int64
+
+	// Per-field attribution of compacted tools' bytes, and follow-up calls
+	// suggesting a spec stripped something the agent needed. In memory
+	// only, like the per-tool call counts. Guarded by mu.
+	fieldAttribution map[ToolName]*toolFieldAttribution
+	overCompactions  map[overCompactionKey]int64
+
+	// Sampled tokenizer measurements by pipeline stage; see
+	// metrics_tokens.go. In memory only. tokenStages is guarded by mu.
+	tokenizer    atomic.Pointer[tokenizerRef]
+	tokenSeq     atomic.Int64
+	tokenStages  map[string]*tokenStage
+	tokenWorker  sync.Once
+	tokenJobs    chan tokenJob
+	tokenPending sync.WaitGroup
+
 	markdownBytesBefore atomic.Int64
 	markdownBytesAfter  atomic.Int64
 	markdownSamplesAll  atomic.Int64
@@ -77,6 +105,14 @@ type Metrics struct {
 	scriptFinalBytes        atomic.Int64
 	scriptSavingsSamples    atomic.Int64
 
+	// Bucketed calls, errors, latency and savings over time; see
+	// metrics_history.go. Persisted beside the lifetime totals.
+	history *metricsHistory
+
+	// The slowest recent calls, with their arguments; see
+	// metrics_latency.go. In memory only.
+	slowCalls *slowCallLog
+
 	// Global counters.
 	totalExecutions atomic.Int64
 	totalErrors     atomic.Int64
@@ -87,6 +123,34 @@ type Metrics struct {
 	// Persistence bookkeeping.
 	dirty    atomic.Bool // set on every record; cleared after a successful flush.
 	filePath string      // empty disables persistence.
+
+	observer MetricsObserver // guarded by mu; nil when nothing is exported
+}
+
+// MetricsObserver receives each event as Metrics records it, so it can be
+// exported to an external system (see the telemetry package). Methods are
+// called synchronously on the recording goroutine and must be cheap.
+type MetricsObserver interface {
+	ObserveExecution(integration IntegrationName, tool ToolName, duration time.Duration, isError bool, retries int)
+	ObserveCircuitBreak(integration IntegrationName)
+	ObserveCompaction(tool ToolName, beforeSize, afterSize int)
+	ObserveTruncation()
+	ObserveSearch()
+	ObserveScript()
+}
+
+// SetObserver installs o to receive every subsequent recorded event.
+// Pass nil to stop forwarding.
+func (m *Metrics) SetObserver(o MetricsObserver) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observer = o
+}
+
+func (m *Metrics) getObserver() MetricsObserver {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.observer
 }
 
 type toolMetric struct {
@@ -94,14 +158,53 @@ type toolMetric struct {
 	Errors  atomic.Int64
 	TotalNs atomic.Int64 // sum of latencies in nanoseconds
 	Retries atomic.Int64
+	Latency latencyHistogram
+
+	Redactions        atomic.Int64
+	InjectionFindings atomic.Int64
 }
 
 type integrationMetric struct {
 	Calls   atomic.Int64
 	Errors  atomic.Int64
 	TotalNs atomic.Int64
+	Latency latencyHistogram
+}
+
+// maxAttributedFields caps the fields tracked per tool; the rest are summed
+// under OtherFieldsPath.
+const maxAttributedFields = 100
+
+// FieldAttributionSampleEvery is how many of a tool's compacted calls share
+// one field attribution: the first of every FieldAttributionSampleEvery is
+// measured. Attribution walks the response twice, so measuring every call
+// would add to the latency of the largest responses.
+const FieldAttributionSampleEvery = 10
+
+type toolFieldAttribution struct {
+	calls, samples int64
+	fields         map[string]*fieldAttribution
+}
+
+type fieldAttribution struct {
+	before, after int64
 }
 
+type overCompactionKey struct {
+	tool, followUp ToolName
+	kind           string
+}
+
+// Over-compaction follow-up kinds.
+const (
+	// OverCompactionGet is a get tool called right after a compacted list
+	// from the same integration.
+	OverCompactionGet = "get"
+	// OverCompactionView is a compacted tool re-run right away with a
+	// different view, typically "full".
+	OverCompactionView = "view"
+)
+
 type compactionSample struct {
 	Tool       ToolName
 	BeforeSize int
@@ -111,10 +214,17 @@ type compactionSample struct {
 // NewMetrics returns an initialized Metrics collector with no persistence.
 func NewMetrics() *Metrics {
 	return &Metrics{
-		toolCalls:        make(map[ToolName]*toolMetric),
-		integrationCalls: make(map[IntegrationName]*integrationMetric),
-		circuitBreaks:    make(map[IntegrationName]*atomic.Int64),
-		startTime:        time.Now(),
+		toolCalls:         make(map[ToolName]*toolMetric),
+		integrationCalls:  make(map[IntegrationName]*integrationMetric),
+		circuitBreaks:     make(map[IntegrationName]*atomic.Int64),
+		redactions:        make(map[string]int64),
+		injectionFindings: make(map[string]int64),
+		fieldAttribution:  make(map[ToolName]*toolFieldAttribution),
+		overCompactions:   make(map[overCompactionKey]int64),
+		tokenStages:       make(map[string]*tokenStage),
+		history:           newMetricsHistory(),
+		slowCalls:         &slowCallLog{},
+		startTime:         time.Now(),
 	}
 }
 
@@ -137,6 +247,7 @@ func (m *Metrics) RecordExecution(integration IntegrationName, tool ToolName, du
 	}
 	tm.TotalNs.Add(ns)
 	tm.Retries.Add(int64(retries))
+	tm.Latency.observe(duration)
 
 	im := m.getIntegrationMetric(integration)
 	im.Calls.Add(1)
@@ -144,19 +255,30 @@ func (m *Metrics) RecordExecution(integration IntegrationName, tool ToolName, du
 		im.Errors.Add(1)
 	}
 	im.TotalNs.Add(ns)
+	im.Latency.observe(duration)
+	m.history.recordExecution(time.Now(), integration, tool, duration, isError)
 	m.dirty.Store(true)
+	if o := m.getObserver(); o != nil {
+		o.ObserveExecution(integration, tool, duration, isError, retries)
+	}
 }
 
 // RecordSearch records a search invocation.
 func (m *Metrics) RecordSearch() {
 	m.searchCount.Add(1)
 	m.dirty.Store(true)
+	if o := m.getObserver(); o != nil {
+		o.ObserveSearch()
+	}
 }
 
 // RecordScript records a script execution.
 func (m *Metrics) RecordScript() {
 	m.scriptCount.Add(1)
 	m.dirty.Store(true)
+	if o := m.getObserver(); o != nil {
+		o.ObserveScript()
+	}
 }
 
 // RecordCircuitBreak records a circuit breaker trip for an integration.
@@ -175,6 +297,9 @@ func (m *Metrics) RecordCircuitBreak(integration IntegrationName) {
 	}
 	counter.Add(1)
 	m.dirty.Store(true)
+	if o := m.getObserver(); o != nil {
+		o.ObserveCircuitBreak(integration)
+	}
 }
 
 // RecordCompaction records a compaction result.
@@ -192,8 +317,82 @@ func (m *Metrics) RecordCompaction(tool ToolName, beforeSize, afterSize int) {
 	if len(m.compactionSavings) > 1000 {
 		m.compactionSavings = m.compactionSavings[len(m.compactionSavings)-1000:]
 	}
+	o := m.observer
 	m.mu.Unlock()
+	m.history.recordSavings(time.Now(), tool, int64(beforeSize-afterSize))
 	m.dirty.Store(true)
+	if o != nil {
+		o.ObserveCompaction(tool, beforeSize, afterSize)
+	}
+}
+
+// RecordTransforms records the values a compaction's value transforms changed
+// and the bytes they removed.
+func (m *Metrics) RecordTransforms(tool ToolName, values, bytesSaved int) {
+	m.transformValues.Add(int64(values))
+	m.transformBytesSaved.Add(int64(bytesSaved))
+	m.dirty.Store(true)
+}
+
+// RecordCompactedCall counts one call of tool that went through a compaction
+// spec and reports whether its fields should be attributed, which is true
+// for the first of every FieldAttributionSampleEvery calls.
+func (m *Metrics) RecordCompactedCall(tool ToolName) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ta := m.toolAttribution(tool)
+	ta.calls++
+	return (ta.calls-1)%FieldAttributionSampleEvery == 0
+}
+
+// RecordFieldAttribution records how one sampled compacted response's bytes
+// split across its fields before and after compaction, as measured by
+// FieldBytes and CompactedFieldBytes.
+func (m *Metrics) RecordFieldAttribution(tool ToolName, before, after map[string]int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ta := m.toolAttribution(tool)
+	ta.samples++
+	for path, n := range before {
+		ta.field(path).before += int64(n)
+	}
+	for path, n := range after {
+		ta.field(path).after += int64(n)
+	}
+}
+
+// toolAttribution returns tool's attribution, creating it. Callers hold mu.
+func (m *Metrics) toolAttribution(tool ToolName) *toolFieldAttribution {
+	ta, ok := m.fieldAttribution[tool]
+	if !ok {
+		ta = &toolFieldAttribution{fields: make(map[string]*fieldAttribution)}
+		m.fieldAttribution[tool] = ta
+	}
+	return ta
+}
+
+func (ta *toolFieldAttribution) field(path string) *fieldAttribution {
+	if fa, ok := ta.fields[path]; ok {
+		return fa
+	}
+	if len(ta.fields) >= maxAttributedFields {
+		path = OtherFieldsPath
+		if fa, ok := ta.fields[path]; ok {
+			return fa
+		}
+	}
+	fa := &fieldAttribution{}
+	ta.fields[path] = fa
+	return fa
+}
+
+// RecordOverCompaction records followUp being called right after tool's
+// compacted response, a sign tool's spec dropped fields the agent needed.
+// kind is OverCompactionGet or OverCompactionView.
+func (m *Metrics) RecordOverCompaction(tool, followUp ToolName, kind string) {
+	m.mu.Lock()
+	m.overCompactions[overCompactionKey{tool: tool, followUp: followUp, kind: kind}]++
+	m.mu.Unlock()
 }
 
 // RecordMarkdownRender records a markdown rendering result.
@@ -212,6 +411,7 @@ func (m *Metrics) RecordMarkdownRender(tool ToolName, beforeSize, afterSize int)
 		m.markdownRenders = m.markdownRenders[len(m.markdownRenders)-1000:]
 	}
 	m.mu.Unlock()
+	m.history.recordSavings(time.Now(), tool, int64(beforeSize-afterSize))
 	m.dirty.Store(true)
 }
 
@@ -230,6 +430,7 @@ func (m *Metrics) RecordCatalogAvoidance(bytesAvoided int64) {
 	}
 	m.catalogBytesAvoided.Add(bytesAvoided)
 	m.catalogAvoidedCount.Add(1)
+	m.history.recordSavings(time.Now(), "", bytesAvoided)
 	m.dirty.Store(true)
 }
 
@@ -249,13 +450,53 @@ func (m *Metrics) RecordScriptSavings(intermediate, final int64) {
 		m.scriptFinalBytes.Add(final)
 	}
 	m.scriptSavingsSamples.Add(1)
+	m.history.recordSavings(time.Now(), "", intermediate-final)
 	m.dirty.Store(true)
 }
 
+// RecordRedactions records the matches a Redactor replaced in one of tool's
+// results, by type.
+func (m *Metrics) RecordRedactions(tool ToolName, counts map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
+	total := 0
+	m.mu.Lock()
+	for kind, n := range counts {
+		m.redactions[kind] += int64(n)
+		total += n
+	}
+	m.mu.Unlock()
+	m.getToolMetric(tool).Redactions.Add(int64(total))
+}
+
+// RecordQuarantine records one of tool's results passing through the
+// prompt-injection quarantine: the fields it wrapped and the patterns it
+// found, by kind.
+func (m *Metrics) RecordQuarantine(tool ToolName, fields int, findings map[string]int) {
+	if fields == 0 && len(findings) == 0 {
+		return
+	}
+	total := 0
+	m.mu.Lock()
+	m.quarantinedFields += int64(fields)
+	for kind, n := range findings {
+		m.injectionFindings[kind] += int64(n)
+		total += n
+	}
+	m.mu.Unlock()
+	if total > 0 {
+		m.getToolMetric(tool).InjectionFindings.Add(int64(total))
+	}
+}
+
 // RecordTruncation records a response that exceeded the size cap.
 func (m *Metrics) RecordTruncation() {
 	m.truncations.Add(1)
 	m.dirty.Store(true)
+	if o := m.getObserver(); o != nil {
+		o.ObserveTruncation()
+	}
 }
 
 // Snapshot returns a point-in-time copy of all metrics.
@@ -294,10 +535,13 @@ func (m *Metrics) snapshotWithPricing(dollarsPerMTok float64, showDollars bool)
 			avgNs = tm.TotalNs.Load() / calls
 		}
 		s.Tools[string(name)] = ToolSnapshot{
-			Calls:        calls,
-			Errors:       tm.Errors.Load(),
-			AvgLatencyMs: float64(avgNs) / 1e6,
-			Retries:      tm.Retries.Load(),
+			Calls:              calls,
+			Errors:             tm.Errors.Load(),
+			AvgLatencyMs:       float64(avgNs) / 1e6,
+			LatencyPercentiles: percentilesOf(tm.Latency.counts()),
+			Retries:            tm.Retries.Load(),
+			Redactions:         tm.Redactions.Load(),
+			InjectionFindings:  tm.InjectionFindings.Load(),
 		}
 	}
 
@@ -308,15 +552,32 @@ func (m *Metrics) snapshotWithPricing(dollarsPerMTok float64, showDollars bool)
 			avgNs = im.TotalNs.Load() / calls
 		}
 		s.Integrations[string(name)] = IntegrationSnapshot{
-			Calls:        calls,
-			Errors:       im.Errors.Load(),
-			AvgLatencyMs: float64(avgNs) / 1e6,
+			Calls:              calls,
+			Errors:             im.Errors.Load(),
+			AvgLatencyMs:       float64(avgNs) / 1e6,
+			LatencyPercentiles: percentilesOf(im.Latency.counts()),
 		}
 	}
 
 	for name, counter := range m.circuitBreaks {
 		s.CircuitBreaks[string(name)] = counter.Load()
 	}
+	s.SlowCalls = m.slowCalls.snapshot(time.Now())
+	if len(m.redactions) > 0 {
+		s.Redactions = make(map[string]int64, len(m.redactions))
+		for kind, n := range m.redactions {
+			s.Redactions[kind] = n
+			s.TotalRedactions += n
+		}
+	}
+	s.QuarantinedFields = m.quarantinedFields
+	if len(m.injectionFindings) > 0 {
+		s.InjectionFindings = make(map[string]int64, len(m.injectionFindings))
+		for kind, n := range m.injectionFindings {
+			s.InjectionFindings[kind] = n
+			s.TotalInjectionFindings += n
+		}
+	}
 
 	// Compaction lifetime totals (atomics survive sample-slice trimming).
 	cBefore := m.compactionBytesBefore.Load()
@@ -327,6 +588,9 @@ func (m *Metrics) snapshotWithPricing(dollarsPerMTok float64, showDollars bool)
 	if cBefore > 0 {
 		s.CompactionSavingsPct = 100 - int(100*cAfter/cBefore)
 	}
+	s.TransformValues = m.transformValues.Load()
+	s.TransformBytesSaved = m.transformBytesSaved.Load()
+	s.CompactionTools, s.OverCompactions = m.compactionAnalytics()
 
 	// Markdown rendering lifetime totals.
 	mBefore := m.markdownBytesBefore.Load()
@@ -362,6 +626,20 @@ func (m *Metrics) snapshotWithPricing(dollarsPerMTok float64, showDollars bool)
 	s.CatalogTokensSaved = BytesToTokens(s.CatalogBytesAvoided)
 	s.ScriptTokensSaved = BytesToTokens(s.ScriptIntermediateHidden)
 
+	// Where a tokenizer has measured sampled responses, convert the
+	// response buckets at the measured ratio instead of CharsPerToken.
+	s.TokenMeasurement = m.tokenMeasurement()
+	measured := false
+	if t, ok := m.measuredTokensSaved(s.CompactionBytesSaved, TokenStageCompaction, TokenStageViews); ok {
+		s.CompactionTokensSaved, measured = t, true
+	}
+	if t, ok := m.measuredTokensSaved(s.MarkdownBytesSaved, TokenStageMarkdown); ok {
+		s.MarkdownTokensSaved, measured = t, true
+	}
+	if measured {
+		s.TotalTokensSaved = s.CompactionTokensSaved + s.MarkdownTokensSaved + s.CatalogTokensSaved + s.ScriptTokensSaved
+	}
+
 	if showDollars && dollarsPerMTok > 0 && s.TotalTokensSaved > 0 {
 		dollars := (float64(s.TotalTokensSaved) / 1_000_000.0) * dollarsPerMTok
 		s.EstDollarsSaved = fmt.Sprintf("$%.2f", dollars)
@@ -371,6 +649,59 @@ func (m *Metrics) snapshotWithPricing(dollarsPerMTok float64, showDollars bool)
 	return s
 }
 
+// compactionAnalytics snapshots field attribution and over-compaction
+// follow-ups. Callers hold mu.
+func (m *Metrics) compactionAnalytics() (map[string]CompactionToolSnapshot, []OverCompactionSnapshot) {
+	if len(m.fieldAttribution) == 0 && len(m.overCompactions) == 0 {
+		return nil, nil
+	}
+	tools := make(map[string]CompactionToolSnapshot, len(m.fieldAttribution))
+	for name, ta := range m.fieldAttribution {
+		ts := CompactionToolSnapshot{Calls: ta.calls, Samples: ta.samples, Fields: make([]FieldAttributionSnapshot, 0, len(ta.fields))}
+		for path, fa := range ta.fields {
+			ts.BytesBefore += fa.before
+			ts.BytesAfter += fa.after
+			fs := FieldAttributionSnapshot{Path: path, BytesBefore: fa.before, BytesKept: min(fa.after, fa.before)}
+			if fa.before == 0 {
+				// Only seen after compaction, e.g. moved past the field cap.
+				fs.BytesKept = fa.after
+			}
+			fs.BytesDropped = fa.before - fs.BytesKept
+			ts.Fields = append(ts.Fields, fs)
+		}
+		sort.Slice(ts.Fields, func(i, j int) bool {
+			a, b := ts.Fields[i], ts.Fields[j]
+			if a.BytesDropped != b.BytesDropped {
+				return a.BytesDropped > b.BytesDropped
+			}
+			if a.BytesKept != b.BytesKept {
+				return a.BytesKept > b.BytesKept
+			}
+			return a.Path < b.Path
+		})
+		tools[string(name)] = ts
+	}
+
+	var overs []OverCompactionSnapshot
+	for k, n := range m.overCompactions {
+		overs = append(overs, OverCompactionSnapshot{Tool: k.tool, FollowUp: k.followUp, Kind: k.kind, Count: n})
+		ts := tools[string(k.tool)]
+		ts.OverCompactions += n
+		tools[string(k.tool)] = ts
+	}
+	sort.Slice(overs, func(i, j int) bool {
+		a, b := overs[i], overs[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Tool != b.Tool {
+			return a.Tool < b.Tool
+		}
+		return a.FollowUp < b.FollowUp
+	})
+	return tools, overs
+}
+
 // TopTools returns the N most-called tools, sorted by call count descending.
 func (m *Metrics) TopTools(n int) []ToolRank {
 	m.mu.RLock()
@@ -445,6 +776,14 @@ type MetricsSnapshot struct {
 	CompactionBytesSaved  int64 `json:"compaction_bytes_saved"`
 	CompactionSavingsPct  int   `json:"compaction_savings_pct"`
 	CompactionTokensSaved int64 `json:"compaction_tokens_saved"`
+	// Value transforms within compaction; TransformBytesSaved is part of
+	// CompactionBytesSaved.
+	TransformValues     int64 `json:"transform_values"`
+	TransformBytesSaved int64 `json:"transform_bytes_saved"`
+	// Per-tool field attribution and over-compaction follow-ups, since
+	// startup (not persisted).
+	CompactionTools map[string]CompactionToolSnapshot `json:"compaction_tools,omitempty"`
+	OverCompactions []OverCompactionSnapshot          `json:"over_compactions,omitempty"`
 
 	// Markdown rendering (HTML/JSON document → markdown).
 	MarkdownSamples     int   `json:"markdown_samples"`
@@ -469,6 +808,11 @@ type MetricsSnapshot struct {
 	TotalBytesSaved  int64 `json:"total_bytes_saved"`
 	TotalTokensSaved int64 `json:"total_tokens_saved"`
 
+	// Tokenizer measurements on sampled responses, nil when no tokenizer
+	// is selected. When present, the compaction and markdown token figures
+	// above use its measured ratio rather than CharsPerToken.
+	TokenMeasurement *TokenMeasurementSnapshot `json:"token_measurement,omitempty"`
+
 	// Optional dollar estimate (populated only when Settings → Show Dollar Estimate is on).
 	EstDollarsSaved string  `json:"est_dollars_saved,omitempty"`
 	DollarsPerMTok  float64 `json:"dollars_per_mtok,omitempty"`
@@ -476,6 +820,19 @@ type MetricsSnapshot struct {
 	Tools         map[string]ToolSnapshot        `json:"tools"`
 	Integrations  map[string]IntegrationSnapshot `json:"integrations"`
 	CircuitBreaks map[string]int64               `json:"circuit_breaks"`
+
+	// The slowest calls of the last SlowCallWindow, slowest first.
+	SlowCalls []SlowCall `json:"slow_calls,omitempty"`
+
+	// Matches replaced by the redaction stage since startup, by type.
+	TotalRedactions int64            `json:"total_redactions"`
+	Redactions      map[string]int64 `json:"redactions,omitempty"`
+
+	// Untrusted fields wrapped by the prompt-injection quarantine since
+	// startup, and the injection patterns found in them, by kind.
+	QuarantinedFields      int64            `json:"quarantined_fields"`
+	TotalInjectionFindings int64            `json:"total_injection_findings"`
+	InjectionFindings      map[string]int64 `json:"injection_findings,omitempty"`
 }
 
 // ErrorRate returns the error rate as a percentage (0-100).
@@ -486,19 +843,64 @@ func (s MetricsSnapshot) ErrorRate() float64 {
 	return float64(s.TotalErrors) / float64(s.TotalExecutions) * 100
 }
 
-// ToolSnapshot holds metrics for a single tool.
+// ToolSnapshot holds metrics for a single tool. Latency percentiles cover
+// every call since startup.
 type ToolSnapshot struct {
 	Calls        int64   `json:"calls"`
 	Errors       int64   `json:"errors"`
 	AvgLatencyMs float64 `json:"avg_latency_ms"`
-	Retries      int64   `json:"retries"`
+	LatencyPercentiles
+	Retries           int64 `json:"retries"`
+	Redactions        int64 `json:"redactions,omitempty"`
+	InjectionFindings int64 `json:"injection_findings,omitempty"`
 }
 
-// IntegrationSnapshot holds metrics for a single integration.
+// IntegrationSnapshot holds metrics for a single integration. Latency
+// percentiles cover every call since startup.
 type IntegrationSnapshot struct {
 	Calls        int64   `json:"calls"`
 	Errors       int64   `json:"errors"`
 	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	LatencyPercentiles
+}
+
+// CompactionToolSnapshot attributes one compacted tool's bytes to its fields.
+// Fields is sorted by bytes dropped, largest first.
+type CompactionToolSnapshot struct {
+	Calls int64 `json:"calls"`
+	// Samples is how many of the calls were attributed field by field (see
+	// FieldAttributionSampleEvery); the byte figures cover those only.
+	Samples         int64                      `json:"samples"`
+	BytesBefore     int64                      `json:"bytes_before"`
+	BytesAfter      int64                      `json:"bytes_after"`
+	OverCompactions int64                      `json:"over_compactions"`
+	Fields          []FieldAttributionSnapshot `json:"fields"`
+}
+
+// OverCompactionRate is the share of the tool's compacted calls (0-100)
+// that were followed by an over-compaction follow-up.
+func (s CompactionToolSnapshot) OverCompactionRate() float64 {
+	if s.Calls == 0 {
+		return 0
+	}
+	return float64(s.OverCompactions) / float64(s.Calls) * 100
+}
+
+// FieldAttributionSnapshot is one field's bytes before compaction and how
+// many of them the spec kept or dropped, keyed by source path.
+type FieldAttributionSnapshot struct {
+	Path         string `json:"path"`
+	BytesBefore  int64  `json:"bytes_before"`
+	BytesKept    int64  `json:"bytes_kept"`
+	BytesDropped int64  `json:"bytes_dropped"`
+}
+
+// OverCompactionSnapshot counts one (compacted tool, follow-up) pair.
+type OverCompactionSnapshot struct {
+	Tool     ToolName `json:"tool"`
+	FollowUp ToolName `json:"follow_up"`
+	Kind     string   `json:"kind"`
+	Count    int64    `json:"count"`
 }
 
 // ToolRank pairs a tool name with its call count for ranking.
@@ -513,7 +915,9 @@ type ToolRank struct {
 // do NOT persist the rolling sample slices (compactionSavings, markdownRenders)
 // or per-tool/per-integration call counts — those would balloon the file with
 // tool churn. The persisted document is small (<1KB) and only flushed when
-// dirty, so it does not produce a steady stream of disk writes.
+// dirty, so it does not produce a steady stream of disk writes. Per-tool and
+// per-integration series over time are kept separately, bounded by
+// retention; see metrics_history.go.
 
 // persistedMetrics is the on-disk schema. Keep field tags stable.
 type persistedMetrics struct {
@@ -530,6 +934,9 @@ type persistedMetrics struct {
 	CompactionBytesAfter  int64 `json:"compaction_bytes_after"`
 	CompactionSamplesAll  int64 `json:"compaction_samples_all"`
 
+	TransformValues     int64 `json:"transform_values"`
+	TransformBytesSaved int64 `json:"transform_bytes_saved"`
+
 	MarkdownBytesBefore int64 `json:"markdown_bytes_before"`
 	MarkdownBytesAfter  int64 `json:"markdown_bytes_after"`
 	MarkdownSamplesAll  int64 `json:"markdown_samples_all"`
@@ -548,7 +955,8 @@ const persistedMetricsVersion = 1
 
 // WithPersistence configures the metrics collector to load lifetime totals
 // from `path` (if present) and to flush back to that path on Flush() or
-// shutdown. Pass an empty path to disable persistence.
+// shutdown. History is loaded from and flushed to HistoryPath(path). Pass
+// an empty path to disable persistence.
 //
 // Returns the same Metrics for chaining. Logs (via the standard logger) but
 // does not fail if the file cannot be read — a corrupt or missing file simply
@@ -559,11 +967,18 @@ func (m *Metrics) WithPersistence(path string) *Metrics {
 		return m
 	}
 	m.loadFromDisk()
+	m.history.load(HistoryPath(path))
 	// Loading does not count as dirty.
 	m.dirty.Store(false)
 	return m
 }
 
+// HistoryPath returns the history file kept beside the lifetime totals at
+// path: metrics.json → metrics-history.json.
+func HistoryPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + "-history.json"
+}
+
 func (m *Metrics) loadFromDisk() {
 	if m.filePath == "" {
 		return
@@ -587,6 +1002,9 @@ func (m *Metrics) loadFromDisk() {
 	m.compactionBytesAfter.Store(p.CompactionBytesAfter)
 	m.compactionSamplesAll.Store(p.CompactionSamplesAll)
 
+	m.transformValues.Store(p.TransformValues)
+	m.transformBytesSaved.Store(p.TransformBytesSaved)
+
 	m.markdownBytesBefore.Store(p.MarkdownBytesBefore)
 	m.markdownBytesAfter.Store(p.MarkdownBytesAfter)
 	m.markdownSamplesAll.Store(p.MarkdownSamplesAll)
@@ -607,6 +1025,9 @@ func (m *Metrics) Flush() error {
 	if m.filePath == "" {
 		return nil
 	}
+	if err := m.history.flush(HistoryPath(m.filePath)); err != nil {
+		return err
+	}
 	if !m.dirty.Load() {
 		return nil
 	}
@@ -621,6 +1042,8 @@ func (m *Metrics) Flush() error {
 		CompactionBytesBefore:   m.compactionBytesBefore.Load(),
 		CompactionBytesAfter:    m.compactionBytesAfter.Load(),
 		CompactionSamplesAll:    m.compactionSamplesAll.Load(),
+		TransformValues:         m.transformValues.Load(),
+		TransformBytesSaved:     m.transformBytesSaved.Load(),
 		MarkdownBytesBefore:     m.markdownBytesBefore.Load(),
 		MarkdownBytesAfter:      m.markdownBytesAfter.Load(),
 		MarkdownSamplesAll:      m.markdownSamplesAll.Load(),
@@ -635,19 +1058,27 @@ func (m *Metrics) Flush() error {
 	if err != nil {
 		return fmt.Errorf("marshal metrics: %w", err)
 	}
-	dir := filepath.Dir(m.filePath)
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return fmt.Errorf("create metrics dir: %w", err)
+	if err := writeFileAtomic(m.filePath, data); err != nil {
+		return fmt.Errorf("metrics: %w", err)
 	}
-	tmp := m.filePath + ".tmp"
+	m.dirty.Store(false)
+	return nil
+}
+
+// writeFileAtomic writes data to a tmp file beside path and renames it into
+// place, so a crash mid-write cannot corrupt the existing file.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("create dir: %w", err)
+	}
+	tmp := path + ".tmp"
 	if err := os.WriteFile(tmp, data, 0600); err != nil {
-		return fmt.Errorf("write metrics tmp: %w", err)
+		return fmt.Errorf("write tmp: %w", err)
 	}
-	if err := os.Rename(tmp, m.filePath); err != nil {
+	if err := os.Rename(tmp, path); err != nil {
 		_ = os.Remove(tmp)
-		return fmt.Errorf("rename metrics: %w", err)
+		return fmt.Errorf("rename: %w", err)
 	}
-	m.dirty.Store(false)
 	return nil
 }
 
@@ -671,6 +1102,8 @@ func (m *Metrics) Reset() {
 	m.scriptIntermediateBytes.Store(0)
 	m.scriptFinalBytes.Store(0)
 	m.scriptSavingsSamples.Store(0)
+	m.transformValues.Store(0)
+	m.transformBytesSaved.Store(0)
 
 	m.mu.Lock()
 	m.compactionSavings = nil
@@ -678,8 +1111,18 @@ func (m *Metrics) Reset() {
 	m.toolCalls = make(map[ToolName]*toolMetric)
 	m.integrationCalls = make(map[IntegrationName]*integrationMetric)
 	m.circuitBreaks = make(map[IntegrationName]*atomic.Int64)
+	m.redactions = make(map[string]int64)
+	m.injectionFindings = make(map[string]int64)
+	m.quarantinedFields = 0
+	m.fieldAttribution = make(map[ToolName]*toolFieldAttribution)
+	m.overCompactions = make(map[overCompactionKey]int64)
+	m.tokenStages = make(map[string]*tokenStage)
 	m.startTime = time.Now()
 	m.mu.Unlock()
+	m.history.reset()
+	m.slowCalls.mu.Lock()
+	m.slowCalls.calls = nil
+	m.slowCalls.mu.Unlock()
 
 	m.dirty.Store(true)
 }
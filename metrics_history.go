@@ -0,0 +1,449 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// History resolutions.
+const (
+	HistoryMinute = "minute"
+	HistoryHour   = "hour"
+)
+
+const (
+	// DefaultHistoryRetention is how long hourly history is kept when
+	// Config.MetricsHistoryDays is unset.
+	DefaultHistoryRetention = 7 * 24 * time.Hour
+	// MaxHistoryRetention caps the configurable retention so the history
+	// file stays a manageable size.
+	MaxHistoryRetention = 90 * 24 * time.Hour
+	// minuteHistoryRetention is how long per-minute history is kept. Minute
+	// buckets exist for "what just happened"; trends use hourly buckets.
+	minuteHistoryRetention = 3 * time.Hour
+	// maxHistoryTools caps the tools tracked per bucket; the rest are summed
+	// under OtherHistoryTool.
+	maxHistoryTools = 100
+)
+
+// OtherHistoryTool collects the calls of tools beyond maxHistoryTools in one
+// history bucket.
+const OtherHistoryTool ToolName = "(other)"
+
+// historyCounts is one series' totals within a bucket.
+type historyCounts struct {
+	Calls      int64 `json:"calls,omitempty"`
+	Errors     int64 `json:"errors,omitempty"`
+	BytesSaved int64 `json:"bytes_saved,omitempty"`
+	// Latency counts calls per latencyBoundsMs bucket, trailing zeros
+	// trimmed.
+	Latency []int64 `json:"latency,omitempty"`
+}
+
+func (c *historyCounts) observe(d time.Duration, isError bool) {
+	c.Calls++
+	if isError {
+		c.Errors++
+	}
+	i := latencyBucket(d)
+	if len(c.Latency) <= i {
+		c.Latency = append(c.Latency, make([]int64, i+1-len(c.Latency))...)
+	}
+	c.Latency[i]++
+}
+
+func (c *historyCounts) add(o *historyCounts) {
+	c.Calls += o.Calls
+	c.Errors += o.Errors
+	c.BytesSaved += o.BytesSaved
+	if len(c.Latency) < len(o.Latency) {
+		c.Latency = append(c.Latency, make([]int64, len(o.Latency)-len(c.Latency))...)
+	}
+	for i, n := range o.Latency {
+		c.Latency[i] += n
+	}
+}
+
+type historyBucket struct {
+	Start        time.Time                          `json:"start"`
+	Total        historyCounts                      `json:"total"`
+	Integrations map[IntegrationName]*historyCounts `json:"integrations,omitempty"`
+	Tools        map[ToolName]*historyCounts        `json:"tools,omitempty"`
+}
+
+func (b *historyBucket) integration(name IntegrationName) *historyCounts {
+	if b.Integrations == nil {
+		b.Integrations = make(map[IntegrationName]*historyCounts)
+	}
+	c, ok := b.Integrations[name]
+	if !ok {
+		c = &historyCounts{}
+		b.Integrations[name] = c
+	}
+	return c
+}
+
+func (b *historyBucket) tool(name ToolName) *historyCounts {
+	if b.Tools == nil {
+		b.Tools = make(map[ToolName]*historyCounts)
+	}
+	if c, ok := b.Tools[name]; ok {
+		return c
+	}
+	if len(b.Tools) >= maxHistoryTools {
+		name = OtherHistoryTool
+		if c, ok := b.Tools[name]; ok {
+			return c
+		}
+	}
+	c := &historyCounts{}
+	b.Tools[name] = c
+	return c
+}
+
+// metricsHistory keeps per-minute and hourly buckets of calls, errors,
+// latency and bytes saved, oldest first. It has its own lock so recording
+// history never contends with snapshots of the lifetime totals.
+type metricsHistory struct {
+	mu        sync.Mutex
+	minutes   []*historyBucket
+	hours     []*historyBucket
+	retention time.Duration
+	// toolIntegration maps tools to the integration that last executed
+	// them, so bytes saved (recorded per tool) can be credited to
+	// integrations too.
+	toolIntegration map[ToolName]IntegrationName
+	dirty           bool
+}
+
+func newMetricsHistory() *metricsHistory {
+	return &metricsHistory{
+		retention:       DefaultHistoryRetention,
+		toolIntegration: make(map[ToolName]IntegrationName),
+	}
+}
+
+// buckets returns the current minute and hour buckets for at, appending
+// them as needed and dropping buckets past retention. Callers hold mu.
+func (h *metricsHistory) buckets(at time.Time) (minute, hour *historyBucket) {
+	h.minutes = appendBucket(h.minutes, at.Truncate(time.Minute))
+	h.hours = appendBucket(h.hours, at.Truncate(time.Hour))
+	h.prune(at)
+	h.dirty = true
+	return h.minutes[len(h.minutes)-1], h.hours[len(h.hours)-1]
+}
+
+func appendBucket(buckets []*historyBucket, start time.Time) []*historyBucket {
+	if n := len(buckets); n > 0 && !buckets[n-1].Start.Before(start) {
+		return buckets
+	}
+	return append(buckets, &historyBucket{Start: start})
+}
+
+// prune drops buckets older than their resolution's retention. Callers
+// hold mu.
+func (h *metricsHistory) prune(now time.Time) {
+	h.minutes = dropBefore(h.minutes, now.Add(-minuteHistoryRetention))
+	h.hours = dropBefore(h.hours, now.Add(-h.retention))
+}
+
+func dropBefore(buckets []*historyBucket, cutoff time.Time) []*historyBucket {
+	i := sort.Search(len(buckets), func(i int) bool { return !buckets[i].Start.Before(cutoff) })
+	if i == 0 {
+		return buckets
+	}
+	return append(buckets[:0:0], buckets[i:]...)
+}
+
+func (h *metricsHistory) recordExecution(at time.Time, integration IntegrationName, tool ToolName, d time.Duration, isError bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.toolIntegration[tool] = integration
+	minute, hour := h.buckets(at)
+	for _, b := range [...]*historyBucket{minute, hour} {
+		b.Total.observe(d, isError)
+		b.integration(integration).observe(d, isError)
+		b.tool(tool).observe(d, isError)
+	}
+}
+
+// recordSavings credits bytesSaved to tool (and its integration, when
+// known). An empty tool credits only the total, for savings not tied to a
+// tool call such as catalog avoidance.
+func (h *metricsHistory) recordSavings(at time.Time, tool ToolName, bytesSaved int64) {
+	if bytesSaved <= 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	integration, known := h.toolIntegration[tool]
+	minute, hour := h.buckets(at)
+	for _, b := range [...]*historyBucket{minute, hour} {
+		b.Total.BytesSaved += bytesSaved
+		if tool == "" {
+			continue
+		}
+		b.tool(tool).BytesSaved += bytesSaved
+		if known {
+			b.integration(integration).BytesSaved += bytesSaved
+		}
+	}
+}
+
+// SetHistoryRetention sets how long hourly history is kept, clamped to
+// MaxHistoryRetention. Zero or negative restores DefaultHistoryRetention.
+// Buckets already past the new retention are dropped.
+func (m *Metrics) SetHistoryRetention(d time.Duration) {
+	switch {
+	case d <= 0:
+		d = DefaultHistoryRetention
+	case d > MaxHistoryRetention:
+		d = MaxHistoryRetention
+	}
+	h := m.history
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.retention = d
+	h.prune(time.Now())
+}
+
+// HistoryRetention returns how long hourly history is kept.
+func (m *Metrics) HistoryRetention() time.Duration {
+	m.history.mu.Lock()
+	defer m.history.mu.Unlock()
+	return m.history.retention
+}
+
+// HistoryQuery selects one series from the metrics history.
+type HistoryQuery struct {
+	// Resolution is HistoryMinute or HistoryHour (the default).
+	Resolution string
+	// Window is how far back to look: default one hour of minutes or a day
+	// of hours, capped at the resolution's retention.
+	Window time.Duration
+	// Integration and Tool narrow the series; Tool takes precedence. Both
+	// empty selects the total across all calls.
+	Integration IntegrationName
+	Tool        ToolName
+}
+
+// MetricsHistory is one series over time. Points cover the whole window,
+// oldest first, with empty buckets included so charts keep their scale.
+type MetricsHistory struct {
+	Resolution    string          `json:"resolution"`
+	BucketSeconds int64           `json:"bucket_seconds"`
+	Integration   IntegrationName `json:"integration,omitempty"`
+	Tool          ToolName        `json:"tool,omitempty"`
+	Points        []HistoryPoint  `json:"points"`
+	// Integrations and Tools list the series with calls in the window,
+	// busiest first, for choosing what to chart next.
+	Integrations []IntegrationName `json:"integrations"`
+	Tools        []ToolName        `json:"tools"`
+}
+
+// HistoryPoint is one bucket of a series. Latency percentiles are estimated
+// from a histogram and are zero for buckets without calls.
+type HistoryPoint struct {
+	Time       time.Time `json:"time"`
+	Calls      int64     `json:"calls"`
+	Errors     int64     `json:"errors"`
+	ErrorRate  float64   `json:"error_rate"`
+	P50Ms      float64   `json:"p50_ms"`
+	P95Ms      float64   `json:"p95_ms"`
+	P99Ms      float64   `json:"p99_ms"`
+	BytesSaved int64     `json:"bytes_saved"`
+}
+
+// ValidateHistoryQuery reports whether q names a known resolution and a
+// non-negative window.
+func ValidateHistoryQuery(q HistoryQuery) error {
+	switch q.Resolution {
+	case "", HistoryMinute, HistoryHour:
+	default:
+		return fmt.Errorf("unknown resolution %q (want %q or %q)", q.Resolution, HistoryMinute, HistoryHour)
+	}
+	if q.Window < 0 {
+		return fmt.Errorf("window must not be negative")
+	}
+	return nil
+}
+
+// History returns the series q selects. Invalid queries fall back to the
+// defaults; check them with ValidateHistoryQuery first.
+func (m *Metrics) History(q HistoryQuery) MetricsHistory {
+	return m.historyAt(q, time.Now())
+}
+
+func (m *Metrics) historyAt(q HistoryQuery, now time.Time) MetricsHistory {
+	h := m.history
+	step, window, retention := time.Hour, 24*time.Hour, m.HistoryRetention()
+	res := HistoryHour
+	if q.Resolution == HistoryMinute {
+		res, step, window, retention = HistoryMinute, time.Minute, time.Hour, minuteHistoryRetention
+	}
+	if q.Window > 0 {
+		window = q.Window
+	}
+	window = min(window, retention)
+
+	out := MetricsHistory{
+		Resolution:    res,
+		BucketSeconds: int64(step / time.Second),
+		Integration:   q.Integration,
+		Tool:          q.Tool,
+	}
+	last := now.Truncate(step)
+	first := last.Add(-window + step)
+	if first.After(last) {
+		first = last
+	}
+	n := int(last.Sub(first)/step) + 1
+	series := make([]historyCounts, n)
+	integrations := map[IntegrationName]int64{}
+	tools := map[ToolName]int64{}
+
+	h.mu.Lock()
+	buckets := h.hours
+	if res == HistoryMinute {
+		buckets = h.minutes
+	}
+	for _, b := range buckets {
+		if b.Start.Before(first) || b.Start.After(last) {
+			continue
+		}
+		i := int(b.Start.Sub(first) / step)
+		switch {
+		case q.Tool != "":
+			if c, ok := b.Tools[q.Tool]; ok {
+				series[i].add(c)
+			}
+		case q.Integration != "":
+			if c, ok := b.Integrations[q.Integration]; ok {
+				series[i].add(c)
+			}
+		default:
+			series[i].add(&b.Total)
+		}
+		for name, c := range b.Integrations {
+			if c.Calls > 0 {
+				integrations[name] += c.Calls
+			}
+		}
+		for name, c := range b.Tools {
+			if c.Calls > 0 && (q.Integration == "" || h.toolIntegration[name] == q.Integration) {
+				tools[name] += c.Calls
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	out.Points = make([]HistoryPoint, n)
+	for i := range series {
+		c := &series[i]
+		p := HistoryPoint{
+			Time:       first.Add(time.Duration(i) * step),
+			Calls:      c.Calls,
+			Errors:     c.Errors,
+			P50Ms:      latencyPercentile(c.Latency, 0.50),
+			P95Ms:      latencyPercentile(c.Latency, 0.95),
+			P99Ms:      latencyPercentile(c.Latency, 0.99),
+			BytesSaved: c.BytesSaved,
+		}
+		if c.Calls > 0 {
+			p.ErrorRate = float64(c.Errors) / float64(c.Calls) * 100
+		}
+		out.Points[i] = p
+	}
+	out.Integrations = rankedByCalls(integrations)
+	out.Tools = rankedByCalls(tools)
+	return out
+}
+
+func rankedByCalls[K ~string](calls map[K]int64) []K {
+	names := make([]K, 0, len(calls))
+	for name := range calls {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if calls[names[i]] != calls[names[j]] {
+			return calls[names[i]] > calls[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// --- History persistence ----------------------------------------------------
+//
+// History lives in its own file beside the lifetime totals (metrics.json →
+// metrics-history.json): unlike the totals it holds per-tool series, so it
+// is larger and bounded by retention rather than by design.
+
+type persistedHistory struct {
+	Version         int                          `json:"version"`
+	Minutes         []*historyBucket             `json:"minutes"`
+	Hours           []*historyBucket             `json:"hours"`
+	ToolIntegration map[ToolName]IntegrationName `json:"tool_integration,omitempty"`
+	SavedAt         time.Time                    `json:"saved_at"`
+}
+
+const persistedHistoryVersion = 1
+
+func (h *metricsHistory) load(path string) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path derived from the operator-controlled metrics path
+	if err != nil {
+		return // missing file is fine
+	}
+	var p persistedHistory
+	if err := json.Unmarshal(data, &p); err != nil || p.Version != persistedHistoryVersion {
+		return // corrupt or foreign file: start fresh
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.minutes, h.hours = p.Minutes, p.Hours
+	for tool, integration := range p.ToolIntegration {
+		h.toolIntegration[tool] = integration
+	}
+	h.prune(time.Now())
+}
+
+// flush writes the history to path when it changed since the last flush.
+func (h *metricsHistory) flush(path string) error {
+	h.mu.Lock()
+	if !h.dirty {
+		h.mu.Unlock()
+		return nil
+	}
+	h.prune(time.Now())
+	data, err := json.Marshal(&persistedHistory{
+		Version:         persistedHistoryVersion,
+		Minutes:         h.minutes,
+		Hours:           h.hours,
+		ToolIntegration: h.toolIntegration,
+		SavedAt:         time.Now(),
+	})
+	h.dirty = false
+	h.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("marshal metrics history: %w", err)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		h.mu.Lock()
+		h.dirty = true
+		h.mu.Unlock()
+		return fmt.Errorf("metrics history: %w", err)
+	}
+	return nil
+}
+
+func (h *metricsHistory) reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.minutes, h.hours = nil, nil
+	h.toolIntegration = make(map[ToolName]IntegrationName)
+	h.dirty = true
+}
@@ -0,0 +1,191 @@
+package mcp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsHistory_HourlySeries(t *testing.T) {
+	m := NewMetrics()
+	now := time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC)
+	h := m.history
+	h.recordExecution(now.Add(-2*time.Hour), "datadog", "datadog_search_logs", 40*time.Millisecond, false)
+	h.recordExecution(now.Add(-2*time.Hour), "datadog", "datadog_search_logs", 40*time.Millisecond, true)
+	h.recordExecution(now, "datadog", "datadog_list_monitors", 300*time.Millisecond, false)
+	h.recordExecution(now, "github", "github_list_issues", 20*time.Millisecond, false)
+	h.recordSavings(now, "datadog_list_monitors", 1000)
+	h.recordSavings(now, "", 500)
+
+	all := m.historyAt(HistoryQuery{}, now)
+	assert.Equal(t, HistoryHour, all.Resolution)
+	assert.Equal(t, int64(3600), all.BucketSeconds)
+	require.Len(t, all.Points, 24, "a day of hours, empty buckets included")
+	last := all.Points[23]
+	assert.Equal(t, now.Truncate(time.Hour), last.Time)
+	assert.Equal(t, int64(2), last.Calls)
+	assert.Equal(t, int64(1500), last.BytesSaved)
+	assert.Equal(t, int64(2), all.Points[21].Calls)
+	assert.Equal(t, 50.0, all.Points[21].ErrorRate)
+	assert.Equal(t, []IntegrationName{"datadog", "github"}, all.Integrations)
+
+	dd := m.historyAt(HistoryQuery{Integration: "datadog", Window: 3 * time.Hour}, now)
+	require.Len(t, dd.Points, 3)
+	assert.Equal(t, int64(2), dd.Points[0].Calls)
+	assert.Equal(t, int64(1), dd.Points[0].Errors)
+	assert.Equal(t, int64(1), dd.Points[2].Calls)
+	assert.Equal(t, int64(1000), dd.Points[2].BytesSaved, "savings credited to the tool's integration")
+	assert.Equal(t, []ToolName{"datadog_search_logs", "datadog_list_monitors"}, dd.Tools)
+
+	tool := m.historyAt(HistoryQuery{Tool: "datadog_list_monitors", Window: time.Hour}, now)
+	require.Len(t, tool.Points, 1)
+	assert.Equal(t, int64(1), tool.Points[0].Calls)
+	assert.InDelta(t, 250, tool.Points[0].P50Ms, 250, "within the 250-500ms bucket")
+}
+
+func TestMetricsHistory_MinuteResolution(t *testing.T) {
+	m := NewMetrics()
+	now := time.Date(2026, 3, 10, 15, 30, 20, 0, time.UTC)
+	m.history.recordExecution(now.Add(-5*time.Minute), "slack", "slack_post_message", 10*time.Millisecond, false)
+	m.history.recordExecution(now, "slack", "slack_post_message", 10*time.Millisecond, false)
+
+	h := m.historyAt(HistoryQuery{Resolution: HistoryMinute, Window: 10 * time.Minute}, now)
+	require.Len(t, h.Points, 10)
+	assert.Equal(t, int64(60), h.BucketSeconds)
+	assert.Equal(t, int64(1), h.Points[4].Calls)
+	assert.Equal(t, int64(1), h.Points[9].Calls)
+
+	capped := m.historyAt(HistoryQuery{Resolution: HistoryMinute, Window: 24 * time.Hour}, now)
+	assert.Len(t, capped.Points, int(minuteHistoryRetention/time.Minute), "window capped at minute retention")
+}
+
+func TestMetricsHistory_Percentiles(t *testing.T) {
+	var c historyCounts
+	assert.Equal(t, 0.0, latencyPercentile(c.Latency, 0.5))
+	for range 90 {
+		c.observe(3*time.Millisecond, false)
+	}
+	for range 10 {
+		c.observe(800*time.Millisecond, false)
+	}
+	assert.InDelta(t, 2, latencyPercentile(c.Latency, 0.5), 3, "median in the 2-5ms bucket")
+	assert.GreaterOrEqual(t, latencyPercentile(c.Latency, 0.95), 500.0)
+	assert.LessOrEqual(t, latencyPercentile(c.Latency, 0.95), 1000.0)
+
+	var slow historyCounts
+	slow.observe(5*time.Minute, false)
+	assert.Equal(t, 60000.0, latencyPercentile(slow.Latency, 0.99), "overflow reports the last bound")
+}
+
+func TestMetricsHistory_Retention(t *testing.T) {
+	m := NewMetrics()
+	m.SetHistoryRetention(48 * time.Hour)
+	assert.Equal(t, 48*time.Hour, m.HistoryRetention())
+
+	now := time.Now()
+	m.history.recordExecution(now.Add(-47*time.Hour), "github", "github_get_issue", time.Millisecond, false)
+	m.history.recordExecution(now, "github", "github_get_issue", time.Millisecond, false)
+	m.SetHistoryRetention(24 * time.Hour)
+	m.history.mu.Lock()
+	assert.Len(t, m.history.hours, 1, "buckets past the new retention are dropped")
+	m.history.mu.Unlock()
+
+	m.SetHistoryRetention(0)
+	assert.Equal(t, DefaultHistoryRetention, m.HistoryRetention())
+	m.SetHistoryRetention(365 * 24 * time.Hour)
+	assert.Equal(t, MaxHistoryRetention, m.HistoryRetention())
+}
+
+func TestMetricsHistory_CapsToolsPerBucket(t *testing.T) {
+	m := NewMetrics()
+	now := time.Now()
+	for i := range maxHistoryTools + 5 {
+		m.history.recordExecution(now, "github", ToolName(fmt.Sprintf("github_tool_%d", i)), time.Millisecond, false)
+	}
+	m.history.mu.Lock()
+	defer m.history.mu.Unlock()
+	tools := m.history.hours[0].Tools
+	assert.Len(t, tools, maxHistoryTools+1)
+	assert.Equal(t, int64(5), tools[OtherHistoryTool].Calls)
+}
+
+func TestMetricsHistory_RecordedByMetrics(t *testing.T) {
+	m := NewMetrics()
+	m.RecordExecution("github", "github_list_issues", 30*time.Millisecond, true, 0)
+	m.RecordCompaction("github_list_issues", 1000, 400)
+	m.RecordMarkdownRender("github_list_issues", 500, 300)
+	m.RecordCatalogAvoidance(100)
+	m.RecordScriptSavings(80, 30)
+
+	p := sumPoints(m.History(HistoryQuery{Window: 2 * time.Hour}))
+	assert.Equal(t, int64(1), p.Calls)
+	assert.Equal(t, int64(1), p.Errors)
+	assert.Equal(t, int64(600+200+100+50), p.BytesSaved)
+
+	gh := sumPoints(m.History(HistoryQuery{Integration: "github", Window: 2 * time.Hour}))
+	assert.Equal(t, int64(800), gh.BytesSaved, "catalog and script savings stay in the total")
+
+	m.Reset()
+	assert.Empty(t, m.History(HistoryQuery{}).Integrations)
+}
+
+func TestMetricsHistory_Persistence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "metrics.json")
+	assert.Equal(t, filepath.Join(dir, "metrics-history.json"), HistoryPath(path))
+
+	m := NewMetrics().WithPersistence(path)
+	require.NoError(t, m.Flush())
+	_, err := os.Stat(HistoryPath(path))
+	assert.True(t, os.IsNotExist(err), "no history file until something is recorded")
+
+	m.RecordExecution("linear", "linear_list_issues", 20*time.Millisecond, false, 0)
+	m.RecordCompaction("linear_list_issues", 900, 300)
+	require.NoError(t, m.Flush())
+
+	m2 := NewMetrics().WithPersistence(path)
+	p := sumPoints(m2.History(HistoryQuery{Integration: "linear", Window: 2 * time.Hour}))
+	assert.Equal(t, int64(1), p.Calls)
+	assert.Equal(t, int64(600), p.BytesSaved)
+	assert.Greater(t, p.P50Ms, 0.0)
+
+	// Savings for a tool learned before the restart are still credited.
+	m2.RecordCompaction("linear_list_issues", 200, 100)
+	p = sumPoints(m2.History(HistoryQuery{Integration: "linear", Window: 2 * time.Hour}))
+	assert.Equal(t, int64(700), p.BytesSaved)
+}
+
+func TestMetricsHistory_CorruptFileStartsFresh(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	require.NoError(t, os.WriteFile(HistoryPath(path), []byte("{not json"), 0600))
+	m := NewMetrics().WithPersistence(path)
+	assert.Empty(t, m.History(HistoryQuery{}).Integrations)
+}
+
+func TestValidateHistoryQuery(t *testing.T) {
+	assert.NoError(t, ValidateHistoryQuery(HistoryQuery{}))
+	assert.NoError(t, ValidateHistoryQuery(HistoryQuery{Resolution: HistoryMinute, Window: time.Hour}))
+	assert.Error(t, ValidateHistoryQuery(HistoryQuery{Resolution: "day"}))
+	assert.Error(t, ValidateHistoryQuery(HistoryQuery{Window: -time.Hour}))
+}
+
+// sumPoints folds a series into one point (latency from the busiest
+// bucket), so tests recording at time.Now() pass across bucket boundaries.
+func sumPoints(h MetricsHistory) HistoryPoint {
+	var sum HistoryPoint
+	var busiest int64
+	for _, p := range h.Points {
+		sum.Calls += p.Calls
+		sum.Errors += p.Errors
+		sum.BytesSaved += p.BytesSaved
+		if p.Calls > busiest {
+			busiest, sum.P50Ms = p.Calls, p.P50Ms
+		}
+	}
+	return sum
+}
@@ -0,0 +1,315 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBoundsMs are the upper bounds of the latency histogram buckets; a
+// final bucket counts calls slower than the last bound.
+var latencyBoundsMs = [...]float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000}
+
+// latencyHistogram is a lock-free streaming histogram over latencyBoundsMs,
+// kept per tool and per integration since startup.
+type latencyHistogram [len(latencyBoundsMs) + 1]atomic.Int64
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	h[latencyBucket(d)].Add(1)
+}
+
+func (h *latencyHistogram) counts() []int64 {
+	c := make([]int64, len(h))
+	for i := range h {
+		c[i] = h[i].Load()
+	}
+	return c
+}
+
+func latencyBucket(d time.Duration) int {
+	return sort.SearchFloat64s(latencyBoundsMs[:], float64(d)/float64(time.Millisecond))
+}
+
+// latencyPercentile estimates the q-th latency quantile (0 < q <= 1) in
+// milliseconds from histogram counts over latencyBoundsMs, interpolating
+// within the bucket it falls in. Calls past the last bound report it.
+func latencyPercentile(counts []int64, q float64) float64 {
+	var total int64
+	for _, n := range counts {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := q * float64(total)
+	var seen int64
+	for i, n := range counts {
+		if n == 0 || float64(seen+n) < rank {
+			seen += n
+			continue
+		}
+		if i >= len(latencyBoundsMs) {
+			break
+		}
+		lo := 0.0
+		if i > 0 {
+			lo = latencyBoundsMs[i-1]
+		}
+		return lo + (latencyBoundsMs[i]-lo)*(rank-float64(seen))/float64(n)
+	}
+	return latencyBoundsMs[len(latencyBoundsMs)-1]
+}
+
+// LatencyPercentiles are estimated from a histogram, so they are accurate to
+// the bucket (1, 2, 5, 10, 25 … 60000 ms), not to the millisecond.
+type LatencyPercentiles struct {
+	P50Ms float64 `json:"p50_ms"`
+	P95Ms float64 `json:"p95_ms"`
+	P99Ms float64 `json:"p99_ms"`
+}
+
+func percentilesOf(counts []int64) LatencyPercentiles {
+	return LatencyPercentiles{
+		P50Ms: latencyPercentile(counts, 0.50),
+		P95Ms: latencyPercentile(counts, 0.95),
+		P99Ms: latencyPercentile(counts, 0.99),
+	}
+}
+
+// --- Slow-call log ----------------------------------------------------------
+
+const (
+	// SlowCallWindow is how long a call stays in the slow-call log.
+	SlowCallWindow = time.Hour
+	// SlowCallThreshold is the shortest call the log considers slow.
+	SlowCallThreshold = 500 * time.Millisecond
+	// maxSlowCalls bounds the log: once full, a new call displaces the
+	// fastest entry only if it is slower.
+	maxSlowCalls = 25
+)
+
+// RedactedArg replaces argument values whose names look like credentials.
+const RedactedArg = "[redacted]"
+
+// SlowCall is one logged execution. Args hold only the arguments' names and
+// shapes; see RedactArgs.
+type SlowCall struct {
+	At          time.Time       `json:"at"`
+	Integration IntegrationName `json:"integration"`
+	Tool        ToolName        `json:"tool"`
+	DurationMs  float64         `json:"duration_ms"`
+	Retries     int             `json:"retries,omitempty"`
+	Error       bool            `json:"error,omitempty"`
+	Args        map[string]any  `json:"args,omitempty"`
+}
+
+// slowCallLog keeps the slowest calls of the last SlowCallWindow.
+type slowCallLog struct {
+	mu    sync.Mutex
+	calls []SlowCall
+}
+
+// admits reports whether a call of duration d at now would enter the log,
+// dropping expired entries. Callers hold mu.
+func (l *slowCallLog) admits(now time.Time, d time.Duration) bool {
+	if d < SlowCallThreshold {
+		return false
+	}
+	cutoff := now.Add(-SlowCallWindow)
+	live := l.calls[:0]
+	for _, c := range l.calls {
+		if c.At.After(cutoff) {
+			live = append(live, c)
+		}
+	}
+	clear(l.calls[len(live):])
+	l.calls = live
+	return len(l.calls) < maxSlowCalls || float64(d)/float64(time.Millisecond) > l.calls[l.fastest()].DurationMs
+}
+
+func (l *slowCallLog) fastest() int {
+	at := 0
+	for i, c := range l.calls {
+		if c.DurationMs < l.calls[at].DurationMs {
+			at = i
+		}
+	}
+	return at
+}
+
+func (l *slowCallLog) add(c SlowCall) {
+	if len(l.calls) < maxSlowCalls {
+		l.calls = append(l.calls, c)
+		return
+	}
+	l.calls[l.fastest()] = c
+}
+
+// snapshot returns the live entries, slowest first.
+func (l *slowCallLog) snapshot(now time.Time) []SlowCall {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	cutoff := now.Add(-SlowCallWindow)
+	out := make([]SlowCall, 0, len(l.calls))
+	for _, c := range l.calls {
+		if c.At.After(cutoff) {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].DurationMs > out[j].DurationMs })
+	return out
+}
+
+// RecordSlowCall logs an execution when it is among the slowest of the last
+// SlowCallWindow. Call it alongside RecordExecution with the call's
+// arguments; they are redacted only when the call is logged, so fast calls
+// cost a lock and a comparison.
+func (m *Metrics) RecordSlowCall(integration IntegrationName, tool ToolName, args map[string]any, duration time.Duration, isError bool, retries int) {
+	m.recordSlowCallAt(time.Now(), integration, tool, args, duration, isError, retries)
+}
+
+func (m *Metrics) recordSlowCallAt(now time.Time, integration IntegrationName, tool ToolName, args map[string]any, duration time.Duration, isError bool, retries int) {
+	l := m.slowCalls
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.admits(now, duration) {
+		return
+	}
+	l.add(SlowCall{
+		At:          now,
+		Integration: integration,
+		Tool:        tool,
+		DurationMs:  float64(duration) / float64(time.Millisecond),
+		Retries:     retries,
+		Error:       isError,
+		Args:        RedactArgs(args),
+	})
+}
+
+// SlowCalls returns the slowest calls of the last SlowCallWindow, slowest
+// first.
+func (m *Metrics) SlowCalls() []SlowCall {
+	return m.slowCalls.snapshot(time.Now())
+}
+
+// sensitiveArgNames are substrings of argument names whose values are never
+// logged.
+var sensitiveArgNames = []string{"token", "secret", "password", "passwd", "authorization", "bearer", "credential", "cookie", "session", "api_key", "apikey", "private_key", "signature"}
+
+// RedactArgs returns a copy of args safe to keep in logs. Names are kept but
+// no value is: strings become their size ("[12-byte string]"), other
+// scalars their type, and values under names that look like credentials
+// RedactedArg. Nested maps and slices keep their structure.
+func RedactArgs(args map[string]any) map[string]any {
+	if len(args) == 0 {
+		return nil
+	}
+	out := make(map[string]any, len(args))
+	for k, v := range args {
+		if isSensitiveArg(k) {
+			out[k] = RedactedArg
+			continue
+		}
+		out[k] = redactValue(v)
+	}
+	return out
+}
+
+func redactValue(v any) any {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		return RedactArgs(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = redactValue(e)
+		}
+		return out
+	case string:
+		return fmt.Sprintf("[%d-byte string]", len(v))
+	case bool:
+		return "[bool]"
+	case float64, json.Number, int, int64:
+		return "[number]"
+	default:
+		return fmt.Sprintf("[%T]", v)
+	}
+}
+
+func isSensitiveArg(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range sensitiveArgNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// --- Recent latency ---------------------------------------------------------
+
+// IntegrationLatency summarizes one integration's calls over a recent
+// window.
+type IntegrationLatency struct {
+	Integration IntegrationName `json:"integration"`
+	Calls       int64           `json:"calls"`
+	Errors      int64           `json:"errors"`
+	ErrorRate   float64         `json:"error_rate"`
+	LatencyPercentiles
+}
+
+// RecentLatency summarizes each integration's calls over the last window
+// (capped at the minute history's retention), slowest p95 first.
+func (m *Metrics) RecentLatency(window time.Duration) []IntegrationLatency {
+	return m.recentLatencyAt(time.Now(), window)
+}
+
+func (m *Metrics) recentLatencyAt(now time.Time, window time.Duration) []IntegrationLatency {
+	window = min(window, minuteHistoryRetention)
+	cutoff := now.Truncate(time.Minute).Add(-window + time.Minute)
+	totals := map[IntegrationName]*historyCounts{}
+
+	h := m.history
+	h.mu.Lock()
+	for _, b := range h.minutes {
+		if b.Start.Before(cutoff) {
+			continue
+		}
+		for name, c := range b.Integrations {
+			t, ok := totals[name]
+			if !ok {
+				t = &historyCounts{}
+				totals[name] = t
+			}
+			t.add(c)
+		}
+	}
+	h.mu.Unlock()
+
+	out := make([]IntegrationLatency, 0, len(totals))
+	for name, c := range totals {
+		if c.Calls == 0 {
+			continue // bytes saved only
+		}
+		out = append(out, IntegrationLatency{
+			Integration:        name,
+			Calls:              c.Calls,
+			Errors:             c.Errors,
+			ErrorRate:          float64(c.Errors) / float64(c.Calls) * 100,
+			LatencyPercentiles: percentilesOf(c.Latency),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].P95Ms != out[j].P95Ms {
+			return out[i].P95Ms > out[j].P95Ms
+		}
+		return out[i].Integration < out[j].Integration
+	})
+	return out
+}
@@ -0,0 +1,136 @@
+package mcp
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_LatencyPercentiles(t *testing.T) {
+	m := NewMetrics()
+	for range 95 {
+		m.RecordExecution("github", "github_list_issues", 8*time.Millisecond, false, 0)
+	}
+	for range 5 {
+		m.RecordExecution("github", "github_list_issues", 4*time.Second, false, 0)
+	}
+
+	snap := m.Snapshot()
+	tool := snap.Tools["github_list_issues"]
+	assert.InDelta(t, 7.5, tool.P50Ms, 2.5, "median in the 5-10ms bucket")
+	assert.LessOrEqual(t, tool.P95Ms, 10.0, "p95 still in the fast bucket")
+	assert.GreaterOrEqual(t, tool.P99Ms, 2500.0, "p99 shows the tail the average hides")
+	assert.LessOrEqual(t, tool.P99Ms, 5000.0)
+	assert.Equal(t, tool.LatencyPercentiles, snap.Integrations["github"].LatencyPercentiles)
+}
+
+func TestLatencyPercentile(t *testing.T) {
+	assert.Equal(t, 0.0, latencyPercentile(nil, 0.5))
+
+	counts := make([]int64, len(latencyBoundsMs)+1)
+	counts[latencyBucket(3*time.Millisecond)] = 90
+	counts[latencyBucket(800*time.Millisecond)] = 10
+	assert.InDelta(t, 3.5, latencyPercentile(counts, 0.5), 1.5, "median in the 2-5ms bucket")
+	assert.GreaterOrEqual(t, latencyPercentile(counts, 0.95), 500.0)
+	assert.LessOrEqual(t, latencyPercentile(counts, 0.95), 1000.0)
+
+	overflow := make([]int64, len(latencyBoundsMs)+1)
+	overflow[latencyBucket(5*time.Minute)] = 1
+	assert.Equal(t, 60000.0, latencyPercentile(overflow, 0.99), "overflow reports the last bound")
+}
+
+func TestMetrics_RecordSlowCall(t *testing.T) {
+	m := NewMetrics()
+	now := time.Now()
+	m.recordSlowCallAt(now, "github", "github_list_issues", nil, 100*time.Millisecond, false, 0)
+	assert.Empty(t, m.SlowCalls(), "calls under the threshold are not logged")
+
+	m.recordSlowCallAt(now, "github", "github_search_code", map[string]any{"query": "x", "token": "ghp_secret"}, 2*time.Second, true, 2)
+	calls := m.SlowCalls()
+	require.Len(t, calls, 1)
+	c := calls[0]
+	assert.Equal(t, IntegrationName("github"), c.Integration)
+	assert.Equal(t, ToolName("github_search_code"), c.Tool)
+	assert.Equal(t, 2000.0, c.DurationMs)
+	assert.Equal(t, 2, c.Retries)
+	assert.True(t, c.Error)
+	assert.Equal(t, map[string]any{"query": "[1-byte string]", "token": RedactedArg}, c.Args)
+	assert.Equal(t, calls, m.Snapshot().SlowCalls)
+
+	m.Reset()
+	assert.Empty(t, m.SlowCalls())
+}
+
+func TestMetrics_SlowCallLogKeepsSlowest(t *testing.T) {
+	m := NewMetrics()
+	now := time.Now()
+	for i := range maxSlowCalls + 10 {
+		m.recordSlowCallAt(now, "slack", ToolName(fmt.Sprintf("slack_tool_%d", i)), nil, SlowCallThreshold+time.Duration(i)*time.Millisecond, false, 0)
+	}
+	calls := m.SlowCalls()
+	require.Len(t, calls, maxSlowCalls)
+	assert.Equal(t, ToolName(fmt.Sprintf("slack_tool_%d", maxSlowCalls+9)), calls[0].Tool, "slowest first")
+	assert.Equal(t, ToolName("slack_tool_10"), calls[len(calls)-1].Tool, "the fastest were displaced")
+
+	m.recordSlowCallAt(now, "slack", "slack_fast", nil, SlowCallThreshold, false, 0)
+	assert.Len(t, m.SlowCalls(), maxSlowCalls)
+	assert.NotEqual(t, ToolName("slack_fast"), m.SlowCalls()[maxSlowCalls-1].Tool, "a faster call does not displace a slower one")
+
+	later := now.Add(SlowCallWindow + time.Minute)
+	m.recordSlowCallAt(later, "slack", "slack_later", nil, SlowCallThreshold, false, 0)
+	m.slowCalls.mu.Lock()
+	defer m.slowCalls.mu.Unlock()
+	require.Len(t, m.slowCalls.calls, 1, "expired entries are dropped")
+	assert.Equal(t, ToolName("slack_later"), m.slowCalls.calls[0].Tool)
+}
+
+func TestRedactArgs(t *testing.T) {
+	assert.Nil(t, RedactArgs(nil))
+
+	got := RedactArgs(map[string]any{
+		"owner":         "daltoniam",
+		"author":        "someone",
+		"api_key":       "sk-123",
+		"Authorization": "Bearer abc",
+		"limit":         float64(10),
+		"draft":         true,
+		"body":          "é",
+		"cursor":        nil,
+		"headers":       map[string]any{"X-Session-Id": "abc", "Accept": "json"},
+		"items":         []any{map[string]any{"password": "p"}, "ok"},
+	})
+	assert.Equal(t, "[9-byte string]", got["owner"], "values are never kept")
+	assert.Equal(t, "[7-byte string]", got["author"], "author is not an auth credential")
+	assert.Equal(t, RedactedArg, got["api_key"])
+	assert.Equal(t, RedactedArg, got["Authorization"])
+	assert.Equal(t, "[number]", got["limit"])
+	assert.Equal(t, "[bool]", got["draft"])
+	assert.Equal(t, "[2-byte string]", got["body"])
+	assert.Nil(t, got["cursor"])
+	assert.Equal(t, map[string]any{"X-Session-Id": RedactedArg, "Accept": "[4-byte string]"}, got["headers"])
+	assert.Equal(t, []any{map[string]any{"password": RedactedArg}, "[2-byte string]"}, got["items"])
+}
+
+func TestMetrics_RecentLatency(t *testing.T) {
+	m := NewMetrics()
+	now := time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC)
+	h := m.history
+	h.recordExecution(now.Add(-30*time.Minute), "slack", "slack_post_message", 20*time.Second, false)
+	h.recordExecution(now.Add(-time.Minute), "github", "github_list_issues", 20*time.Millisecond, false)
+	h.recordExecution(now, "datadog", "datadog_search_logs", 3*time.Second, false)
+	h.recordExecution(now, "datadog", "datadog_search_logs", 3*time.Second, true)
+	h.recordSavings(now, "", 100)
+
+	got := m.recentLatencyAt(now, 15*time.Minute)
+	require.Len(t, got, 2, "slack's call is outside the window")
+	assert.Equal(t, IntegrationName("datadog"), got[0].Integration, "slowest p95 first")
+	assert.Equal(t, int64(2), got[0].Calls)
+	assert.Equal(t, 50.0, got[0].ErrorRate)
+	assert.Greater(t, got[0].P95Ms, 2500.0)
+	assert.Equal(t, IntegrationName("github"), got[1].Integration)
+
+	assert.Len(t, m.recentLatencyAt(now, time.Hour), 3)
+}
@@ -1,6 +1,7 @@
 package mcp
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -87,6 +88,98 @@ func TestMetrics_RecordTruncation(t *testing.T) {
 	assert.Equal(t, int64(2), snap.Truncations)
 }
 
+func TestMetrics_RecordTransforms(t *testing.T) {
+	m := NewMetrics()
+
+	m.RecordTransforms("github_list_issues", 3, 1200)
+	m.RecordTransforms("github_get_issue", 1, 300)
+	m.RecordTransforms("github_get_issue", 0, 0)
+
+	snap := m.Snapshot()
+	assert.Equal(t, int64(4), snap.TransformValues)
+	assert.Equal(t, int64(1500), snap.TransformBytesSaved)
+}
+
+func TestMetrics_RecordFieldAttribution(t *testing.T) {
+	m := NewMetrics()
+
+	m.RecordCompactedCall("github_list_issues")
+	m.RecordFieldAttribution("github_list_issues",
+		map[string]int{"id": 10, "body": 500, "user.login": 40},
+		map[string]int{"id": 10, "body": 120, "user.login": 40})
+	m.RecordCompactedCall("github_list_issues")
+	m.RecordFieldAttribution("github_list_issues",
+		map[string]int{"id": 10, "body": 300},
+		map[string]int{"id": 10, "body": 100})
+
+	snap := m.Snapshot()
+	tool := snap.CompactionTools["github_list_issues"]
+	assert.Equal(t, int64(2), tool.Calls)
+	assert.Equal(t, int64(2), tool.Samples)
+	assert.Equal(t, int64(860), tool.BytesBefore)
+	assert.Equal(t, int64(280), tool.BytesAfter)
+	require.Len(t, tool.Fields, 3)
+	assert.Equal(t, FieldAttributionSnapshot{Path: "body", BytesBefore: 800, BytesKept: 220, BytesDropped: 580}, tool.Fields[0])
+	assert.Equal(t, "user.login", tool.Fields[1].Path, "ties on dropped bytes rank by kept bytes")
+	assert.Equal(t, "id", tool.Fields[2].Path)
+}
+
+func TestMetrics_RecordFieldAttribution_CapsFields(t *testing.T) {
+	m := NewMetrics()
+
+	before := make(map[string]int)
+	for i := range maxAttributedFields + 20 {
+		before[fmt.Sprintf("f%03d", i)] = 1
+	}
+	m.RecordFieldAttribution("tool", before, nil)
+
+	fields := m.Snapshot().CompactionTools["tool"].Fields
+	assert.Len(t, fields, maxAttributedFields+1)
+	var other int64
+	for _, f := range fields {
+		if f.Path == OtherFieldsPath {
+			other = f.BytesBefore
+		}
+	}
+	assert.Equal(t, int64(20), other)
+}
+
+func TestMetrics_RecordCompactedCall_Samples(t *testing.T) {
+	m := NewMetrics()
+
+	var sampled []int
+	for i := range 2*FieldAttributionSampleEvery + 1 {
+		if m.RecordCompactedCall("tool") {
+			sampled = append(sampled, i)
+		}
+	}
+	assert.Equal(t, []int{0, FieldAttributionSampleEvery, 2 * FieldAttributionSampleEvery}, sampled)
+	assert.True(t, m.RecordCompactedCall("other"), "each tool is sampled from its first call")
+	assert.Equal(t, int64(2*FieldAttributionSampleEvery+1), m.Snapshot().CompactionTools["tool"].Calls)
+}
+
+func TestMetrics_RecordOverCompaction(t *testing.T) {
+	m := NewMetrics()
+
+	m.RecordCompactedCall("github_list_issues")
+	m.RecordCompactedCall("github_list_issues")
+	m.RecordFieldAttribution("github_list_issues", map[string]int{"id": 1}, map[string]int{"id": 1})
+	m.RecordOverCompaction("github_list_issues", "github_get_issue", OverCompactionGet)
+	m.RecordOverCompaction("github_list_issues", "github_get_issue", OverCompactionGet)
+	m.RecordOverCompaction("notion_search", "notion_search", OverCompactionView)
+
+	snap := m.Snapshot()
+	require.Len(t, snap.OverCompactions, 2)
+	assert.Equal(t, OverCompactionSnapshot{Tool: "github_list_issues", FollowUp: "github_get_issue", Kind: OverCompactionGet, Count: 2}, snap.OverCompactions[0])
+	assert.Equal(t, 100.0, snap.CompactionTools["github_list_issues"].OverCompactionRate())
+	assert.Equal(t, int64(1), snap.CompactionTools["notion_search"].OverCompactions)
+
+	m.Reset()
+	snap = m.Snapshot()
+	assert.Empty(t, snap.CompactionTools)
+	assert.Empty(t, snap.OverCompactions)
+}
+
 func TestMetrics_TopTools(t *testing.T) {
 	m := NewMetrics()
 
@@ -299,6 +392,7 @@ func TestMetrics_Persistence_RoundTrip(t *testing.T) {
 	m.RecordCatalogAvoidance(120_000)
 	m.RecordScriptSavings(50_000, 500)
 	m.RecordTruncation()
+	m.RecordTransforms("github_list_issues", 2, 700)
 	require.NoError(t, m.Flush())
 
 	// Idempotent: a second Flush with no changes should be a no-op (dirty=false).
@@ -315,6 +409,8 @@ func TestMetrics_Persistence_RoundTrip(t *testing.T) {
 	assert.Equal(t, int64(36_000), snap.MarkdownBytesSaved)
 	assert.Equal(t, int64(120_000), snap.CatalogBytesAvoided)
 	assert.Equal(t, int64(49_500), snap.ScriptIntermediateHidden)
+	assert.Equal(t, int64(2), snap.TransformValues)
+	assert.Equal(t, int64(700), snap.TransformBytesSaved)
 }
 
 func TestMetrics_Persistence_DirtyFlag(t *testing.T) {
@@ -0,0 +1,194 @@
+package mcp
+
+import "math"
+
+// Tokenizer counts the tokens a model family's tokenizer produces for a
+// text. Implementations live in the tokenizer package; Metrics uses one to
+// measure a sample of responses instead of relying on CharsPerToken alone.
+type Tokenizer interface {
+	// Name identifies the vocabulary, e.g. "cl100k_base".
+	Name() string
+	CountTokens(text string) int
+}
+
+// Response pipeline stages whose output is measured with the tokenizer.
+const (
+	TokenStageCompaction = "compaction" // field compaction and columnar reshape
+	TokenStageViews      = "views"      // multi-view projection and rendering
+	TokenStageMarkdown   = "markdown"   // HTML/JSON documents rendered to markdown
+)
+
+const (
+	// TokenSampleEvery is how many processed responses share one
+	// measurement: the first of every TokenSampleEvery is tokenized before
+	// and after processing. BPE counting costs far more than the pipeline
+	// itself, so measuring every call would show up in latency.
+	TokenSampleEvery = 10
+	// maxTokenSampleBytes skips responses too large to tokenize inline.
+	maxTokenSampleBytes = 512 << 10
+)
+
+type tokenizerRef struct{ t Tokenizer }
+
+type tokenStage struct {
+	samples                   int64
+	bytesBefore, bytesAfter   int64
+	tokensBefore, tokensAfter int64
+}
+
+// SetTokenizer selects the tokenizer used to measure sampled responses.
+// nil turns measuring off. Changing tokenizers drops the samples taken with
+// the previous one, so figures never mix vocabularies.
+func (m *Metrics) SetTokenizer(t Tokenizer) {
+	var ref *tokenizerRef
+	if t != nil {
+		ref = &tokenizerRef{t: t}
+	}
+	m.tokenizer.Store(ref)
+	m.mu.Lock()
+	m.tokenStages = make(map[string]*tokenStage)
+	m.mu.Unlock()
+}
+
+// MeasureTokens tokenizes one response before and after a pipeline stage
+// when a tokenizer is set and the response falls in the sample. Safe to
+// call on every response; unsampled calls cost an atomic increment.
+// Sampled responses are counted by a background worker, never in the
+// caller, and are dropped while tokenQueueSize samples are already queued.
+func (m *Metrics) MeasureTokens(stage, before, after string) {
+	ref := m.tokenizer.Load()
+	if ref == nil || len(before) > maxTokenSampleBytes {
+		return
+	}
+	if (m.tokenSeq.Add(1)-1)%TokenSampleEvery != 0 {
+		return
+	}
+	m.tokenWorker.Do(m.startTokenWorker)
+	m.tokenPending.Add(1)
+	select {
+	case m.tokenJobs <- tokenJob{ref: ref, stage: stage, before: before, after: after}:
+	default:
+		m.tokenPending.Done()
+	}
+}
+
+// FlushTokenSamples waits until every queued sample has been recorded.
+func (m *Metrics) FlushTokenSamples() {
+	m.tokenPending.Wait()
+}
+
+// tokenQueueSize bounds the samples waiting for the worker. BPE counting a
+// sample can take tens of milliseconds, so a burst of sampled responses
+// queues a few and drops the rest rather than pinning a CPU.
+const tokenQueueSize = 4
+
+type tokenJob struct {
+	ref                  *tokenizerRef
+	stage, before, after string
+}
+
+func (m *Metrics) startTokenWorker() {
+	m.tokenJobs = make(chan tokenJob, tokenQueueSize)
+	go func() {
+		for job := range m.tokenJobs {
+			tb, ta := job.ref.t.CountTokens(job.before), job.ref.t.CountTokens(job.after)
+			// A sample taken before SetTokenizer changed vocabularies would
+			// mix them in the fresh totals.
+			if m.tokenizer.Load() == job.ref {
+				m.RecordTokenSample(job.stage, len(job.before), len(job.after), tb, ta)
+			}
+			m.tokenPending.Done()
+		}
+	}()
+}
+
+// RecordTokenSample records one measured response: its size in bytes and
+// tokens before and after stage.
+func (m *Metrics) RecordTokenSample(stage string, bytesBefore, bytesAfter, tokensBefore, tokensAfter int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ts, ok := m.tokenStages[stage]
+	if !ok {
+		ts = &tokenStage{}
+		m.tokenStages[stage] = ts
+	}
+	ts.samples++
+	ts.bytesBefore += int64(bytesBefore)
+	ts.bytesAfter += int64(bytesAfter)
+	ts.tokensBefore += int64(tokensBefore)
+	ts.tokensAfter += int64(tokensAfter)
+}
+
+// TokenMeasurementSnapshot compares measured token counts on the sampled
+// responses with the CharsPerToken estimate for the same bytes.
+type TokenMeasurementSnapshot struct {
+	Tokenizer string `json:"tokenizer"`
+	TokenStageSnapshot
+	// CharsPerToken is the measured bytes per token across all samples,
+	// before and after processing; compare with the CharsPerToken constant.
+	CharsPerToken float64                       `json:"chars_per_token"`
+	Stages        map[string]TokenStageSnapshot `json:"stages"`
+}
+
+// TokenStageSnapshot totals the sampled responses of one stage.
+type TokenStageSnapshot struct {
+	Samples               int64 `json:"samples"`
+	BytesBefore           int64 `json:"bytes_before"`
+	BytesAfter            int64 `json:"bytes_after"`
+	TokensBefore          int64 `json:"tokens_before"`
+	TokensAfter           int64 `json:"tokens_after"`
+	EstimatedTokensBefore int64 `json:"estimated_tokens_before"`
+	EstimatedTokensAfter  int64 `json:"estimated_tokens_after"`
+}
+
+func (ts *tokenStage) snapshot() TokenStageSnapshot {
+	return TokenStageSnapshot{
+		Samples:               ts.samples,
+		BytesBefore:           ts.bytesBefore,
+		BytesAfter:            ts.bytesAfter,
+		TokensBefore:          ts.tokensBefore,
+		TokensAfter:           ts.tokensAfter,
+		EstimatedTokensBefore: BytesToTokens(ts.bytesBefore),
+		EstimatedTokensAfter:  BytesToTokens(ts.bytesAfter),
+	}
+}
+
+// tokenMeasurement snapshots the sampled measurements. Callers hold mu.
+func (m *Metrics) tokenMeasurement() *TokenMeasurementSnapshot {
+	ref := m.tokenizer.Load()
+	if ref == nil {
+		return nil
+	}
+	var total tokenStage
+	s := &TokenMeasurementSnapshot{Tokenizer: ref.t.Name(), Stages: make(map[string]TokenStageSnapshot, len(m.tokenStages))}
+	for name, ts := range m.tokenStages {
+		s.Stages[name] = ts.snapshot()
+		total.samples += ts.samples
+		total.bytesBefore += ts.bytesBefore
+		total.bytesAfter += ts.bytesAfter
+		total.tokensBefore += ts.tokensBefore
+		total.tokensAfter += ts.tokensAfter
+	}
+	s.TokenStageSnapshot = total.snapshot()
+	if tokens := total.tokensBefore + total.tokensAfter; tokens > 0 {
+		s.CharsPerToken = math.Round(float64(total.bytesBefore+total.bytesAfter)/float64(tokens)*100) / 100
+	}
+	return s
+}
+
+// measuredTokensSaved converts bytesSaved to tokens at the bytes-per-saved-
+// token ratio measured on stages' samples. ok is false when the stages have
+// no samples with a measurable saving, leaving the caller on the estimate.
+func (m *Metrics) measuredTokensSaved(bytesSaved int64, stages ...string) (tokens int64, ok bool) {
+	var bytes, saved int64
+	for _, name := range stages {
+		if ts, found := m.tokenStages[name]; found {
+			bytes += ts.bytesBefore - ts.bytesAfter
+			saved += ts.tokensBefore - ts.tokensAfter
+		}
+	}
+	if bytes <= 0 || saved <= 0 {
+		return 0, false
+	}
+	return int64(math.Round(float64(bytesSaved) * float64(saved) / float64(bytes))), true
+}
@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wordTokenizer counts whitespace-separated words, so expected counts are
+// easy to read off the inputs.
+type wordTokenizer struct{}
+
+func (wordTokenizer) Name() string                { return "words" }
+func (wordTokenizer) CountTokens(text string) int { return len(strings.Fields(text)) }
+
+func TestMetrics_MeasureTokens_Samples(t *testing.T) {
+	m := NewMetrics()
+	m.MeasureTokens(TokenStageCompaction, "a b c d", "a b")
+	assert.Nil(t, m.Snapshot().TokenMeasurement, "no tokenizer, no measurement")
+
+	m.SetTokenizer(wordTokenizer{})
+	for range TokenSampleEvery + 1 {
+		m.MeasureTokens(TokenStageCompaction, "aaaa bbbb cccc dddd", "aaaa bbbb")
+	}
+	m.FlushTokenSamples()
+
+	tm := m.Snapshot().TokenMeasurement
+	require.NotNil(t, tm)
+	assert.Equal(t, "words", tm.Tokenizer)
+	assert.Equal(t, int64(2), tm.Samples, "the first of every TokenSampleEvery calls is measured")
+	assert.Equal(t, int64(8), tm.TokensBefore)
+	assert.Equal(t, int64(4), tm.TokensAfter)
+	assert.Equal(t, BytesToTokens(2*19), tm.EstimatedTokensBefore)
+	assert.Equal(t, int64(2), tm.Stages[TokenStageCompaction].Samples)
+	assert.Equal(t, 4.67, tm.CharsPerToken) // (38+18) bytes / 12 tokens
+}
+
+// gatedTokenizer blocks CountTokens until release is closed.
+type gatedTokenizer struct{ release chan struct{} }
+
+func (gatedTokenizer) Name() string { return "gated" }
+func (g gatedTokenizer) CountTokens(text string) int {
+	<-g.release
+	return len(text)
+}
+
+func TestMetrics_MeasureTokens_OffTheCallerPath(t *testing.T) {
+	m := NewMetrics()
+	g := gatedTokenizer{release: make(chan struct{})}
+	m.SetTokenizer(g)
+
+	// Every call returns while the worker is stuck; samples past the queue
+	// are dropped instead of waiting.
+	for range (tokenQueueSize + 3) * TokenSampleEvery {
+		m.MeasureTokens(TokenStageViews, "before", "after")
+	}
+	close(g.release)
+	m.FlushTokenSamples()
+
+	tm := m.Snapshot().TokenMeasurement
+	require.NotNil(t, tm)
+	assert.LessOrEqual(t, tm.Samples, int64(tokenQueueSize+1), "one in the worker plus a full queue")
+	assert.Positive(t, tm.Samples)
+}
+
+func TestMetrics_MeasureTokens_DropsSamplesFromReplacedTokenizer(t *testing.T) {
+	m := NewMetrics()
+	g := gatedTokenizer{release: make(chan struct{})}
+	m.SetTokenizer(g)
+	m.MeasureTokens(TokenStageViews, "before", "after")
+
+	m.SetTokenizer(wordTokenizer{})
+	close(g.release)
+	m.FlushTokenSamples()
+	assert.Zero(t, m.Snapshot().TokenMeasurement.Samples, "the gated sample finished after the switch")
+}
+
+func TestMetrics_MeasuredRatioConvertsResponseBuckets(t *testing.T) {
+	m := NewMetrics()
+	m.RecordCompaction("t", 10_000, 2_000)
+	m.RecordMarkdownRender("doc", 4_000, 1_000)
+	m.RecordCatalogAvoidance(800)
+
+	est := m.Snapshot()
+	assert.Equal(t, int64(2_000), est.CompactionTokensSaved)
+
+	m.SetTokenizer(wordTokenizer{})
+	// 400 bytes saved for 200 tokens: 2 bytes per saved token.
+	m.RecordTokenSample(TokenStageCompaction, 500, 100, 250, 50)
+
+	snap := m.Snapshot()
+	assert.Equal(t, int64(4_000), snap.CompactionTokensSaved)
+	assert.Equal(t, int64(750), snap.MarkdownTokensSaved, "no markdown samples: still estimated")
+	assert.Equal(t, int64(200), snap.CatalogTokensSaved)
+	assert.Equal(t, snap.CompactionTokensSaved+snap.MarkdownTokensSaved+snap.CatalogTokensSaved+snap.ScriptTokensSaved, snap.TotalTokensSaved)
+
+	m.SetTokenizer(nil)
+	assert.Equal(t, est.CompactionTokensSaved, m.Snapshot().CompactionTokensSaved, "turning measurement off drops the samples")
+}
+
+func TestMetrics_ResetClearsTokenSamples(t *testing.T) {
+	m := NewMetrics()
+	m.SetTokenizer(wordTokenizer{})
+	m.RecordTokenSample(TokenStageMarkdown, 100, 10, 20, 2)
+	m.Reset()
+
+	tm := m.Snapshot().TokenMeasurement
+	require.NotNil(t, tm, "Reset keeps the selected tokenizer")
+	assert.Zero(t, tm.Samples)
+	assert.Empty(t, tm.Stages)
+}
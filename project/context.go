@@ -2,6 +2,7 @@ package project
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -117,25 +118,68 @@ func assembleManifestFromConfig(ctx *ContextConfig, repoRoot, configDir, project
 	return deduplicate(entries)
 }
 
-// ReadContextFile reads a context file by path, searching the context store first,
-// then the repo. Returns content and an error if not found.
-func ReadContextFile(def *Definition, configDir, path string) (string, error) {
-	contextDir := filepath.Join(configDir, "context", def.Name)
+// ReadContextFile reads a context file from role's manifest, preferring the
+// context store over the repo. Paths outside the manifest are not found, and
+// files larger than the context's maxBytes must be read with ReadContextChunk.
+func ReadContextFile(def *Definition, configDir, role, path string) (string, error) {
+	full, maxBytes, err := lookupContextPath(def, configDir, role, path)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(full)
+	if err != nil {
+		return "", fmt.Errorf("read context file %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
 
-	candidates := []string{
-		filepath.Join(contextDir, path),
+	var r io.Reader = f
+	if maxBytes > 0 {
+		r = io.LimitReader(f, int64(maxBytes)+1)
 	}
-	if repoRoot := def.ResolvedRepo(); repoRoot != "" {
-		candidates = append(candidates, filepath.Join(repoRoot, path))
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("read context file %s: %w", path, err)
 	}
+	if maxBytes > 0 && len(data) > maxBytes {
+		return "", fmt.Errorf("context file %s exceeds %d bytes; read it in parts with offset and length", path, maxBytes)
+	}
+	return string(data), nil
+}
 
-	for _, candidate := range candidates {
-		data, err := os.ReadFile(candidate)
-		if err == nil {
-			return string(data), nil
+// lookupContextPath returns the file backing path if it is in role's
+// manifest, along with the role's maxBytes.
+func lookupContextPath(def *Definition, configDir, role, path string) (string, int, error) {
+	clean := filepath.Clean(path)
+	for _, e := range AssembleManifestWithRole(def, configDir, role) {
+		if filepath.Clean(e.Path) != clean {
+			continue
+		}
+		full, err := contextEntryPath(def, configDir, e)
+		if err != nil {
+			return "", 0, err
+		}
+		maxBytes := 0
+		if ctx := effectiveContext(def, role); ctx != nil {
+			maxBytes = ctx.MaxBytes
 		}
+		return full, maxBytes, nil
 	}
-	return "", fmt.Errorf("context file not found: %s", path)
+	return "", 0, fmt.Errorf("context file not found: %s", path)
+}
+
+// contextEntryPath returns the file backing a manifest entry, which must lie
+// inside the context store or repository it was listed from.
+func contextEntryPath(def *Definition, configDir string, e ContextEntry) (string, error) {
+	root := filepath.Join(configDir, "context", def.Name)
+	if e.Source == "repo" {
+		root = def.ResolvedRepo()
+	}
+	full := filepath.Join(root, e.Path)
+	rel, err := filepath.Rel(root, full)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("context file %s is outside the %s", e.Path, e.Source)
+	}
+	return full, nil
 }
 
 // AssembleBundle assembles the full context bundle as a concatenated string.
@@ -155,10 +199,15 @@ func AssembleBundle(def *Definition, configDir string, role string) (string, []C
 	var sb strings.Builder
 	var included []ContextEntry
 	for _, entry := range entries {
-		content, err := ReadContextFile(def, configDir, entry.Path)
+		full, err := contextEntryPath(def, configDir, entry)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(full)
 		if err != nil {
 			continue
 		}
+		content := string(data)
 		section := fmt.Sprintf("--- %s (%s) ---\n%s\n\n", entry.Path, entry.Source, content)
 		if maxBytes > 0 && sb.Len()+len(section) > maxBytes {
 			fmt.Fprintf(&sb, "--- TRUNCATED: context exceeded %d bytes ---\n", maxBytes)
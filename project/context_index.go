@@ -0,0 +1,279 @@
+package project
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultContextSearchLimit caps content search results when the caller
+	// does not ask for a specific number.
+	DefaultContextSearchLimit = 10
+	// DefaultContextChunkLines is the number of lines returned by
+	// ReadContextChunk when no length is given.
+	DefaultContextChunkLines = 200
+
+	// maxSnippetLine truncates long lines (minified JSON, generated files)
+	// so a single match cannot flood the response.
+	maxSnippetLine = 240
+)
+
+// ContextMatch is a ranked line-level hit from a content search.
+type ContextMatch struct {
+	Path    string  `json:"path"`
+	Source  string  `json:"source"`
+	Line    int     `json:"line"`
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet"`
+}
+
+// ContextChunk is a line range of a context file.
+type ContextChunk struct {
+	Path       string `json:"path"`
+	StartLine  int    `json:"startLine"`
+	EndLine    int    `json:"endLine"`
+	TotalLines int    `json:"totalLines"`
+	HasMore    bool   `json:"hasMore"`
+	Content    string `json:"content"`
+}
+
+// ContextIndex is a full-text index over a project's context files. It is
+// rebuilt lazily whenever the set of files, or the size or modification time
+// of any of them, differs from what was indexed, so edits under RepoIncludes
+// and the context store are picked up on the next search.
+type ContextIndex struct {
+	mu          sync.Mutex
+	fingerprint string
+	files       []indexedContextFile
+	df          map[string]int
+}
+
+type indexedContextFile struct {
+	entry  ContextEntry
+	lines  []string
+	tokens []map[string]int // per line
+}
+
+// NewContextIndex returns an empty index; the first Search builds it.
+func NewContextIndex() *ContextIndex {
+	return &ContextIndex{}
+}
+
+// Search ranks lines of the role's context files against query. Lines score
+// by the inverse document frequency of the query terms they contain, with a
+// bonus when the whole query appears verbatim. Snippets include one line of
+// surrounding context.
+func (ix *ContextIndex) Search(def *Definition, configDir, role, query string, limit int) []ContextMatch {
+	terms := contextTerms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	if limit <= 0 {
+		limit = DefaultContextSearchLimit
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.refresh(def, configDir, role)
+
+	phrase := strings.ToLower(strings.TrimSpace(query))
+	n := float64(len(ix.files))
+	var matches []ContextMatch
+	for _, f := range ix.files {
+		for i, toks := range f.tokens {
+			score := 0.0
+			for _, t := range terms {
+				if tf := toks[t]; tf > 0 {
+					idf := math.Log(1 + n/float64(ix.df[t]))
+					score += idf * (1 + math.Log(float64(tf)))
+				}
+			}
+			if score == 0 {
+				continue
+			}
+			if len(terms) > 1 && strings.Contains(strings.ToLower(f.lines[i]), phrase) {
+				score *= 2
+			}
+			matches = append(matches, ContextMatch{
+				Path:    f.entry.Path,
+				Source:  f.entry.Source,
+				Line:    i + 1,
+				Score:   math.Round(score*1000) / 1000,
+				Snippet: snippet(f.lines, i),
+			})
+		}
+	}
+
+	slices.SortStableFunc(matches, func(a, b ContextMatch) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.Path, b.Path); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Line, b.Line)
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// refresh rebuilds the index if the context files changed since the last
+// build. Callers hold ix.mu.
+func (ix *ContextIndex) refresh(def *Definition, configDir, role string) {
+	entries := AssembleManifestWithRole(def, configDir, role)
+
+	var fp strings.Builder
+	paths := make([]string, len(entries))
+	for i, e := range entries {
+		full, err := contextEntryPath(def, configDir, e)
+		if err != nil {
+			continue
+		}
+		paths[i] = full
+		info, err := os.Stat(full)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&fp, "%s\x00%d\x00%d\n", full, info.Size(), info.ModTime().UnixNano())
+	}
+	if fp.String() == ix.fingerprint && ix.df != nil {
+		return
+	}
+
+	ix.fingerprint = fp.String()
+	ix.files = ix.files[:0]
+	ix.df = make(map[string]int)
+	for i, e := range entries {
+		if paths[i] == "" {
+			continue
+		}
+		data, err := os.ReadFile(paths[i])
+		if err != nil {
+			continue
+		}
+		f := indexedContextFile{entry: e, lines: strings.Split(string(data), "\n")}
+		seen := make(map[string]bool)
+		f.tokens = make([]map[string]int, len(f.lines))
+		for j, line := range f.lines {
+			toks := make(map[string]int)
+			for _, t := range contextTerms(line) {
+				toks[t]++
+				if !seen[t] {
+					seen[t] = true
+					ix.df[t]++
+				}
+			}
+			f.tokens[j] = toks
+		}
+		ix.files = append(ix.files, f)
+	}
+}
+
+// contextTerms lowercases s and splits it into terms of letters and digits
+// in any script.
+func contextTerms(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func snippet(lines []string, i int) string {
+	lo, hi := max(i-1, 0), min(i+2, len(lines))
+	out := make([]string, 0, hi-lo)
+	for _, l := range lines[lo:hi] {
+		if len(l) > maxSnippetLine {
+			// Back off to a rune boundary so a cut never splits a character.
+			end := maxSnippetLine
+			for end > 0 && !utf8.RuneStart(l[end]) {
+				end--
+			}
+			l = l[:end] + "…"
+		}
+		out = append(out, l)
+	}
+	return strings.Join(out, "\n")
+}
+
+// ReadContextChunk returns up to length lines of a context file from role's
+// manifest starting at the 1-based line offset, so large files can be paged
+// through using the line numbers reported by Search. An offset below 1 starts
+// at the top. The file is streamed, and a chunk stops early rather than grow
+// past the context's maxBytes.
+func ReadContextChunk(def *Definition, configDir, role, path string, offset, length int) (*ContextChunk, error) {
+	full, maxBytes, err := lookupContextPath(def, configDir, role, path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(full)
+	if err != nil {
+		return nil, fmt.Errorf("read context file %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if length <= 0 {
+		length = DefaultContextChunkLines
+	}
+	offset = max(offset, 1)
+
+	var content strings.Builder
+	end, total := offset-1, 0
+	capped := false
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("read context file %s: %w", path, err)
+		}
+		total++
+		if total >= offset && total < offset+length && !capped {
+			line = strings.TrimSuffix(line, "\n")
+			size := len(line)
+			if total > offset {
+				size++ // separator
+			}
+			switch {
+			case maxBytes <= 0 || content.Len()+size <= maxBytes:
+				if total > offset {
+					content.WriteByte('\n')
+				}
+				content.WriteString(line)
+				end = total
+			case total == offset:
+				// A single line over the limit is cut on a rune boundary.
+				cut := maxBytes
+				for cut > 0 && !utf8.RuneStart(line[cut]) {
+					cut--
+				}
+				content.WriteString(line[:cut])
+				end, capped = total, true
+			default:
+				capped = true
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	if offset > total {
+		return nil, fmt.Errorf("offset %d is past the end of %s (%d lines)", offset, path, total)
+	}
+	return &ContextChunk{
+		Path:       path,
+		StartLine:  offset,
+		EndLine:    end,
+		TotalLines: total,
+		HasMore:    end < total,
+		Content:    content.String(),
+	}, nil
+}
@@ -0,0 +1,165 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func indexTestDef(t *testing.T) (*Definition, string, string) {
+	t.Helper()
+	configDir, repoDir := setupContextTestDirs(t)
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "docs", "arch.md"), []byte(
+		"# Architecture\n\nRequests go through the gateway.\nThe gateway retries failed upstream calls.\n\nStorage is sqlite.\n",
+	), 0600))
+	def := &Definition{
+		Version: "1",
+		Name:    "test-project",
+		Repo:    repoDir,
+		Context: &ContextConfig{
+			Files:        []string{"sprint.md"},
+			RepoIncludes: []string{"AGENTS.md", "docs/*.md"},
+		},
+	}
+	return def, configDir, repoDir
+}
+
+func TestContextIndex_Search(t *testing.T) {
+	def, configDir, _ := indexTestDef(t)
+	ix := NewContextIndex()
+
+	matches := ix.Search(def, configDir, "", "gateway retries", 0)
+	require.Len(t, matches, 2)
+	assert.Equal(t, "docs/arch.md", matches[0].Path)
+	assert.Equal(t, 4, matches[0].Line, "the line with both terms ranks first")
+	assert.Greater(t, matches[0].Score, matches[1].Score)
+	assert.Contains(t, matches[0].Snippet, "Requests go through the gateway.", "snippet includes the previous line")
+
+	assert.Empty(t, ix.Search(def, configDir, "", "kubernetes", 0))
+	assert.Empty(t, ix.Search(def, configDir, "", "  ", 0))
+	assert.Len(t, ix.Search(def, configDir, "", "gateway", 1), 1)
+}
+
+func TestContextIndex_NonASCII(t *testing.T) {
+	def, configDir, repoDir := indexTestDef(t)
+	long := strings.Repeat("x", maxSnippetLine-1) + "é" + strings.Repeat("y", 10)
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "AGENTS.md"), []byte(
+		"Die Größe der Warteschlange ist begrenzt.\n配置 文件\n"+long+" überlauf\n",
+	), 0600))
+	ix := NewContextIndex()
+
+	matches := ix.Search(def, configDir, "", "GRÖßE", 0)
+	require.Len(t, matches, 1, "terms keep their non-ASCII letters")
+	assert.Equal(t, 1, matches[0].Line)
+	assert.Len(t, ix.Search(def, configDir, "", "配置", 0), 1)
+
+	matches = ix.Search(def, configDir, "", "überlauf", 0)
+	require.Len(t, matches, 1)
+	assert.True(t, utf8.ValidString(matches[0].Snippet), "a long line is cut on a rune boundary")
+	assert.Contains(t, matches[0].Snippet, strings.Repeat("x", maxSnippetLine-1)+"…")
+}
+
+func TestContextIndex_RebuildsOnChange(t *testing.T) {
+	def, configDir, repoDir := indexTestDef(t)
+	ix := NewContextIndex()
+
+	assert.Empty(t, ix.Search(def, configDir, "", "deploy", 0))
+
+	path := filepath.Join(repoDir, "AGENTS.md")
+	require.NoError(t, os.WriteFile(path, []byte("Agent instructions\nDeploy with make release.\n"), 0600))
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, future, future))
+
+	matches := ix.Search(def, configDir, "", "deploy", 0)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "AGENTS.md", matches[0].Path)
+	assert.Equal(t, 2, matches[0].Line)
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "docs", "runbook.md"), []byte("Deploy on Tuesdays.\n"), 0600))
+	assert.Len(t, ix.Search(def, configDir, "", "deploy", 0), 2, "new files matching a glob are indexed")
+}
+
+func TestContextIndex_RoleScoped(t *testing.T) {
+	def, configDir, _ := indexTestDef(t)
+	def.Agents = &AgentsConfig{Roles: map[string]*RoleDefinition{
+		"planner": {ContextOverrides: &ContextConfig{Files: []string{"sprint.md"}, RepoIncludes: []string{"AGENTS.md"}}},
+	}}
+	ix := NewContextIndex()
+
+	assert.NotEmpty(t, ix.Search(def, configDir, "", "gateway", 0))
+	assert.Empty(t, ix.Search(def, configDir, "planner", "gateway", 0))
+}
+
+func TestReadContextChunk(t *testing.T) {
+	def, configDir, repoDir := indexTestDef(t)
+	var lines []string
+	for i := 1; i <= 450; i++ {
+		lines = append(lines, "line "+strings.Repeat("x", i%7))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "docs", "big.md"), []byte(strings.Join(lines, "\n")), 0600))
+
+	chunk, err := ReadContextChunk(def, configDir, "", "docs/big.md", 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, chunk.StartLine)
+	assert.Equal(t, DefaultContextChunkLines, chunk.EndLine)
+	assert.Equal(t, 450, chunk.TotalLines)
+	assert.True(t, chunk.HasMore)
+
+	chunk, err = ReadContextChunk(def, configDir, "", "docs/big.md", 401, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 450, chunk.EndLine)
+	assert.False(t, chunk.HasMore)
+	assert.Equal(t, strings.Join(lines[400:], "\n"), chunk.Content)
+
+	_, err = ReadContextChunk(def, configDir, "", "docs/big.md", 451, 10)
+	assert.ErrorContains(t, err, "past the end")
+
+	_, err = ReadContextChunk(def, configDir, "", "missing.md", 1, 10)
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestReadContextChunk_ManifestOnly(t *testing.T) {
+	def, configDir, repoDir := indexTestDef(t)
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "secret.env"), []byte("TOKEN=abc\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(repoDir), "outside.md"), []byte("outside\n"), 0600))
+	def.Agents = &AgentsConfig{Roles: map[string]*RoleDefinition{
+		"planner": {ContextOverrides: &ContextConfig{Files: []string{"sprint.md"}, RepoIncludes: []string{"AGENTS.md"}}},
+	}}
+
+	for _, path := range []string{"secret.env", "../outside.md", "../../../../etc/passwd", "docs/../../outside.md"} {
+		_, err := ReadContextChunk(def, configDir, "", path, 1, 10)
+		assert.ErrorContains(t, err, "not found", path)
+	}
+	_, err := ReadContextChunk(def, configDir, "planner", "docs/arch.md", 1, 10)
+	assert.ErrorContains(t, err, "not found", "the role's manifest does not list docs/arch.md")
+
+	chunk, err := ReadContextChunk(def, configDir, "", "./docs/arch.md", 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "# Architecture", chunk.Content)
+}
+
+func TestReadContextChunk_MaxBytes(t *testing.T) {
+	def, configDir, repoDir := indexTestDef(t)
+	def.Context.MaxBytes = 25
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "docs", "big.md"), []byte(
+		"0123456789\n0123456789\n0123456789\n"+strings.Repeat("é", 20)+"\n",
+	), 0600))
+
+	chunk, err := ReadContextChunk(def, configDir, "", "docs/big.md", 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789\n0123456789", chunk.Content)
+	assert.Equal(t, 2, chunk.EndLine)
+	assert.Equal(t, 5, chunk.TotalLines)
+	assert.True(t, chunk.HasMore)
+
+	chunk, err = ReadContextChunk(def, configDir, "", "docs/big.md", 4, 10)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("é", 12), chunk.Content, "a line over the limit is cut on a rune boundary")
+	assert.Equal(t, 4, chunk.EndLine)
+}
@@ -101,23 +101,48 @@ func TestReadContextFile(t *testing.T) {
 		Version: "1",
 		Name:    "test-project",
 		Repo:    repoDir,
+		Context: &ContextConfig{
+			Files:        []string{"sprint.md", "../../../repo/docs/arch.md"},
+			RepoIncludes: []string{"AGENTS.md", "../config/context/test-project/sprint.md"},
+		},
 	}
 
 	t.Run("reads from store", func(t *testing.T) {
-		content, err := ReadContextFile(def, configDir, "sprint.md")
+		content, err := ReadContextFile(def, configDir, "", "sprint.md")
 		require.NoError(t, err)
 		assert.Equal(t, "Sprint goals", content)
 	})
 
 	t.Run("reads from repo", func(t *testing.T) {
-		content, err := ReadContextFile(def, configDir, "AGENTS.md")
+		content, err := ReadContextFile(def, configDir, "", "AGENTS.md")
 		require.NoError(t, err)
 		assert.Equal(t, "Agent instructions", content)
 	})
 
 	t.Run("not found", func(t *testing.T) {
-		_, err := ReadContextFile(def, configDir, "nonexistent.md")
+		_, err := ReadContextFile(def, configDir, "", "nonexistent.md")
+		assert.ErrorContains(t, err, "context file not found")
+	})
+
+	t.Run("not in the manifest", func(t *testing.T) {
+		_, err := ReadContextFile(def, configDir, "", "docs/arch.md")
 		assert.ErrorContains(t, err, "context file not found")
+		_, err = ReadContextFile(def, configDir, "", "../../../../etc/passwd")
+		assert.ErrorContains(t, err, "context file not found")
+	})
+
+	t.Run("manifest entries stay inside their root", func(t *testing.T) {
+		_, err := ReadContextFile(def, configDir, "", "../../../repo/docs/arch.md")
+		assert.ErrorContains(t, err, "outside the store")
+		_, err = ReadContextFile(def, configDir, "", "../config/context/test-project/sprint.md")
+		assert.ErrorContains(t, err, "outside the repo")
+	})
+
+	t.Run("over max bytes", func(t *testing.T) {
+		def.Context.MaxBytes = 5
+		defer func() { def.Context.MaxBytes = 0 }()
+		_, err := ReadContextFile(def, configDir, "", "AGENTS.md")
+		assert.ErrorContains(t, err, "exceeds 5 bytes")
 	})
 }
 
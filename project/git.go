@@ -0,0 +1,143 @@
+package project
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RepoInfo describes a git checkout detected on disk.
+type RepoInfo struct {
+	Root      string `json:"root"`
+	Branch    string `json:"branch,omitempty"`
+	RemoteURL string `json:"remoteUrl,omitempty"`
+	Host      string `json:"host,omitempty"`
+	Owner     string `json:"owner,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+// DetectRepo finds the git checkout containing dir and reads its current
+// branch and remote (origin, or the first remote listed) straight from the
+// .git directory, so it works without a git binary.
+func DetectRepo(dir string) (*RepoInfo, error) {
+	abs, err := filepath.Abs(ExpandHome(dir))
+	if err != nil {
+		return nil, err
+	}
+	root, gitDir, err := findGitDir(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &RepoInfo{Root: root}
+	if head, err := os.ReadFile(filepath.Join(gitDir, "HEAD")); err == nil {
+		// A detached HEAD holds a commit hash and leaves Branch empty.
+		if branch, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: refs/heads/"); ok {
+			info.Branch = branch
+		}
+	}
+
+	// Linked worktrees keep the shared config in the common dir.
+	configDir := gitDir
+	if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		configDir = filepath.Join(gitDir, strings.TrimSpace(string(common)))
+	}
+	if url := readRemoteURL(filepath.Join(configDir, "config")); url != "" {
+		info.RemoteURL = url
+		info.Host, info.Owner, info.Name = ParseRemoteURL(url)
+	}
+	return info, nil
+}
+
+// findGitDir walks up from dir to the checkout root, following the
+// "gitdir:" pointer file used by worktrees and submodules.
+func findGitDir(dir string) (root, gitDir string, err error) {
+	for d := dir; ; {
+		candidate := filepath.Join(d, ".git")
+		if fi, err := os.Stat(candidate); err == nil {
+			if fi.IsDir() {
+				return d, candidate, nil
+			}
+			data, err := os.ReadFile(candidate)
+			if err != nil {
+				return "", "", err
+			}
+			ptr := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(data)), "gitdir:"))
+			if !filepath.IsAbs(ptr) {
+				ptr = filepath.Join(d, ptr)
+			}
+			return d, ptr, nil
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return "", "", fmt.Errorf("%s is not inside a git repository", dir)
+		}
+		d = parent
+	}
+}
+
+// readRemoteURL returns the url of remote "origin" from a git config file,
+// falling back to the first remote that has one.
+func readRemoteURL(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close() //nolint:errcheck
+
+	var section, first string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "[") {
+			section = line
+			continue
+		}
+		if !strings.HasPrefix(section, `[remote "`) {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(key) != "url" {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if section == `[remote "origin"]` {
+			return value
+		}
+		if first == "" {
+			first = value
+		}
+	}
+	return first
+}
+
+// ParseRemoteURL splits a git remote URL into host, owner and repository
+// name. It understands scp-style (git@host:owner/repo.git) and URL-style
+// (https://host/owner/repo, ssh://git@host/owner/repo.git) remotes; parts it
+// cannot find are returned empty.
+func ParseRemoteURL(url string) (host, owner, name string) {
+	rest := url
+	if i := strings.Index(rest, "://"); i >= 0 {
+		rest = rest[i+3:]
+	} else if at, colon := strings.Index(rest, "@"), strings.Index(rest, ":"); colon > 0 && (at < 0 || at < colon) {
+		rest = rest[:colon] + "/" + rest[colon+1:]
+	} else {
+		return "", "", ""
+	}
+	if i := strings.Index(rest, "@"); i >= 0 && i < strings.Index(rest+"/", "/") {
+		rest = rest[i+1:]
+	}
+
+	parts := strings.Split(strings.Trim(rest, "/"), "/")
+	host = parts[0]
+	if i := strings.Index(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+	if len(parts) >= 3 {
+		owner = parts[len(parts)-2]
+		name = strings.TrimSuffix(parts[len(parts)-1], ".git")
+	}
+	return host, owner, name
+}
@@ -0,0 +1,93 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeGitDir(t *testing.T, root, head, config string) {
+	t.Helper()
+	gitDir := filepath.Join(root, ".git")
+	require.NoError(t, os.MkdirAll(gitDir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte(head), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(gitDir, "config"), []byte(config), 0600))
+}
+
+func TestDetectRepo(t *testing.T) {
+	root := t.TempDir()
+	writeGitDir(t, root, "ref: refs/heads/feature/login\n", `[core]
+	bare = false
+[remote "upstream"]
+	url = https://github.com/upstream/svc.git
+[remote "origin"]
+	url = git@github.com:acme/billing-api.git
+	fetch = +refs/heads/*:refs/remotes/origin/*
+`)
+	sub := filepath.Join(root, "cmd", "api")
+	require.NoError(t, os.MkdirAll(sub, 0700))
+
+	info, err := DetectRepo(sub)
+	require.NoError(t, err)
+	assert.Equal(t, root, info.Root)
+	assert.Equal(t, "feature/login", info.Branch)
+	assert.Equal(t, "git@github.com:acme/billing-api.git", info.RemoteURL)
+	assert.Equal(t, "github.com", info.Host)
+	assert.Equal(t, "acme", info.Owner)
+	assert.Equal(t, "billing-api", info.Name)
+}
+
+func TestDetectRepo_DetachedWithoutOrigin(t *testing.T) {
+	root := t.TempDir()
+	writeGitDir(t, root, "0123456789abcdef0123456789abcdef01234567\n", `[remote "fork"]
+	url = https://gitlab.com/me/svc
+`)
+
+	info, err := DetectRepo(root)
+	require.NoError(t, err)
+	assert.Empty(t, info.Branch)
+	assert.Equal(t, "gitlab.com", info.Host)
+	assert.Equal(t, "me", info.Owner)
+}
+
+func TestDetectRepo_Worktree(t *testing.T) {
+	main := t.TempDir()
+	writeGitDir(t, main, "ref: refs/heads/main\n", "[remote \"origin\"]\n\turl = https://github.com/acme/app\n")
+	wtGit := filepath.Join(main, ".git", "worktrees", "wt")
+	require.NoError(t, os.MkdirAll(wtGit, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(wtGit, "HEAD"), []byte("ref: refs/heads/fix\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(wtGit, "commondir"), []byte("../..\n"), 0600))
+
+	wt := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(wt, ".git"), []byte("gitdir: "+wtGit+"\n"), 0600))
+
+	info, err := DetectRepo(wt)
+	require.NoError(t, err)
+	assert.Equal(t, wt, info.Root)
+	assert.Equal(t, "fix", info.Branch)
+	assert.Equal(t, "app", info.Name)
+}
+
+func TestDetectRepo_NotARepo(t *testing.T) {
+	_, err := DetectRepo(t.TempDir())
+	assert.ErrorContains(t, err, "not inside a git repository")
+}
+
+func TestParseRemoteURL(t *testing.T) {
+	cases := []struct {
+		url, host, owner, name string
+	}{
+		{"git@github.com:acme/api.git", "github.com", "acme", "api"},
+		{"https://github.com/acme/api", "github.com", "acme", "api"},
+		{"https://token@github.com/acme/api.git", "github.com", "acme", "api"},
+		{"ssh://git@github.example.com:2222/org/team/api.git", "github.example.com", "team", "api"},
+		{"/srv/git/api.git", "", "", ""},
+	}
+	for _, tc := range cases {
+		host, owner, name := ParseRemoteURL(tc.url)
+		assert.Equal(t, []string{tc.host, tc.owner, tc.name}, []string{host, owner, name}, tc.url)
+	}
+}
@@ -0,0 +1,195 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultServerID is the key under Definition.Tools that scopes switchboard's
+// own tools.
+const DefaultServerID = "switchboard"
+
+// Template is a starting point for a new project definition.
+type Template struct {
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+
+	// Integrations the template scopes, in the order their allow globs are
+	// proposed.
+	Integrations  []string                `json:"integrations"`
+	Deny          []string                `json:"deny,omitempty"`
+	RepoIncludes  []string                `json:"repoIncludes,omitempty"`
+	Prompt        string                  `json:"prompt,omitempty"`
+	MaxConcurrent int                     `json:"maxConcurrent,omitempty"`
+	Roles         map[string]TemplateRole `json:"roles,omitempty"`
+}
+
+// TemplateRole is an agent role a template sets up. Allow replaces the
+// project allow list for the role and Deny adds to it (see ResolveRoleScope).
+type TemplateRole struct {
+	Description string   `json:"description"`
+	Allow       []string `json:"allow,omitempty"`
+	Deny        []string `json:"deny,omitempty"`
+}
+
+var templates = []Template{
+	{
+		Name:         "backend-service",
+		Title:        "Backend service",
+		Description:  "A service repository: code review, issue tracking, error monitoring and the service's database and cluster.",
+		Integrations: []string{"github", "linear", "jira", "sentry", "datadog", "postgres", "kubernetes"},
+		Deny:         []string{"*_delete_*"},
+		RepoIncludes: []string{"AGENTS.md", "README.md", "docs/*.md"},
+		Prompt:       "Read the project context before changing code. Link pull requests to their tracking issue.",
+		Roles: map[string]TemplateRole{
+			"reviewer": {
+				Description: "Reviews pull requests and triages errors without touching infrastructure.",
+				Deny:        []string{"postgres_*", "kubernetes_*"},
+			},
+		},
+	},
+	{
+		Name:         "incident-response",
+		Title:        "Incident response",
+		Description:  "Investigate and coordinate an incident across monitoring, infrastructure and chat.",
+		Integrations: []string{"sentry", "datadog", "signoz", "kubernetes", "aws", "gcp", "slack", "github", "linear"},
+		Deny:         []string{"*_delete_*"},
+		RepoIncludes: []string{"RUNBOOK.md", "runbooks/*.md", "docs/oncall*.md"},
+		Prompt:       "An incident is in progress. Gather evidence before acting, and post a summary of each finding to the incident channel.",
+		// Several responders plus a scribe, without every agent on the
+		// team piling onto the same incident.
+		MaxConcurrent: 4,
+		Roles: map[string]TemplateRole{
+			"responder": {
+				Description: "Investigates the incident using monitoring and infrastructure tools.",
+			},
+			"scribe": {
+				Description: "Keeps the incident timeline and posts status updates.",
+				Allow:       []string{"slack_*", "linear_*", "github_*"},
+			},
+		},
+	},
+	{
+		Name:         "docs-site",
+		Title:        "Docs site",
+		Description:  "A documentation repository published from git, with drafts in wikis and shared docs.",
+		Integrations: []string{"github", "notion", "confluence", "gdocs", "vercel"},
+		Deny:         []string{"*_delete_*"},
+		RepoIncludes: []string{"README.md", "CONTRIBUTING.md", "docs/*.md", "docs/*/*.md"},
+		Prompt:       "Follow the style guide in the repository. Preview changes before publishing.",
+		Roles: map[string]TemplateRole{
+			"editor": {
+				Description: "Edits and reviews pages without deploying.",
+				Deny:        []string{"vercel_*"},
+			},
+		},
+	},
+}
+
+// Templates returns the built-in project templates.
+func Templates() []Template {
+	return slices.Clone(templates)
+}
+
+// FindTemplate returns the built-in template with the given name.
+func FindTemplate(name string) (Template, bool) {
+	for _, t := range templates {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Template{}, false
+}
+
+// ScaffoldOptions are the inputs to Scaffold.
+type ScaffoldOptions struct {
+	// Name of the project. Defaults to the repository directory name.
+	Name string
+	// Repo is the detected checkout, if any. It fills Repo and Branch,
+	// and owner/repo defaults for github tools.
+	Repo *RepoInfo
+	// Enabled lists the enabled integrations. Only template integrations
+	// in this list get allow globs; when none are enabled, all of the
+	// template's integrations are proposed so the scope stays narrow.
+	Enabled []string
+	// ServerID keys the tool scope. Defaults to DefaultServerID.
+	ServerID string
+}
+
+// Scaffold builds a project definition from a template and validates it.
+func Scaffold(t Template, opts ScaffoldOptions) (*Definition, error) {
+	serverID := opts.ServerID
+	if serverID == "" {
+		serverID = DefaultServerID
+	}
+
+	def := &Definition{Version: "1", Name: opts.Name}
+	if opts.Repo != nil {
+		def.Repo = opts.Repo.Root
+		def.Branch = opts.Repo.Branch
+		if def.Name == "" {
+			def.Name = ProjectName(filepath.Base(opts.Repo.Root))
+		}
+	}
+
+	integrations := make([]string, 0, len(t.Integrations))
+	for _, name := range t.Integrations {
+		if slices.Contains(opts.Enabled, name) {
+			integrations = append(integrations, name)
+		}
+	}
+	if len(integrations) == 0 {
+		integrations = t.Integrations
+	}
+
+	rule := &ScopeRule{Deny: slices.Clone(t.Deny)}
+	for _, name := range integrations {
+		rule.Allow = append(rule.Allow, name+"_*")
+	}
+	if r := opts.Repo; r != nil && r.Host == "github.com" && r.Owner != "" && slices.Contains(integrations, "github") {
+		rule.Defaults = map[string]map[string]any{
+			"github_*": {"owner": r.Owner, "repo": r.Name},
+		}
+	}
+	def.Tools = map[string]*ScopeRule{serverID: rule}
+
+	if def.Repo != "" && len(t.RepoIncludes) > 0 {
+		def.Context = &ContextConfig{RepoIncludes: slices.Clone(t.RepoIncludes)}
+	}
+	if t.Prompt != "" {
+		def.Launch = &LaunchConfig{Prompt: t.Prompt}
+	}
+	if len(t.Roles) > 0 || t.MaxConcurrent > 0 {
+		def.Agents = &AgentsConfig{MaxConcurrent: t.MaxConcurrent}
+		for name, role := range t.Roles {
+			if def.Agents.Roles == nil {
+				def.Agents.Roles = make(map[string]*RoleDefinition)
+			}
+			rd := &RoleDefinition{Description: role.Description}
+			if len(role.Allow) > 0 || len(role.Deny) > 0 {
+				rd.ToolOverrides = map[string]*ScopeRule{
+					serverID: {Allow: slices.Clone(role.Allow), Deny: slices.Clone(role.Deny)},
+				}
+			}
+			def.Agents.Roles[name] = rd
+		}
+	}
+
+	if err := def.Validate(); err != nil {
+		return nil, fmt.Errorf("scaffold %s: %w", t.Name, err)
+	}
+	return def, nil
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// ProjectName turns an arbitrary string, such as a directory name, into a
+// valid project name.
+func ProjectName(s string) string {
+	s = invalidNameChars.ReplaceAllString(s, "-")
+	return strings.TrimLeft(s, ".-_")
+}
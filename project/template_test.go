@@ -0,0 +1,91 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplates_ScaffoldValid(t *testing.T) {
+	require.NotEmpty(t, Templates())
+	for _, tmpl := range Templates() {
+		def, err := Scaffold(tmpl, ScaffoldOptions{Name: "p"})
+		require.NoError(t, err, tmpl.Name)
+		assert.NotEmpty(t, def.Tools[DefaultServerID].Allow, tmpl.Name)
+	}
+}
+
+func TestFindTemplate(t *testing.T) {
+	tmpl, ok := FindTemplate("incident-response")
+	require.True(t, ok)
+	assert.Equal(t, "Incident response", tmpl.Title)
+
+	_, ok = FindTemplate("nope")
+	assert.False(t, ok)
+}
+
+func TestScaffold_FromRepo(t *testing.T) {
+	tmpl, _ := FindTemplate("backend-service")
+	def, err := Scaffold(tmpl, ScaffoldOptions{
+		Repo: &RepoInfo{
+			Root: "/src/billing api", Branch: "main",
+			Host: "github.com", Owner: "acme", Name: "billing-api",
+		},
+		Enabled: []string{"github", "sentry", "slack"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "billing-api", def.Name)
+	assert.Equal(t, "/src/billing api", def.Repo)
+	assert.Equal(t, "main", def.Branch)
+
+	rule := def.Tools[DefaultServerID]
+	assert.Equal(t, []string{"github_*", "sentry_*"}, rule.Allow, "only enabled template integrations")
+	assert.Equal(t, []string{"*_delete_*"}, rule.Deny)
+	assert.Equal(t, map[string]any{"owner": "acme", "repo": "billing-api"}, rule.Defaults["github_*"])
+
+	require.NotNil(t, def.Context)
+	assert.Contains(t, def.Context.RepoIncludes, "AGENTS.md")
+
+	reviewer := def.Agents.Roles["reviewer"]
+	require.NotNil(t, reviewer)
+	assert.Equal(t, []string{"postgres_*", "kubernetes_*"}, reviewer.ToolOverrides[DefaultServerID].Deny)
+	assert.False(t, IsToolPermitted("postgres_query", GetEffectiveRule(def, DefaultServerID, "reviewer")))
+}
+
+func TestScaffold_NoEnabledIntegrations(t *testing.T) {
+	tmpl, _ := FindTemplate("docs-site")
+	def, err := Scaffold(tmpl, ScaffoldOptions{Name: "docs", Enabled: []string{"slack"}})
+	require.NoError(t, err)
+
+	rule := def.Tools[DefaultServerID]
+	assert.Len(t, rule.Allow, len(tmpl.Integrations), "falls back to the template's integrations")
+	assert.Nil(t, rule.Defaults, "no repo, no github defaults")
+	assert.Nil(t, def.Context, "no repo, no repo includes")
+}
+
+func TestScaffold_NonGitHubRemote(t *testing.T) {
+	tmpl, _ := FindTemplate("backend-service")
+	def, err := Scaffold(tmpl, ScaffoldOptions{
+		Repo:    &RepoInfo{Root: "/src/api", Host: "gitlab.com", Owner: "acme", Name: "api"},
+		Enabled: []string{"github"},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, def.Tools[DefaultServerID].Defaults)
+}
+
+func TestScaffold_InvalidName(t *testing.T) {
+	tmpl, _ := FindTemplate("backend-service")
+	_, err := Scaffold(tmpl, ScaffoldOptions{Name: "bad name!"})
+	assert.ErrorContains(t, err, "does not match pattern")
+
+	_, err = Scaffold(tmpl, ScaffoldOptions{})
+	assert.ErrorContains(t, err, "name is required")
+}
+
+func TestProjectName(t *testing.T) {
+	assert.Equal(t, "billing-api", ProjectName("billing api"))
+	assert.Equal(t, "svc", ProjectName(".svc"))
+	assert.Equal(t, "a.b_c-d", ProjectName("a.b_c-d"))
+}
@@ -0,0 +1,318 @@
+package mcp
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Quarantine modes, set globally in QuarantineConfig.Mode and per
+// integration in QuarantineConfig.Integrations.
+const (
+	// QuarantineFlag wraps untrusted content in envelopes and names the
+	// injection patterns found in it, leaving the text as is.
+	QuarantineFlag = "flag"
+	// QuarantineStrip also removes the matched patterns and hidden
+	// characters from the text.
+	QuarantineStrip = "strip"
+	// QuarantineOff leaves an integration's results untouched.
+	QuarantineOff = "off"
+)
+
+// Prompt-injection patterns found in untrusted content.
+const (
+	// InjectionOverride is an attempt to replace the agent's instructions:
+	// "ignore previous instructions", chat-template tokens, fake system
+	// prompts.
+	InjectionOverride = "instruction_override"
+	// InjectionToolCall asks the agent to call a tool, or imitates tool-call
+	// syntax.
+	InjectionToolCall = "tool_call"
+	// InjectionExfilURL is a URL built to carry data out: a markdown image
+	// with a query string, a URL with template placeholders, or an
+	// instruction to send secrets or conversation data to a URL.
+	InjectionExfilURL = "exfiltration_url"
+	// InjectionHiddenUnicode is a run of invisible characters: zero-width
+	// spaces, bidi overrides, Unicode tag characters.
+	InjectionHiddenUnicode = "hidden_unicode"
+	// InjectionDelimiterSpoof is an untrusted_content tag inside untrusted
+	// content, which could close the envelope early. It is always removed.
+	InjectionDelimiterSpoof = "delimiter_spoof"
+)
+
+// UntrustedWholeResult, as a tool's untrusted path, marks its entire
+// result as untrusted.
+const UntrustedWholeResult = "."
+
+// UntrustedTag names the envelope around untrusted content.
+const UntrustedTag = "untrusted_content"
+
+// QuarantineConfig controls the prompt-injection quarantine of tool result
+// fields declared untrusted in compact.yaml.
+type QuarantineConfig struct {
+	Enabled bool `json:"enabled"`
+	// Mode is QuarantineFlag (the default) or QuarantineStrip.
+	Mode string `json:"mode,omitempty"`
+	// Integrations overrides Mode per integration name, including
+	// QuarantineOff to exempt one.
+	Integrations map[string]string `json:"integrations,omitempty"`
+}
+
+// ModeFor returns the quarantine mode for integration: QuarantineOff when
+// cfg is nil or disabled.
+func (cfg *QuarantineConfig) ModeFor(integration IntegrationName) string {
+	if cfg == nil || !cfg.Enabled {
+		return QuarantineOff
+	}
+	if mode, ok := cfg.Integrations[string(integration)]; ok {
+		return mode
+	}
+	if cfg.Mode == "" {
+		return QuarantineFlag
+	}
+	return cfg.Mode
+}
+
+// ValidateQuarantine checks cfg's modes. A nil cfg is valid.
+func ValidateQuarantine(cfg *QuarantineConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.Mode != "" && cfg.Mode != QuarantineFlag && cfg.Mode != QuarantineStrip {
+		return fmt.Errorf("quarantine mode %q: want %q or %q", cfg.Mode, QuarantineFlag, QuarantineStrip)
+	}
+	for name, mode := range cfg.Integrations {
+		if mode != QuarantineFlag && mode != QuarantineStrip && mode != QuarantineOff {
+			return fmt.Errorf("quarantine mode %q for integration %q: want %q, %q or %q", mode, name, QuarantineFlag, QuarantineStrip, QuarantineOff)
+		}
+	}
+	return nil
+}
+
+// ValidateUntrustedPath checks an untrusted-field path: UntrustedWholeResult
+// or dot-separated keys, each optionally suffixed with [] to mark an array
+// ("messages[].text", "[].body", "labels[]").
+func ValidateUntrustedPath(p string) error {
+	if p == UntrustedWholeResult {
+		return nil
+	}
+	if _, err := parseUntrustedPath(p); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parseUntrustedPath returns the keys along p; a top-level array is the
+// empty key. The [] markers are documentation only: arrays are walked
+// wherever they appear.
+func parseUntrustedPath(p string) ([]string, error) {
+	if p == "" {
+		return nil, fmt.Errorf("untrusted path: empty")
+	}
+	parts := strings.Split(p, ".")
+	keys := make([]string, len(parts))
+	for i, part := range parts {
+		key, each := strings.CutSuffix(part, "[]")
+		if strings.ContainsAny(key, "[]") || (key == "" && (i > 0 || !each)) {
+			return nil, fmt.Errorf("untrusted path %q: invalid segment %q", p, part)
+		}
+		keys[i] = key
+	}
+	return keys, nil
+}
+
+// QuarantineReport summarizes one result's quarantine: how many values were
+// wrapped and the injection patterns found in them, by kind.
+type QuarantineReport struct {
+	Fields   int
+	Findings map[string]int
+}
+
+func (r *QuarantineReport) add(kind string, n int) {
+	if n == 0 {
+		return
+	}
+	if r.Findings == nil {
+		r.Findings = map[string]int{}
+	}
+	r.Findings[kind] += n
+}
+
+// QuarantineValue wraps the strings at paths in a decoded JSON value in
+// untrusted-content envelopes, in place, scanning each for injection
+// patterns under mode. A path naming an object or array wraps every string
+// inside it. UntrustedWholeResult paths are skipped; apply QuarantineText
+// to the rendered result for those.
+func QuarantineValue(v any, paths []string, mode string) (any, QuarantineReport) {
+	var report QuarantineReport
+	if mode == QuarantineOff {
+		return v, report
+	}
+	for _, p := range paths {
+		if p == UntrustedWholeResult {
+			continue
+		}
+		keys, err := parseUntrustedPath(p)
+		if err != nil {
+			continue // the loader rejects these
+		}
+		v = quarantineAt(v, keys, mode, &report)
+	}
+	return v, report
+}
+
+func quarantineAt(v any, keys []string, mode string, report *QuarantineReport) any {
+	if len(keys) == 0 {
+		return quarantineLeaves(v, mode, report)
+	}
+	switch t := v.(type) {
+	case []any:
+		// Arrays are walked whether or not the path marks them, so a
+		// path survives an API returning a list where it documents one
+		// object.
+		rest := keys
+		if keys[0] == "" {
+			rest = keys[1:]
+		}
+		for i, e := range t {
+			t[i] = quarantineAt(e, rest, mode, report)
+		}
+		return t
+	case map[string]any:
+		child, ok := t[keys[0]]
+		if !ok || keys[0] == "" {
+			return t
+		}
+		t[keys[0]] = quarantineAt(child, keys[1:], mode, report)
+		return t
+	default:
+		return v
+	}
+}
+
+func quarantineLeaves(v any, mode string, report *QuarantineReport) any {
+	switch t := v.(type) {
+	case string:
+		if t == "" {
+			return t
+		}
+		report.Fields++
+		return quarantineString(t, mode, false, report)
+	case []any:
+		for i, e := range t {
+			t[i] = quarantineLeaves(e, mode, report)
+		}
+		return t
+	case map[string]any:
+		for k, e := range t {
+			t[k] = quarantineLeaves(e, mode, report)
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+// QuarantineLeaves wraps every string in a decoded JSON value, in place.
+// It stands in for UntrustedWholeResult where the result must stay JSON,
+// such as a raw pinned result read back through pin.
+func QuarantineLeaves(v any, mode string) (any, QuarantineReport) {
+	var report QuarantineReport
+	if mode == QuarantineOff {
+		return v, report
+	}
+	return quarantineLeaves(v, mode, &report), report
+}
+
+// QuarantineText wraps an entire rendered result (plain text, markdown)
+// in one untrusted-content envelope, scanning it under mode.
+func QuarantineText(text, mode string) (string, QuarantineReport) {
+	var report QuarantineReport
+	if mode == QuarantineOff || text == "" {
+		return text, report
+	}
+	report.Fields = 1
+	return quarantineString(text, mode, true, &report), report
+}
+
+type injectionPattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+var (
+	delimiterRE = regexp.MustCompile(`(?i)<\s*/?\s*` + UntrustedTag + `\b[^>]*>`)
+
+	// hiddenUnicodeRE leaves out U+200C and U+200D (zero-width
+	// non-joiner and joiner), which emoji sequences and several scripts
+	// need.
+	hiddenUnicodeRE = regexp.MustCompile(`[\x{200B}\x{200E}\x{200F}\x{202A}-\x{202E}\x{2060}-\x{2064}\x{2066}-\x{2069}\x{FEFF}\x{E0000}-\x{E007F}]+`)
+
+	injectionPatterns = []injectionPattern{
+		{InjectionOverride, regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget|override)\b[^.\n]{0,40}?\b(?:previous|prior|above|earlier|preceding|all|any|your|system)\b[^.\n]{0,20}?\b(?:instructions?|prompts?|rules|guidelines|directives)\b`)},
+		{InjectionOverride, regexp.MustCompile(`(?i)\b(?:new|updated|real|actual)\s+(?:system\s+)?instructions?\s*:|<\|im_(?:start|end)\|>|\[/?INST\]|<</?SYS>>|</?\s*system\s*>`)},
+		{InjectionToolCall, regexp.MustCompile(`(?i)\b(?:call|invoke|use|run|execute|trigger)\s+(?:the\s+|this\s+|a\s+)?(?:[\w.-]+\s+)?tool\b|"tool(?:_name)?"\s*:\s*"|</?\s*(?:tool_call|tool_use|function_calls?|invoke)\b[^>]*>`)},
+		{InjectionExfilURL, regexp.MustCompile(`(?i)!\[[^\]]*\]\(\s*https?://[^)\s]*\?[^)\s]*\)|https?://[^\s"'<>]*(?:\{\{?[^}\s]*\}\}?|\$\{?[A-Z_][A-Z0-9_]*\}?|%7B%7B)|\b(?:send|post|upload|forward|exfiltrate|leak|append)\b[^.\n]{0,40}?\b(?:secrets?|tokens?|keys?|passwords?|credentials?|env(?:ironment)?\s+variables?|conversation|chat\s+history)\b[^.\n]{0,40}?https?://\S+`)},
+	}
+)
+
+// quarantineString scans s, strips what mode removes, and wraps it in an
+// envelope. block puts the envelope tags on their own lines.
+func quarantineString(s, mode string, block bool, report *QuarantineReport) string {
+	found := map[string]bool{}
+	s = delimiterRE.ReplaceAllStringFunc(s, func(string) string {
+		report.add(InjectionDelimiterSpoof, 1)
+		found[InjectionDelimiterSpoof] = true
+		return removedPlaceholder(InjectionDelimiterSpoof)
+	})
+
+	// Patterns are matched with hidden characters removed, so a zero-width
+	// space inside "ign\u200bore" does not hide the instruction.
+	visible := s
+	if n := len(hiddenUnicodeRE.FindAllStringIndex(s, -1)); n > 0 {
+		report.add(InjectionHiddenUnicode, n)
+		found[InjectionHiddenUnicode] = true
+		visible = hiddenUnicodeRE.ReplaceAllString(s, "")
+	}
+	if mode == QuarantineStrip {
+		s = visible
+	}
+	for _, p := range injectionPatterns {
+		if mode == QuarantineStrip {
+			s = p.re.ReplaceAllStringFunc(s, func(string) string {
+				report.add(p.kind, 1)
+				found[p.kind] = true
+				return removedPlaceholder(p.kind)
+			})
+			continue
+		}
+		if n := len(p.re.FindAllStringIndex(visible, -1)); n > 0 {
+			report.add(p.kind, n)
+			found[p.kind] = true
+		}
+	}
+
+	open := "<" + UntrustedTag + ">"
+	if len(found) > 0 {
+		kinds := make([]string, 0, len(found))
+		for kind := range found {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		verb := "found"
+		if mode == QuarantineStrip {
+			verb = "removed"
+		}
+		open = fmt.Sprintf(`<%s warning="prompt injection patterns %s: %s">`, UntrustedTag, verb, strings.Join(kinds, ", "))
+	}
+	closeTag := "</" + UntrustedTag + ">"
+	if block {
+		return open + "\n" + s + "\n" + closeTag
+	}
+	return open + s + closeTag
+}
+
+func removedPlaceholder(kind string) string {
+	return "[removed:" + kind + "]"
+}
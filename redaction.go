@@ -0,0 +1,318 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Built-in redaction detectors, named in RedactionConfig.Detectors and in
+// the placeholders that replace their matches.
+const (
+	// RedactSecret matches API keys and tokens by their vendors' known
+	// prefixes (GitHub, Slack, AWS, Stripe, OpenAI, Anthropic, Google,
+	// GitLab), JWTs and PEM private keys.
+	RedactSecret = "secret"
+	// RedactEmail matches email addresses.
+	RedactEmail = "email"
+	// RedactCreditCard matches 13-19 digit card numbers, optionally
+	// grouped by spaces or dashes, that start like a card network's and
+	// pass the Luhn check.
+	RedactCreditCard = "credit_card"
+	// RedactIP matches IPv4 and IPv6 addresses.
+	RedactIP = "ip"
+
+	// RedactNone, alone in RedactionConfig.Detectors, disables the
+	// built-in detectors.
+	RedactNone = "none"
+)
+
+// RedactionDetectors lists the built-in detectors in the order they run.
+var RedactionDetectors = []string{RedactSecret, RedactCreditCard, RedactEmail, RedactIP}
+
+// RedactionConfig controls scrubbing of secrets and personal data from tool
+// results before they reach the LLM.
+type RedactionConfig struct {
+	Enabled bool `json:"enabled"`
+	// Detectors names the built-in detectors to run. Empty runs all of
+	// RedactionDetectors; RedactNone runs only the custom rules.
+	Detectors []string `json:"detectors,omitempty"`
+	// Rules are custom patterns, run before the built-in detectors.
+	Rules []RedactionRule `json:"rules,omitempty"`
+}
+
+// RedactionRule is a custom pattern whose matches are replaced with
+// RedactionPlaceholder(Type). It applies to every tool unless narrowed by
+// Integrations and ToolGlobs, which must both match when set.
+type RedactionRule struct {
+	Type         string   `json:"type"`
+	Pattern      string   `json:"pattern"`
+	Integrations []string `json:"integrations,omitempty"`
+	ToolGlobs    []string `json:"tool_globs,omitempty"`
+}
+
+// RedactionPlaceholder is the text that replaces a redacted match of kind,
+// e.g. "[redacted:email]".
+func RedactionPlaceholder(kind string) string {
+	return "[redacted:" + kind + "]"
+}
+
+var redactionTypeRE = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// ValidateRedaction checks that cfg names known detectors and that its
+// rules have valid types, patterns and tool globs. A nil cfg is valid.
+func ValidateRedaction(cfg *RedactionConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	_, err := compileRedaction(cfg)
+	return err
+}
+
+// Redactor replaces secrets and personal data in tool results with typed
+// placeholders. A nil *Redactor redacts nothing.
+type Redactor struct {
+	rules     []redactionRule
+	detectors []redactionRule
+}
+
+type redactionRule struct {
+	kind         string
+	re           *regexp.Regexp
+	valid        func(string) bool // nil accepts every match
+	integrations []string
+	toolGlobs    []string
+}
+
+func (r redactionRule) appliesTo(integration IntegrationName, tool ToolName) bool {
+	if len(r.integrations) > 0 && !slices.Contains(r.integrations, string(integration)) {
+		return false
+	}
+	if len(r.toolGlobs) == 0 {
+		return true
+	}
+	for _, pattern := range r.toolGlobs {
+		if ok, _ := path.Match(pattern, string(tool)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// NewRedactor compiles cfg. It returns nil, and no error, when cfg is nil
+// or disabled.
+func NewRedactor(cfg *RedactionConfig) (*Redactor, error) {
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+	return compileRedaction(cfg)
+}
+
+func compileRedaction(cfg *RedactionConfig) (*Redactor, error) {
+	r := &Redactor{}
+	for i, rule := range cfg.Rules {
+		if !redactionTypeRE.MatchString(rule.Type) {
+			return nil, fmt.Errorf("redaction rule %d: type %q must be lowercase letters, digits and underscores", i, rule.Type)
+		}
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("redaction rule %d (%s): empty pattern", i, rule.Type)
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("redaction rule %d (%s): %w", i, rule.Type, err)
+		}
+		if err := ValidateToolGlobs(rule.ToolGlobs); err != nil {
+			return nil, fmt.Errorf("redaction rule %d (%s): %w", i, rule.Type, err)
+		}
+		r.rules = append(r.rules, redactionRule{
+			kind:         rule.Type,
+			re:           re,
+			integrations: rule.Integrations,
+			toolGlobs:    rule.ToolGlobs,
+		})
+	}
+	names := cfg.Detectors
+	if len(names) == 0 {
+		names = RedactionDetectors
+	}
+	for _, name := range RedactionDetectors {
+		if slices.Contains(names, name) {
+			r.detectors = append(r.detectors, builtinDetectors[name])
+		}
+	}
+	for _, name := range names {
+		if _, ok := builtinDetectors[name]; !ok && (name != RedactNone || len(names) > 1) {
+			return nil, fmt.Errorf("unknown redaction detector %q (known: %s)", name, strings.Join(RedactionDetectors, ", "))
+		}
+	}
+	return r, nil
+}
+
+var builtinDetectors = map[string]redactionRule{
+	RedactSecret: {
+		kind: RedactSecret,
+		re: regexp.MustCompile(strings.Join([]string{
+			`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+			`\bgh[pousr]_[A-Za-z0-9]{36,}`,
+			`\bgithub_pat_[A-Za-z0-9_]{22,}`,
+			`\bglpat-[A-Za-z0-9_-]{20,}`,
+			`\bxox[abposr]-[A-Za-z0-9-]{10,}`,
+			`\bxapp-[A-Za-z0-9-]{10,}`,
+			`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+			`\b(?:sk|rk|pk)_(?:live|test)_[A-Za-z0-9]{16,}`,
+			`\bwhsec_[A-Za-z0-9]{24,}`,
+			`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}`,
+			`\bAIza[0-9A-Za-z_-]{35}`,
+			`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`,
+		}, "|")),
+	},
+	RedactEmail: {
+		kind: RedactEmail,
+		re:   regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`),
+	},
+	RedactCreditCard: {
+		kind:  RedactCreditCard,
+		re:    regexp.MustCompile(`\b[2-6]\d{3}(?:[ -]?\d){9,15}\b`),
+		valid: validCardNumber,
+	},
+	RedactIP: {
+		kind: RedactIP,
+		re: regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b` +
+			`|[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}`),
+		valid: validIPAddress,
+	},
+}
+
+// validCardNumber reports whether s, with its separators removed, has a
+// card number's length and Luhn checksum. The leading digit is checked by
+// the pattern, which keeps epoch-millisecond timestamps out.
+func validCardNumber(s string) bool {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(s)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := range len(digits) {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// validIPAddress accepts IPv4 addresses and IPv6 addresses with at least
+// three groups, so "::", "d::" (as in "std::fmt") and clock times are kept.
+func validIPAddress(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return false
+	}
+	if addr.Is4() {
+		return true
+	}
+	groups := 0
+	for _, g := range strings.Split(s, ":") {
+		if g != "" {
+			groups++
+		}
+	}
+	return groups >= 3
+}
+
+// Redact replaces every match of the rules that apply to tool, then of the
+// built-in detectors, with typed placeholders, returning the result and
+// the number of redactions by type (nil when there were none).
+//
+// JSON text is redacted value by value, so placeholders never break its
+// escapes; numbers that match (a card number stored as a number) become
+// placeholder strings. Other text is redacted as a whole.
+func (r *Redactor) Redact(integration IntegrationName, tool ToolName, text string) (string, map[string]int) {
+	if r == nil || text == "" {
+		return text, nil
+	}
+	rules := make([]redactionRule, 0, len(r.rules)+len(r.detectors))
+	for _, rule := range r.rules {
+		if rule.appliesTo(integration, tool) {
+			rules = append(rules, rule)
+		}
+	}
+	rules = append(rules, r.detectors...)
+	if len(rules) == 0 {
+		return text, nil
+	}
+
+	counts := map[string]int{}
+	trimmed := strings.TrimLeft(text, " \t\n\r")
+	if trimmed != "" && (trimmed[0] == '{' || trimmed[0] == '[') {
+		dec := json.NewDecoder(strings.NewReader(text))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err == nil && !dec.More() {
+			v = redactJSON(v, rules, counts)
+			if len(counts) == 0 {
+				return text, nil
+			}
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			if err := enc.Encode(v); err == nil {
+				return strings.TrimSuffix(buf.String(), "\n"), counts
+			}
+			// Encoding a decoded value cannot fail; fall through to
+			// redacting the text as a whole if it somehow does.
+			clear(counts)
+		}
+	}
+	out := redactString(text, rules, counts)
+	if len(counts) == 0 {
+		return text, nil
+	}
+	return out, counts
+}
+
+func redactJSON(v any, rules []redactionRule, counts map[string]int) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = redactJSON(e, rules, counts)
+		}
+		return v
+	case []any:
+		for i, e := range v {
+			v[i] = redactJSON(e, rules, counts)
+		}
+		return v
+	case string:
+		return redactString(v, rules, counts)
+	case json.Number:
+		if s := redactString(string(v), rules, counts); s != string(v) {
+			return s
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func redactString(s string, rules []redactionRule, counts map[string]int) string {
+	for _, rule := range rules {
+		s = rule.re.ReplaceAllStringFunc(s, func(m string) string {
+			if rule.valid != nil && !rule.valid(m) {
+				return m
+			}
+			counts[rule.kind]++
+			return RedactionPlaceholder(rule.kind)
+		})
+	}
+	return s
+}
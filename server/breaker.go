@@ -52,3 +52,17 @@ func (b *breaker) recordFailure() {
 	b.failures++
 	b.lastFailure = time.Now()
 }
+
+// state reports "closed", "open" or "half-open" without consuming a probe.
+func (b *breaker) state() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.failures < b.threshold:
+		return "closed"
+	case time.Since(b.lastFailure) >= b.cooldown:
+		return "half-open"
+	default:
+		return "open"
+	}
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"strings"
+	"time"
+
+	mcp "github.com/daltoniam/switchboard"
+	"github.com/daltoniam/switchboard/compact"
+)
+
+// overCompactionWindow bounds how soon after a compacted response a
+// follow-up call still counts as the agent reaching for dropped fields.
+const overCompactionWindow = 2 * time.Minute
+
+// recordOverCompaction checks whether calling tool with args right now looks
+// like a follow-up to the session's previous, compacted call: the same tool
+// re-run with a different view, or a get tool on the same integration right
+// after a list. Either suggests the previous tool's spec dropped something
+// the agent needed. Must run before the call's own breadcrumb is added.
+func (s *Server) recordOverCompaction(sess *Session, tool mcp.ToolName, args map[string]any) {
+	metrics := s.services.Metrics
+	if metrics == nil {
+		return
+	}
+	recent := sess.RecentBreadcrumbs(1, "")
+	if len(recent) == 0 {
+		return
+	}
+	prev := recent[0]
+	if prev.IsError || prev.From != "" || time.Since(prev.Timestamp) > overCompactionWindow {
+		return
+	}
+	prevView := compact.ParseViewArgs(prev.Args)
+	if prev.Tool == tool {
+		view := compact.ParseViewArgs(args)
+		if view.Err() != nil || view.View == "" {
+			return
+		}
+		integration, _, err := s.findTool(tool)
+		if err != nil {
+			return
+		}
+		if resolveViewName(integration, tool, view) != resolveViewName(integration, tool, prevView) && s.isCompacted(prev.Tool, prevView) {
+			metrics.RecordOverCompaction(prev.Tool, tool, mcp.OverCompactionView)
+		}
+		return
+	}
+	if !isGetTool(tool) || isGetTool(prev.Tool) {
+		return
+	}
+	integration, _, err := s.findTool(tool)
+	if err != nil {
+		return
+	}
+	prevIntegration, _, err := s.findTool(prev.Tool)
+	if err != nil || prevIntegration.Name() != integration.Name() {
+		return
+	}
+	if s.isCompacted(prev.Tool, prevView) {
+		metrics.RecordOverCompaction(prev.Tool, tool, mcp.OverCompactionGet)
+	}
+}
+
+// isCompacted reports whether tool's response, under view, went through a
+// compaction spec.
+func (s *Server) isCompacted(tool mcp.ToolName, view compact.ViewArgs) bool {
+	integration, _, err := s.findTool(tool)
+	if err != nil {
+		return false
+	}
+	if tv, ok := integration.(compact.ToolViewsIntegration); ok {
+		if vs, ok := tv.Views(tool); ok {
+			v, ok := vs.Views[resolveViewName(integration, tool, view)]
+			return ok && len(v.Spec) > 0
+		}
+	}
+	if fc, ok := integration.(mcp.FieldCompactionIntegration); ok {
+		_, ok := fc.CompactSpec(tool)
+		return ok
+	}
+	return false
+}
+
+// resolveViewName returns the view a call selected, falling back to the
+// tool's default view when it named none.
+func resolveViewName(integration mcp.Integration, tool mcp.ToolName, view compact.ViewArgs) compact.ViewName {
+	if view.View != "" {
+		return view.View
+	}
+	if tv, ok := integration.(compact.ToolViewsIntegration); ok {
+		if vs, ok := tv.Views(tool); ok {
+			return vs.Default.View
+		}
+	}
+	return ""
+}
+
+// isGetTool reports whether tool fetches a single item by name convention
+// (github_get_issue, linear_issue_get).
+func isGetTool(tool mcp.ToolName) bool {
+	name := string(tool)
+	return strings.Contains(name, "_get_") || strings.HasSuffix(name, "_get")
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mcp "github.com/daltoniam/switchboard"
+	"github.com/daltoniam/switchboard/compact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func overCompactionServer(t *testing.T) (*Server, *mcp.Metrics) {
+	t.Helper()
+	mi := &mockFieldCompactionIntegration{
+		mockIntegration: mockIntegration{
+			name:    "gh",
+			healthy: true,
+			tools: []mcp.ToolDefinition{
+				{Name: "gh_list_issues", Description: "List issues"},
+				{Name: "gh_get_issue", Description: "Get an issue"},
+				{Name: "gh_list_pulls", Description: "List pulls"},
+			},
+			execFn: func(_ context.Context, _ mcp.ToolName, _ map[string]any) (*mcp.ToolResult, error) {
+				return &mcp.ToolResult{Data: `[{"id":1,"title":"t","body":"long body"}]`}, nil
+			},
+		},
+		specs: map[mcp.ToolName][]mcp.CompactField{
+			"gh_list_issues": mustParseCompactSpecs(t, []string{"id", "title"}),
+		},
+	}
+	s := setupTestServerWithIntegration(mi)
+	s.services.Metrics = mcp.NewMetrics()
+	return s, s.services.Metrics
+}
+
+func runExecute(t *testing.T, s *Server, tool string, args map[string]any) {
+	t.Helper()
+	result, err := s.handleExecute(context.Background(), executeRequest(tool, args))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+}
+
+func TestOverCompaction_GetAfterCompactedList(t *testing.T) {
+	s, metrics := overCompactionServer(t)
+
+	runExecute(t, s, "gh_list_issues", nil)
+	runExecute(t, s, "gh_get_issue", map[string]any{"number": 1})
+	// Neither a get after a get nor a get after an uncompacted list counts.
+	runExecute(t, s, "gh_get_issue", map[string]any{"number": 2})
+	runExecute(t, s, "gh_list_pulls", nil)
+	runExecute(t, s, "gh_get_issue", map[string]any{"number": 3})
+
+	snap := metrics.Snapshot()
+	require.Len(t, snap.OverCompactions, 1)
+	assert.Equal(t, mcp.OverCompactionSnapshot{
+		Tool: "gh_list_issues", FollowUp: "gh_get_issue", Kind: mcp.OverCompactionGet, Count: 1,
+	}, snap.OverCompactions[0])
+
+	list := snap.CompactionTools["gh_list_issues"]
+	assert.Equal(t, int64(1), list.Calls)
+	assert.Equal(t, int64(1), list.OverCompactions)
+	require.NotEmpty(t, list.Fields)
+	assert.Equal(t, "body", list.Fields[0].Path, "the dropped field leads the breakdown")
+	assert.Equal(t, list.Fields[0].BytesBefore, list.Fields[0].BytesDropped)
+}
+
+func TestOverCompaction_IgnoresStaleAndErroredCalls(t *testing.T) {
+	s, metrics := overCompactionServer(t)
+
+	runExecute(t, s, "gh_list_issues", nil)
+	sess := s.sessionStore.GetOrCreate(defaultSessionID)
+	sess.mu.Lock()
+	sess.Breadcrumbs[len(sess.Breadcrumbs)-1].Timestamp = time.Now().Add(-overCompactionWindow - time.Second)
+	sess.mu.Unlock()
+	runExecute(t, s, "gh_get_issue", nil)
+
+	runExecute(t, s, "gh_list_issues", nil)
+	sess.AddBreadcrumb("gh_list_issues", nil, "boom", true)
+	runExecute(t, s, "gh_get_issue", nil)
+
+	assert.Empty(t, metrics.Snapshot().OverCompactions)
+}
+
+func TestOverCompaction_RerunWithDifferentView(t *testing.T) {
+	vs := buildTestViewSet(t)
+	toc := vs.Views["toc"]
+	toc.Spec = mustParseCompactSpecs(t, []string{"id"})
+	vs.Views["toc"] = toc
+
+	mvi := &mockViewsIntegration{
+		mockIntegration: &mockIntegration{
+			name:    "vw",
+			healthy: true,
+			tools:   []mcp.ToolDefinition{{Name: "vw_search", Description: "Search"}},
+			execFn: func(_ context.Context, _ mcp.ToolName, _ map[string]any) (*mcp.ToolResult, error) {
+				return &mcp.ToolResult{Data: `{"id":"x","body":"text"}`}, nil
+			},
+		},
+		viewsByTool: map[mcp.ToolName]compact.ViewSet{"vw_search": vs},
+	}
+	s := setupTestServerWithIntegration(mvi)
+	metrics := mcp.NewMetrics()
+	s.services.Metrics = metrics
+
+	runExecute(t, s, "vw_search", nil)
+	// Naming the default view explicitly is not a re-run for more fields.
+	runExecute(t, s, "vw_search", map[string]any{"view": "toc"})
+	runExecute(t, s, "vw_search", map[string]any{"view": "full"})
+	// The full view has no spec, so asking for it again is not flagged.
+	runExecute(t, s, "vw_search", map[string]any{"view": "toc"})
+
+	snap := metrics.Snapshot()
+	require.Len(t, snap.OverCompactions, 1)
+	assert.Equal(t, mcp.OverCompactionView, snap.OverCompactions[0].Kind)
+	assert.Equal(t, mcp.ToolName("vw_search"), snap.OverCompactions[0].Tool)
+}
+
+func TestIsGetTool(t *testing.T) {
+	assert.True(t, isGetTool("github_get_issue"))
+	assert.True(t, isGetTool("linear_issue_get"))
+	assert.False(t, isGetTool("github_list_issues"))
+	assert.False(t, isGetTool("budget_target"))
+}
@@ -13,46 +13,80 @@ import (
 	"time"
 
 	mcp "github.com/daltoniam/switchboard"
-	"github.com/daltoniam/switchboard/compact"
 	"github.com/daltoniam/switchboard/project"
+	"github.com/daltoniam/switchboard/script"
 	"github.com/daltoniam/switchboard/version"
 	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-const defaultServerID = "switchboard"
+const defaultServerID = project.DefaultServerID
+
+// projectRoleHeader selects the agent role for a connection when the URL
+// has no ?role= parameter.
+const projectRoleHeader = "X-Switchboard-Role"
+
+// projectSessionTimeout closes project sessions idle this long, so abandoned
+// connections stop counting against agents.maxConcurrent.
+const projectSessionTimeout = 30 * time.Minute
 
 // ProjectRouter serves project-scoped MCP endpoints at /mcp/{project}.
+// Tool calls run through the main Server's execution core, so project
+// endpoints share its validation, retries, circuit breakers and sessions.
 type ProjectRouter struct {
+	core     *Server
 	services *mcp.Services
 	store    *project.Store
 	serverID string
-	search   SearchIndex
 
-	mu      sync.RWMutex
-	servers map[string]*projectMCPServer
+	mu        sync.RWMutex
+	servers   map[projectServerKey]*projectMCPServer
+	retired   map[string][]*projectMCPServer // by project; see getOrCreate
+	endpoints map[string]*projectEndpoint
+}
+
+// projectServerKey identifies the MCP server for one role of a project. The
+// empty role is the project's base scope.
+type projectServerKey struct {
+	project string
+	role    string
 }
 
 type projectMCPServer struct {
 	mcpSrv *mcpsdk.Server
 	def    *project.Definition
+	role   string
+}
+
+// projectEndpoint is the HTTP side of a project. Its sessions stay bound to
+// the role server chosen when they were initialized.
+type projectEndpoint struct {
+	handler http.Handler
+	// initMu serializes session creation so the maxConcurrent check and the
+	// new session it admits cannot interleave with another.
+	initMu sync.Mutex
 }
 
 // NewProjectRouter creates a router that dispatches /mcp/{project} requests
-// to per-project MCP servers with tool scoping and context delivery.
-func NewProjectRouter(services *mcp.Services, store *project.Store, serverID string, search SearchIndex) *ProjectRouter {
+// to per-project MCP servers with tool scoping and context delivery, executing
+// tools through core.
+func NewProjectRouter(core *Server, store *project.Store, serverID string) *ProjectRouter {
 	if serverID == "" {
 		serverID = defaultServerID
 	}
 	return &ProjectRouter{
-		services: services,
-		store:    store,
-		serverID: serverID,
-		search:   search,
-		servers:  make(map[string]*projectMCPServer),
+		core:      core,
+		services:  core.services,
+		store:     store,
+		serverID:  serverID,
+		servers:   make(map[projectServerKey]*projectMCPServer),
+		retired:   make(map[string][]*projectMCPServer),
+		endpoints: make(map[string]*projectEndpoint),
 	}
 }
 
-// Handler returns an http.Handler for /mcp/{project} routes.
+// Handler returns an http.Handler for /mcp/{project} routes. The agent role
+// comes from the role query parameter or the X-Switchboard-Role header and
+// applies to the session for its lifetime.
 func (pr *ProjectRouter) Handler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		projectName := r.PathValue("project")
@@ -61,65 +95,152 @@ func (pr *ProjectRouter) Handler() http.Handler {
 			return
 		}
 
-		srv, err := pr.getOrCreate(projectName)
+		srv, err := pr.getOrCreate(projectName, requestRole(r))
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
 		}
 
-		handler := mcpsdk.NewStreamableHTTPHandler(
-			func(_ *http.Request) *mcpsdk.Server {
+		ep := pr.endpoint(projectName)
+		if r.Method == http.MethodPost && r.Header.Get("Mcp-Session-Id") == "" {
+			ep.initMu.Lock()
+			defer ep.initMu.Unlock()
+			if limit := maxConcurrent(srv.def); limit > 0 && pr.activeSessions(projectName) >= limit {
+				http.Error(w, fmt.Sprintf("project %q already has %d concurrent sessions (agents.maxConcurrent)", projectName, limit), http.StatusTooManyRequests)
+				return
+			}
+		}
+		ep.handler.ServeHTTP(w, r)
+	})
+}
+
+// requestRole returns the agent role requested by r, if any.
+func requestRole(r *http.Request) string {
+	if role := r.URL.Query().Get("role"); role != "" {
+		return role
+	}
+	return r.Header.Get(projectRoleHeader)
+}
+
+func maxConcurrent(def *project.Definition) int {
+	if def.Agents == nil {
+		return 0
+	}
+	return def.Agents.MaxConcurrent
+}
+
+// endpoint returns the cached HTTP endpoint for projectName.
+func (pr *ProjectRouter) endpoint(projectName string) *projectEndpoint {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	if ep, ok := pr.endpoints[projectName]; ok {
+		return ep
+	}
+	ep := &projectEndpoint{
+		handler: mcpsdk.NewStreamableHTTPHandler(
+			func(r *http.Request) *mcpsdk.Server {
+				srv, err := pr.getOrCreate(projectName, requestRole(r))
+				if err != nil {
+					return nil
+				}
 				return srv.mcpSrv
 			},
 			&mcpsdk.StreamableHTTPOptions{
-				Stateless: true,
-				Logger:    slog.Default(),
+				SessionTimeout: projectSessionTimeout,
+				Logger:         slog.Default(),
 			},
-		)
-		handler.ServeHTTP(w, r)
-	})
+		),
+	}
+	pr.endpoints[projectName] = ep
+	return ep
 }
 
-func (pr *ProjectRouter) getOrCreate(projectName string) (*projectMCPServer, error) {
-	pr.mu.RLock()
-	srv, ok := pr.servers[projectName]
-	pr.mu.RUnlock()
-	if ok {
-		return srv, nil
+// activeSessions counts open sessions across all role servers of a project,
+// including servers replaced by a definition update. Replaced servers are
+// dropped once their last session closes.
+func (pr *ProjectRouter) activeSessions(projectName string) int {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	n := 0
+	for key, srv := range pr.servers {
+		if key.project == projectName {
+			n += countSessions(srv.mcpSrv)
+		}
+	}
+	live := pr.retired[projectName][:0]
+	for _, srv := range pr.retired[projectName] {
+		if c := countSessions(srv.mcpSrv); c > 0 {
+			n += c
+			live = append(live, srv)
+		}
 	}
+	if len(live) == 0 {
+		delete(pr.retired, projectName)
+	} else {
+		pr.retired[projectName] = live
+	}
+	return n
+}
 
+func countSessions(srv *mcpsdk.Server) int {
+	n := 0
+	for range srv.Sessions() {
+		n++
+	}
+	return n
+}
+
+// getOrCreate returns the MCP server for a project role, building it on first
+// use. The store replaces a definition on every update, so a cached server
+// built from an older definition is rebuilt; sessions already open on it
+// keep running and still count toward agents.maxConcurrent until they close.
+func (pr *ProjectRouter) getOrCreate(projectName, role string) (*projectMCPServer, error) {
 	def, exists := pr.store.Get(projectName)
 	if !exists {
 		return nil, fmt.Errorf("project %q not found", projectName)
 	}
 
-	srv = pr.buildServer(def)
+	key := projectServerKey{project: projectName, role: role}
+	pr.mu.RLock()
+	srv, ok := pr.servers[key]
+	pr.mu.RUnlock()
+	if ok && srv.def == def {
+		return srv, nil
+	}
 
-	pr.mu.Lock()
-	pr.servers[projectName] = srv
-	pr.mu.Unlock()
+	if role != "" && (def.Agents == nil || def.Agents.Roles[role] == nil) {
+		return nil, fmt.Errorf("project %q has no role %q", projectName, role)
+	}
 
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	if srv, ok := pr.servers[key]; ok {
+		if srv.def == def {
+			return srv, nil
+		}
+		pr.retired[projectName] = append(pr.retired[projectName], srv)
+	}
+	srv = pr.buildServer(def, role)
+	pr.servers[key] = srv
 	return srv, nil
 }
 
-func (pr *ProjectRouter) buildServer(def *project.Definition) *projectMCPServer {
+func (pr *ProjectRouter) buildServer(def *project.Definition, role string) *projectMCPServer {
 	mcpSrv := mcpsdk.NewServer(
 		&mcpsdk.Implementation{
 			Name:    "switchboard",
 			Version: version.String(),
 		},
 		&mcpsdk.ServerOptions{
-			Instructions: fmt.Sprintf(
-				"Project-scoped MCP server for %q. Use the search tool to discover available operations — do not guess tool names. Use project_context to retrieve project context.",
-				def.Name,
-			),
-			Logger: slog.Default(),
+			Instructions: projectInstructions(def, role),
+			Logger:       slog.Default(),
 		},
 	)
+	mcpSrv.AddReceivingMiddleware(traceMiddleware("/mcp/" + def.Name))
 
-	ps := &projectMCPServer{mcpSrv: mcpSrv, def: def}
+	ps := &projectMCPServer{mcpSrv: mcpSrv, def: def, role: role}
 
-	scopeRule := project.GetEffectiveRule(def, pr.serverID, "")
+	scopeRule := project.GetEffectiveRule(def, pr.serverID, role)
 
 	searchTool := &mcpsdk.Tool{
 		Name: "search",
@@ -157,9 +278,11 @@ Examples:
 
 Mode 1 — Single tool (provide tool_name + arguments):
   {"tool_name": "github_list_issues", "arguments": {"state": "open"}}
+  Session context fills missing arguments before project defaults. Results are auto-pinned ($1, $2, ...).
 
 Mode 2 — Script (provide script):
   Write JavaScript that calls api.call(toolName, args) to invoke tools.
+  Each call inside the script is scoped to this project and gets its default arguments.
 
 Use search first to discover available tools and their parameter schemas.`,
 		InputSchema: objectSchema(map[string]any{
@@ -180,13 +303,34 @@ Use search first to discover available tools and their parameter schemas.`,
 
 	mcpSrv.AddTool(searchTool, pr.makeSearchHandler(scopeRule))
 	mcpSrv.AddTool(executeTool, pr.makeExecuteHandler(def, scopeRule))
+	mcpSrv.AddTool(sessionTool(), pr.withProjectSession(def, pr.core.handleSession))
+	mcpSrv.AddTool(historyTool(), pr.withProjectSession(def, pr.core.handleHistory))
+	mcpSrv.AddTool(pinTool(), pr.withProjectSession(def, pr.core.handlePin))
 
-	pr.addContextTool(mcpSrv, def)
-	pr.addProjectManagementTools(mcpSrv, def)
+	pr.addContextTool(mcpSrv, def, role)
+	pr.addProjectManagementTools(mcpSrv, def, role)
 
 	return ps
 }
 
+// projectInstructions returns the MCP instructions for a project server,
+// naming the connection's role when it has one.
+func projectInstructions(def *project.Definition, role string) string {
+	text := fmt.Sprintf(
+		"Project-scoped MCP server for %q. Use the search tool to discover available operations — do not guess tool names. Use project_context to retrieve project context. "+
+			"Use the session tool to set default arguments once, history to review prior calls, and pin handles ($1, $2, ...) to reference previous results.",
+		def.Name,
+	)
+	if role == "" {
+		return text
+	}
+	text += fmt.Sprintf(" You are connected as the %q agent role", role)
+	if desc := def.Agents.Roles[role].Description; desc != "" {
+		text += ": " + desc
+	}
+	return text + ". Tools and context are scoped to this role."
+}
+
 func (pr *ProjectRouter) makeSearchHandler(scopeRule *project.ScopeRule) mcpsdk.ToolHandler {
 	return func(ctx context.Context, req *mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
 		if pr.services.Metrics != nil {
@@ -213,8 +357,9 @@ func (pr *ProjectRouter) makeSearchHandler(scopeRule *project.ScopeRule) mcpsdk.
 		query := strings.ToLower(args.Query)
 
 		// Filter indexed tools to project-permitted ones.
+		index := pr.core.SearchIndex()
 		var permitted []toolWithIntegration
-		for _, ti := range pr.search.AllTools {
+		for _, ti := range index.AllTools {
 			if project.IsToolPermitted(string(ti.Tool.Name), scopeRule) {
 				permitted = append(permitted, ti)
 			}
@@ -223,7 +368,7 @@ func (pr *ProjectRouter) makeSearchHandler(scopeRule *project.ScopeRule) mcpsdk.
 		// Score if query present, otherwise return all alphabetically.
 		var results []searchToolInfo
 		if query != "" {
-			for _, r := range scoreTools(query, permitted, pr.search.IDF, pr.search.SynMap) {
+			for _, r := range scoreTools(query, permitted, index.IDF, index.SynMap) {
 				results = append(results, toToolInfo(r))
 			}
 		} else {
@@ -284,119 +429,96 @@ func (pr *ProjectRouter) makeSearchHandler(scopeRule *project.ScopeRule) mcpsdk.
 }
 
 func (pr *ProjectRouter) makeExecuteHandler(def *project.Definition, scopeRule *project.ScopeRule) mcpsdk.ToolHandler {
-	return func(ctx context.Context, req *mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
-		var args struct {
-			ToolName  mcp.ToolName   `json:"tool_name"`
-			Arguments map[string]any `json:"arguments"`
-			Script    string         `json:"script"`
-		}
-		if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
-			return errorResult("invalid arguments: " + err.Error()), nil
-		}
-
-		if args.Script != "" {
-			return errorResult("scripts are not supported on project-scoped endpoints"), nil
-		}
-
-		if args.ToolName == "" {
-			return errorResult("tool_name is required"), nil
-		}
-
-		toolStr := string(args.ToolName)
-		if !project.IsToolPermitted(toolStr, scopeRule) {
-			return errorResult(fmt.Sprintf("tool %q is denied by project scoping rules", args.ToolName)), nil
-		}
-
-		if args.Arguments == nil {
-			args.Arguments = map[string]any{}
-		}
-		args.Arguments = project.ResolveDefaults(toolStr, scopeRule, args.Arguments)
-
-		integration, found := pr.findIntegration(toolStr)
-		if !found {
-			return errorResult(fmt.Sprintf("tool %q not found. Use the search tool to discover available tools.", args.ToolName)), nil
-		}
-
-		tool := args.ToolName
-		callStart := time.Now()
-		result, err := integration.Execute(ctx, tool, args.Arguments)
-		callDuration := time.Since(callStart)
-		if err != nil {
-			if pr.services.Metrics != nil {
-				pr.services.Metrics.RecordExecution(mcp.IntegrationName(integration.Name()), tool, callDuration, true, 0)
-			}
-			return errorResult(err.Error()), nil
-		}
-
-		if pr.services.Metrics != nil {
-			pr.services.Metrics.RecordExecution(mcp.IntegrationName(integration.Name()), tool, callDuration, result.IsError, 0)
-		}
+	scope := func(tool mcp.ToolName, args map[string]any) (map[string]any, *mcp.ToolResult) {
+		return scopeArguments(scopeRule, tool, args)
+	}
+	engine := script.New(&toolExecutor{server: pr.core, scope: scope})
+	return pr.withProjectSession(def, func(ctx context.Context, req *mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+		return pr.core.execute(ctx, req, engine, scope)
+	})
+}
 
-		applyResultProcessing(integration, tool, compact.ParseViewArgs(args.Arguments), result, pr.services.Metrics)
-		if !result.IsError {
-			limit := responseLimitFor(integration, tool)
-			if len(result.Data) > limit {
-				if pr.services.Metrics != nil {
-					pr.services.Metrics.RecordTruncation()
-				}
-				return errorResult(fmt.Sprintf(
-					"Response exceeded %dKB (actual: %dKB). Use more specific filters, lower limit/per_page, or fetch individual items.",
-					limit/1024, len(result.Data)/1024,
-				)), nil
-			}
+// scopeArguments checks tool against the project's scope rule and returns
+// args with the rule's defaults applied. A denied tool yields an error result
+// instead. Every project-scoped call, direct or from a script, goes through
+// here.
+func scopeArguments(rule *project.ScopeRule, tool mcp.ToolName, args map[string]any) (map[string]any, *mcp.ToolResult) {
+	if !project.IsToolPermitted(string(tool), rule) {
+		return nil, &mcp.ToolResult{
+			Data:    fmt.Sprintf("tool %q is denied by project scoping rules", tool),
+			IsError: true,
 		}
-
-		return &mcpsdk.CallToolResult{
-			Content: []mcpsdk.Content{&mcpsdk.TextContent{Text: result.Data}},
-			IsError: result.IsError,
-		}, nil
 	}
+	if args == nil {
+		args = map[string]any{}
+	}
+	return project.ResolveDefaults(string(tool), rule, args), nil
 }
 
-func (pr *ProjectRouter) findIntegration(toolName string) (mcp.Integration, bool) {
-	for _, name := range pr.services.Config.EnabledIntegrations() {
-		integration, ok := pr.services.Registry.Get(name)
-		if !ok {
-			continue
-		}
-		for _, tool := range integration.Tools() {
-			if string(tool.Name) == toolName {
-				return integration, true
-			}
-		}
+// projectSessionID namespaces a client session ID by project so session
+// context, history and pins on one project endpoint never leak into another
+// or into /mcp.
+func projectSessionID(projectName, id string) string {
+	return "project/" + projectName + "/" + id
+}
+
+// withProjectSession resolves the caller's session in def's namespace before
+// running h.
+func (pr *ProjectRouter) withProjectSession(def *project.Definition, h mcpsdk.ToolHandler) mcpsdk.ToolHandler {
+	return func(ctx context.Context, req *mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+		sess := pr.core.sessionStore.GetOrCreate(projectSessionID(def.Name, sessionIDFromReq(req.Session)))
+		return h(withSession(ctx, sess), req)
 	}
-	return nil, false
 }
 
-func (pr *ProjectRouter) addContextTool(mcpSrv *mcpsdk.Server, def *project.Definition) {
+func (pr *ProjectRouter) addContextTool(mcpSrv *mcpsdk.Server, def *project.Definition, role string) {
 	contextTool := &mcpsdk.Tool{
-		Name:        "project_context",
-		Description: "Search and retrieve project context files. Call with no arguments to list available context entries, with a query to search, or with a path to fetch a specific file's content.",
+		Name: "project_context",
+		Description: "Search and retrieve project context files. Call with no arguments to list available context entries, with a query to filter them by path, " +
+			"with mode \"content\" and a query to search inside files (ranked matches with line numbers and snippets), or with a path to fetch a file. " +
+			"Use offset and length with a path to page through large files by line.",
 		InputSchema: objectSchema(map[string]any{
 			"query": map[string]any{
 				"type":        "string",
-				"description": "Search query to filter context entries by path. If omitted, returns a manifest of all available context.",
+				"description": "Search query. Filters context entries by path, or searches file contents when mode is \"content\". If omitted, returns a manifest of all available context.",
 			},
-			"path": map[string]any{
+			"mode": map[string]any{
 				"type":        "string",
-				"description": "Exact path of a context file to retrieve its full content.",
+				"enum":        []string{"manifest", "content"},
+				"description": "\"manifest\" (default) matches the query against paths; \"content\" searches file contents.",
+			},
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("Maximum content matches to return (default %d).", project.DefaultContextSearchLimit),
 			},
-			"role": map[string]any{
+			"path": map[string]any{
 				"type":        "string",
-				"description": "Role name to apply context overrides.",
+				"description": "Exact manifest path of a context file to retrieve. Returns the full content unless offset or length is set; files over the project's maxBytes must be read with them.",
+			},
+			"offset": map[string]any{
+				"type":        "integer",
+				"description": "1-based line to start reading from, e.g. a line number from a content search.",
+			},
+			"length": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("Number of lines to read from offset (default %d).", project.DefaultContextChunkLines),
 			},
 		}, nil),
 	}
 
-	mcpSrv.AddTool(contextTool, pr.makeContextHandler(def))
+	mcpSrv.AddTool(contextTool, pr.makeContextHandler(def, role))
 }
 
-func (pr *ProjectRouter) makeContextHandler(def *project.Definition) mcpsdk.ToolHandler {
+func (pr *ProjectRouter) makeContextHandler(def *project.Definition, role string) mcpsdk.ToolHandler {
+	index := project.NewContextIndex()
 	return func(ctx context.Context, req *mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
 		var args struct {
-			Query string `json:"query"`
-			Path  string `json:"path"`
-			Role  string `json:"role"`
+			Query  string `json:"query"`
+			Mode   string `json:"mode"`
+			Limit  int    `json:"limit"`
+			Path   string `json:"path"`
+			Offset int    `json:"offset"`
+			Length int    `json:"length"`
 		}
 		if req.Params.Arguments != nil {
 			if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
@@ -406,8 +528,16 @@ func (pr *ProjectRouter) makeContextHandler(def *project.Definition) mcpsdk.Tool
 
 		configDir := pr.store.ConfigDir()
 
+		if args.Path != "" && (args.Offset > 0 || args.Length > 0) {
+			chunk, err := project.ReadContextChunk(def, configDir, role, args.Path, args.Offset, args.Length)
+			if err != nil {
+				return errorResult(err.Error()), nil
+			}
+			return jsonResult(chunk)
+		}
+
 		if args.Path != "" {
-			content, err := project.ReadContextFile(def, configDir, args.Path)
+			content, err := project.ReadContextFile(def, configDir, role, args.Path)
 			if err != nil {
 				return &mcpsdk.CallToolResult{
 					Content: []mcpsdk.Content{&mcpsdk.TextContent{Text: err.Error()}},
@@ -418,7 +548,25 @@ func (pr *ProjectRouter) makeContextHandler(def *project.Definition) mcpsdk.Tool
 			}, nil
 		}
 
-		entries := project.AssembleManifestWithRole(def, configDir, args.Role)
+		switch args.Mode {
+		case "", "manifest":
+		case "content":
+			if args.Query == "" {
+				return errorResult("query is required for content search"), nil
+			}
+			matches := index.Search(def, configDir, role, args.Query, args.Limit)
+			if matches == nil {
+				matches = []project.ContextMatch{}
+			}
+			return jsonResult(map[string]any{
+				"summary": fmt.Sprintf("Found %d matches for %q", len(matches), args.Query),
+				"matches": matches,
+			})
+		default:
+			return errorResult(fmt.Sprintf("unknown mode %q (want \"manifest\" or \"content\")", args.Mode)), nil
+		}
+
+		entries := project.AssembleManifestWithRole(def, configDir, role)
 
 		if args.Query != "" {
 			q := strings.ToLower(args.Query)
@@ -431,17 +579,22 @@ func (pr *ProjectRouter) makeContextHandler(def *project.Definition) mcpsdk.Tool
 			entries = filtered
 		}
 
-		data, err := json.MarshalIndent(entries, "", "  ")
-		if err != nil {
-			return errorResult("failed to marshal context manifest: " + err.Error()), nil
-		}
-		return &mcpsdk.CallToolResult{
-			Content: []mcpsdk.Content{&mcpsdk.TextContent{Text: string(data)}},
-		}, nil
+		return jsonResult(entries)
 	}
 }
 
-func (pr *ProjectRouter) addProjectManagementTools(mcpSrv *mcpsdk.Server, boundDef *project.Definition) {
+// jsonResult renders v as an indented JSON tool result.
+func jsonResult(v any) (*mcpsdk.CallToolResult, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return errorResult("failed to marshal context response: " + err.Error()), nil
+	}
+	return &mcpsdk.CallToolResult{
+		Content: []mcpsdk.Content{&mcpsdk.TextContent{Text: string(data)}},
+	}, nil
+}
+
+func (pr *ProjectRouter) addProjectManagementTools(mcpSrv *mcpsdk.Server, boundDef *project.Definition, role string) {
 	listTool := &mcpsdk.Tool{
 		Name:        "project_list",
 		Description: "List all project names and summaries.",
@@ -506,22 +659,18 @@ func (pr *ProjectRouter) addProjectManagementTools(mcpSrv *mcpsdk.Server, boundD
 
 	toolsTool := &mcpsdk.Tool{
 		Name:        "project_tools",
-		Description: "Return the resolved tool manifest after allow/deny/role filtering.",
+		Description: "Return the resolved tool manifest after allow/deny filtering for the connection's role.",
 		InputSchema: objectSchema(map[string]any{
 			"name": map[string]any{
 				"type":        "string",
 				"description": "Project name. Optional when served at a project-scoped URL.",
 			},
-			"role": map[string]any{
-				"type":        "string",
-				"description": "Role name to apply tool overrides.",
-			},
 		}, nil),
 	}
 
 	defaultsTool := &mcpsdk.Tool{
 		Name:        "project_defaults",
-		Description: "Return the resolved default arguments for a specific tool.",
+		Description: "Return the resolved default arguments for a specific tool under the connection's role.",
 		InputSchema: objectSchema(map[string]any{
 			"name": map[string]any{
 				"type":        "string",
@@ -539,8 +688,8 @@ func (pr *ProjectRouter) addProjectManagementTools(mcpSrv *mcpsdk.Server, boundD
 	mcpSrv.AddTool(createTool, pr.handleProjectCreate)
 	mcpSrv.AddTool(updateTool, pr.makeProjectUpdateHandler(boundDef))
 	mcpSrv.AddTool(deleteTool, pr.makeProjectDeleteHandler(boundDef))
-	mcpSrv.AddTool(toolsTool, pr.makeProjectToolsHandler(boundDef))
-	mcpSrv.AddTool(defaultsTool, pr.makeProjectDefaultsHandler(boundDef))
+	mcpSrv.AddTool(toolsTool, pr.makeProjectToolsHandler(boundDef, role))
+	mcpSrv.AddTool(defaultsTool, pr.makeProjectDefaultsHandler(boundDef, role))
 }
 
 func (pr *ProjectRouter) handleProjectList(_ context.Context, _ *mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
@@ -647,11 +796,13 @@ func (pr *ProjectRouter) makeProjectDeleteHandler(boundDef *project.Definition)
 	}
 }
 
-func (pr *ProjectRouter) makeProjectToolsHandler(boundDef *project.Definition) mcpsdk.ToolHandler {
+// makeProjectToolsHandler lists the tools the connection's role may call.
+// The role comes from the connection, never from the arguments, so an agent
+// cannot widen its own scope.
+func (pr *ProjectRouter) makeProjectToolsHandler(boundDef *project.Definition, role string) mcpsdk.ToolHandler {
 	return func(_ context.Context, req *mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
 		var args struct {
 			Name string `json:"name"`
-			Role string `json:"role"`
 		}
 		if req.Params.Arguments != nil {
 			_ = json.Unmarshal(req.Params.Arguments, &args)
@@ -665,7 +816,7 @@ func (pr *ProjectRouter) makeProjectToolsHandler(boundDef *project.Definition) m
 			return errorResult(fmt.Sprintf("project %q not found", name)), nil
 		}
 
-		rule := project.GetEffectiveRule(def, pr.serverID, args.Role)
+		rule := project.GetEffectiveRule(def, pr.serverID, role)
 
 		var toolNames []string
 		for _, intName := range pr.services.Config.EnabledIntegrations() {
@@ -686,7 +837,7 @@ func (pr *ProjectRouter) makeProjectToolsHandler(boundDef *project.Definition) m
 	}
 }
 
-func (pr *ProjectRouter) makeProjectDefaultsHandler(boundDef *project.Definition) mcpsdk.ToolHandler {
+func (pr *ProjectRouter) makeProjectDefaultsHandler(boundDef *project.Definition, role string) mcpsdk.ToolHandler {
 	return func(_ context.Context, req *mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
 		var args struct {
 			Name     string `json:"name"`
@@ -704,7 +855,7 @@ func (pr *ProjectRouter) makeProjectDefaultsHandler(boundDef *project.Definition
 			return errorResult(fmt.Sprintf("project %q not found", name)), nil
 		}
 
-		rule := project.GetEffectiveRule(def, pr.serverID, "")
+		rule := project.GetEffectiveRule(def, pr.serverID, role)
 		defaults := project.ResolveDefaults(args.ToolName, rule, nil)
 
 		data, _ := json.MarshalIndent(defaults, "", "  ")
@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	mcp "github.com/daltoniam/switchboard"
 	"github.com/daltoniam/switchboard/project"
@@ -38,18 +41,9 @@ func setupProjectRouter(t *testing.T, def *project.Definition, integrations ...*
 		Registry: reg,
 	}
 
-	// Build search index from test integrations so project-scoped search
-	// gets TF-IDF + synonym scoring.
-	sm := buildSynonymMap(synonymGroups)
-	var tools []toolWithIntegration
-	for _, i := range integrations {
-		for _, tool := range i.Tools() {
-			tools = append(tools, toolWithIntegration{Integration: i.Name(), Tool: tool})
-		}
-	}
-	idf := computeIDF(tools)
-
-	router := NewProjectRouter(services, store, "switchboard", SearchIndex{IDF: idf, SynMap: sm, AllTools: tools})
+	// The core server builds the search index from the test integrations,
+	// so project-scoped search gets TF-IDF + synonym scoring.
+	router := NewProjectRouter(New(services), store, "switchboard")
 	return router, store
 }
 
@@ -72,14 +66,7 @@ func setupProjectRouterWithIntegration(t *testing.T, def *project.Definition, i
 		Registry: reg,
 	}
 
-	sm := buildSynonymMap(synonymGroups)
-	var tools []toolWithIntegration
-	for _, tool := range i.Tools() {
-		tools = append(tools, toolWithIntegration{Integration: i.Name(), Tool: tool})
-	}
-	idf := computeIDF(tools)
-
-	router := NewProjectRouter(services, store, "switchboard", SearchIndex{IDF: idf, SynMap: sm, AllTools: tools})
+	router := NewProjectRouter(New(services), store, "switchboard")
 	return router, store
 }
 
@@ -104,20 +91,39 @@ func TestProjectRouter_GetOrCreate(t *testing.T) {
 	}
 	router, _ := setupProjectRouter(t, def, mi)
 
-	srv, err := router.getOrCreate("test-project")
+	srv, err := router.getOrCreate("test-project", "")
 	require.NoError(t, err)
 	require.NotNil(t, srv)
 
-	srv2, err := router.getOrCreate("test-project")
+	srv2, err := router.getOrCreate("test-project", "")
 	require.NoError(t, err)
 	assert.Same(t, srv, srv2)
 }
 
+func TestProjectRouter_GetOrCreate_RebuildsAfterUpdate(t *testing.T) {
+	def := &project.Definition{Version: "1", Name: "test-project"}
+	router, store := setupProjectRouter(t, def)
+
+	srv, err := router.getOrCreate("test-project", "")
+	require.NoError(t, err)
+
+	_, err = store.Update("test-project", json.RawMessage(`{"branch":"main"}`))
+	require.NoError(t, err)
+	updated, err := router.getOrCreate("test-project", "")
+	require.NoError(t, err)
+	assert.NotSame(t, srv, updated)
+	assert.Equal(t, "main", updated.def.Branch)
+
+	require.NoError(t, store.Delete("test-project"))
+	_, err = router.getOrCreate("test-project", "")
+	assert.ErrorContains(t, err, "not found", "a deleted project is not served from the cache")
+}
+
 func TestProjectRouter_GetOrCreate_NotFound(t *testing.T) {
 	def := &project.Definition{Version: "1", Name: "test-project"}
 	router, _ := setupProjectRouter(t, def)
 
-	_, err := router.getOrCreate("nonexistent")
+	_, err := router.getOrCreate("nonexistent", "")
 	assert.ErrorContains(t, err, "not found")
 }
 
@@ -143,7 +149,7 @@ func TestProjectRouter_SearchFiltersTools(t *testing.T) {
 	}
 	router, _ := setupProjectRouter(t, def, mi)
 
-	srv, err := router.getOrCreate("scoped")
+	srv, err := router.getOrCreate("scoped", "")
 	require.NoError(t, err)
 
 	handler := router.makeSearchHandler(project.GetEffectiveRule(def, "switchboard", ""))
@@ -274,6 +280,160 @@ func TestProjectRouter_ExecuteDenied(t *testing.T) {
 	assert.Contains(t, tc.Text, "denied")
 }
 
+func TestProjectRouter_ScriptAppliesScope(t *testing.T) {
+	def := &project.Definition{
+		Version: "1",
+		Name:    "script-test",
+		Tools: map[string]*project.ScopeRule{
+			"switchboard": {
+				Deny: []string{"github_delete_*"},
+				Defaults: map[string]map[string]any{
+					"github_*": {"owner": "myorg"},
+				},
+			},
+		},
+	}
+
+	var calls []mcp.ToolName
+	var capturedArgs map[string]any
+	mi := &mockIntegration{
+		name:    "github",
+		healthy: true,
+		tools: []mcp.ToolDefinition{
+			{Name: mcp.ToolName("github_list_issues"), Description: "List issues"},
+			{Name: mcp.ToolName("github_delete_repo"), Description: "Delete repo"},
+		},
+		execFn: func(_ context.Context, tool mcp.ToolName, args map[string]any) (*mcp.ToolResult, error) {
+			calls = append(calls, tool)
+			capturedArgs = args
+			return &mcp.ToolResult{Data: `[{"number":1}]`}, nil
+		},
+	}
+	router, _ := setupProjectRouter(t, def, mi)
+	handler := router.makeExecuteHandler(def, project.GetEffectiveRule(def, "switchboard", ""))
+
+	t.Run("permitted call gets defaults", func(t *testing.T) {
+		result, err := handler(context.Background(), projectToolRequest("execute", map[string]any{
+			"script": `var issues = api.call("github_list_issues", {state: "open"}); issues.length`,
+		}))
+		require.NoError(t, err)
+		assert.False(t, result.IsError, result.Content[0].(*mcpsdk.TextContent).Text)
+		assert.Equal(t, "1", result.Content[0].(*mcpsdk.TextContent).Text)
+		assert.Equal(t, "myorg", capturedArgs["owner"])
+		assert.Equal(t, "open", capturedArgs["state"])
+	})
+
+	t.Run("denied call fails inside the script", func(t *testing.T) {
+		calls = nil
+		result, err := handler(context.Background(), projectToolRequest("execute", map[string]any{
+			"script": `api.call("github_delete_repo", {}); "unreachable"`,
+		}))
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(*mcpsdk.TextContent).Text, "denied by project scoping rules")
+		assert.Empty(t, calls, "denied tool must not reach the integration")
+	})
+
+	t.Run("tryCall reports denial", func(t *testing.T) {
+		result, err := handler(context.Background(), projectToolRequest("execute", map[string]any{
+			"script": `api.tryCall("github_delete_repo", {}).ok`,
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "false", result.Content[0].(*mcpsdk.TextContent).Text)
+	})
+}
+
+func TestProjectRouter_ExecuteUsesCorePipeline(t *testing.T) {
+	def := &project.Definition{Version: "1", Name: "core-test"}
+
+	attempts := 0
+	mi := &mockIntegration{
+		name:    "github",
+		healthy: true,
+		tools: []mcp.ToolDefinition{
+			{
+				Name:        mcp.ToolName("github_get_issue"),
+				Description: "Get issue",
+				Parameters:  map[string]string{"number": "Issue number"},
+				Required:    []string{"number"},
+			},
+		},
+		execFn: func(_ context.Context, _ mcp.ToolName, _ map[string]any) (*mcp.ToolResult, error) {
+			attempts++
+			if attempts == 1 {
+				return nil, &mcp.RetryableError{StatusCode: 503, Err: fmt.Errorf("service unavailable")}
+			}
+			return &mcp.ToolResult{Data: `{"number":7}`}, nil
+		},
+	}
+	router, _ := setupProjectRouter(t, def, mi)
+	router.core.retryBackoff = time.Millisecond
+	handler := router.makeExecuteHandler(def, project.GetEffectiveRule(def, "switchboard", ""))
+
+	t.Run("validates arguments", func(t *testing.T) {
+		result, err := handler(context.Background(), projectToolRequest("execute", map[string]any{
+			"tool_name": "github_get_issue",
+			"arguments": map[string]any{"numbr": 7},
+		}))
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(*mcpsdk.TextContent).Text, "missing required parameter")
+		assert.Zero(t, attempts)
+	})
+
+	t.Run("retries and pins", func(t *testing.T) {
+		result, err := handler(context.Background(), projectToolRequest("execute", map[string]any{
+			"tool_name": "github_get_issue",
+			"arguments": map[string]any{"number": 7},
+		}))
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		assert.Equal(t, 2, attempts, "retryable error should be retried")
+		require.Len(t, result.Content, 2)
+		assert.Equal(t, "pinned as $1", result.Content[1].(*mcpsdk.TextContent).Text)
+	})
+}
+
+func TestProjectRouter_SessionsNamespacedPerProject(t *testing.T) {
+	defA := &project.Definition{Version: "1", Name: "alpha"}
+	var capturedArgs map[string]any
+	mi := &mockIntegration{
+		name:    "github",
+		healthy: true,
+		tools: []mcp.ToolDefinition{
+			{Name: mcp.ToolName("github_list_issues"), Description: "List issues"},
+		},
+		execFn: func(_ context.Context, _ mcp.ToolName, args map[string]any) (*mcp.ToolResult, error) {
+			capturedArgs = args
+			return &mcp.ToolResult{Data: `[]`}, nil
+		},
+	}
+	router, store := setupProjectRouter(t, defA, mi)
+	defB := &project.Definition{Version: "1", Name: "beta"}
+	require.NoError(t, store.Create(defB))
+
+	setSession := router.withProjectSession(defA, router.core.handleSession)
+	_, err := setSession(context.Background(), projectToolRequest("session", map[string]any{
+		"action":  "set",
+		"context": map[string]any{"owner": "alpha-org"},
+	}))
+	require.NoError(t, err)
+
+	execA := router.makeExecuteHandler(defA, nil)
+	_, err = execA(context.Background(), projectToolRequest("execute", map[string]any{"tool_name": "github_list_issues"}))
+	require.NoError(t, err)
+	assert.Equal(t, "alpha-org", capturedArgs["owner"], "session context applies within the project")
+
+	execB := router.makeExecuteHandler(defB, nil)
+	_, err = execB(context.Background(), projectToolRequest("execute", map[string]any{"tool_name": "github_list_issues"}))
+	require.NoError(t, err)
+	assert.NotContains(t, capturedArgs, "owner", "session context must not leak across projects")
+
+	_, err = router.core.handleExecute(context.Background(), projectToolRequest("execute", map[string]any{"tool_name": "github_list_issues"}))
+	require.NoError(t, err)
+	assert.NotContains(t, capturedArgs, "owner", "session context must not leak into /mcp")
+}
+
 func TestProjectRouter_ExecutePerIntegrationCap(t *testing.T) {
 	// The project router's execute handler and server.handleExecute both call
 	// responseLimitFor. These subtests pin the project router path so a future
@@ -321,14 +481,15 @@ func TestProjectRouter_ExecutePerIntegrationCap(t *testing.T) {
 	})
 
 	t.Run("still enforced above override", func(t *testing.T) {
-		// 300KB payload exceeds even the raised 256KB cap — must still be rejected,
-		// and the error must report the integration's cap, not the default.
+		// 300KB payload exceeds even the raised 256KB cap — must still be paged,
+		// and the note must report the integration's cap, not the default.
 		payload := fmt.Sprintf(`{"data":"%s"}`, strings.Repeat("x", 300*1024))
 		result := executeTool(t, buildIntegration(payload))
 
-		assert.True(t, result.IsError, "response above per-integration cap should be rejected")
-		tc := result.Content[0].(*mcpsdk.TextContent)
-		assert.Contains(t, tc.Text, "256KB", "error should report the integration's own cap")
+		assert.False(t, result.IsError, "response above per-integration cap should be paged")
+		require.Len(t, result.Content, 2)
+		tc := result.Content[1].(*mcpsdk.TextContent)
+		assert.Contains(t, tc.Text, "256KB", "paging note should report the integration's own cap")
 	})
 }
 
@@ -381,10 +542,11 @@ func TestProjectRouter_ExecutePerToolCap(t *testing.T) {
 		payload := fmt.Sprintf(`{"data":"%s"}`, strings.Repeat("y", 60*1024)) // 60KB, above default
 		result := execute(t, buildIntegration("bigint_get_thing", payload), "bigint_get_thing")
 
-		assert.True(t, result.IsError, "tools without an override must use the default cap")
-		tc := result.Content[0].(*mcpsdk.TextContent)
+		assert.False(t, result.IsError)
+		require.Len(t, result.Content, 2, "tools without an override must be paged at the default cap")
+		tc := result.Content[1].(*mcpsdk.TextContent)
 		capKB := fmt.Sprintf("%dKB", defaultMaxResponseBytes/1024)
-		assert.Contains(t, tc.Text, capKB, "error should report the default cap")
+		assert.Contains(t, tc.Text, capKB, "paging note should report the default cap")
 	})
 }
 
@@ -429,9 +591,9 @@ func TestProjectRouter_ContextManifest(t *testing.T) {
 		Config:   newMockConfigService(nil),
 		Registry: newMockRegistry(),
 	}
-	router := NewProjectRouter(services, store, "switchboard", SearchIndex{})
+	router := NewProjectRouter(New(services), store, "switchboard")
 
-	handler := router.makeContextHandler(def)
+	handler := router.makeContextHandler(def, "")
 
 	t.Run("manifest with no args", func(t *testing.T) {
 		result, err := handler(context.Background(), projectToolRequest("project_context", map[string]any{}))
@@ -466,6 +628,92 @@ func TestProjectRouter_ContextManifest(t *testing.T) {
 		assert.Len(t, entries, 1)
 		assert.Equal(t, "sprint.md", entries[0].Path)
 	})
+
+	t.Run("content search", func(t *testing.T) {
+		result, err := handler(context.Background(), projectToolRequest("project_context", map[string]any{
+			"query": "goals",
+			"mode":  "content",
+		}))
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+
+		tc := result.Content[0].(*mcpsdk.TextContent)
+		var resp struct {
+			Matches []project.ContextMatch `json:"matches"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(tc.Text), &resp))
+		require.Len(t, resp.Matches, 1)
+		assert.Equal(t, "sprint.md", resp.Matches[0].Path)
+		assert.Equal(t, 1, resp.Matches[0].Line)
+	})
+
+	t.Run("content search needs a query", func(t *testing.T) {
+		result, err := handler(context.Background(), projectToolRequest("project_context", map[string]any{
+			"mode": "content",
+		}))
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+
+	t.Run("chunked read", func(t *testing.T) {
+		result, err := handler(context.Background(), projectToolRequest("project_context", map[string]any{
+			"path":   "AGENTS.md",
+			"length": 1,
+		}))
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+
+		tc := result.Content[0].(*mcpsdk.TextContent)
+		var chunk project.ContextChunk
+		require.NoError(t, json.Unmarshal([]byte(tc.Text), &chunk))
+		assert.Equal(t, "instructions", chunk.Content)
+		assert.Equal(t, 1, chunk.TotalLines)
+		assert.False(t, chunk.HasMore)
+	})
+}
+
+func TestProjectRouter_ContextUsesConnectionRole(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repo")
+	require.NoError(t, os.MkdirAll(repoDir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "AGENTS.md"), []byte("instructions"), 0600))
+
+	configDir := filepath.Join(dir, "config")
+	contextDir := filepath.Join(configDir, "context", "ctx-role")
+	require.NoError(t, os.MkdirAll(contextDir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(contextDir, "sprint.md"), []byte("goals"), 0600))
+
+	def := &project.Definition{
+		Version: "1",
+		Name:    "ctx-role",
+		Repo:    repoDir,
+		Context: &project.ContextConfig{
+			Files:        []string{"sprint.md"},
+			RepoIncludes: []string{"AGENTS.md"},
+		},
+		Agents: &project.AgentsConfig{Roles: map[string]*project.RoleDefinition{
+			"planner": {ContextOverrides: &project.ContextConfig{RepoIncludes: []string{"PLAN.md"}}},
+		}},
+	}
+	store := project.NewStore(configDir)
+	require.NoError(t, store.Create(def))
+	services := &mcp.Services{
+		Config:   newMockConfigService(nil),
+		Registry: newMockRegistry(),
+	}
+	router := NewProjectRouter(New(services), store, "switchboard")
+	handler := router.makeContextHandler(def, "planner")
+
+	result, err := handler(context.Background(), projectToolRequest("project_context", map[string]any{"role": ""}))
+	require.NoError(t, err)
+	var entries []project.ContextEntry
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcpsdk.TextContent).Text), &entries))
+	require.Len(t, entries, 1, "a role argument cannot widen the connection's manifest")
+	assert.Equal(t, "sprint.md", entries[0].Path)
+
+	result, err = handler(context.Background(), projectToolRequest("project_context", map[string]any{"path": "AGENTS.md", "role": ""}))
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].(*mcpsdk.TextContent).Text, "context file not found")
 }
 
 func TestProjectRouter_ProjectList(t *testing.T) {
@@ -576,7 +824,7 @@ func TestProjectRouter_ProjectTools(t *testing.T) {
 	}
 	router, _ := setupProjectRouter(t, def, mi)
 
-	handler := router.makeProjectToolsHandler(def)
+	handler := router.makeProjectToolsHandler(def, "")
 	result, err := handler(context.Background(), projectToolRequest("project_tools", map[string]any{}))
 	require.NoError(t, err)
 
@@ -586,6 +834,42 @@ func TestProjectRouter_ProjectTools(t *testing.T) {
 	assert.Equal(t, []string{"github_list_issues"}, tools)
 }
 
+func TestProjectRouter_ProjectToolsUsesConnectionRole(t *testing.T) {
+	def := reviewerProject("tools-role", 0)
+	def.Agents.Roles["reviewer"].ToolOverrides["switchboard"].Defaults = map[string]map[string]any{
+		"github_*": {"state": "open"},
+	}
+	mi := &mockIntegration{
+		name:    "github",
+		healthy: true,
+		tools: []mcp.ToolDefinition{
+			{Name: mcp.ToolName("github_list_issues"), Description: "List issues"},
+			{Name: mcp.ToolName("github_delete_repo"), Description: "Delete repo"},
+		},
+	}
+	router, _ := setupProjectRouter(t, def, mi)
+
+	tools := func(role string, args map[string]any) []string {
+		t.Helper()
+		result, err := router.makeProjectToolsHandler(def, role)(context.Background(), projectToolRequest("project_tools", args))
+		require.NoError(t, err)
+		var names []string
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcpsdk.TextContent).Text), &names))
+		return names
+	}
+	assert.Equal(t, []string{"github_list_issues"}, tools("reviewer", map[string]any{"role": ""}),
+		"a role argument cannot lift the connection's deny rules")
+	assert.Equal(t, []string{"github_delete_repo", "github_list_issues"}, tools("", map[string]any{"role": "reviewer"}))
+
+	result, err := router.makeProjectDefaultsHandler(def, "reviewer")(context.Background(), projectToolRequest("project_defaults", map[string]any{
+		"tool_name": "github_list_issues",
+	}))
+	require.NoError(t, err)
+	var defaults map[string]any
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcpsdk.TextContent).Text), &defaults))
+	assert.Equal(t, "open", defaults["state"], "defaults resolve under the connection's role")
+}
+
 func TestProjectRouter_ProjectDefaults(t *testing.T) {
 	def := &project.Definition{
 		Version: "1",
@@ -600,7 +884,7 @@ func TestProjectRouter_ProjectDefaults(t *testing.T) {
 	}
 	router, _ := setupProjectRouter(t, def)
 
-	handler := router.makeProjectDefaultsHandler(def)
+	handler := router.makeProjectDefaultsHandler(def, "")
 	result, err := handler(context.Background(), projectToolRequest("project_defaults", map[string]any{
 		"tool_name": "github_list_issues",
 	}))
@@ -618,3 +902,148 @@ func TestProjectRouter_Handler(t *testing.T) {
 	handler := router.Handler()
 	assert.NotNil(t, handler)
 }
+
+func reviewerProject(name string, maxConcurrent int) *project.Definition {
+	return &project.Definition{
+		Version: "1",
+		Name:    name,
+		Tools: map[string]*project.ScopeRule{
+			"switchboard": {Allow: []string{"github_*"}},
+		},
+		Agents: &project.AgentsConfig{
+			MaxConcurrent: maxConcurrent,
+			Roles: map[string]*project.RoleDefinition{
+				"reviewer": {
+					Description: "Reads pull requests and leaves comments.",
+					ToolOverrides: map[string]*project.ScopeRule{
+						"switchboard": {Deny: []string{"github_delete_*"}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// serveProjects mounts the router the way cmd/server does.
+func serveProjects(t *testing.T, router *ProjectRouter) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.Handle("/mcp/{project}", router.Handler())
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func connectProject(ctx context.Context, url string) (*mcpsdk.ClientSession, error) {
+	client := mcpsdk.NewClient(&mcpsdk.Implementation{Name: "test", Version: "1.0"}, nil)
+	return client.Connect(ctx, &mcpsdk.StreamableClientTransport{Endpoint: url}, nil)
+}
+
+func TestProjectRouter_RoleServers(t *testing.T) {
+	def := reviewerProject("roles", 0)
+	router, _ := setupProjectRouter(t, def)
+
+	base, err := router.getOrCreate("roles", "")
+	require.NoError(t, err)
+	reviewer, err := router.getOrCreate("roles", "reviewer")
+	require.NoError(t, err)
+	assert.NotSame(t, base, reviewer, "each role gets its own server")
+	assert.Equal(t, "reviewer", reviewer.role)
+
+	again, err := router.getOrCreate("roles", "reviewer")
+	require.NoError(t, err)
+	assert.Same(t, reviewer, again)
+
+	_, err = router.getOrCreate("roles", "admin")
+	assert.ErrorContains(t, err, `no role "admin"`)
+}
+
+func TestProjectInstructions_Role(t *testing.T) {
+	def := reviewerProject("roles", 0)
+
+	base := projectInstructions(def, "")
+	assert.Contains(t, base, `"roles"`)
+	assert.NotContains(t, base, "reviewer")
+
+	got := projectInstructions(def, "reviewer")
+	assert.Contains(t, got, `"reviewer" agent role`)
+	assert.Contains(t, got, "Reads pull requests and leaves comments.")
+}
+
+func TestProjectRouter_RoleSelectedPerConnection(t *testing.T) {
+	def := reviewerProject("roles", 0)
+	mi := &mockIntegration{
+		name:    "github",
+		healthy: true,
+		tools: []mcp.ToolDefinition{
+			{Name: mcp.ToolName("github_list_issues"), Description: "List issues"},
+			{Name: mcp.ToolName("github_delete_repo"), Description: "Delete repo"},
+		},
+		execFn: func(_ context.Context, _ mcp.ToolName, _ map[string]any) (*mcp.ToolResult, error) {
+			return &mcp.ToolResult{Data: "ok"}, nil
+		},
+	}
+	router, _ := setupProjectRouter(t, def, mi)
+	ts := serveProjects(t, router)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	call := func(url string) *mcpsdk.CallToolResult {
+		t.Helper()
+		cs, err := connectProject(ctx, url)
+		require.NoError(t, err)
+		defer cs.Close() //nolint:errcheck
+		res, err := cs.CallTool(ctx, &mcpsdk.CallToolParams{
+			Name:      "execute",
+			Arguments: map[string]any{"tool_name": "github_delete_repo"},
+		})
+		require.NoError(t, err)
+		return res
+	}
+
+	assert.False(t, call(ts.URL+"/mcp/roles").IsError, "base scope allows github_*")
+	assert.True(t, call(ts.URL+"/mcp/roles?role=reviewer").IsError, "reviewer role denies deletes")
+
+	_, err := connectProject(ctx, ts.URL+"/mcp/roles?role=admin")
+	assert.Error(t, err, "unknown roles are rejected")
+}
+
+func TestProjectRouter_RoleHeader(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/mcp/p", nil)
+	assert.Empty(t, requestRole(r))
+
+	r.Header.Set(projectRoleHeader, "reviewer")
+	assert.Equal(t, "reviewer", requestRole(r))
+
+	r = httptest.NewRequest(http.MethodPost, "/mcp/p?role=writer", nil)
+	r.Header.Set(projectRoleHeader, "reviewer")
+	assert.Equal(t, "writer", requestRole(r), "query parameter wins over the header")
+}
+
+func TestProjectRouter_MaxConcurrent(t *testing.T) {
+	def := reviewerProject("capped", 2)
+	router, _ := setupProjectRouter(t, def)
+	ts := serveProjects(t, router)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	first, err := connectProject(ctx, ts.URL+"/mcp/capped")
+	require.NoError(t, err)
+	second, err := connectProject(ctx, ts.URL+"/mcp/capped?role=reviewer")
+	require.NoError(t, err, "the cap counts sessions across roles")
+	defer second.Close() //nolint:errcheck
+
+	_, err = connectProject(ctx, ts.URL+"/mcp/capped")
+	require.Error(t, err)
+	assert.Equal(t, 2, router.activeSessions("capped"))
+
+	_, err = first.ListTools(ctx, nil)
+	require.NoError(t, err, "existing sessions keep working at the cap")
+
+	require.NoError(t, first.Close())
+	third, err := connectProject(ctx, ts.URL+"/mcp/capped")
+	require.NoError(t, err, "closing a session frees a slot")
+	defer third.Close() //nolint:errcheck
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	mcp "github.com/daltoniam/switchboard"
+)
+
+// resultProcessorFor builds integration's result processor with the
+// server's current quarantine mode for it.
+func (s *Server) resultProcessorFor(integration mcp.Integration) resultProcessor {
+	rp := buildResultProcessor(integration)
+	if c := s.services.Config.Get(); c != nil {
+		rp.quarantine = c.Quarantine.ModeFor(mcp.IntegrationName(integration.Name()))
+	}
+	return rp
+}
+
+// untrustedPaths returns the untrusted-content paths toolName declares, or
+// nil when it declares none or quarantine is off.
+func (rp resultProcessor) untrustedPaths(toolName mcp.ToolName) []string {
+	if rp.untrusted == nil || rp.quarantine == "" || rp.quarantine == mcp.QuarantineOff {
+		return nil
+	}
+	paths, _ := rp.untrusted(toolName)
+	return paths
+}
+
+// quarantineValue wraps the untrusted fields of a decoded result and
+// records what it found.
+func quarantineValue(rp resultProcessor, toolName mcp.ToolName, v any, paths []string, metrics *mcp.Metrics) any {
+	if len(paths) == 0 {
+		return v
+	}
+	v, report := mcp.QuarantineValue(v, paths, rp.quarantine)
+	recordQuarantine(toolName, report, metrics)
+	return v
+}
+
+// quarantineText wraps a whole rendered result and records what it found.
+func quarantineText(rp resultProcessor, toolName mcp.ToolName, text string, metrics *mcp.Metrics) string {
+	text, report := mcp.QuarantineText(text, rp.quarantine)
+	recordQuarantine(toolName, report, metrics)
+	return text
+}
+
+func recordQuarantine(toolName mcp.ToolName, report mcp.QuarantineReport, metrics *mcp.Metrics) {
+	if metrics != nil && report.Fields > 0 {
+		metrics.RecordQuarantine(toolName, report.Fields, report.Findings)
+	}
+}
+
+// storedQuarantineMode returns the quarantine mode for toolName's results
+// when they are read back out of the session, or "" when the tool declares
+// no untrusted content, quarantine is off for it, or the tool is unknown.
+func (s *Server) storedQuarantineMode(toolName mcp.ToolName) string {
+	integration, _, err := s.findTool(toolName)
+	if err != nil {
+		return ""
+	}
+	rp := s.resultProcessorFor(integration)
+	if len(rp.untrustedPaths(toolName)) == 0 {
+		return ""
+	}
+	return rp.quarantine
+}
+
+// quarantineStored quarantines text taken from a stored result of toolName
+// — a pin get or page, a breadcrumb, a search snippet — on its way back to
+// the LLM. Pinned copies are kept as the tool returned them, so pin
+// references pass the original values to the next call; the wrapping
+// happens only here. Untrusted paths describe the compacted shape, not the
+// raw one, so every string is wrapped. Nothing is counted in metrics: the
+// result was counted when it was first returned.
+func (s *Server) quarantineStored(toolName mcp.ToolName, text string) string {
+	mode := s.storedQuarantineMode(toolName)
+	if mode == "" {
+		return text
+	}
+	text, _ = quarantineAll(text, mode)
+	return text
+}
+
+// quarantineAll wraps every string of a JSON text, keeping it JSON, and
+// other text whole.
+func quarantineAll(text, mode string) (string, mcp.QuarantineReport) {
+	trimmed := strings.TrimLeft(text, " \t\n\r")
+	if trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[' && trimmed[0] != '"') {
+		return mcp.QuarantineText(text, mode)
+	}
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	var parsed any
+	if err := dec.Decode(&parsed); err != nil || dec.More() {
+		return mcp.QuarantineText(text, mode)
+	}
+	parsed, report := mcp.QuarantineLeaves(parsed, mode)
+	if report.Fields == 0 {
+		return text, report
+	}
+	out, err := marshalResult(parsed)
+	if err != nil {
+		// Encoding a decoded value cannot fail; wrap the text whole if it
+		// somehow does.
+		return mcp.QuarantineText(text, mode)
+	}
+	return string(out), report
+}
+
+type scriptTaintKey struct{}
+
+// scriptTaint records the strictest quarantine mode among the tools a
+// script called. api.call() hands scripts the raw result so they can pass
+// its values on unchanged; runScript quarantines what the script returns
+// instead.
+type scriptTaint struct {
+	mu   sync.Mutex
+	mode string
+}
+
+func withScriptTaint(ctx context.Context) (context.Context, *scriptTaint) {
+	t := &scriptTaint{}
+	return context.WithValue(ctx, scriptTaintKey{}, t), t
+}
+
+// taintScript marks the script running under ctx, if any, as having read
+// toolName's untrusted content.
+func (s *Server) taintScript(ctx context.Context, toolName mcp.ToolName) {
+	t, _ := ctx.Value(scriptTaintKey{}).(*scriptTaint)
+	if t == nil {
+		return
+	}
+	mode := s.storedQuarantineMode(toolName)
+	if mode == "" {
+		return
+	}
+	t.mu.Lock()
+	if t.mode != mcp.QuarantineStrip {
+		t.mode = mode
+	}
+	t.mu.Unlock()
+}
+
+// quarantine wraps a script's output when a tool it called declared
+// untrusted content, recording what it found under the "script" tool.
+func (t *scriptTaint) quarantine(out string, metrics *mcp.Metrics) string {
+	t.mu.Lock()
+	mode := t.mode
+	t.mu.Unlock()
+	if mode == "" {
+		return out
+	}
+	out, report := quarantineAll(out, mode)
+	recordQuarantine("script", report, metrics)
+	return out
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+
+	mcp "github.com/daltoniam/switchboard"
+)
+
+// redactor returns the Redactor for the current redaction config,
+// recompiling it only when the config has been replaced. Config load and
+// Update validate the rules, so a compile error here means the config was
+// changed behind the manager's back; it is logged and nothing is redacted.
+func (s *Server) redactor() *mcp.Redactor {
+	var cfg *mcp.RedactionConfig
+	if c := s.services.Config.Get(); c != nil {
+		cfg = c.Redaction
+	}
+	s.redactorMu.Lock()
+	defer s.redactorMu.Unlock()
+	if s.redactorBuilt && cfg == s.redactionCfg {
+		return s.redactorCached
+	}
+	r, err := mcp.NewRedactor(cfg)
+	if err != nil {
+		slog.Error("redaction disabled: invalid config", "err", err)
+	}
+	s.redactionCfg, s.redactorCached, s.redactorBuilt = cfg, r, true
+	return r
+}
+
+// redact runs the redaction stage on text from integration's tool. Counts
+// are recorded in metrics only when record is set, so a result redacted
+// twice (the response and its pinned copy) is counted once.
+func (s *Server) redact(integration mcp.Integration, tool mcp.ToolName, text string, record bool) string {
+	r := s.redactor()
+	if r == nil || integration == nil {
+		return text
+	}
+	out, counts := r.Redact(mcp.IntegrationName(integration.Name()), tool, text)
+	if record && s.services.Metrics != nil {
+		s.services.Metrics.RecordRedactions(tool, counts)
+	}
+	return out
+}
+
+// redactFailure redacts the text of a failed call, which can echo request
+// or upstream data as readily as a result. executeTool drops the
+// integration when a call fails outright, so it is looked up by tool name.
+func (s *Server) redactFailure(integration mcp.Integration, tool mcp.ToolName, text string) string {
+	if integration == nil {
+		integration, _, _ = s.findTool(tool)
+	}
+	return s.redact(integration, tool, text, true)
+}
+
+// redactErr is redactFailure for an error, replaced only when redaction
+// changed its text.
+func (s *Server) redactErr(integration mcp.Integration, tool mcp.ToolName, err error) error {
+	if err == nil {
+		return nil
+	}
+	if msg := s.redactFailure(integration, tool, err.Error()); msg != err.Error() {
+		return errors.New(msg)
+	}
+	return err
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"bytes"
 	"cmp"
 	"context"
 	"encoding/json"
@@ -19,8 +20,11 @@ import (
 	mcp "github.com/daltoniam/switchboard"
 	"github.com/daltoniam/switchboard/compact"
 	"github.com/daltoniam/switchboard/script"
+	"github.com/daltoniam/switchboard/telemetry"
 	"github.com/daltoniam/switchboard/version"
 	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const defaultSearchLimit = 20
@@ -74,6 +78,7 @@ type Server struct {
 	scriptEngine      *script.Engine
 	sessionStore      SessionStore
 	retryBackoff      time.Duration
+	breakersMu        sync.Mutex
 	breakers          map[string]*breaker
 	breakerThreshold  int
 	breakerCooldown   time.Duration
@@ -84,6 +89,13 @@ type Server struct {
 	catalogBytes      int64                 // byte size of full tool catalog (for savings accounting)
 	discoverAll       bool
 	extraInstructions string // appended to the base MCP instructions
+
+	// The compiled redaction stage and the config it was built from; see
+	// redaction.go.
+	redactorMu     sync.Mutex
+	redactionCfg   *mcp.RedactionConfig
+	redactorCached *mcp.Redactor
+	redactorBuilt  bool
 }
 
 // baseInstructions is the default guidance sent to clients in the MCP
@@ -158,6 +170,7 @@ func New(services *mcp.Services, opts ...Option) *Server {
 		},
 		&mcpsdk.ServerOptions{Instructions: instructions},
 	)
+	s.mcpServer.AddReceivingMiddleware(traceMiddleware("/mcp"))
 
 	s.scriptEngine = script.New(&toolExecutor{server: s})
 
@@ -279,7 +292,17 @@ Pipe rendered document content between tools (callRendered returns readable text
 		}, nil),
 	}
 
-	sessionTool := &mcpsdk.Tool{
+	s.mcpServer.AddTool(searchTool, s.handleSearch)
+	s.mcpServer.AddTool(executeTool, s.handleExecute)
+	s.mcpServer.AddTool(sessionTool(), s.handleSession)
+	s.mcpServer.AddTool(historyTool(), s.handleHistory)
+	s.mcpServer.AddTool(pinTool(), s.handlePin)
+}
+
+// sessionTool, historyTool and pinTool define the stateful meta-tools served
+// on /mcp and on every project endpoint.
+func sessionTool() *mcpsdk.Tool {
+	return &mcpsdk.Tool{
 		Name: "session",
 		Description: `Manage session-scoped context to avoid repeating parameters.
 
@@ -290,28 +313,46 @@ Actions:
 - "set": Upsert key-value pairs into session context
 - "get": Return current session context
 - "clear": Reset session context to empty
+- "export": Save context, history and pinned results as a snapshot another agent can import. Returns the snapshot's random "id"
+- "import": Attach the snapshot with the given "id" to this session (its context overrides; pins keep their handles unless taken — see "handles" in the result)
+
+Sharing: anyone holding a snapshot's id can import it, from any session (on a project endpoint, any session of the same project), until it expires after 7 days. Ids cannot be guessed; pass one only to the agents that should continue the work.
 
 Example: {"action": "set", "context": {"owner": "daltoniam", "repo": "switchboard"}}
 Then: execute({tool_name: "github_list_issues", arguments: {state: "open"}}) — owner/repo injected automatically.`,
 		InputSchema: objectSchema(map[string]any{
 			"action": map[string]any{
 				"type":        "string",
-				"description": `The action to perform: "set", "get", or "clear".`,
-				"enum":        []string{"set", "get", "clear"},
+				"description": `The action to perform: "set", "get", "clear", "export", or "import".`,
+				"enum":        []string{"set", "get", "clear", "export", "import"},
 			},
 			"context": map[string]any{
 				"type":        "object",
 				"description": "Key-value pairs to set in session context (only for \"set\" action).",
 			},
+			"name": map[string]any{
+				"type":        "string",
+				"description": "Optional label for \"export\". Letters, digits, '.', '_' and '-'.",
+			},
+			"id": map[string]any{
+				"type":        "string",
+				"description": "Snapshot id returned by \"export\" (required for \"import\").",
+			},
 		}, []string{"action"}),
 	}
+}
 
-	historyTool := &mcpsdk.Tool{
+func historyTool() *mcpsdk.Tool {
+	return &mcpsdk.Tool{
 		Name: "history",
 		Description: `Retrieve a compact log of tool calls made in this session.
 
 Useful after context compression to recover what was already fetched without re-executing.
-Returns: [{seq, tool, args, summary, is_error, timestamp}] ordered by time.`,
+Returns: [{seq, tool, args, summary, is_error, timestamp}] ordered by time.
+
+With "query", full-text searches the results of past calls instead and returns
+[{handle, tool, pinned_at, snippet, resident}] ranked by relevance. Resident handles
+can be used directly in execute arguments; use pin get to read the full result.`,
 		InputSchema: objectSchema(map[string]any{
 			"last_n": map[string]any{
 				"type":        "integer",
@@ -321,10 +362,16 @@ Returns: [{seq, tool, args, summary, is_error, timestamp}] ordered by time.`,
 				"type":        "string",
 				"description": "Filter breadcrumbs to a specific tool name.",
 			},
+			"query": map[string]any{
+				"type":        "string",
+				"description": "Search past results for these words (all must match). last_n caps the number of matches.",
+			},
 		}, nil),
 	}
+}
 
-	pinTool := &mcpsdk.Tool{
+func pinTool() *mcpsdk.Tool {
+	return &mcpsdk.Tool{
 		Name: "pin",
 		Description: `Manage pinned results from previous execute calls.
 
@@ -332,15 +379,24 @@ Every successful execute auto-pins its result with a handle ($1, $2, ...).
 Use handles in execute arguments to reference previous results without re-fetching:
   execute({tool_name: "github_get_issue", arguments: {owner: "$1.owner.login", issue_number: "$2.number"}})
 
+Paths support index and slice ($3.items[0], $3.items[-1], $3.items[0:5]), wildcards
+($3.items[*].id — every id) and filters ($3.items[?state=='open'].number; also !=, <, <=, >, >=,
+and [?draft] for truthy fields). Embed values in text with {{...}}:
+  {title: "Fixes {{$2.identifier}}"}
+
+A response too large to return whole is pinned and returned a page at a time, with a
+cursor such as "$7#page=2" for the next page.
+
 Actions:
 - "list": Show all pinned handles with tool name and size
 - "get": Retrieve a pinned result by handle, optionally extracting a sub-field via path
+- "page": Retrieve one page of a pinned result by cursor ("$7#page=2")
 - "unpin": Free memory by removing a pinned result`,
 		InputSchema: objectSchema(map[string]any{
 			"action": map[string]any{
 				"type":        "string",
-				"description": `The action to perform: "list", "get", or "unpin".`,
-				"enum":        []string{"list", "get", "unpin"},
+				"description": `The action to perform: "list", "get", "page", or "unpin".`,
+				"enum":        []string{"list", "get", "page", "unpin"},
 			},
 			"handle": map[string]any{
 				"type":        "string",
@@ -348,16 +404,14 @@ Actions:
 			},
 			"path": map[string]any{
 				"type":        "string",
-				"description": "Dot-notation path to extract a sub-field (e.g. \"user.login\"). Only for get.",
+				"description": "Path to extract from the result (e.g. \"user.login\" or \"items[?state=='open'].id\"). Only for get.",
+			},
+			"cursor": map[string]any{
+				"type":        "string",
+				"description": "The page to retrieve (e.g. \"$7#page=2\"). Only for page; a bare handle returns page 1.",
 			},
 		}, []string{"action"}),
 	}
-
-	s.mcpServer.AddTool(searchTool, s.handleSearch)
-	s.mcpServer.AddTool(executeTool, s.handleExecute)
-	s.mcpServer.AddTool(sessionTool, s.handleSession)
-	s.mcpServer.AddTool(historyTool, s.handleHistory)
-	s.mcpServer.AddTool(pinTool, s.handlePin)
 }
 
 func (s *Server) configureIntegrations() {
@@ -757,7 +811,20 @@ func extractSharedParameters(tools []searchToolInfo) map[string]string {
 	return candidates
 }
 
+// argScope checks a tool call against a caller-specific policy and returns
+// the arguments to run it with, or an error result refusing it. Project
+// endpoints use it to apply their scope rules and default arguments.
+type argScope func(toolName mcp.ToolName, args map[string]any) (map[string]any, *mcp.ToolResult)
+
 func (s *Server) handleExecute(ctx context.Context, req *mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
+	return s.execute(ctx, req, s.scriptEngine, nil)
+}
+
+// execute implements the execute meta-tool for /mcp and /mcp/{project}.
+// engine runs scripts; scope, when set, is applied to single calls after
+// session defaults and pin references are resolved. Scripts are scoped by
+// their engine's executor.
+func (s *Server) execute(ctx context.Context, req *mcpsdk.CallToolRequest, engine *script.Engine, scope argScope) (*mcpsdk.CallToolResult, error) {
 	var args struct {
 		ToolName  mcp.ToolName   `json:"tool_name"`
 		Arguments map[string]any `json:"arguments"`
@@ -767,8 +834,18 @@ func (s *Server) handleExecute(ctx context.Context, req *mcpsdk.CallToolRequest)
 		return errorResult("invalid arguments: " + err.Error()), nil
 	}
 
+	sess := sessionFromCtx(ctx)
+	if sess == nil {
+		sess = s.sessionStore.GetOrCreate(sessionIDFromReq(req.Session))
+	}
+
 	if args.Script != "" {
-		return s.handleScriptExecute(ctx, args.Script)
+		return runScript(ctx, engine, s.services.Metrics, args.Script, func(out string) *mcpsdk.CallToolResult {
+			paged := pagedResult(sess, "script", out, defaultMaxResponseBytes, fmt.Sprintf(
+				"Script output exceeded %dKB (actual: %dKB).", defaultMaxResponseBytes/1024, len(out)/1024))
+			_ = s.sessionStore.Save(sess)
+			return paged
+		})
 	}
 
 	if args.ToolName == "" {
@@ -783,67 +860,98 @@ func (s *Server) handleExecute(ctx context.Context, req *mcpsdk.CallToolRequest)
 		args.Arguments = map[string]any{}
 	}
 
-	sess := sessionFromCtx(ctx)
-	if sess == nil {
-		sess = s.sessionStore.GetOrCreate(sessionIDFromReq(req.Session))
+	if err := resolveRefs(sess, args.Arguments); err != nil {
+		return errorResult(err.Error()), nil
 	}
-	resolveRefs(sess, args.Arguments)
 	args.Arguments = sess.MergeDefaults(args.Arguments)
+	if scope != nil {
+		scoped, denied := scope(args.ToolName, args.Arguments)
+		if denied != nil {
+			return errorResult(denied.Data), nil
+		}
+		args.Arguments = scoped
+	}
 
+	s.recordOverCompaction(sess, args.ToolName, args.Arguments)
 	integration, result, err := s.executeTool(ctx, args.ToolName, args.Arguments)
 	if err != nil {
-		sess.AddBreadcrumb(args.ToolName, args.Arguments, err.Error(), true)
+		msg := s.redactFailure(integration, args.ToolName, err.Error())
+		sess.AddBreadcrumb(args.ToolName, args.Arguments, msg, true)
 		_ = s.sessionStore.Save(sess)
-		return errorResult(err.Error()), nil
+		return errorResult(msg), nil
 	}
-	var handle string
-	if !result.IsError {
-		handle = sess.PinResult(args.ToolName, result.Data)
-	}
-	sess.AddBreadcrumb(args.ToolName, args.Arguments, result.Data, result.IsError)
-	_ = s.sessionStore.Save(sess)
 	if result.IsError {
+		result.Data = s.redactFailure(integration, args.ToolName, result.Data)
+		sess.AddBreadcrumb(args.ToolName, args.Arguments, result.Data, true)
+		_ = s.sessionStore.Save(sess)
 		return &mcpsdk.CallToolResult{
 			Content: []mcpsdk.Content{&mcpsdk.TextContent{Text: result.Data}},
 			IsError: true,
 		}, nil
 	}
-	applyResultProcessing(integration, args.ToolName, compact.ParseViewArgs(args.Arguments), result, s.services.Metrics)
+
+	// An oversized response is pinned as processed and returned a page at
+	// a time rather than rejected; the tool's own max_bytes, when exceeded,
+	// sets the page size below the response cap.
+	text, toolCap := s.shapeResult(integration, args.ToolName, compact.ParseViewArgs(args.Arguments), result.Data)
+	text = s.redact(integration, args.ToolName, text, true)
+	// The raw result is pinned and kept in breadcrumbs, where pin and
+	// history can hand it back to the LLM, so it is redacted too. It is
+	// quarantined only as pin and history read it back, so references
+	// like $1.body pass the original value to the next call.
+	result.Data = s.redact(integration, args.ToolName, result.Data, false)
 	limit := responseLimitFor(integration, args.ToolName)
-	if len(result.Data) > limit {
+	var reason string
+	switch {
+	case toolCap > 0:
+		limit = min(limit, toolCap)
+		reason = fmt.Sprintf("Response exceeded %s's %dKB max_bytes (actual: %dKB).", args.ToolName, toolCap/1024, len(text)/1024)
+	case len(text) > limit:
+		reason = fmt.Sprintf("Response exceeded %dKB (actual: %dKB).", limit/1024, len(text)/1024)
+	}
+	if reason != "" {
 		if s.services.Metrics != nil {
 			s.services.Metrics.RecordTruncation()
 		}
-		return errorResult(fmt.Sprintf(
-			"Response exceeded %dKB (actual: %dKB). Use more specific filters, lower limit/per_page, or fetch individual items.",
-			limit/1024,
-			len(result.Data)/1024,
-		)), nil
-	}
-	text := result.Data
-	if handle != "" {
-		return &mcpsdk.CallToolResult{
-			Content: []mcpsdk.Content{
-				&mcpsdk.TextContent{Text: text},
-				&mcpsdk.TextContent{Text: "pinned as " + handle},
-			},
-		}, nil
+		paged := pagedResult(sess, args.ToolName, text, limit, reason)
+		sess.AddBreadcrumb(args.ToolName, args.Arguments, result.Data, false)
+		_ = s.sessionStore.Save(sess)
+		return paged, nil
 	}
+
+	handle := sess.PinResult(args.ToolName, result.Data)
+	sess.AddBreadcrumb(args.ToolName, args.Arguments, result.Data, false)
+	_ = s.sessionStore.Save(sess)
 	return &mcpsdk.CallToolResult{
 		Content: []mcpsdk.Content{
 			&mcpsdk.TextContent{Text: text},
+			&mcpsdk.TextContent{Text: "pinned as " + handle},
 		},
 	}, nil
 }
 
 const maxScriptRetries = 10
 
-func (s *Server) handleScriptExecute(ctx context.Context, source string) (*mcpsdk.CallToolResult, error) {
-	if s.services.Metrics != nil {
-		s.services.Metrics.RecordScript()
+// runScript runs source on engine and shapes its output for the LLM. It is
+// shared by the main and project-scoped execute tools; the engine's executor
+// decides which tools a script may reach. Output over the response cap is
+// handed to page, which pins it and returns its first page.
+func runScript(ctx context.Context, engine *script.Engine, metrics *mcp.Metrics, source string, page func(out string) *mcpsdk.CallToolResult) (*mcpsdk.CallToolResult, error) {
+	if metrics != nil {
+		metrics.RecordScript()
 	}
+	ctx, span := telemetry.Tracer().Start(ctx, "script",
+		trace.WithAttributes(attribute.Int("switchboard.script.bytes", len(source))))
 	ctx = withRetryBudget(ctx, maxScriptRetries)
-	result, err := s.scriptEngine.Run(ctx, source)
+	ctx, taint := withScriptTaint(ctx)
+	result, err := engine.Run(ctx, source)
+	if result != nil {
+		span.SetAttributes(
+			attribute.Int64("switchboard.script.intermediate_bytes", result.IntermediateBytes),
+			attribute.Int64("switchboard.script.final_bytes", result.FinalBytes),
+		)
+	}
+	endSpan(span, result, err)
 	if err != nil {
 		return errorResult(err.Error()), nil
 	}
@@ -854,27 +962,24 @@ func (s *Server) handleScriptExecute(ctx context.Context, source string) (*mcpsd
 	// columnarization can cut output 30-50%, so credit the post-columnar
 	// size on the happy path.
 	if result.IsError {
-		if s.services.Metrics != nil {
-			s.services.Metrics.RecordScriptSavings(result.IntermediateBytes, result.FinalBytes)
+		if metrics != nil {
+			metrics.RecordScriptSavings(result.IntermediateBytes, result.FinalBytes)
 		}
 		return &mcpsdk.CallToolResult{
-			Content: []mcpsdk.Content{&mcpsdk.TextContent{Text: result.Data}},
+			Content: []mcpsdk.Content{&mcpsdk.TextContent{Text: taint.quarantine(result.Data, metrics)}},
 			IsError: true,
 		}, nil
 	}
 	result.Data = columnarizeResult(result.Data)
-	if s.services.Metrics != nil {
-		s.services.Metrics.RecordScriptSavings(result.IntermediateBytes, int64(len(result.Data)))
+	if metrics != nil {
+		metrics.RecordScriptSavings(result.IntermediateBytes, int64(len(result.Data)))
 	}
+	result.Data = taint.quarantine(result.Data, metrics)
 	if len(result.Data) > defaultMaxResponseBytes {
-		if s.services.Metrics != nil {
-			s.services.Metrics.RecordTruncation()
+		if metrics != nil {
+			metrics.RecordTruncation()
 		}
-		return errorResult(fmt.Sprintf(
-			"Script output exceeded %dKB (actual: %dKB). Return only the fields you need from each api.call() result.",
-			defaultMaxResponseBytes/1024,
-			len(result.Data)/1024,
-		)), nil
+		return page(result.Data), nil
 	}
 	return &mcpsdk.CallToolResult{
 		Content: []mcpsdk.Content{
@@ -887,12 +992,25 @@ func (s *Server) handleScriptExecute(ctx context.Context, source string) (*mcpsd
 // Built from an integration via buildResultProcessor; decouples processResult
 // from knowledge of which optional interfaces exist.
 type resultProcessor struct {
-	markdown func(mcp.ToolName, []byte) (mcp.Markdown, bool)
-	compact  func(mcp.ToolName) ([]mcp.CompactField, bool)
-	maxBytes func(mcp.ToolName) (int, bool)
-	views    func(mcp.ToolName) (compact.ViewSet, bool)
+	markdown  func(mcp.ToolName, []byte) (mcp.Markdown, bool)
+	compact   func(mcp.ToolName) ([]mcp.CompactField, bool)
+	maxBytes  func(mcp.ToolName) (int, bool)
+	views     func(mcp.ToolName) (compact.ViewSet, bool)
+	untrusted func(mcp.ToolName) ([]string, bool)
+	record    func(mcp.ToolName, []byte)
+
+	// quarantine is the prompt-injection quarantine mode for the
+	// integration's untrusted fields; empty or mcp.QuarantineOff skips it.
+	// Set by Server.resultProcessorFor from config, not by
+	// buildResultProcessor.
+	quarantine string
 }
 
+// responseRecorder captures raw JSON responses for `switchboard compact
+// suggest` when $SWITCHBOARD_COMPACT_RECORD names a directory. Nil (the
+// default) records nothing.
+var responseRecorder = compact.RecorderFromEnv()
+
 // buildResultProcessor inspects an integration's optional interfaces once
 // and captures them as function fields. The returned processor is safe to
 // reuse for the lifetime of the integration (capabilities are static).
@@ -910,6 +1028,12 @@ func buildResultProcessor(integration mcp.Integration) resultProcessor {
 	if tv, ok := integration.(compact.ToolViewsIntegration); ok {
 		rp.views = tv.Views
 	}
+	if ut, ok := integration.(mcp.UntrustedContentIntegration); ok {
+		rp.untrusted = ut.UntrustedFields
+	}
+	if responseRecorder != nil {
+		rp.record = responseRecorder.Record
+	}
 	return rp
 }
 
@@ -921,12 +1045,22 @@ func buildResultProcessor(integration mcp.Integration) resultProcessor {
 // map) is the structural defense against the "caller forgot to thread args"
 // leak: the parameter has no nil — every callsite must parse explicitly, and
 // "no selection" is the well-defined zero value (which means "use defaults").
-func applyResultProcessing(integration mcp.Integration, toolName mcp.ToolName, view compact.ViewArgs, result *mcp.ToolResult, metrics *mcp.Metrics) {
+func (s *Server) applyResultProcessing(integration mcp.Integration, toolName mcp.ToolName, view compact.ViewArgs, result *mcp.ToolResult) {
 	if integration == nil || result == nil || result.IsError {
 		return
 	}
-	rp := buildResultProcessor(integration)
-	result.Data = processResult(rp, toolName, view, result.Data, metrics)
+	result.Data = processResult(s.resultProcessorFor(integration), toolName, view, result.Data, s.services.Metrics)
+}
+
+// shapeResult runs the integration's response pipeline like
+// applyResultProcessing, but instead of replacing output over the tool's
+// max_bytes with a response_too_large envelope it returns the output whole,
+// with the cap it exceeded (0 when within it), so execute can page it.
+func (s *Server) shapeResult(integration mcp.Integration, toolName mcp.ToolName, view compact.ViewArgs, data string) (string, int) {
+	if integration == nil {
+		return data, 0
+	}
+	return processResultCapped(s.resultProcessorFor(integration), toolName, view, data, s.services.Metrics)
 }
 
 // processResult applies markdown rendering, compaction, and columnarization.
@@ -939,25 +1073,52 @@ func applyResultProcessing(integration mcp.Integration, toolName mcp.ToolName, v
 // pipeline dispatches via processViewsResult instead — view.View and
 // view.Format select the projection and renderer.
 func processResult(rp resultProcessor, toolName mcp.ToolName, view compact.ViewArgs, data string, metrics *mcp.Metrics) string {
+	out, limit := processResultCapped(rp, toolName, view, data, metrics)
+	if limit > 0 {
+		return tooLargeEnvelope(toolName, len(out), limit)
+	}
+	return out
+}
+
+// processResultCapped is processResult without the max_bytes envelope: it
+// returns the processed output and, when the output exceeds the tool's or
+// view's max_bytes, that cap.
+func processResultCapped(rp resultProcessor, toolName mcp.ToolName, view compact.ViewArgs, data string, metrics *mcp.Metrics) (string, int) {
+	untrusted := rp.untrustedPaths(toolName)
+
 	trimmed := strings.TrimLeft(data, " \t\n\r")
 	if len(trimmed) == 0 || (trimmed[0] != '[' && trimmed[0] != '{') {
-		return data
+		// Text from a tool with untrusted fields cannot be walked by
+		// path, so all of it is quarantined.
+		if len(untrusted) > 0 {
+			return quarantineText(rp, toolName, data, metrics), 0
+		}
+		return data, 0
+	}
+
+	if rp.record != nil {
+		rp.record(toolName, []byte(data))
 	}
 
 	// Multi-view path takes priority when declared.
 	if rp.views != nil {
 		if viewSet, ok := rp.views(toolName); ok {
-			return processViewsResult(viewSet, toolName, view, data, metrics)
+			return processViewsResult(rp, viewSet, toolName, view, data, metrics)
 		}
 	}
 
 	// Try markdown rendering first — replaces JSON compaction entirely.
 	if rp.markdown != nil {
 		if md, rendered := rp.markdown(toolName, []byte(data)); rendered {
+			text := string(md)
 			if metrics != nil {
 				metrics.RecordMarkdownRender(toolName, len(data), len(md))
+				metrics.MeasureTokens(mcp.TokenStageMarkdown, data, text)
 			}
-			return string(md)
+			if len(untrusted) > 0 {
+				text = quarantineText(rp, toolName, text, metrics)
+			}
+			return text, 0
 		}
 	}
 
@@ -966,21 +1127,26 @@ func processResult(rp resultProcessor, toolName mcp.ToolName, view compact.ViewA
 	var parsed any
 	if err := json.Unmarshal([]byte(data), &parsed); err != nil {
 		slog.Warn("processResult: unmarshal failed", "tool", toolName, "err", err)
-		return data
+		return data, 0
 	}
 
 	if rp.compact != nil {
 		if fields, ok := rp.compact(toolName); ok {
-			parsed = mcp.CompactAny(parsed, fields)
+			parsed = compactWithMetrics(parsed, fields, toolName, metrics)
 		}
 	}
 
+	// Quarantine after compaction, whose value transforms (truncation)
+	// would otherwise cut envelopes open, and before columnarization
+	// moves fields out of their declared paths.
+	parsed = quarantineValue(rp, toolName, parsed, untrusted, metrics)
+
 	parsed = mcp.ColumnarizeAny(parsed)
 
-	result, err := json.Marshal(parsed)
+	result, err := marshalResult(parsed)
 	if err != nil {
 		slog.Warn("processResult: marshal failed", "tool", toolName, "err", err)
-		return data
+		return data, 0
 	}
 
 	if slog.Default().Handler().Enabled(context.Background(), slog.LevelDebug) {
@@ -996,45 +1162,63 @@ func processResult(rp resultProcessor, toolName mcp.ToolName, view compact.ViewA
 		)
 	}
 
+	text := string(result)
 	if metrics != nil && len(result) < originalLen {
 		metrics.RecordCompaction(toolName, originalLen, len(result))
+		metrics.MeasureTokens(mcp.TokenStageCompaction, data, text)
+	}
+	if slices.Contains(untrusted, mcp.UntrustedWholeResult) {
+		text = quarantineText(rp, toolName, text, metrics)
 	}
 
 	if rp.maxBytes != nil {
-		if limit, ok := rp.maxBytes(toolName); ok && limit > 0 && len(result) > limit {
-			return tooLargeEnvelope(toolName, len(result), limit)
+		if limit, ok := rp.maxBytes(toolName); ok && limit > 0 && len(text) > limit {
+			return text, limit
 		}
 	}
 
-	return string(result)
+	return text, 0
+}
+
+// marshalResult is json.Marshal without HTML escaping: the LLM reads
+// <, > and & more cheaply than \u003c escapes, and untrusted-content
+// envelopes stay legible as tags.
+func marshalResult(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
 }
 
 // processViewsResult handles the multi-view dispatch path. The tool's
 // ViewSet was resolved at load time; here we parse the LLM's selection
 // from args, apply the chosen view's spec, render in the chosen format,
-// and enforce the per-view max_bytes cap.
+// and report the per-view max_bytes cap when the output exceeds it.
 //
 // Unsupported (view, format) combos return a structured error envelope
 // rather than silently falling back — the YAML is the contract, what's
 // not declared is not available.
-func processViewsResult(viewSet compact.ViewSet, toolName mcp.ToolName, view compact.ViewArgs, data string, metrics *mcp.Metrics) string {
+func processViewsResult(rp resultProcessor, viewSet compact.ViewSet, toolName mcp.ToolName, view compact.ViewArgs, data string, metrics *mcp.Metrics) (string, int) {
 	// Parse errors from the boundary surface here as a view envelope. The
 	// parse boundary (compact.ParseViewArgs) catches type errors like
 	// view=123; ViewSet-relative validation (unknown view, undeclared format)
 	// happens in resolveSelection below.
 	if err := view.Err(); err != nil {
-		return viewErrorEnvelope(toolName, err)
+		return viewErrorEnvelope(toolName, err), 0
 	}
 
 	selection, err := resolveSelection(view, viewSet)
 	if err != nil {
-		return viewErrorEnvelope(toolName, err)
+		return viewErrorEnvelope(toolName, err), 0
 	}
 
 	renderer := viewSet.Renderers[selection.View][selection.Format]
 	if renderer == nil {
 		// Loader validated this combo at parse, so a miss here is a bug.
-		return viewErrorEnvelope(toolName, fmt.Errorf("internal: no renderer for (view=%s, format=%s)", selection.View, selection.Format))
+		return viewErrorEnvelope(toolName, fmt.Errorf("internal: no renderer for (view=%s, format=%s)", selection.View, selection.Format)), 0
 	}
 
 	originalLen := len(data)
@@ -1042,34 +1226,42 @@ func processViewsResult(viewSet compact.ViewSet, toolName mcp.ToolName, view com
 	var parsed any
 	if err := json.Unmarshal([]byte(data), &parsed); err != nil {
 		slog.Warn("processViewsResult: unmarshal failed", "tool", toolName, "err", err)
-		return data
+		return data, 0
 	}
 
 	parsedView := viewSet.Views[selection.View]
 	if len(parsedView.Spec) > 0 {
-		parsed = mcp.CompactAny(parsed, parsedView.Spec)
+		parsed = compactWithMetrics(parsed, parsedView.Spec, toolName, metrics)
 	}
 
+	untrusted := rp.untrustedPaths(toolName)
+	parsed = quarantineValue(rp, toolName, parsed, untrusted, metrics)
+
 	out, err := renderer(parsed)
 	if err != nil {
 		slog.Warn("processViewsResult: render failed", "tool", toolName, "view", selection.View, "format", selection.Format, "err", err)
-		return viewErrorEnvelope(toolName, fmt.Errorf("render failed: %w", err))
+		return viewErrorEnvelope(toolName, fmt.Errorf("render failed: %w", err)), 0
+	}
+	if slices.Contains(untrusted, mcp.UntrustedWholeResult) {
+		out = []byte(quarantineText(rp, toolName, string(out), metrics))
 	}
 
 	out = appendMoreHint(out, viewSet, selection)
 
 	if parsedView.MaxBytes > 0 && len(out) > parsedView.MaxBytes {
-		return tooLargeEnvelope(toolName, len(out), parsedView.MaxBytes)
+		return string(out), parsedView.MaxBytes
 	}
 
 	// Match the flat path's contract: only record when output actually shrank.
 	// appendMoreHint can grow the response past input; recording every call
 	// would feed negative savings into compaction-rate monitoring.
+	text := string(out)
 	if metrics != nil && len(out) < originalLen {
 		metrics.RecordCompaction(toolName, originalLen, len(out))
+		metrics.MeasureTokens(mcp.TokenStageViews, data, text)
 	}
 
-	return string(out)
+	return text, 0
 }
 
 // resolveSelection takes the typed-but-unvalidated ViewArgs from the request
@@ -1169,7 +1361,7 @@ func appendMoreHint(out []byte, viewSet compact.ViewSet, selection compact.ViewS
 		return out
 	}
 
-	result, err := json.Marshal(augmented)
+	result, err := marshalResult(augmented)
 	if err != nil {
 		slog.Warn("appendMoreHint: marshal failed; returning unaugmented", "err", err)
 		return out
@@ -1194,6 +1386,27 @@ func viewErrorEnvelope(toolName mcp.ToolName, err error) string {
 	return string(out)
 }
 
+// compactWithMetrics applies a compaction spec and records what its value
+// transforms removed, attributing bytes to fields on a sample of calls.
+func compactWithMetrics(v any, fields []mcp.CompactField, toolName mcp.ToolName, metrics *mcp.Metrics) any {
+	if metrics == nil {
+		return mcp.CompactAny(v, fields)
+	}
+	var before map[string]int
+	sample := metrics.RecordCompactedCall(toolName)
+	if sample {
+		before = mcp.FieldBytes(v)
+	}
+	out, st := mcp.CompactAnyWithStats(v, fields)
+	if st.Values > 0 {
+		metrics.RecordTransforms(toolName, st.Values, st.BytesSaved)
+	}
+	if sample {
+		metrics.RecordFieldAttribution(toolName, before, mcp.CompactedFieldBytes(out, fields))
+	}
+	return out
+}
+
 // tooLargeEnvelope returns a structured JSON error replacing an oversized response.
 // LLM-observable; preserves parseability where a raw truncation would corrupt JSON.
 func tooLargeEnvelope(toolName mcp.ToolName, size, limit int) string {
@@ -1248,6 +1461,8 @@ func (s *Server) computeBackoff(attempt int) time.Duration {
 
 // getBreaker returns the circuit breaker for the given integration, creating one if needed.
 func (s *Server) getBreaker(integrationName string) *breaker {
+	s.breakersMu.Lock()
+	defer s.breakersMu.Unlock()
 	if b, ok := s.breakers[integrationName]; ok {
 		return b
 	}
@@ -1262,7 +1477,18 @@ func (s *Server) getBreaker(integrationName string) *breaker {
 // apply post-processing (e.g. compaction) without a redundant findTool call.
 // Retries automatically on RetryableError (5xx, 429) with exponential backoff.
 // Respects per-integration circuit breakers to avoid hammering down services.
+//
+// Each call is traced as an "execute_tool" span with one child span per
+// attempt.
 func (s *Server) executeTool(ctx context.Context, toolName mcp.ToolName, args map[string]any) (mcp.Integration, *mcp.ToolResult, error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "execute_tool "+string(toolName),
+		trace.WithAttributes(attribute.String("switchboard.tool", string(toolName))))
+	integration, result, err := s.executeToolAttempts(ctx, span, toolName, args)
+	endSpan(span, result, err)
+	return integration, result, err
+}
+
+func (s *Server) executeToolAttempts(ctx context.Context, span trace.Span, toolName mcp.ToolName, args map[string]any) (mcp.Integration, *mcp.ToolResult, error) {
 	integration, toolDef, err := s.findTool(toolName)
 	if err != nil {
 		return nil, &mcp.ToolResult{
@@ -1276,6 +1502,10 @@ func (s *Server) executeTool(ctx context.Context, toolName mcp.ToolName, args ma
 	}
 
 	cb := s.getBreaker(integration.Name())
+	span.SetAttributes(
+		attribute.String("switchboard.integration", integration.Name()),
+		attribute.String("switchboard.breaker.state", cb.state()),
+	)
 	if !cb.allow() {
 		if s.services.Metrics != nil {
 			s.services.Metrics.RecordCircuitBreak(mcp.IntegrationName(integration.Name()))
@@ -1292,22 +1522,22 @@ func (s *Server) executeTool(ctx context.Context, toolName mcp.ToolName, args ma
 	var lastErr error
 	retries := 0
 	var callDuration time.Duration
+	defer func() { span.SetAttributes(attribute.Int("switchboard.retries", retries)) }()
 	for attempt := range maxRetries {
 		callStart := time.Now()
-		result, err := integration.Execute(ctx, toolName, args)
+		attemptCtx, attemptSpan := telemetry.Tracer().Start(ctx, "attempt",
+			trace.WithAttributes(attribute.Int("switchboard.attempt", attempt+1)))
+		result, err := integration.Execute(attemptCtx, toolName, args)
+		endSpan(attemptSpan, result, err)
 		callDuration += time.Since(callStart)
 		if err == nil {
 			cb.recordSuccess()
-			if s.services.Metrics != nil {
-				s.services.Metrics.RecordExecution(mcp.IntegrationName(integration.Name()), toolName, callDuration, false, retries)
-			}
+			s.recordExecution(integration, toolName, args, callDuration, false, retries)
 			return integration, result, nil
 		}
 
 		if !mcp.IsRetryable(err) {
-			if s.services.Metrics != nil {
-				s.services.Metrics.RecordExecution(mcp.IntegrationName(integration.Name()), toolName, callDuration, true, retries)
-			}
+			s.recordExecution(integration, toolName, args, callDuration, true, retries)
 			return nil, result, err
 		}
 		lastErr = err
@@ -1333,12 +1563,21 @@ func (s *Server) executeTool(ctx context.Context, toolName mcp.ToolName, args ma
 
 	// All retries exhausted — record one failure per call (not per attempt).
 	cb.recordFailure()
-	if s.services.Metrics != nil {
-		s.services.Metrics.RecordExecution(mcp.IntegrationName(integration.Name()), toolName, callDuration, true, retries)
-	}
+	s.recordExecution(integration, toolName, args, callDuration, true, retries)
 	return nil, &mcp.ToolResult{Data: lastErr.Error(), IsError: true}, nil
 }
 
+// recordExecution records one finished call in metrics, including the
+// slow-call log, which keeps the call's (redacted) arguments.
+func (s *Server) recordExecution(integration mcp.Integration, toolName mcp.ToolName, args map[string]any, d time.Duration, isError bool, retries int) {
+	if s.services.Metrics == nil {
+		return
+	}
+	name := mcp.IntegrationName(integration.Name())
+	s.services.Metrics.RecordExecution(name, toolName, d, isError, retries)
+	s.services.Metrics.RecordSlowCall(name, toolName, args, d, isError, retries)
+}
+
 // findTool returns the integration and tool definition that owns toolName.
 // Respects ABAC tool glob restrictions. Returns a descriptive error when
 // the tool exists but its integration is not configured.
@@ -1493,9 +1732,11 @@ func paramNames(params map[string]string) []string {
 	return names
 }
 
-// toolExecutor bridges the script.Executor interface to the server's tool dispatch.
+// toolExecutor bridges the script.Executor interface to the server's tool
+// dispatch. scope, when set, applies to every api.call.
 type toolExecutor struct {
 	server *Server
+	scope  argScope
 }
 
 // checkMetaTool rejects meta-tools from script calls.
@@ -1512,14 +1753,47 @@ func (te *toolExecutor) checkMetaTool(toolName mcp.ToolName) *mcp.ToolResult {
 	return nil
 }
 
+// applyScope runs the executor's scope, if any, over a script call.
+func (te *toolExecutor) applyScope(toolName mcp.ToolName, args map[string]any) (map[string]any, *mcp.ToolResult) {
+	if te.scope == nil {
+		return args, nil
+	}
+	return te.scope(toolName, args)
+}
+
 func (te *toolExecutor) Execute(ctx context.Context, toolName mcp.ToolName, args map[string]any) (*mcp.ToolResult, error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "api.call "+string(toolName),
+		trace.WithAttributes(attribute.String("switchboard.tool", string(toolName))))
+	result, err := te.execute(ctx, toolName, args)
+	endSpan(span, result, err)
+	return result, err
+}
+
+func (te *toolExecutor) execute(ctx context.Context, toolName mcp.ToolName, args map[string]any) (*mcp.ToolResult, error) {
 	if r := te.checkMetaTool(toolName); r != nil {
 		return r, nil
 	}
-	// Integration is discarded: script-path calls intentionally skip per-tool
-	// compaction so scripts can access all fields by name before projecting.
-	_, result, err := te.server.executeTool(ctx, toolName, args)
-	return result, err
+	args, denied := te.applyScope(toolName, args)
+	if denied != nil {
+		return denied, nil
+	}
+	// Script-path calls intentionally skip per-tool compaction so scripts
+	// can access all fields by name before projecting. Redaction still
+	// applies, since whatever the script returns reaches the LLM; untrusted
+	// content taints the script instead, and runScript quarantines its
+	// output.
+	integration, result, err := te.server.executeTool(ctx, toolName, args)
+	switch {
+	case err != nil:
+		return result, te.server.redactErr(integration, toolName, err)
+	case result == nil:
+	case result.IsError:
+		result.Data = te.server.redactFailure(integration, toolName, result.Data)
+	default:
+		result.Data = te.server.redact(integration, toolName, result.Data, true)
+		te.server.taintScript(ctx, toolName)
+	}
+	return result, nil
 }
 
 // ExecuteRendered applies the same markdown/compaction/columnarization pipeline
@@ -1528,11 +1802,20 @@ func (te *toolExecutor) ExecuteRendered(ctx context.Context, toolName mcp.ToolNa
 	if r := te.checkMetaTool(toolName); r != nil {
 		return r, nil
 	}
+	args, denied := te.applyScope(toolName, args)
+	if denied != nil {
+		return denied, nil
+	}
 	integration, result, err := te.server.executeTool(ctx, toolName, args)
 	if err != nil {
-		return nil, err
+		return nil, te.server.redactErr(integration, toolName, err)
+	}
+	te.server.applyResultProcessing(integration, toolName, compact.ParseViewArgs(args), result)
+	if result.IsError {
+		result.Data = te.server.redactFailure(integration, toolName, result.Data)
+	} else {
+		result.Data = te.server.redact(integration, toolName, result.Data, true)
 	}
-	applyResultProcessing(integration, toolName, compact.ParseViewArgs(args), result, te.server.services.Metrics)
 	return result, nil
 }
 
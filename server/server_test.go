@@ -1446,6 +1446,72 @@ func TestProcessViews_NoCompactionRecordedWhenOutputGrows(t *testing.T) {
 		"RecordCompaction must not fire when output grew past input (negative savings)")
 }
 
+func TestProcessResult_RecordsRawResponse(t *testing.T) {
+	var recorded []string
+	rp := resultProcessor{
+		compact: func(_ mcp.ToolName) ([]mcp.CompactField, bool) {
+			return mustParseCompactSpecs(t, []string{"id"}), true
+		},
+		record: func(tool mcp.ToolName, data []byte) { recorded = append(recorded, string(tool)+" "+string(data)) },
+	}
+
+	got := processResult(rp, "tool", compact.ViewArgs{}, `{"id":1,"secret":"s"}`, nil)
+	assert.JSONEq(t, `{"id":1}`, got)
+	processResult(rp, "tool", compact.ViewArgs{}, `plain text`, nil)
+
+	assert.Equal(t, []string{`tool {"id":1,"secret":"s"}`}, recorded, "the recorder sees the uncompacted JSON response only")
+}
+
+func TestProcessResult_RecordsTransforms(t *testing.T) {
+	fields := mustParseCompactSpecs(t, []string{"id", "body|truncate:10"})
+	rp := resultProcessor{
+		compact: func(_ mcp.ToolName) ([]mcp.CompactField, bool) { return fields, true },
+	}
+	metrics := mcp.NewMetrics()
+
+	got := processResult(rp, "tool", compact.ViewArgs{}, `{"id":1,"body":"`+strings.Repeat("x", 50)+`"}`, metrics)
+	assert.JSONEq(t, `{"id":1,"body":"xxxxxxxxx…"}`, got)
+
+	snap := metrics.Snapshot()
+	assert.Equal(t, int64(1), snap.TransformValues)
+	assert.Equal(t, int64(50-len("xxxxxxxxx…")), snap.TransformBytesSaved)
+}
+
+type byteTokenizer struct{}
+
+func (byteTokenizer) Name() string                { return "bytes" }
+func (byteTokenizer) CountTokens(text string) int { return len(text) }
+
+func TestProcessResult_MeasuresTokensPerStage(t *testing.T) {
+	fields := mustParseCompactSpecs(t, []string{"id"})
+	rp := resultProcessor{
+		compact: func(_ mcp.ToolName) ([]mcp.CompactField, bool) { return fields, true },
+		markdown: func(tool mcp.ToolName, _ []byte) (mcp.Markdown, bool) {
+			return "# doc", tool == "doc_tool"
+		},
+	}
+	metrics := mcp.NewMetrics()
+	metrics.SetTokenizer(byteTokenizer{})
+
+	in := `{"id":1,"body":"text"}`
+	got := processResult(rp, "tool", compact.ViewArgs{}, in, metrics)
+	for range mcp.TokenSampleEvery - 1 {
+		processResult(rp, "tool", compact.ViewArgs{}, in, metrics)
+	}
+	processResult(rp, "doc_tool", compact.ViewArgs{}, in, metrics)
+	metrics.FlushTokenSamples()
+
+	tm := metrics.Snapshot().TokenMeasurement
+	require.NotNil(t, tm)
+	assert.Equal(t, mcp.TokenStageSnapshot{
+		Samples: 1, BytesBefore: int64(len(in)), BytesAfter: int64(len(got)),
+		TokensBefore: int64(len(in)), TokensAfter: int64(len(got)),
+		EstimatedTokensBefore: mcp.BytesToTokens(int64(len(in))), EstimatedTokensAfter: mcp.BytesToTokens(int64(len(got))),
+	}, tm.Stages[mcp.TokenStageCompaction])
+	assert.Equal(t, int64(1), tm.Stages[mcp.TokenStageMarkdown].Samples)
+	assert.Equal(t, int64(len("# doc")), tm.Stages[mcp.TokenStageMarkdown].TokensAfter)
+}
+
 // Map iteration order in Go is non-deterministic. Error messages built
 // by iterating viewSet.Views or viewSet.Renderers will flake any test
 // that asserts substring content. listViewNames and listFormats must
@@ -1476,6 +1542,42 @@ func TestListFormats_SortedOutput(t *testing.T) {
 		"formats must be sorted for deterministic error messages")
 }
 
+func TestProcessViews_BuiltinTabularFormatsRecordSavings(t *testing.T) {
+	res, err := compact.Load([]byte(`version: 1
+tools:
+  list:
+    views:
+      rows:
+        spec: [id, title]
+        formats: [json, csv, toon]
+    default:
+      view: rows
+      format: json
+`), compact.Options{Strict: true})
+	require.NoError(t, err)
+	vs := res.Views["list"]
+	rp := resultProcessor{
+		views: func(_ mcp.ToolName) (compact.ViewSet, bool) { return vs, true },
+	}
+
+	items := make([]map[string]any, 20)
+	for i := range items {
+		items[i] = map[string]any{"id": i + 1, "title": fmt.Sprintf("Issue %d", i+1), "url": "https://example.com/issues"}
+	}
+	input, _ := json.Marshal(items)
+
+	metrics := mcp.NewMetrics()
+	got := processResult(rp, "list", compact.ViewArgs{Format: compact.FormatCSV}, string(input), metrics)
+	assert.True(t, strings.HasPrefix(got, "id,title\n1,Issue 1\n"), got)
+
+	got = processResult(rp, "list", compact.ViewArgs{Format: compact.FormatTOON}, string(input), metrics)
+	assert.True(t, strings.HasPrefix(got, "[20]{id,title}:\n  1,Issue 1\n"), got)
+
+	snap := metrics.Snapshot()
+	assert.Equal(t, 2, snap.CompactionSamples)
+	assert.Greater(t, snap.CompactionBytesSaved, int64(len(input)))
+}
+
 func TestProcessViews_MoreEnvelopeOnObjectRoot(t *testing.T) {
 	vs := buildTestViewSet(t)
 	rp := resultProcessor{
@@ -1668,11 +1770,15 @@ func TestHandleExecute_ByteCapEnforced(t *testing.T) {
 	s := setupTestServer(mi)
 	result, err := s.handleExecute(context.Background(), executeRequest("testint_big", nil))
 	require.NoError(t, err)
-	assert.True(t, result.IsError, "over-cap response should return error")
+	assert.False(t, result.IsError, "over-cap response should be paged, not rejected")
+	require.Len(t, result.Content, 2)
 
-	tc := result.Content[0].(*mcpsdk.TextContent)
+	page := result.Content[0].(*mcpsdk.TextContent)
+	assert.LessOrEqual(t, len(page.Text), defaultMaxResponseBytes)
+	tc := result.Content[1].(*mcpsdk.TextContent)
 	capKB := fmt.Sprintf("%dKB", defaultMaxResponseBytes/1024)
 	assert.Contains(t, tc.Text, capKB)
+	assert.Contains(t, tc.Text, `"cursor":"$1#page=2"`)
 }
 
 func TestHandleExecute_ByteCapSkippedOnError(t *testing.T) {
@@ -1770,10 +1876,13 @@ func TestHandleExecute_PerIntegrationCapStillEnforced(t *testing.T) {
 	s := setupTestServerWithIntegration(mi)
 	result, err := s.handleExecute(context.Background(), executeRequest("bigint_get_page", nil))
 	require.NoError(t, err)
-	assert.True(t, result.IsError, "response above per-integration cap should be rejected")
+	assert.False(t, result.IsError, "response above per-integration cap should be paged")
+	require.Len(t, result.Content, 2)
 
-	tc := result.Content[0].(*mcpsdk.TextContent)
-	assert.Contains(t, tc.Text, "256KB", "error should report the integration's own cap")
+	page := result.Content[0].(*mcpsdk.TextContent)
+	assert.LessOrEqual(t, len(page.Text), 256*1024)
+	tc := result.Content[1].(*mcpsdk.TextContent)
+	assert.Contains(t, tc.Text, "256KB", "paging note should report the integration's own cap")
 }
 
 // mockIntegrationWithPerToolCap also implements
@@ -1840,11 +1949,12 @@ func TestHandleExecute_PerToolCapDoesNotLeakToOtherTools(t *testing.T) {
 	s := setupTestServerWithIntegration(mi)
 	result, err := s.handleExecute(context.Background(), executeRequest("bigint_get_thing", nil))
 	require.NoError(t, err)
-	assert.True(t, result.IsError, "other tools on the same integration must use the default cap")
+	assert.False(t, result.IsError)
+	require.Len(t, result.Content, 2, "other tools on the same integration must be paged at the default cap")
 
-	tc := result.Content[0].(*mcpsdk.TextContent)
+	tc := result.Content[1].(*mcpsdk.TextContent)
 	capKB := fmt.Sprintf("%dKB", defaultMaxResponseBytes/1024)
-	assert.Contains(t, tc.Text, capKB, "error should report the default cap, not the per-tool override")
+	assert.Contains(t, tc.Text, capKB, "paging note should report the default cap, not the per-tool override")
 }
 
 // mockIntegrationWithBothCaps implements both the integration-wide cap and the
@@ -1920,6 +2030,116 @@ func TestExecuteTool_SingleTool(t *testing.T) {
 	assert.Equal(t, "testint", integration.Name())
 }
 
+func TestRecordExecution_LogsSlowCallsWithRedactedArgs(t *testing.T) {
+	mi := &mockIntegration{name: "testint", healthy: true}
+	s := setupTestServer(mi)
+	s.services.Metrics = mcp.NewMetrics()
+
+	s.recordExecution(mi, "testint_get_item", map[string]any{"id": "1"}, 10*time.Millisecond, false, 0)
+	s.recordExecution(mi, "testint_search", map[string]any{"q": "x", "access_token": "secret"}, 2*time.Second, true, 1)
+
+	snap := s.services.Metrics.Snapshot()
+	assert.Equal(t, int64(2), snap.TotalExecutions)
+	require.Len(t, snap.SlowCalls, 1)
+	assert.Equal(t, mcp.ToolName("testint_search"), snap.SlowCalls[0].Tool)
+	assert.Equal(t, 1, snap.SlowCalls[0].Retries)
+	assert.Equal(t, map[string]any{"q": "[1-byte string]", "access_token": mcp.RedactedArg}, snap.SlowCalls[0].Args)
+
+	s.services.Metrics = nil
+	s.recordExecution(mi, "testint_search", nil, time.Second, false, 0) // no panic without metrics
+}
+
+func TestHandleExecute_Redaction(t *testing.T) {
+	mi := &mockIntegration{
+		name:    "testint",
+		healthy: true,
+		tools: []mcp.ToolDefinition{
+			{Name: mcp.ToolName("testint_get_user"), Description: "Get user"},
+		},
+		execFn: func(_ context.Context, _ mcp.ToolName, _ map[string]any) (*mcp.ToolResult, error) {
+			return &mcp.ToolResult{Data: `{"name":"Bob","email":"bob@example.com","note":"badge B-1234"}`}, nil
+		},
+	}
+	s := setupTestServer(mi)
+	s.services.Metrics = mcp.NewMetrics()
+
+	result, err := s.handleExecute(context.Background(), executeRequest("testint_get_user", nil))
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].(*mcpsdk.TextContent).Text, "bob@example.com", "off by default")
+
+	cfg := s.services.Config.Get()
+	cfg.Redaction = &mcp.RedactionConfig{
+		Enabled: true,
+		Rules:   []mcp.RedactionRule{{Type: "badge", Pattern: `B-\d{4}`, ToolGlobs: []string{"testint_get_*"}}},
+	}
+	require.NoError(t, s.services.Config.Update(cfg))
+
+	ctx := context.Background()
+	result, err = s.handleExecute(ctx, executeRequest("testint_get_user", nil))
+	require.NoError(t, err)
+	text := result.Content[0].(*mcpsdk.TextContent).Text
+	assert.NotContains(t, text, "bob@example.com")
+	assert.Contains(t, text, mcp.RedactionPlaceholder(mcp.RedactEmail))
+	assert.Contains(t, text, mcp.RedactionPlaceholder("badge"))
+
+	snap := s.services.Metrics.Snapshot()
+	assert.Equal(t, map[string]int64{mcp.RedactEmail: 1, "badge": 1}, snap.Redactions, "the pinned copy is not counted twice")
+	assert.Equal(t, int64(2), snap.Tools["testint_get_user"].Redactions)
+
+	out, err := s.scriptEngine.Run(ctx, `api.call("testint_get_user", {}).email`)
+	require.NoError(t, err)
+	assert.Equal(t, `"`+mcp.RedactionPlaceholder(mcp.RedactEmail)+`"`, out.Data, "script calls are redacted before the script sees them")
+}
+
+func TestHandleExecute_RedactsErrors(t *testing.T) {
+	mi := &mockIntegration{
+		name:    "testint",
+		healthy: true,
+		tools: []mcp.ToolDefinition{
+			{Name: mcp.ToolName("testint_get_user"), Description: "Get user"},
+			{Name: mcp.ToolName("testint_update_user"), Description: "Update user"},
+		},
+		execFn: func(_ context.Context, toolName mcp.ToolName, _ map[string]any) (*mcp.ToolResult, error) {
+			if toolName == "testint_update_user" {
+				return nil, fmt.Errorf("upstream rejected bob@example.com")
+			}
+			return &mcp.ToolResult{Data: `user bob@example.com is suspended`, IsError: true}, nil
+		},
+	}
+	s := setupTestServer(mi)
+	s.services.Metrics = mcp.NewMetrics()
+	cfg := s.services.Config.Get()
+	cfg.Redaction = &mcp.RedactionConfig{Enabled: true}
+	require.NoError(t, s.services.Config.Update(cfg))
+	ctx := context.Background()
+	email := mcp.RedactionPlaceholder(mcp.RedactEmail)
+
+	for _, tool := range []string{"testint_get_user", "testint_update_user"} {
+		result, err := s.handleExecute(ctx, executeRequest(tool, nil))
+		require.NoError(t, err)
+		require.True(t, result.IsError)
+		text := result.Content[0].(*mcpsdk.TextContent).Text
+		assert.NotContains(t, text, "bob@example.com", tool)
+		assert.Contains(t, text, email, tool)
+
+		sess := s.sessionStore.GetOrCreate(defaultSessionID)
+		crumb := sess.Breadcrumbs[len(sess.Breadcrumbs)-1]
+		assert.True(t, crumb.IsError)
+		assert.NotContains(t, crumb.Summary, "bob@example.com", "%s breadcrumb", tool)
+	}
+	assert.Equal(t, map[string]int64{mcp.RedactEmail: 2}, s.services.Metrics.Snapshot().Redactions)
+
+	for _, src := range []string{
+		`api.tryCall("testint_get_user", {}).error`,
+		`api.tryCall("testint_update_user", {}).error`,
+		`api.callRendered("testint_get_user", {})`,
+	} {
+		out, err := s.scriptEngine.Run(ctx, src)
+		require.NoError(t, err)
+		assert.NotContains(t, out.Data, "bob@example.com", src)
+	}
+}
+
 func TestExecuteTool_NotFound(t *testing.T) {
 	s := setupTestServer()
 	integration, result, err := s.executeTool(context.Background(), "nonexistent_tool", map[string]any{})
@@ -2460,12 +2680,16 @@ func TestScriptExecution_OutputByteCapEnforced(t *testing.T) {
 	}
 	result, err := s.handleExecute(context.Background(), req)
 	require.NoError(t, err)
-	assert.True(t, result.IsError, "over-cap script output should return error")
+	assert.False(t, result.IsError, "over-cap script output should be paged, not rejected")
+	require.Len(t, result.Content, 2)
 
-	tc := result.Content[0].(*mcpsdk.TextContent)
+	page := result.Content[0].(*mcpsdk.TextContent)
+	assert.LessOrEqual(t, len(page.Text), defaultMaxResponseBytes)
+	tc := result.Content[1].(*mcpsdk.TextContent)
 	capKB := fmt.Sprintf("%dKB", defaultMaxResponseBytes/1024)
 	assert.Contains(t, tc.Text, capKB)
 	assert.Contains(t, tc.Text, "Script output exceeded")
+	assert.Contains(t, tc.Text, `"cursor":"$1#page=2"`)
 }
 
 func TestScriptExecution_OutputByteCapSkippedOnError(t *testing.T) {
@@ -3767,3 +3991,66 @@ func TestWithExtraInstructions(t *testing.T) {
 		})
 	}
 }
+
+func compactBenchmarkInput() (any, []mcp.CompactField) {
+	items := make([]any, 100)
+	for i := range items {
+		items[i] = map[string]any{
+			"number": float64(i + 1),
+			"title":  "Issue title for benchmarking field attribution",
+			"state":  "open",
+			"body":   strings.Repeat("Long issue body with a detailed description of the bug. ", 20),
+			"user":   map[string]any{"login": "developer", "id": float64(999), "avatar_url": "https://avatars.githubusercontent.com/u/999"},
+			"labels": []any{map[string]any{"name": "bug", "color": "d73a4a"}, map[string]any{"name": "P1", "color": "e11d48"}},
+		}
+	}
+	fields, _ := mcp.ParseCompactSpecs([]string{"number", "title", "state", "user.login", "labels[].name"})
+	return items, fields
+}
+
+func TestCompactWithMetrics_SamplesFieldAttribution(t *testing.T) {
+	v, fields := compactBenchmarkInput()
+	metrics := mcp.NewMetrics()
+
+	for range mcp.FieldAttributionSampleEvery + 1 {
+		compactWithMetrics(v, fields, "gh_list_issues", metrics)
+	}
+
+	tool := metrics.Snapshot().CompactionTools["gh_list_issues"]
+	assert.Equal(t, int64(mcp.FieldAttributionSampleEvery+1), tool.Calls)
+	assert.Equal(t, int64(2), tool.Samples, "the first call and the first of the next batch are attributed")
+	require.NotEmpty(t, tool.Fields)
+	assert.Equal(t, "body", tool.Fields[0].Path)
+}
+
+// BenchmarkCompactWithMetrics compares compaction alone with attributing
+// field bytes on every call and on the sampled calls compactWithMetrics
+// attributes.
+func BenchmarkCompactWithMetrics(b *testing.B) {
+	v, fields := compactBenchmarkInput()
+
+	b.Run("no_metrics", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			compactWithMetrics(v, fields, "gh_list_issues", nil)
+		}
+	})
+
+	b.Run("attribute_every_call", func(b *testing.B) {
+		metrics := mcp.NewMetrics()
+		b.ReportAllocs()
+		for b.Loop() {
+			before := mcp.FieldBytes(v)
+			out, _ := mcp.CompactAnyWithStats(v, fields)
+			metrics.RecordFieldAttribution("gh_list_issues", before, mcp.CompactedFieldBytes(out, fields))
+		}
+	})
+
+	b.Run("sampled", func(b *testing.B) {
+		metrics := mcp.NewMetrics()
+		b.ReportAllocs()
+		for b.Loop() {
+			compactWithMetrics(v, fields, "gh_list_issues", metrics)
+		}
+	})
+}
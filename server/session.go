@@ -20,6 +20,8 @@ type Breadcrumb struct {
 	Args      map[string]any `json:"args,omitempty"`
 	Summary   string         `json:"summary,omitempty"`
 	IsError   bool           `json:"is_error,omitempty"`
+	// From is the session a breadcrumb was imported from, if any.
+	From string `json:"from,omitempty"`
 }
 
 type Session struct {
@@ -140,18 +142,25 @@ func (s *Session) touch() {
 // SessionStore is the pluggable storage interface for sessions.
 // The default implementation is in-memory (MemorySessionStore).
 // The commercial version can plug in Postgres, an API shim, etc.
+//
+// Snapshots are copies of a session that outlive it, stored under random
+// IDs, so one client can hand its context, breadcrumbs and pinned results to
+// another it shares the ID with.
 type SessionStore interface {
 	GetOrCreate(id string) *Session
 	Get(id string) (*Session, bool)
 	Save(s *Session) error
 	Delete(id string)
+	SaveSnapshot(snap *SessionSnapshot) error
+	LoadSnapshot(id string) (*SessionSnapshot, error)
 }
 
 // MemorySessionStore keeps sessions in memory with TTL-based eviction.
 type MemorySessionStore struct {
-	mu       sync.RWMutex
-	sessions map[string]*Session
-	ttl      time.Duration
+	mu        sync.RWMutex
+	sessions  map[string]*Session
+	snapshots map[string]*SessionSnapshot
+	ttl       time.Duration
 }
 
 func NewMemorySessionStore(ttl time.Duration) *MemorySessionStore {
@@ -159,8 +168,9 @@ func NewMemorySessionStore(ttl time.Duration) *MemorySessionStore {
 		ttl = DefaultSessionTTL
 	}
 	return &MemorySessionStore{
-		sessions: make(map[string]*Session),
-		ttl:      ttl,
+		sessions:  make(map[string]*Session),
+		snapshots: make(map[string]*SessionSnapshot),
+		ttl:       ttl,
 	}
 }
 
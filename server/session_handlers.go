@@ -3,6 +3,8 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
 
 	mcp "github.com/daltoniam/switchboard"
 	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
@@ -17,6 +19,8 @@ func (s *Server) handleSession(ctx context.Context, req *mcpsdk.CallToolRequest)
 	var args struct {
 		Action  string         `json:"action"`
 		Context map[string]any `json:"context"`
+		Name    string         `json:"name"`
+		ID      string         `json:"id"`
 	}
 	if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
 		return errorResult("invalid arguments: " + err.Error()), nil
@@ -39,8 +43,12 @@ func (s *Server) handleSession(ctx context.Context, req *mcpsdk.CallToolRequest)
 	case "clear":
 		sess.ClearContext()
 		_ = s.sessionStore.Save(sess)
+	case "export":
+		return s.exportSession(sess, args.Name)
+	case "import":
+		return s.importSession(sess, args.ID)
 	default:
-		return errorResult("unknown action: " + args.Action + ". Valid actions: set, get, clear"), nil
+		return errorResult("unknown action: " + args.Action + ". Valid actions: set, get, clear, export, import"), nil
 	}
 
 	result, err := mcp.JSONResult(map[string]any{
@@ -57,10 +65,71 @@ func (s *Server) handleSession(ctx context.Context, req *mcpsdk.CallToolRequest)
 	}, nil
 }
 
+// exportSession snapshots sess under a fresh random ID. Only the exporter is
+// told the ID; whoever it is shared with can import the snapshot until it
+// expires.
+func (s *Server) exportSession(sess *Session, name string) (*mcpsdk.CallToolResult, error) {
+	if name != "" && !snapshotNameRE.MatchString(name) {
+		return errorResult(fmt.Sprintf("invalid snapshot name %q: use letters, digits, '.', '_' or '-'", name)), nil
+	}
+	id := newSnapshotID()
+	key, err := snapshotKey(sess, id)
+	if err != nil {
+		return errorResult(err.Error()), nil
+	}
+	snap := sess.Snapshot(key, name)
+	if err := s.sessionStore.SaveSnapshot(snap); err != nil {
+		return errorResult("save snapshot: " + err.Error()), nil
+	}
+
+	result, err := mcp.JSONResult(map[string]any{
+		"session_id": sess.ID,
+		"exported":   snapshotSummary(id, snap),
+		"hint": fmt.Sprintf("Another agent can continue from here with session({action: \"import\", id: %q}). "+
+			"Anyone given this ID can import the snapshot until it expires, so share it only with agents that should see this session.", id),
+	})
+	if err != nil {
+		return errorResult(err.Error()), nil
+	}
+	return &mcpsdk.CallToolResult{
+		Content: []mcpsdk.Content{&mcpsdk.TextContent{Text: result.Data}},
+	}, nil
+}
+
+func (s *Server) importSession(sess *Session, id string) (*mcpsdk.CallToolResult, error) {
+	if id == "" {
+		return errorResult("\"id\" is required for \"import\" action: use the id returned by \"export\""), nil
+	}
+	key, err := snapshotKey(sess, id)
+	if err != nil {
+		return errorResult(err.Error()), nil
+	}
+	snap, err := s.sessionStore.LoadSnapshot(key)
+	if err != nil {
+		return errorResult(fmt.Sprintf("snapshot %q: %v", id, err)), nil
+	}
+	handles := sess.Attach(snap)
+	_ = s.sessionStore.Save(sess)
+
+	result, err := mcp.JSONResult(map[string]any{
+		"session_id": sess.ID,
+		"imported":   snapshotSummary(id, snap),
+		"context":    sess.GetContext(),
+		"handles":    handles,
+	})
+	if err != nil {
+		return errorResult(err.Error()), nil
+	}
+	return &mcpsdk.CallToolResult{
+		Content: []mcpsdk.Content{&mcpsdk.TextContent{Text: result.Data}},
+	}, nil
+}
+
 func (s *Server) handleHistory(ctx context.Context, req *mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
 	var args struct {
 		LastN int    `json:"last_n"`
 		Tool  string `json:"tool"`
+		Query string `json:"query"`
 	}
 	if req.Params.Arguments != nil {
 		if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
@@ -79,7 +148,14 @@ func (s *Server) handleHistory(ctx context.Context, req *mcpsdk.CallToolRequest)
 		sess = s.sessionStore.GetOrCreate(sessionIDFromReq(req.Session))
 	}
 
+	if args.Query != "" {
+		return s.searchHistory(sess, args.Query, args.LastN)
+	}
+
 	bcs := sess.RecentBreadcrumbs(args.LastN, mcp.ToolName(args.Tool))
+	for i := range bcs {
+		bcs[i].Summary = s.quarantineStored(bcs[i].Tool, bcs[i].Summary)
+	}
 
 	result, err := mcp.JSONResult(map[string]any{
 		"session_id":       sess.ID,
@@ -97,11 +173,47 @@ func (s *Server) handleHistory(ctx context.Context, req *mcpsdk.CallToolRequest)
 	}, nil
 }
 
+// searchHistory full-text searches the session's pinned results, through the
+// store's index when it has one.
+func (s *Server) searchHistory(sess *Session, query string, limit int) (*mcpsdk.CallToolResult, error) {
+	var matches []PinMatch
+	indexed := false
+	if ps, ok := s.sessionStore.(PinSearcher); ok {
+		var err error
+		if matches, err = ps.SearchPinned(sess.ID, query, limit); err != nil {
+			return errorResult(err.Error()), nil
+		}
+		indexed = true
+	} else {
+		matches = sess.SearchPinned(query, limit)
+	}
+	for i := range matches {
+		matches[i].Snippet = s.quarantineStored(matches[i].Tool, matches[i].Snippet)
+	}
+
+	body := map[string]any{
+		"session_id": sess.ID,
+		"query":      query,
+		"matches":    matches,
+	}
+	if !indexed {
+		body["note"] = "Only results still pinned in memory were searched; set session_store to \"sqlite\" to search every result in the session."
+	}
+	result, err := mcp.JSONResult(body)
+	if err != nil {
+		return errorResult(err.Error()), nil
+	}
+	return &mcpsdk.CallToolResult{
+		Content: []mcpsdk.Content{&mcpsdk.TextContent{Text: result.Data}},
+	}, nil
+}
+
 func (s *Server) handlePin(ctx context.Context, req *mcpsdk.CallToolRequest) (*mcpsdk.CallToolResult, error) {
 	var args struct {
 		Action string `json:"action"`
 		Handle string `json:"handle"`
 		Path   string `json:"path"`
+		Cursor string `json:"cursor"`
 	}
 	if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
 		return errorResult("invalid arguments: " + err.Error()), nil
@@ -146,8 +258,10 @@ func (s *Server) handlePin(ctx context.Context, req *mcpsdk.CallToolRequest) (*m
 			return errorResult("\"handle\" is required for \"get\" action"), nil
 		}
 		ref := args.Handle
-		if args.Path != "" {
+		if args.Path != "" && !strings.HasPrefix(args.Path, "[") {
 			ref = args.Handle + "." + args.Path
+		} else {
+			ref = args.Handle + args.Path
 		}
 		val, err := sess.ResolveRef(ref)
 		if err != nil {
@@ -157,8 +271,40 @@ func (s *Server) handlePin(ctx context.Context, req *mcpsdk.CallToolRequest) (*m
 		if err != nil {
 			return errorResult(err.Error()), nil
 		}
+		text := result.Data
+		if pr, ok := sess.GetPinned(args.Handle); ok {
+			text = s.quarantineStored(pr.Tool, text)
+		}
 		return &mcpsdk.CallToolResult{
-			Content: []mcpsdk.Content{&mcpsdk.TextContent{Text: result.Data}},
+			Content: []mcpsdk.Content{&mcpsdk.TextContent{Text: text}},
+		}, nil
+
+	case "page":
+		cursor := args.Cursor
+		if cursor == "" {
+			cursor = args.Handle
+		}
+		if cursor == "" {
+			return errorResult("\"cursor\" is required for \"page\" action"), nil
+		}
+		if !strings.Contains(cursor, "#") {
+			cursor = pageCursor(cursor, 1)
+		}
+		pg, err := sess.Page(cursor)
+		if err != nil {
+			return errorResult(err.Error()), nil
+		}
+		handle, _, _ := parsePageCursor(cursor)
+		// Paged pins hold processed output, quarantined when it was made;
+		// other pins hold the raw result.
+		if pr, ok := sess.GetPinned(handle); ok && pr.PageBytes == 0 {
+			pg.Data = s.quarantineStored(pr.Tool, pg.Data)
+		}
+		return &mcpsdk.CallToolResult{
+			Content: []mcpsdk.Content{
+				&mcpsdk.TextContent{Text: pg.Data},
+				&mcpsdk.TextContent{Text: pageNav(handle, pg)},
+			},
 		}, nil
 
 	case "unpin":
@@ -179,6 +325,6 @@ func (s *Server) handlePin(ctx context.Context, req *mcpsdk.CallToolRequest) (*m
 		}, nil
 
 	default:
-		return errorResult("unknown action: " + args.Action + ". Valid actions: list, get, unpin"), nil
+		return errorResult("unknown action: " + args.Action + ". Valid actions: list, get, page, unpin"), nil
 	}
 }
@@ -0,0 +1,258 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+
+	mcp "github.com/daltoniam/switchboard"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// pageCursorRE matches a paging cursor such as "$7#page=2".
+var pageCursorRE = regexp.MustCompile(`^(\$\d+)#page=(\d+)$`)
+
+// resultPage is one page of a pinned result.
+//
+// A result holding a JSON array, or an object with a nested array, is paged
+// by array element: the largest array is split and the rest of the object is
+// repeated on every page, so each page is a valid document of the same shape.
+// Anything else, including objects whose other fields alone exceed a page, is
+// paged by byte range.
+type resultPage struct {
+	Data  string
+	Page  int
+	Pages int
+	Unit  string // "items" or "bytes"
+	// From and To are the 1-based, inclusive range of items or bytes on the
+	// page, out of Total.
+	From, To, Total int
+}
+
+type pageSpan struct{ start, end int }
+
+func pageCursor(handle string, page int) string {
+	return handle + "#page=" + strconv.Itoa(page)
+}
+
+func parsePageCursor(cursor string) (handle string, page int, err error) {
+	m := pageCursorRE.FindStringSubmatch(cursor)
+	if m == nil {
+		return "", 0, fmt.Errorf("invalid cursor %q: expected a handle and page such as \"$7#page=2\"", cursor)
+	}
+	page, err = strconv.Atoi(m[2])
+	if err != nil || page < 1 {
+		return "", 0, fmt.Errorf("invalid cursor %q: page must be at least 1", cursor)
+	}
+	return m[1], page, nil
+}
+
+// Page returns the page of a pinned result named by cursor ("$7#page=2").
+// Results pinned by PinPaged keep the page size they were cut to; any other
+// pinned result is paged at the default response cap.
+func (s *Session) Page(cursor string) (resultPage, error) {
+	handle, n, err := parsePageCursor(cursor)
+	if err != nil {
+		return resultPage{}, err
+	}
+	pr, ok := s.GetPinned(handle)
+	if !ok {
+		return resultPage{}, fmt.Errorf("no pinned result for handle %q", handle)
+	}
+	size := pr.PageBytes
+	if size <= 0 {
+		size = defaultMaxResponseBytes
+	}
+	pg, err := pageOf(pr.Data, size, n)
+	if err != nil {
+		return resultPage{}, fmt.Errorf("%s: %w", handle, err)
+	}
+	return pg, nil
+}
+
+// pageOf returns page n (1-based) of data split into pages of at most
+// pageBytes.
+func pageOf(data []byte, pageBytes, n int) (resultPage, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err == nil {
+		if _, err := dec.Token(); err == io.EOF {
+			if text, ok := v.(string); ok {
+				return bytePage(text, pageBytes, n)
+			}
+			if pg, ok, err := itemPage(v, pageBytes, n); ok {
+				return pg, err
+			}
+		}
+	}
+	return bytePage(string(data), pageBytes, n)
+}
+
+// itemPage pages v by the elements of its largest array. It reports false
+// when v has no array to split or a single element does not fit on a page.
+func itemPage(v any, pageBytes, n int) (resultPage, bool, error) {
+	path, arr := largestArray(v)
+	if len(arr) == 0 {
+		return resultPage{}, false, nil
+	}
+	elems := make([]json.RawMessage, len(arr))
+	for i, el := range arr {
+		b, err := json.Marshal(el)
+		if err != nil {
+			return resultPage{}, false, nil
+		}
+		elems[i] = b
+	}
+	shell, err := json.Marshal(withArray(v, path, []any{}))
+	if err != nil {
+		return resultPage{}, false, nil
+	}
+	budget := pageBytes - len(shell)
+	if budget <= 0 {
+		return resultPage{}, false, nil
+	}
+
+	var spans []pageSpan
+	cur, size := pageSpan{}, 0
+	for i, el := range elems {
+		if len(el) > budget {
+			return resultPage{}, false, nil
+		}
+		w := len(el)
+		if i > cur.start {
+			w++ // separating comma
+		}
+		if size+w > budget {
+			spans = append(spans, pageSpan{cur.start, i})
+			cur, w = pageSpan{start: i}, len(el)
+			size = 0
+		}
+		size += w
+	}
+	spans = append(spans, pageSpan{cur.start, len(elems)})
+	if n > len(spans) {
+		return resultPage{}, true, fmt.Errorf("page %d is out of range: the result has %d pages", n, len(spans))
+	}
+
+	sp := spans[n-1]
+	items := make([]any, 0, sp.end-sp.start)
+	for _, el := range elems[sp.start:sp.end] {
+		items = append(items, el)
+	}
+	out, err := json.Marshal(withArray(v, path, items))
+	if err != nil {
+		return resultPage{}, false, nil
+	}
+	return resultPage{
+		Data:  string(out),
+		Page:  n,
+		Pages: len(spans),
+		Unit:  "items",
+		From:  sp.start + 1,
+		To:    sp.end,
+		Total: len(elems),
+	}, true, nil
+}
+
+// largestArray finds the array to page v by: v itself, or the array with the
+// longest encoding reachable through v's object fields. Arrays are not
+// searched, so an array of objects is paged as a whole.
+func largestArray(v any) (path []string, arr []any) {
+	switch t := v.(type) {
+	case []any:
+		return nil, t
+	case map[string]any:
+		best := -1
+		for _, k := range slices.Sorted(maps.Keys(t)) {
+			p, a := largestArray(t[k])
+			if a == nil {
+				continue
+			}
+			b, err := json.Marshal(a)
+			if err != nil || len(b) <= best {
+				continue
+			}
+			best, path, arr = len(b), append([]string{k}, p...), a
+		}
+	}
+	return path, arr
+}
+
+// withArray returns a copy of v with the array at path replaced by arr.
+// Only the objects along path are copied.
+func withArray(v any, path []string, arr []any) any {
+	if len(path) == 0 {
+		return arr
+	}
+	m := v.(map[string]any)
+	cp := maps.Clone(m)
+	cp[path[0]] = withArray(m[path[0]], path[1:], arr)
+	return cp
+}
+
+// bytePage pages text by byte range, without splitting UTF-8 sequences.
+func bytePage(text string, pageBytes, n int) (resultPage, error) {
+	var spans []pageSpan
+	for start := 0; start < len(text); {
+		end := min(start+pageBytes, len(text))
+		for end < len(text) && end > start && !utf8.RuneStart(text[end]) {
+			end--
+		}
+		if end == start {
+			end = min(start+pageBytes, len(text))
+		}
+		spans = append(spans, pageSpan{start, end})
+		start = end
+	}
+	if len(spans) == 0 {
+		spans = []pageSpan{{0, 0}}
+	}
+	if n > len(spans) {
+		return resultPage{}, fmt.Errorf("page %d is out of range: the result has %d pages", n, len(spans))
+	}
+	sp := spans[n-1]
+	return resultPage{
+		Data:  text[sp.start:sp.end],
+		Page:  n,
+		Pages: len(spans),
+		Unit:  "bytes",
+		From:  sp.start + 1,
+		To:    sp.end,
+		Total: len(text),
+	}, nil
+}
+
+// pageNav describes where pg sits in the result pinned as handle and how to
+// fetch the next page.
+func pageNav(handle string, pg resultPage) string {
+	nav := fmt.Sprintf("Page %d of %d of %s (%s %d-%d of %d).", pg.Page, pg.Pages, handle, pg.Unit, pg.From, pg.To, pg.Total)
+	if pg.Page < pg.Pages {
+		nav += fmt.Sprintf(` Next page: pin {"action":"page","cursor":%q}.`, pageCursor(handle, pg.Page+1))
+	} else {
+		nav += " This is the last page."
+	}
+	return nav
+}
+
+// pagedResult pins an oversized response in sess and returns its first page.
+// reason explains why the response was paged and leads the navigation line.
+func pagedResult(sess *Session, tool mcp.ToolName, data string, pageBytes int, reason string) *mcpsdk.CallToolResult {
+	handle := sess.PinPaged(tool, data, pageBytes)
+	pg, err := sess.Page(pageCursor(handle, 1))
+	if err != nil {
+		return errorResult(reason + " " + err.Error())
+	}
+	return &mcpsdk.CallToolResult{
+		Content: []mcpsdk.Content{
+			&mcpsdk.TextContent{Text: pg.Data},
+			&mcpsdk.TextContent{Text: reason + " It was pinned as " + handle + ". " + pageNav(handle, pg)},
+		},
+	}
+}
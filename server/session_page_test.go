@@ -0,0 +1,258 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	mcp "github.com/daltoniam/switchboard"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePageCursor(t *testing.T) {
+	handle, page, err := parsePageCursor("$7#page=2")
+	require.NoError(t, err)
+	assert.Equal(t, "$7", handle)
+	assert.Equal(t, 2, page)
+
+	for _, bad := range []string{"$7", "$7#page=0", "7#page=1", "$7#page=x", "$7.items#page=2"} {
+		_, _, err := parsePageCursor(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestPageOf_ArrayByItems(t *testing.T) {
+	items := make([]map[string]any, 50)
+	for i := range items {
+		items[i] = map[string]any{"id": i, "title": strings.Repeat("t", 40)}
+	}
+	data, _ := json.Marshal(items)
+
+	first, err := pageOf(data, 500, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "items", first.Unit)
+	assert.Equal(t, 50, first.Total)
+	assert.Greater(t, first.Pages, 1)
+
+	var got []map[string]any
+	for n := 1; n <= first.Pages; n++ {
+		pg, err := pageOf(data, 500, n)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(pg.Data), 500)
+		var chunk []map[string]any
+		require.NoError(t, json.Unmarshal([]byte(pg.Data), &chunk))
+		assert.Len(t, chunk, pg.To-pg.From+1)
+		got = append(got, chunk...)
+	}
+	require.Len(t, got, 50)
+	assert.EqualValues(t, 0, got[0]["id"])
+	assert.EqualValues(t, 49, got[49]["id"])
+
+	_, err = pageOf(data, 500, first.Pages+1)
+	assert.ErrorContains(t, err, "out of range")
+}
+
+func TestPageOf_NestedArrayKeepsShape(t *testing.T) {
+	rows := make([][]any, 40)
+	for i := range rows {
+		rows[i] = []any{i, strings.Repeat("r", 30)}
+	}
+	data, _ := json.Marshal(map[string]any{
+		"total": 40,
+		"issues": map[string]any{
+			"columns": []string{"id", "title"},
+			"rows":    rows,
+		},
+	})
+
+	pg, err := pageOf(data, 400, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "items", pg.Unit)
+	assert.Equal(t, 40, pg.Total)
+
+	var doc struct {
+		Total  int `json:"total"`
+		Issues struct {
+			Columns []string `json:"columns"`
+			Rows    [][]any  `json:"rows"`
+		} `json:"issues"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(pg.Data), &doc))
+	assert.Equal(t, 40, doc.Total)
+	assert.Equal(t, []string{"id", "title"}, doc.Issues.Columns)
+	require.NotEmpty(t, doc.Issues.Rows)
+	assert.EqualValues(t, pg.From-1, doc.Issues.Rows[0][0])
+}
+
+func TestPageOf_FallsBackToBytes(t *testing.T) {
+	t.Run("no array", func(t *testing.T) {
+		data := []byte(`{"body":"` + strings.Repeat("x", 1000) + `"}`)
+		pg, err := pageOf(data, 300, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "bytes", pg.Unit)
+		assert.Equal(t, 4, pg.Pages)
+		assert.Equal(t, string(data[:300]), pg.Data)
+	})
+
+	t.Run("element larger than a page", func(t *testing.T) {
+		data := []byte(`["` + strings.Repeat("x", 1000) + `","y"]`)
+		pg, err := pageOf(data, 300, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "bytes", pg.Unit)
+	})
+
+	t.Run("plain text stored as a string", func(t *testing.T) {
+		text := strings.Repeat("é", 200) // 400 bytes
+		data, _ := json.Marshal(text)
+		var pages []string
+		for n := 1; ; n++ {
+			pg, err := pageOf(data, 101, n)
+			require.NoError(t, err)
+			pages = append(pages, pg.Data)
+			if n == pg.Pages {
+				break
+			}
+		}
+		for _, p := range pages {
+			assert.LessOrEqual(t, len(p), 101)
+			assert.True(t, strings.HasPrefix(p, "é"), "pages must not split a UTF-8 sequence")
+		}
+		assert.Equal(t, text, strings.Join(pages, ""))
+	})
+}
+
+func TestHandleExecute_PagesOversizedResult(t *testing.T) {
+	items := make([]map[string]any, 300)
+	for i := range items {
+		items[i] = map[string]any{"number": i + 1, "body": fmt.Sprintf("%03d%s", i, strings.Repeat("b", 400))}
+	}
+	payload, _ := json.Marshal(items)
+	mi := &mockIntegration{
+		name:    "github",
+		healthy: true,
+		tools: []mcp.ToolDefinition{
+			{Name: "github_list_issues", Description: "List issues"},
+		},
+		execFn: func(_ context.Context, _ mcp.ToolName, _ map[string]any) (*mcp.ToolResult, error) {
+			return &mcp.ToolResult{Data: string(payload)}, nil
+		},
+	}
+	s := setupTestServer(mi)
+	ctx := context.Background()
+
+	result, err := s.handleExecute(ctx, executeRequest("github_list_issues", nil))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.Len(t, result.Content, 2)
+	note := result.Content[1].(*mcpsdk.TextContent).Text
+	assert.Contains(t, note, "Response exceeded 50KB")
+	assert.Contains(t, note, "pinned as $1")
+	assert.Contains(t, note, `"cursor":"$1#page=2"`)
+
+	// The result is columnarized, so every page carries the columns and a
+	// run of rows.
+	type columnar struct {
+		Columns []string `json:"columns"`
+		Rows    [][]any  `json:"rows"`
+	}
+	var first columnar
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcpsdk.TextContent).Text), &first))
+	rows := first.Rows
+	for n := 2; ; n++ {
+		res, err := s.handlePin(ctx, pinRequest(map[string]any{"action": "page", "cursor": fmt.Sprintf("$1#page=%d", n)}))
+		require.NoError(t, err)
+		require.False(t, res.IsError, n)
+		var pg columnar
+		require.NoError(t, json.Unmarshal([]byte(res.Content[0].(*mcpsdk.TextContent).Text), &pg))
+		assert.Equal(t, first.Columns, pg.Columns)
+		rows = append(rows, pg.Rows...)
+		if strings.Contains(res.Content[1].(*mcpsdk.TextContent).Text, "last page") {
+			break
+		}
+	}
+	require.Len(t, rows, 300)
+
+	// Paths still reach the whole result.
+	sess := s.sessionStore.GetOrCreate("default")
+	v, err := sess.ResolveRef("$1.rows[-1]")
+	require.NoError(t, err)
+	assert.Contains(t, v, any(float64(300)))
+}
+
+// mockIntegrationWithToolMaxBytes declares a per-tool max_bytes cap, as
+// compact.yaml does.
+type mockIntegrationWithToolMaxBytes struct {
+	*mockIntegration
+	maxBytes map[mcp.ToolName]int
+}
+
+func (m *mockIntegrationWithToolMaxBytes) MaxBytes(name mcp.ToolName) (int, bool) {
+	n, ok := m.maxBytes[name]
+	return n, ok
+}
+
+func TestHandleExecute_PagesAtToolMaxBytes(t *testing.T) {
+	items := make([]map[string]any, 400)
+	for i := range items {
+		items[i] = map[string]any{"number": i + 1}
+	}
+	payload, _ := json.Marshal(items)
+	mi := &mockIntegrationWithToolMaxBytes{
+		mockIntegration: &mockIntegration{
+			name:    "github",
+			healthy: true,
+			tools: []mcp.ToolDefinition{
+				{Name: "github_list_issues", Description: "List issues"},
+			},
+			execFn: func(_ context.Context, _ mcp.ToolName, _ map[string]any) (*mcp.ToolResult, error) {
+				return &mcp.ToolResult{Data: string(payload)}, nil
+			},
+		},
+		maxBytes: map[mcp.ToolName]int{"github_list_issues": 1024},
+	}
+	s := setupTestServerWithIntegration(mi)
+
+	result, err := s.handleExecute(context.Background(), executeRequest("github_list_issues", nil))
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.Len(t, result.Content, 2)
+
+	page := result.Content[0].(*mcpsdk.TextContent).Text
+	assert.NotContains(t, page, "response_too_large")
+	assert.LessOrEqual(t, len(page), 1024)
+	note := result.Content[1].(*mcpsdk.TextContent).Text
+	assert.Contains(t, note, "github_list_issues's 1KB max_bytes")
+	assert.Contains(t, note, `"cursor":"$1#page=2"`)
+}
+
+func TestHandlePin_PageErrors(t *testing.T) {
+	s := setupTestServer()
+	ctx := context.Background()
+	sess := s.sessionStore.GetOrCreate("default")
+	sess.PinResult("test_tool", `[1,2,3]`)
+
+	res, err := s.handlePin(ctx, pinRequest(map[string]any{"action": "page"}))
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+
+	res, err = s.handlePin(ctx, pinRequest(map[string]any{"action": "page", "cursor": "$9#page=1"}))
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+	assert.Contains(t, res.Content[0].(*mcpsdk.TextContent).Text, "no pinned result")
+
+	res, err = s.handlePin(ctx, pinRequest(map[string]any{"action": "page", "cursor": "$1#page=2"}))
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+	assert.Contains(t, res.Content[0].(*mcpsdk.TextContent).Text, "out of range")
+
+	// A bare handle is page 1, and a small result is a single page.
+	res, err = s.handlePin(ctx, pinRequest(map[string]any{"action": "page", "handle": "$1"}))
+	require.NoError(t, err)
+	require.False(t, res.IsError)
+	assert.Equal(t, `[1,2,3]`, res.Content[0].(*mcpsdk.TextContent).Text)
+	assert.Contains(t, res.Content[1].(*mcpsdk.TextContent).Text, "Page 1 of 1 of $1")
+}
@@ -3,9 +3,11 @@ package server
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	mcp "github.com/daltoniam/switchboard"
 )
@@ -20,9 +22,26 @@ type PinnedResult struct {
 	Data      json.RawMessage `json:"data"`
 	PinnedAt  time.Time       `json:"pinned_at"`
 	SizeBytes int             `json:"size_bytes"`
+	// PageBytes is the page size for results pinned by PinPaged.
+	PageBytes int `json:"page_bytes,omitempty"`
 }
 
 func (s *Session) PinResult(tool mcp.ToolName, data string) string {
+	return s.pin(tool, json.RawMessage(data), 0)
+}
+
+// PinPaged pins a response too large to return whole, as the agent would
+// have seen it, to be read back in pages of at most pageBytes (see Page).
+// Output that is not JSON is stored as a JSON string.
+func (s *Session) PinPaged(tool mcp.ToolName, data string, pageBytes int) string {
+	raw := json.RawMessage(data)
+	if !json.Valid(raw) {
+		raw, _ = json.Marshal(data)
+	}
+	return s.pin(tool, raw, pageBytes)
+}
+
+func (s *Session) pin(tool mcp.ToolName, data json.RawMessage, pageBytes int) string {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if s.pinned == nil {
@@ -37,9 +56,10 @@ func (s *Session) PinResult(tool mcp.ToolName, data string) string {
 	pr := &PinnedResult{
 		Handle:    handle,
 		Tool:      tool,
-		Data:      json.RawMessage(data),
+		Data:      data,
 		PinnedAt:  time.Now(),
 		SizeBytes: size,
+		PageBytes: pageBytes,
 	}
 	s.pinned[handle] = pr
 	s.pinnedSize += size
@@ -88,6 +108,8 @@ func (s *Session) PinnedCount() int {
 	return len(s.pinned)
 }
 
+// ResolveRef resolves a pin reference such as "$1" or "$3.items[*].id"
+// (see session_pin_path.go for the path syntax).
 func (s *Session) ResolveRef(ref string) (any, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -98,19 +120,14 @@ func (s *Session) ResolveRef(ref string) (any, error) {
 		return nil, fmt.Errorf("no pinned result for handle %q", handle)
 	}
 
+	var v any
+	if err := json.Unmarshal(pr.Data, &v); err != nil {
+		return nil, fmt.Errorf("unmarshal pinned data: %w", err)
+	}
 	if path == "" {
-		var v any
-		if err := json.Unmarshal(pr.Data, &v); err != nil {
-			return nil, fmt.Errorf("unmarshal pinned data: %w", err)
-		}
 		return v, nil
 	}
-
-	var parsed any
-	if err := json.Unmarshal(pr.Data, &parsed); err != nil {
-		return nil, fmt.Errorf("unmarshal pinned data: %w", err)
-	}
-	return extractPath(parsed, path)
+	return extractPath(v, path)
 }
 
 func (s *Session) evictPinnedIfNeeded(incoming int) {
@@ -129,57 +146,135 @@ func (s *Session) evictPinnedIfNeeded(incoming int) {
 	}
 }
 
+// splitRef splits a reference into its handle and the path after it. The
+// separating "." is dropped; a bracket segment is kept ("$1[0]" → "[0]").
 func splitRef(ref string) (handle, path string) {
 	ref = strings.TrimPrefix(ref, "$")
-	dotIdx := strings.IndexByte(ref, '.')
-	if dotIdx < 0 {
+	end := strings.IndexAny(ref, ".[")
+	if end < 0 {
 		return "$" + ref, ""
 	}
-	return "$" + ref[:dotIdx], ref[dotIdx+1:]
+	return "$" + ref[:end], strings.TrimPrefix(ref[end:], ".")
 }
 
 func extractPath(data any, path string) (any, error) {
-	parts := strings.Split(path, ".")
-	current := data
-	for _, part := range parts {
-		switch v := current.(type) {
-		case map[string]any:
-			val, ok := v[part]
-			if !ok {
-				return nil, fmt.Errorf("key %q not found in path %q", part, path)
+	segs, err := parsePinPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path %q: %w", path, err)
+	}
+	return evalPinPath(data, segs, path)
+}
+
+// resolveRefs replaces pin references in args, including inside nested
+// objects and arrays. A string that is entirely a reference ("$2.items[0].id")
+// is replaced by the value it points to; {{$N.path}} inside a longer string
+// is replaced by the value's text. A string naming a handle that does not
+// exist is left alone, since "$5" may be a literal, but a path that does not
+// resolve against an existing handle, or any failed interpolation, is an
+// error.
+func resolveRefs(sess *Session, args map[string]any) error {
+	for k, v := range args {
+		resolved, err := resolveRefValue(sess, v)
+		if err != nil {
+			return fmt.Errorf("argument %q: %w", k, err)
+		}
+		args[k] = resolved
+	}
+	return nil
+}
+
+func resolveRefValue(sess *Session, v any) (any, error) {
+	switch t := v.(type) {
+	case string:
+		if pinRefRE.MatchString(t) {
+			handle, _ := splitRef(t)
+			if _, ok := sess.GetPinned(handle); !ok {
+				return t, nil
 			}
-			current = val
-		case []any:
-			idx, err := strconv.Atoi(part)
+			return sess.ResolveRef(t)
+		}
+		if strings.Contains(t, "{{") {
+			return interpolateRefs(sess, t)
+		}
+		return t, nil
+	case map[string]any:
+		for k, el := range t {
+			r, err := resolveRefValue(sess, el)
 			if err != nil {
-				return nil, fmt.Errorf("expected array index, got %q in path %q", part, path)
+				return nil, fmt.Errorf("%s: %w", k, err)
 			}
-			if idx < 0 || idx >= len(v) {
-				return nil, fmt.Errorf("array index %d out of bounds (len %d) in path %q", idx, len(v), path)
+			t[k] = r
+		}
+		return t, nil
+	case []any:
+		for i, el := range t {
+			r, err := resolveRefValue(sess, el)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
 			}
-			current = v[idx]
-		default:
-			return nil, fmt.Errorf("cannot traverse into %T at %q in path %q", current, part, path)
+			t[i] = r
 		}
+		return t, nil
 	}
-	return current, nil
+	return v, nil
 }
 
-func resolveRefs(sess *Session, args map[string]any) {
-	for k, v := range args {
-		s, ok := v.(string)
-		if !ok || !strings.HasPrefix(s, "$") {
-			continue
-		}
-		if len(s) < 2 {
-			continue
+// SearchPinned is the fallback for stores that do not implement PinSearcher:
+// it scans the session's resident pinned results for those containing every
+// term of query, case-insensitively, most recent first.
+func (s *Session) SearchPinned(query string, limit int) []PinMatch {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+	var matches []PinMatch
+	for _, pr := range s.ListPinned() {
+		data := strings.ToLower(string(pr.Data))
+		first := -1
+		for _, t := range terms {
+			i := strings.Index(data, t)
+			if i < 0 {
+				first = -1
+				break
+			}
+			if first < 0 || i < first {
+				first = i
+			}
 		}
-		if s[1] < '0' || s[1] > '9' {
+		if first < 0 {
 			continue
 		}
-		resolved, err := sess.ResolveRef(s)
-		if err == nil {
-			args[k] = resolved
-		}
+		matches = append(matches, PinMatch{
+			Handle:   pr.Handle,
+			Tool:     pr.Tool,
+			PinnedAt: pr.PinnedAt,
+			Snippet:  pinSnippet(string(pr.Data), first),
+			Resident: true,
+		})
+	}
+	slices.SortFunc(matches, func(a, b PinMatch) int { return b.PinnedAt.Compare(a.PinnedAt) })
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// pinSnippet returns about 160 bytes of data around offset i.
+func pinSnippet(data string, i int) string {
+	const radius = 80
+	lo, hi := max(i-radius, 0), min(i+radius, len(data))
+	for lo > 0 && !utf8.RuneStart(data[lo]) {
+		lo--
+	}
+	for hi < len(data) && !utf8.RuneStart(data[hi]) {
+		hi++
+	}
+	out := data[lo:hi]
+	if lo > 0 {
+		out = "…" + out
+	}
+	if hi < len(data) {
+		out += "…"
 	}
+	return out
 }
@@ -0,0 +1,504 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Pin reference paths select values from a pinned result:
+//
+//	$1.user.login              object keys; a numeric key indexes an array
+//	$1.items[0]  $1.items[-1]  array index, negative counts from the end
+//	$1.items[1:3]              slice (either bound may be omitted)
+//	$1.items[*].id             wildcard: every element, or every value of an object
+//	$1.items[?state=='open']   filter by comparing a field with ==, !=, <, <=, >, >=
+//	$1.items[?draft]           filter by a truthy field
+//	$1['odd.key']              quoted key
+//
+// Wildcards, slices and filters start a projection: key segments after them
+// apply to every element (elements without the key are skipped), a further
+// wildcard or filter applies within every element and flattens, and an index
+// or slice picks from the collected results. So $3.items[?state=='open'][0]
+// is the first open item and $3.items[*].labels[*].name is every label name.
+
+type pathSegKind int
+
+const (
+	segKey pathSegKind = iota
+	segIndex
+	segSlice
+	segWildcard
+	segFilter
+)
+
+type pathSeg struct {
+	kind  pathSegKind
+	key   string
+	index int
+	// slice bounds; nil means open-ended
+	lo, hi *int
+	filter *pathFilter
+}
+
+type pathFilter struct {
+	field []string // dotted field path relative to the element; empty is the element itself
+	op    string   // "" tests truthiness
+	value any
+}
+
+// parsePinPath splits a path (without the leading handle) into segments.
+func parsePinPath(path string) ([]pathSeg, error) {
+	var segs []pathSeg
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			i++
+			if i < len(path) && path[i] == '[' {
+				continue // "$1.[0]" as written by pin get with a bracket path
+			}
+			end := i
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			if end == i {
+				return nil, fmt.Errorf("empty key at offset %d", i)
+			}
+			if key := path[i:end]; key == "*" {
+				segs = append(segs, pathSeg{kind: segWildcard})
+			} else {
+				segs = append(segs, pathSeg{kind: segKey, key: key})
+			}
+			i = end
+		case '[':
+			end, err := closingBracket(path, i)
+			if err != nil {
+				return nil, err
+			}
+			seg, err := parseBracket(path[i+1 : end])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path[i:end+1], err)
+			}
+			segs = append(segs, seg)
+			i = end + 1
+		default:
+			// A path that starts without "." or "[" (pin get's path
+			// argument, or extractPath) begins with a key.
+			if len(segs) > 0 || i > 0 {
+				return nil, fmt.Errorf("unexpected %q at offset %d", path[i], i)
+			}
+			path = "." + path
+		}
+	}
+	return segs, nil
+}
+
+// closingBracket finds the "]" matching the "[" at open, skipping quoted
+// strings.
+func closingBracket(path string, open int) (int, error) {
+	var quote byte
+	for i := open + 1; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ']':
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unclosed %q at offset %d", '[', open)
+}
+
+func parseBracket(expr string) (pathSeg, error) {
+	expr = strings.TrimSpace(expr)
+	switch {
+	case expr == "*":
+		return pathSeg{kind: segWildcard}, nil
+	case strings.HasPrefix(expr, "?"):
+		f, err := parseFilter(strings.TrimSpace(expr[1:]))
+		if err != nil {
+			return pathSeg{}, err
+		}
+		return pathSeg{kind: segFilter, filter: f}, nil
+	case len(expr) >= 2 && (expr[0] == '\'' || expr[0] == '"') && expr[len(expr)-1] == expr[0]:
+		return pathSeg{kind: segKey, key: expr[1 : len(expr)-1]}, nil
+	case strings.Contains(expr, ":"):
+		loStr, hiStr, _ := strings.Cut(expr, ":")
+		seg := pathSeg{kind: segSlice}
+		for _, b := range []struct {
+			s   string
+			dst **int
+		}{{loStr, &seg.lo}, {hiStr, &seg.hi}} {
+			if s := strings.TrimSpace(b.s); s != "" {
+				n, err := strconv.Atoi(s)
+				if err != nil {
+					return pathSeg{}, fmt.Errorf("invalid slice bound %q", s)
+				}
+				*b.dst = &n
+			}
+		}
+		return seg, nil
+	default:
+		n, err := strconv.Atoi(expr)
+		if err != nil {
+			return pathSeg{}, fmt.Errorf("expected index, slice, '*', '?filter' or quoted key, got %q", expr)
+		}
+		return pathSeg{kind: segIndex, index: n}, nil
+	}
+}
+
+var filterOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+func parseFilter(expr string) (*pathFilter, error) {
+	if expr == "" {
+		return nil, fmt.Errorf("empty filter")
+	}
+	f := &pathFilter{}
+	lhs := expr
+	for i := 0; i < len(expr); i++ {
+		if expr[i] == '\'' || expr[i] == '"' {
+			break
+		}
+		for _, op := range filterOps {
+			if strings.HasPrefix(expr[i:], op) {
+				f.op = op
+				lhs = expr[:i]
+				rhs := strings.TrimSpace(expr[i+len(op):])
+				v, err := parseFilterLiteral(rhs)
+				if err != nil {
+					return nil, err
+				}
+				f.value = v
+				break
+			}
+		}
+		if f.op != "" {
+			break
+		}
+	}
+	lhs = strings.TrimPrefix(strings.TrimSpace(lhs), "@")
+	lhs = strings.TrimPrefix(lhs, ".")
+	if strings.ContainsAny(lhs, " '\"=!<>~") {
+		return nil, fmt.Errorf("invalid filter %q: use field, field==value, or field with !=, <, <=, >, >=", expr)
+	}
+	if lhs != "" {
+		f.field = strings.Split(lhs, ".")
+	}
+	return f, nil
+}
+
+func parseFilterLiteral(s string) (any, error) {
+	switch {
+	case len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0]:
+		return s[1 : len(s)-1], nil
+	case s == "true":
+		return true, nil
+	case s == "false":
+		return false, nil
+	case s == "null":
+		return nil, nil
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("filter value %q must be a quoted string, number, true, false or null", s)
+	}
+	return n, nil
+}
+
+// evalPinPath applies segs to data. path is the original text, for errors.
+func evalPinPath(data any, segs []pathSeg, path string) (any, error) {
+	current := data
+	projecting := false
+	for _, seg := range segs {
+		var err error
+		if projecting {
+			current, err = applyProjected(current.([]any), seg, path)
+		} else {
+			current, err = applySeg(current, seg, path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch seg.kind {
+		case segWildcard, segSlice, segFilter:
+			projecting = true
+		case segIndex:
+			// Indexing the collected results ends the projection.
+			projecting = false
+		}
+	}
+	return current, nil
+}
+
+func applySeg(current any, seg pathSeg, path string) (any, error) {
+	switch seg.kind {
+	case segKey:
+		switch v := current.(type) {
+		case map[string]any:
+			val, ok := v[seg.key]
+			if !ok {
+				return nil, fmt.Errorf("key %q not found in path %q", seg.key, path)
+			}
+			return val, nil
+		case []any:
+			idx, err := strconv.Atoi(seg.key)
+			if err != nil {
+				return nil, fmt.Errorf("expected array index, got %q in path %q (use [*].%s to read it from every element)", seg.key, path, seg.key)
+			}
+			return indexArray(v, idx, path)
+		default:
+			return nil, fmt.Errorf("cannot traverse into %T at %q in path %q", current, seg.key, path)
+		}
+	case segIndex:
+		arr, ok := current.([]any)
+		if !ok {
+			return nil, fmt.Errorf("cannot index %T with [%d] in path %q", current, seg.index, path)
+		}
+		return indexArray(arr, seg.index, path)
+	case segSlice:
+		arr, ok := current.([]any)
+		if !ok {
+			return nil, fmt.Errorf("cannot slice %T in path %q", current, path)
+		}
+		return sliceArray(arr, seg), nil
+	case segWildcard:
+		switch v := current.(type) {
+		case []any:
+			return slices.Clone(v), nil
+		case map[string]any:
+			out := make([]any, 0, len(v))
+			for _, k := range slices.Sorted(maps.Keys(v)) {
+				out = append(out, v[k])
+			}
+			return out, nil
+		default:
+			return nil, fmt.Errorf("cannot apply [*] to %T in path %q", current, path)
+		}
+	default:
+		arr, ok := current.([]any)
+		if !ok {
+			return nil, fmt.Errorf("cannot filter %T in path %q: filters apply to arrays", current, path)
+		}
+		out := []any{}
+		for _, el := range arr {
+			if seg.filter.match(el) {
+				out = append(out, el)
+			}
+		}
+		return out, nil
+	}
+}
+
+// applyProjected applies seg across a projection.
+func applyProjected(items []any, seg pathSeg, path string) (any, error) {
+	switch seg.kind {
+	case segIndex:
+		return indexArray(items, seg.index, path)
+	case segSlice:
+		return sliceArray(items, seg), nil
+	case segKey:
+		out := []any{}
+		found := false
+		for _, el := range items {
+			m, ok := el.(map[string]any)
+			if !ok {
+				continue
+			}
+			if val, ok := m[seg.key]; ok {
+				found = true
+				out = append(out, val)
+			}
+		}
+		if !found && len(items) > 0 {
+			return nil, fmt.Errorf("key %q not found in any element in path %q", seg.key, path)
+		}
+		return out, nil
+	default:
+		out := []any{}
+		for _, el := range items {
+			if _, isArr := el.([]any); !isArr && seg.kind == segFilter {
+				continue
+			}
+			v, err := applySeg(el, seg, path)
+			if err != nil {
+				continue
+			}
+			out = append(out, v.([]any)...)
+		}
+		return out, nil
+	}
+}
+
+func indexArray(arr []any, idx int, path string) (any, error) {
+	i := idx
+	if i < 0 {
+		i += len(arr)
+	}
+	if i < 0 || i >= len(arr) {
+		return nil, fmt.Errorf("array index %d out of bounds (len %d) in path %q", idx, len(arr), path)
+	}
+	return arr[i], nil
+}
+
+func sliceArray(arr []any, seg pathSeg) []any {
+	bound := func(p *int, def int) int {
+		if p == nil {
+			return def
+		}
+		n := *p
+		if n < 0 {
+			n += len(arr)
+		}
+		return min(max(n, 0), len(arr))
+	}
+	lo, hi := bound(seg.lo, 0), bound(seg.hi, len(arr))
+	if lo >= hi {
+		return []any{}
+	}
+	return slices.Clone(arr[lo:hi])
+}
+
+func (f *pathFilter) match(el any) bool {
+	v := el
+	for _, k := range f.field {
+		m, ok := v.(map[string]any)
+		if !ok {
+			v = nil
+			break
+		}
+		v = m[k]
+	}
+	switch f.op {
+	case "":
+		return truthy(v)
+	case "==":
+		return equalJSON(v, f.value)
+	case "!=":
+		return !equalJSON(v, f.value)
+	}
+	var c int
+	switch a := v.(type) {
+	case float64:
+		b, ok := f.value.(float64)
+		if !ok {
+			return false
+		}
+		c = compareFloat(a, b)
+	case string:
+		b, ok := f.value.(string)
+		if !ok {
+			return false
+		}
+		c = strings.Compare(a, b)
+	default:
+		return false
+	}
+	switch f.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func equalJSON(a, b any) bool {
+	switch av := a.(type) {
+	case nil:
+		return b == nil
+	case string, float64, bool:
+		return a == b
+	default:
+		ab, _ := json.Marshal(av)
+		bb, _ := json.Marshal(b)
+		return string(ab) == string(bb)
+	}
+}
+
+func truthy(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case string:
+		return t != ""
+	case float64:
+		return t != 0
+	case []any:
+		return len(t) > 0
+	case map[string]any:
+		return len(t) > 0
+	}
+	return true
+}
+
+// pinRefRE matches a string that is entirely a pin reference: a handle
+// optionally followed by a path.
+var pinRefRE = regexp.MustCompile(`^\$[0-9]+(?:[.\[].*)?$`)
+
+// pinTemplateRE matches {{$N...}} interpolations inside a string.
+var pinTemplateRE = regexp.MustCompile(`\{\{\s*(\$[0-9]+[^}]*?)\s*\}\}`)
+
+// interpolateRefs replaces every {{$N.path}} in s with the referenced value.
+// Strings are inserted as-is, arrays of scalars are joined with ", ", and
+// anything else is inserted as JSON.
+func interpolateRefs(sess *Session, s string) (string, error) {
+	var firstErr error
+	out := pinTemplateRE.ReplaceAllStringFunc(s, func(m string) string {
+		if firstErr != nil {
+			return m
+		}
+		ref := pinTemplateRE.FindStringSubmatch(m)[1]
+		v, err := sess.ResolveRef(ref)
+		if err != nil {
+			firstErr = fmt.Errorf("%s: %w", m, err)
+			return m
+		}
+		return formatRefValue(v)
+	})
+	return out, firstErr
+}
+
+func formatRefValue(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case nil:
+		return ""
+	case []any:
+		parts := make([]string, 0, len(t))
+		for _, el := range t {
+			switch el.(type) {
+			case map[string]any, []any:
+				b, _ := json.Marshal(t)
+				return string(b)
+			}
+			parts = append(parts, formatRefValue(el))
+		}
+		return strings.Join(parts, ", ")
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pathTestData = `{
+	"total": 4,
+	"items": [
+		{"number": 1, "state": "open", "draft": false, "labels": [{"name": "bug"}, {"name": "p1"}], "user": {"login": "ann"}},
+		{"number": 2, "state": "closed", "draft": false, "labels": [], "user": {"login": "bob"}},
+		{"number": 3, "state": "open", "draft": true, "labels": [{"name": "docs"}]},
+		{"number": 4, "state": "open", "draft": false, "labels": [{"name": "bug"}], "user": {"login": "ann"}}
+	],
+	"by.dot": "quoted"
+}`
+
+func TestExtractPath_Language(t *testing.T) {
+	var data any
+	require.NoError(t, json.Unmarshal([]byte(pathTestData), &data))
+
+	tests := []struct {
+		path string
+		want any
+	}{
+		{"items[0].number", float64(1)},
+		{"items.0.number", float64(1)},
+		{"items[-1].number", float64(4)},
+		{"items[1:3].number", []any{float64(2), float64(3)}},
+		{"items[:2].number", []any{float64(1), float64(2)}},
+		{"items[-2:].number", []any{float64(3), float64(4)}},
+		{"items[*].number", []any{float64(1), float64(2), float64(3), float64(4)}},
+		{"items.*.number", []any{float64(1), float64(2), float64(3), float64(4)}},
+		{"items[*].user.login", []any{"ann", "bob", "ann"}},
+		{"items[?state=='open'].number", []any{float64(1), float64(3), float64(4)}},
+		{`items[?state!="open"].number`, []any{float64(2)}},
+		{"items[?number>=3].number", []any{float64(3), float64(4)}},
+		{"items[?number<2].number", []any{float64(1)}},
+		{"items[?draft].number", []any{float64(3)}},
+		{"items[?user.login=='ann'].number", []any{float64(1), float64(4)}},
+		{"items[?user==null].number", []any{float64(3)}},
+		{"items[?state=='open'][0].number", float64(1)},
+		{"items[?state=='open'][-1].user.login", "ann"},
+		{"items[*].labels[*].name", []any{"bug", "p1", "docs", "bug"}},
+		{"items[*].labels[?name=='bug']", []any{map[string]any{"name": "bug"}, map[string]any{"name": "bug"}}},
+		{"items[?state=='merged'].number", []any{}},
+		{"['by.dot']", "quoted"},
+		{"total", float64(4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := extractPath(data, tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExtractPath_LanguageErrors(t *testing.T) {
+	var data any
+	require.NoError(t, json.Unmarshal([]byte(pathTestData), &data))
+
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{"items[9]", "out of bounds"},
+		{"items.number", "use [*].number"},
+		{"items[*].missing", `key "missing" not found in any element`},
+		{"total[0]", "cannot index float64"},
+		{"total[?x]", "filters apply to arrays"},
+		{"items[0", "unclosed"},
+		{"items[abc]", "expected index"},
+		{"items[?state=~'open']", "invalid filter"},
+		{"items[?state=='open]", "unclosed"},
+		{"items[?number>abc]", "filter value"},
+		{"items[0]x", "unexpected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, err := extractPath(data, tt.path)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestResolveRefs_PathLanguage(t *testing.T) {
+	s := newSession("test")
+	s.PinResult("linear_get_issue", `{"identifier":"ENG-12","title":"Fix login"}`)
+	s.PinResult("github_list_pulls", pathTestData)
+
+	args := map[string]any{
+		"numbers": "$2.items[?state=='open'].number",
+		"first":   "$2.items[?state=='open'][0].number",
+		"title":   "Fixes {{$1.identifier}}: {{ $1.title }}",
+		"summary": "Open: {{$2.items[?state=='open'].number}}",
+		"nested":  map[string]any{"ids": []any{"$1.identifier", "literal"}},
+		"price":   "$5 off",
+		"tmpl":    "{{ .Name }}",
+	}
+	require.NoError(t, resolveRefs(s, args))
+
+	assert.Equal(t, []any{float64(1), float64(3), float64(4)}, args["numbers"])
+	assert.Equal(t, float64(1), args["first"])
+	assert.Equal(t, "Fixes ENG-12: Fix login", args["title"])
+	assert.Equal(t, "Open: 1, 3, 4", args["summary"])
+	assert.Equal(t, map[string]any{"ids": []any{"ENG-12", "literal"}}, args["nested"])
+	assert.Equal(t, "$5 off", args["price"])
+	assert.Equal(t, "{{ .Name }}", args["tmpl"])
+}
+
+func TestResolveRefs_Errors(t *testing.T) {
+	s := newSession("test")
+	s.PinResult("tool", `{"id":42}`)
+
+	err := resolveRefs(s, map[string]any{"id": "$1.missing"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `argument "id"`)
+	assert.Contains(t, err.Error(), `key "missing" not found`)
+
+	err = resolveRefs(s, map[string]any{"body": "See {{$7.url}}"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `no pinned result for handle "$7"`)
+
+	err = resolveRefs(s, map[string]any{"list": []any{"ok", "$1[0]"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "[1]")
+}
+
+func TestHandlePin_GetBracketPath(t *testing.T) {
+	s := setupTestServer(&mockIntegration{name: "test", healthy: true})
+	sess := s.sessionStore.GetOrCreate("default")
+	sess.PinResult("test_list", `[{"id":1},{"id":2}]`)
+
+	for _, path := range []string{"[*].id", "*.id"} {
+		result, err := s.handlePin(t.Context(), sessionRequest(map[string]any{
+			"action": "get", "handle": "$1", "path": path,
+		}))
+		require.NoError(t, err)
+		require.False(t, result.IsError, path)
+		assert.JSONEq(t, `[1,2]`, result.Content[0].(*mcpsdk.TextContent).Text, path)
+	}
+}
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSnapshotTTL is how long an exported session snapshot stays
+// importable. Handoffs happen within a working session or two, so snapshots
+// outlive sessions but do not accumulate forever.
+const DefaultSnapshotTTL = 7 * 24 * time.Hour
+
+var snapshotNameRE = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,127}$`)
+
+// ErrSnapshotNotFound is returned by LoadSnapshot for unknown or expired
+// snapshot IDs.
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// SessionSnapshot is a point-in-time copy of a session that another client
+// can import to pick up where the first left off. The ID is random and only
+// the exporter is told it, so holding the ID is what grants the import.
+type SessionSnapshot struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name,omitempty"`
+	Source      string          `json:"source_session"`
+	CreatedAt   time.Time       `json:"created_at"`
+	Context     map[string]any  `json:"context"`
+	Breadcrumbs []Breadcrumb    `json:"breadcrumbs,omitempty"`
+	Pinned      []*PinnedResult `json:"pinned,omitempty"`
+}
+
+// Snapshot copies the session's context, breadcrumbs and pinned results
+// into a snapshot stored under id and labelled name.
+func (s *Session) Snapshot(id, name string) *SessionSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	snap := &SessionSnapshot{
+		ID:          id,
+		Name:        name,
+		Source:      s.ID,
+		CreatedAt:   time.Now(),
+		Context:     maps.Clone(s.Context),
+		Breadcrumbs: slices.Clone(s.Breadcrumbs),
+	}
+	for _, pr := range s.pinned {
+		cp := *pr
+		snap.Pinned = append(snap.Pinned, &cp)
+	}
+	slices.SortFunc(snap.Pinned, func(a, b *PinnedResult) int {
+		return a.PinnedAt.Compare(b.PinnedAt)
+	})
+	return snap
+}
+
+// Attach imports a snapshot into the session. Snapshot context overwrites
+// keys already set, imported breadcrumbs are placed before the session's own
+// and marked with their source, and pinned results keep their handles unless
+// the session already uses them, in which case they are renumbered. The
+// returned map gives the new handle for every imported pin.
+func (s *Session) Attach(snap *SessionSnapshot) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, v := range snap.Context {
+		s.Context[k] = v
+	}
+
+	crumbs := make([]Breadcrumb, 0, len(snap.Breadcrumbs)+len(s.Breadcrumbs))
+	for _, bc := range snap.Breadcrumbs {
+		if bc.From == "" {
+			bc.From = snap.Source
+		}
+		crumbs = append(crumbs, bc)
+	}
+	crumbs = append(crumbs, s.Breadcrumbs...)
+	if len(crumbs) > MaxBreadcrumbs {
+		crumbs = crumbs[len(crumbs)-MaxBreadcrumbs:]
+	}
+	for i := range crumbs {
+		crumbs[i].Seq = i + 1
+	}
+	s.Breadcrumbs = crumbs
+	s.nextSeq = len(crumbs)
+
+	if s.pinned == nil {
+		s.pinned = make(map[string]*PinnedResult)
+	}
+	handles := make(map[string]string, len(snap.Pinned))
+	for _, pr := range snap.Pinned {
+		cp := *pr
+		if _, taken := s.pinned[cp.Handle]; taken || cp.Handle == "" {
+			s.nextHandle++
+			cp.Handle = "$" + strconv.Itoa(s.nextHandle)
+		} else if n, err := strconv.Atoi(strings.TrimPrefix(cp.Handle, "$")); err == nil && n > s.nextHandle {
+			s.nextHandle = n
+		}
+		s.evictPinnedIfNeeded(cp.SizeBytes)
+		s.pinned[cp.Handle] = &cp
+		s.pinnedSize += cp.SizeBytes
+		handles[pr.Handle] = cp.Handle
+	}
+	s.LastUsed = time.Now()
+	return handles
+}
+
+// newSnapshotID returns a random snapshot ID with 128 bits of entropy, so a
+// snapshot cannot be imported by guessing its ID.
+func newSnapshotID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return "snap-" + hex.EncodeToString(b[:])
+}
+
+// snapshotKey namespaces a snapshot ID by the session it is used from, so
+// project endpoints only see their own project's snapshots.
+func snapshotKey(sess *Session, id string) (string, error) {
+	if !snapshotNameRE.MatchString(id) {
+		return "", fmt.Errorf("invalid snapshot id %q", id)
+	}
+	if strings.HasPrefix(sess.ID, "project/") {
+		return sess.ID[:strings.LastIndex(sess.ID, "/")+1] + id, nil
+	}
+	return id, nil
+}
+
+func (ss *MemorySessionStore) SaveSnapshot(snap *SessionSnapshot) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	for id, s := range ss.snapshots {
+		if time.Since(s.CreatedAt) > DefaultSnapshotTTL {
+			delete(ss.snapshots, id)
+		}
+	}
+	ss.snapshots[snap.ID] = snap
+	return nil
+}
+
+func (ss *MemorySessionStore) LoadSnapshot(id string) (*SessionSnapshot, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	snap, ok := ss.snapshots[id]
+	if !ok || time.Since(snap.CreatedAt) > DefaultSnapshotTTL {
+		return nil, ErrSnapshotNotFound
+	}
+	return snap, nil
+}
+
+// SaveSnapshot writes the snapshot as JSON under {dir}/snapshots/.
+func (fs *FileSessionStore) SaveSnapshot(snap *SessionSnapshot) error {
+	dir := filepath.Join(fs.dir, "snapshots")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("create snapshot dir: %w", err)
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	path := fs.snapshotPath(snap.ID)
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+func (fs *FileSessionStore) LoadSnapshot(id string) (*SessionSnapshot, error) {
+	path := fs.snapshotPath(id)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot: %w", err)
+	}
+	var snap SessionSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+	if time.Since(snap.CreatedAt) > DefaultSnapshotTTL {
+		_ = os.Remove(path)
+		return nil, ErrSnapshotNotFound
+	}
+	return &snap, nil
+}
+
+func (fs *FileSessionStore) snapshotPath(id string) string {
+	return filepath.Join(fs.dir, "snapshots", sanitizeID(id)+".json")
+}
+
+// snapshotSummary describes a snapshot, under the ID its exporter was given,
+// without its pinned payloads.
+func snapshotSummary(id string, snap *SessionSnapshot) map[string]any {
+	return map[string]any{
+		"id":             id,
+		"name":           snap.Name,
+		"source_session": snap.Source,
+		"created_at":     snap.CreatedAt,
+		"context_keys":   slices.Sorted(maps.Keys(snap.Context)),
+		"breadcrumbs":    len(snap.Breadcrumbs),
+		"pinned":         len(snap.Pinned),
+	}
+}
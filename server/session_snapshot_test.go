@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mcp "github.com/daltoniam/switchboard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSession_SnapshotAttach(t *testing.T) {
+	src := newSession("agent-a")
+	src.SetContext(map[string]any{"owner": "acme", "repo": "api"})
+	src.AddBreadcrumb("github_list_issues", nil, "3 issues", false)
+	h1 := src.PinResult("github_list_issues", `[{"number":1}]`)
+
+	snap := src.Snapshot("snap-1", "handoff")
+	assert.Equal(t, "agent-a", snap.Source)
+	assert.Equal(t, "snap-1", snap.ID)
+	assert.Equal(t, "handoff", snap.Name)
+	require.Len(t, snap.Pinned, 1)
+
+	src.SetContext(map[string]any{"owner": "changed"})
+	assert.Equal(t, "acme", snap.Context["owner"], "snapshot is a copy")
+
+	dst := newSession("agent-b")
+	dst.SetContext(map[string]any{"owner": "mine", "team": "core"})
+	dst.AddBreadcrumb("linear_list_issues", nil, "ok", false)
+
+	handles := dst.Attach(snap)
+	assert.Equal(t, map[string]string{h1: h1}, handles, "free handles are kept")
+	assert.Equal(t, map[string]any{"owner": "acme", "repo": "api", "team": "core"}, dst.GetContext())
+
+	crumbs := dst.RecentBreadcrumbs(10, "")
+	require.Len(t, crumbs, 2)
+	assert.Equal(t, mcp.ToolName("github_list_issues"), crumbs[0].Tool)
+	assert.Equal(t, "agent-a", crumbs[0].From)
+	assert.Equal(t, 1, crumbs[0].Seq)
+	assert.Empty(t, crumbs[1].From)
+	assert.Equal(t, 2, crumbs[1].Seq)
+
+	v, err := dst.ResolveRef(h1 + ".0.number")
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), v)
+
+	next := dst.PinResult("github_get_issue", `{}`)
+	assert.Equal(t, "$2", next, "new pins continue after imported handles")
+}
+
+func TestSession_AttachRenumbersTakenHandles(t *testing.T) {
+	src := newSession("a")
+	src.PinResult("x", `"from a"`)
+	snap := src.Snapshot("s", "")
+
+	dst := newSession("b")
+	dst.PinResult("y", `"from b"`)
+
+	handles := dst.Attach(snap)
+	assert.Equal(t, map[string]string{"$1": "$2"}, handles)
+	v, err := dst.ResolveRef("$1")
+	require.NoError(t, err)
+	assert.Equal(t, "from b", v)
+	v, err = dst.ResolveRef("$2")
+	require.NoError(t, err)
+	assert.Equal(t, "from a", v)
+}
+
+func testSnapshotStore(t *testing.T, store SessionStore) {
+	t.Helper()
+	sess := store.GetOrCreate("src")
+	sess.SetContext(map[string]any{"owner": "acme"})
+	sess.PinResult("github_get_repo", `{"stars":5}`)
+	require.NoError(t, store.SaveSnapshot(sess.Snapshot("handoff", "")))
+
+	snap, err := store.LoadSnapshot("handoff")
+	require.NoError(t, err)
+	assert.Equal(t, "src", snap.Source)
+	assert.Equal(t, "acme", snap.Context["owner"])
+	require.Len(t, snap.Pinned, 1)
+	assert.JSONEq(t, `{"stars":5}`, string(snap.Pinned[0].Data))
+
+	_, err = store.LoadSnapshot("missing")
+	assert.ErrorIs(t, err, ErrSnapshotNotFound)
+
+	old := sess.Snapshot("stale", "")
+	old.CreatedAt = time.Now().Add(-DefaultSnapshotTTL - time.Minute)
+	require.NoError(t, store.SaveSnapshot(old))
+	_, err = store.LoadSnapshot("stale")
+	assert.ErrorIs(t, err, ErrSnapshotNotFound)
+}
+
+func TestMemorySessionStore_Snapshots(t *testing.T) {
+	testSnapshotStore(t, NewMemorySessionStore(time.Hour))
+}
+
+func TestFileSessionStore_Snapshots(t *testing.T) {
+	dir := t.TempDir()
+	testSnapshotStore(t, NewFileSessionStore(dir, time.Hour))
+
+	// Snapshots survive a restart.
+	snap, err := NewFileSessionStore(dir, time.Hour).LoadSnapshot("handoff")
+	require.NoError(t, err)
+	assert.Equal(t, "src", snap.Source)
+}
+
+func TestHandleSession_ExportImport(t *testing.T) {
+	s := setupTestServer(&mockIntegration{name: "test", healthy: true})
+
+	a := s.sessionStore.GetOrCreate("agent-a")
+	a.SetContext(map[string]any{"owner": "acme"})
+	a.AddBreadcrumb("test_list", nil, "listed", false)
+	a.PinResult("test_list", `[1,2,3]`)
+
+	export := func() map[string]any {
+		t.Helper()
+		result, err := s.handleSession(withSession(context.Background(), a), sessionRequest(map[string]any{
+			"action": "export",
+			"name":   "triage-42",
+		}))
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return parseSessionResponse(t, result)["exported"].(map[string]any)
+	}
+	exported := export()
+	assert.Equal(t, "triage-42", exported["name"])
+	assert.Equal(t, float64(1), exported["pinned"])
+	id := exported["id"].(string)
+	assert.Regexp(t, `^snap-[0-9a-f]{32}$`, id)
+	assert.NotEqual(t, id, export()["id"], "each export gets a fresh id")
+
+	b := s.sessionStore.GetOrCreate("agent-b")
+	result, err := s.handleSession(withSession(context.Background(), b), sessionRequest(map[string]any{
+		"action": "import",
+		"name":   "triage-42",
+	}))
+	require.NoError(t, err)
+	assert.True(t, result.IsError, "the label does not grant the import")
+
+	result, err = s.handleSession(withSession(context.Background(), b), sessionRequest(map[string]any{
+		"action": "import",
+		"id":     id,
+	}))
+	require.NoError(t, err)
+	resp := parseSessionResponse(t, result)
+	assert.Equal(t, "acme", resp["context"].(map[string]any)["owner"])
+	assert.Equal(t, map[string]any{"$1": "$1"}, resp["handles"])
+	assert.Equal(t, 1, b.TotalBreadcrumbs())
+	assert.Equal(t, 1, b.PinnedCount())
+}
+
+func TestHandleSession_ExportImportErrors(t *testing.T) {
+	s := setupTestServer(&mockIntegration{name: "test", healthy: true})
+	ctx := context.Background()
+
+	for _, args := range []map[string]any{
+		{"action": "import"},
+		{"action": "export", "name": "../escape"},
+		{"action": "import", "id": "../escape"},
+		{"action": "import", "id": "snap-00000000000000000000000000000000"},
+	} {
+		result, err := s.handleSession(ctx, sessionRequest(args))
+		require.NoError(t, err)
+		assert.True(t, result.IsError, "%v", args)
+	}
+}
+
+func TestSnapshotKey_ProjectNamespace(t *testing.T) {
+	key, err := snapshotKey(newSession(projectSessionID("billing", "abc")), "handoff")
+	require.NoError(t, err)
+	assert.Equal(t, "project/billing/handoff", key)
+
+	key, err = snapshotKey(newSession("abc"), "handoff")
+	require.NoError(t, err)
+	assert.Equal(t, "handoff", key)
+}
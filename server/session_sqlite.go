@@ -0,0 +1,465 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mcp "github.com/daltoniam/switchboard"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSweepInterval bounds how often GetOrCreate deletes expired sessions
+// and snapshots from the database.
+const sqliteSweepInterval = time.Minute
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS sessions (
+	id          TEXT PRIMARY KEY,
+	context     TEXT NOT NULL,
+	created_at  INTEGER NOT NULL,
+	last_used   INTEGER NOT NULL,
+	next_seq    INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS sessions_last_used ON sessions(last_used);
+
+CREATE TABLE IF NOT EXISTS breadcrumbs (
+	session_id  TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+	seq         INTEGER NOT NULL,
+	ts          INTEGER NOT NULL,
+	tool        TEXT NOT NULL,
+	args        TEXT,
+	summary     TEXT,
+	is_error    INTEGER NOT NULL,
+	from_id     TEXT,
+	PRIMARY KEY (session_id, seq)
+);
+
+CREATE TABLE IF NOT EXISTS pins (
+	rowid       INTEGER PRIMARY KEY,
+	session_id  TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+	handle      TEXT NOT NULL,
+	tool        TEXT NOT NULL,
+	data        TEXT NOT NULL,
+	pinned_at   INTEGER NOT NULL,
+	size_bytes  INTEGER NOT NULL,
+	page_bytes  INTEGER NOT NULL DEFAULT 0,
+	UNIQUE (session_id, handle)
+);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS pins_fts USING fts5(
+	tool, data, content='pins', content_rowid='rowid'
+);
+CREATE TRIGGER IF NOT EXISTS pins_ai AFTER INSERT ON pins BEGIN
+	INSERT INTO pins_fts(rowid, tool, data) VALUES (new.rowid, new.tool, new.data);
+END;
+CREATE TRIGGER IF NOT EXISTS pins_ad AFTER DELETE ON pins BEGIN
+	INSERT INTO pins_fts(pins_fts, rowid, tool, data) VALUES ('delete', old.rowid, old.tool, old.data);
+END;
+
+CREATE TABLE IF NOT EXISTS snapshots (
+	name        TEXT PRIMARY KEY,
+	data        TEXT NOT NULL,
+	created_at  INTEGER NOT NULL
+);
+`
+
+// PinMatch is a pinned result returned by a full-text history search.
+type PinMatch struct {
+	Handle   string       `json:"handle"`
+	Tool     mcp.ToolName `json:"tool"`
+	PinnedAt time.Time    `json:"pinned_at"`
+	Snippet  string       `json:"snippet"`
+	// Resident reports whether the handle can still be used in execute
+	// arguments. Results evicted by the pinned-size cap stay searchable.
+	Resident bool `json:"resident"`
+}
+
+// PinSearcher is implemented by session stores that index every pinned
+// result, including ones evicted from memory, for full-text search.
+type PinSearcher interface {
+	SearchPinned(sessionID, query string, limit int) ([]PinMatch, error)
+}
+
+// SQLiteSessionStore persists sessions, breadcrumbs, pinned results and
+// snapshots in a single SQLite database. Live sessions are cached in memory
+// like FileSessionStore; Save writes them through. Pinned results are kept
+// in the database after the in-memory cap evicts them, and are indexed for
+// SearchPinned.
+type SQLiteSessionStore struct {
+	db  *sql.DB
+	ttl time.Duration
+
+	mu        sync.Mutex
+	mem       map[string]*Session
+	lastSweep time.Time
+}
+
+var _ PinSearcher = (*SQLiteSessionStore)(nil)
+
+// NewSQLiteSessionStore opens (creating if needed) the database at path and
+// sweeps sessions idle for longer than ttl.
+func NewSQLiteSessionStore(path string, ttl time.Duration) (*SQLiteSessionStore, error) {
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("create session db dir: %w", err)
+	}
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open session db: %w", err)
+	}
+	// A single connection serializes writers; the in-memory cache absorbs
+	// most reads.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("create session schema: %w", err)
+	}
+	ss := &SQLiteSessionStore{db: db, ttl: ttl, mem: make(map[string]*Session)}
+	if err := ss.sweep(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return ss, nil
+}
+
+// DefaultSessionDBPath returns the default database path for the SQLite
+// session store: ~/.config/switchboard/sessions.db
+func DefaultSessionDBPath() string {
+	return filepath.Join(filepath.Dir(DefaultSessionDir()), "sessions.db")
+}
+
+// Close closes the database.
+func (ss *SQLiteSessionStore) Close() error {
+	return ss.db.Close()
+}
+
+func (ss *SQLiteSessionStore) GetOrCreate(id string) *Session {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if time.Since(ss.lastSweep) > sqliteSweepInterval {
+		_ = ss.sweep()
+	}
+	if s, ok := ss.mem[id]; ok {
+		if time.Since(s.LastUsed) <= ss.ttl {
+			s.touch()
+			return s
+		}
+		delete(ss.mem, id)
+	}
+	s, err := ss.load(id)
+	if err == nil && time.Since(s.LastUsed) <= ss.ttl {
+		ss.mem[id] = s
+		s.touch()
+		return s
+	}
+	s = newSession(id)
+	ss.mem[id] = s
+	return s
+}
+
+func (ss *SQLiteSessionStore) Get(id string) (*Session, bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if s, ok := ss.mem[id]; ok {
+		if time.Since(s.LastUsed) <= ss.ttl {
+			return s, true
+		}
+		return nil, false
+	}
+	s, err := ss.load(id)
+	if err != nil || time.Since(s.LastUsed) > ss.ttl {
+		return nil, false
+	}
+	return s, true
+}
+
+// Save writes the session's context and breadcrumbs, and any pinned results
+// not yet in the database.
+func (ss *SQLiteSessionStore) Save(s *Session) error {
+	ss.mu.Lock()
+	ss.mem[s.ID] = s
+	ss.mu.Unlock()
+
+	s.mu.RLock()
+	ctxJSON, err := json.Marshal(s.Context)
+	createdAt, lastUsed, nextSeq := s.CreatedAt, s.LastUsed, s.nextSeq
+	crumbs := append([]Breadcrumb(nil), s.Breadcrumbs...)
+	pins := make([]PinnedResult, 0, len(s.pinned))
+	for _, pr := range s.pinned {
+		pins = append(pins, *pr)
+	}
+	s.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("encode session context: %w", err)
+	}
+
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return fmt.Errorf("save session: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if _, err := tx.Exec(`INSERT INTO sessions (id, context, created_at, last_used, next_seq)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET context = excluded.context, last_used = excluded.last_used, next_seq = excluded.next_seq`,
+		s.ID, string(ctxJSON), createdAt.UnixNano(), lastUsed.UnixNano(), nextSeq); err != nil {
+		return fmt.Errorf("save session: %w", err)
+	}
+
+	// The trail is at most MaxBreadcrumbs rows and Attach renumbers it, so
+	// it is rewritten rather than appended.
+	if _, err := tx.Exec(`DELETE FROM breadcrumbs WHERE session_id = ?`, s.ID); err != nil {
+		return fmt.Errorf("save breadcrumbs: %w", err)
+	}
+	for _, bc := range crumbs {
+		args, _ := json.Marshal(bc.Args)
+		if _, err := tx.Exec(`INSERT INTO breadcrumbs (session_id, seq, ts, tool, args, summary, is_error, from_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			s.ID, bc.Seq, bc.Timestamp.UnixNano(), string(bc.Tool), string(args), bc.Summary, bc.IsError, bc.From); err != nil {
+			return fmt.Errorf("save breadcrumbs: %w", err)
+		}
+	}
+
+	stored, err := storedPins(tx, s.ID)
+	if err != nil {
+		return err
+	}
+	for _, pr := range pins {
+		at, ok := stored[pr.Handle]
+		if ok && at == pr.PinnedAt.UnixNano() {
+			continue
+		}
+		if ok {
+			// An imported pin reused a handle whose earlier result was
+			// evicted from memory; the new result wins.
+			if _, err := tx.Exec(`DELETE FROM pins WHERE session_id = ? AND handle = ?`, s.ID, pr.Handle); err != nil {
+				return fmt.Errorf("save pinned result: %w", err)
+			}
+		}
+		if _, err := tx.Exec(`INSERT INTO pins (session_id, handle, tool, data, pinned_at, size_bytes, page_bytes)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			s.ID, pr.Handle, string(pr.Tool), string(pr.Data), pr.PinnedAt.UnixNano(), pr.SizeBytes, pr.PageBytes); err != nil {
+			return fmt.Errorf("save pinned result: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (ss *SQLiteSessionStore) Delete(id string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	delete(ss.mem, id)
+	_, _ = ss.db.Exec(`DELETE FROM sessions WHERE id = ?`, id)
+}
+
+func (ss *SQLiteSessionStore) SaveSnapshot(snap *SessionSnapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	_, err = ss.db.Exec(`INSERT INTO snapshots (name, data, created_at) VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET data = excluded.data, created_at = excluded.created_at`,
+		snap.ID, string(data), snap.CreatedAt.UnixNano())
+	if err != nil {
+		return fmt.Errorf("save snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot looks a snapshot up by ID, which the snapshots table keeps
+// in its name column.
+func (ss *SQLiteSessionStore) LoadSnapshot(id string) (*SessionSnapshot, error) {
+	var data string
+	err := ss.db.QueryRow(`SELECT data FROM snapshots WHERE name = ? AND created_at > ?`,
+		id, time.Now().Add(-DefaultSnapshotTTL).UnixNano()).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load snapshot: %w", err)
+	}
+	var snap SessionSnapshot
+	if err := json.Unmarshal([]byte(data), &snap); err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+	return &snap, nil
+}
+
+// SearchPinned ranks the session's pinned results, resident or evicted,
+// against query. Every term must appear; results are ordered by relevance.
+func (ss *SQLiteSessionStore) SearchPinned(sessionID, query string, limit int) ([]PinMatch, error) {
+	match := ftsQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = defaultHistoryN
+	}
+	// Take the session before querying: the store has one connection, and
+	// ss.mu is held across DB calls elsewhere, so holding the connection
+	// (via rows) while waiting on ss.mu would deadlock.
+	ss.mu.Lock()
+	sess := ss.mem[sessionID]
+	ss.mu.Unlock()
+
+	rows, err := ss.db.Query(`SELECT p.handle, p.tool, p.pinned_at,
+			snippet(pins_fts, 1, '«', '»', '…', 24)
+		FROM pins_fts JOIN pins p ON p.rowid = pins_fts.rowid
+		WHERE pins_fts MATCH ? AND p.session_id = ?
+		ORDER BY bm25(pins_fts) LIMIT ?`, match, sessionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("search pinned results: %w", err)
+	}
+	var matches []PinMatch
+	for rows.Next() {
+		var m PinMatch
+		var tool string
+		var at int64
+		if err := rows.Scan(&m.Handle, &tool, &at, &m.Snippet); err != nil {
+			rows.Close() //nolint:errcheck
+			return nil, fmt.Errorf("search pinned results: %w", err)
+		}
+		m.Tool = mcp.ToolName(tool)
+		m.PinnedAt = time.Unix(0, at)
+		matches = append(matches, m)
+	}
+	err = rows.Err()
+	rows.Close() //nolint:errcheck
+	if err != nil {
+		return nil, fmt.Errorf("search pinned results: %w", err)
+	}
+
+	if sess != nil {
+		for i, m := range matches {
+			pr, ok := sess.GetPinned(m.Handle)
+			matches[i].Resident = ok && pr.PinnedAt.Equal(m.PinnedAt)
+		}
+	}
+	return matches, nil
+}
+
+// ftsQuery turns free text into an FTS5 query that matches every term
+// literally, so user input cannot inject FTS operators.
+func ftsQuery(q string) string {
+	var terms []string
+	for _, t := range strings.Fields(q) {
+		terms = append(terms, `"`+strings.ReplaceAll(t, `"`, `""`)+`"`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// load reads a session, and as many of its most recent pinned results as fit
+// under MaxPinnedBytes, from the database.
+func (ss *SQLiteSessionStore) load(id string) (*Session, error) {
+	var ctxJSON string
+	var createdAt, lastUsed int64
+	s := &Session{ID: id}
+	err := ss.db.QueryRow(`SELECT context, created_at, last_used, next_seq FROM sessions WHERE id = ?`, id).
+		Scan(&ctxJSON, &createdAt, &lastUsed, &s.nextSeq)
+	if err != nil {
+		return nil, err
+	}
+	s.CreatedAt, s.LastUsed = time.Unix(0, createdAt), time.Unix(0, lastUsed)
+	if err := json.Unmarshal([]byte(ctxJSON), &s.Context); err != nil || s.Context == nil {
+		s.Context = make(map[string]any)
+	}
+
+	rows, err := ss.db.Query(`SELECT seq, ts, tool, args, summary, is_error, from_id
+		FROM breadcrumbs WHERE session_id = ? ORDER BY seq`, id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var bc Breadcrumb
+		var ts int64
+		var tool string
+		var args, summary, from sql.NullString
+		if err := rows.Scan(&bc.Seq, &ts, &tool, &args, &summary, &bc.IsError, &from); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		bc.Timestamp, bc.Tool, bc.Summary, bc.From = time.Unix(0, ts), mcp.ToolName(tool), summary.String, from.String
+		if args.Valid {
+			_ = json.Unmarshal([]byte(args.String), &bc.Args)
+		}
+		s.Breadcrumbs = append(s.Breadcrumbs, bc)
+	}
+	_ = rows.Close()
+
+	// Handles are never reused, so numbering continues after the highest
+	// one stored, resident or not.
+	rows, err = ss.db.Query(`SELECT handle, tool, data, pinned_at, size_bytes, page_bytes
+		FROM pins WHERE session_id = ? ORDER BY pinned_at DESC`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+	s.pinned = make(map[string]*PinnedResult)
+	for rows.Next() {
+		var pr PinnedResult
+		var tool, data string
+		var at int64
+		if err := rows.Scan(&pr.Handle, &tool, &data, &at, &pr.SizeBytes, &pr.PageBytes); err != nil {
+			return nil, err
+		}
+		if n, err := strconv.Atoi(strings.TrimPrefix(pr.Handle, "$")); err == nil && n > s.nextHandle {
+			s.nextHandle = n
+		}
+		if s.pinnedSize+pr.SizeBytes > MaxPinnedBytes {
+			continue
+		}
+		pr.Tool, pr.Data, pr.PinnedAt = mcp.ToolName(tool), json.RawMessage(data), time.Unix(0, at)
+		s.pinned[pr.Handle] = &pr
+		s.pinnedSize += pr.SizeBytes
+	}
+	return s, rows.Err()
+}
+
+func storedPins(tx *sql.Tx, sessionID string) (map[string]int64, error) {
+	rows, err := tx.Query(`SELECT handle, pinned_at FROM pins WHERE session_id = ?`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("read pinned results: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+	stored := make(map[string]int64)
+	for rows.Next() {
+		var handle string
+		var at int64
+		if err := rows.Scan(&handle, &at); err != nil {
+			return nil, fmt.Errorf("read pinned results: %w", err)
+		}
+		stored[handle] = at
+	}
+	return stored, rows.Err()
+}
+
+// sweep deletes sessions idle for longer than the TTL, with their
+// breadcrumbs and pinned results, and expired snapshots. Callers hold ss.mu
+// or have exclusive access.
+func (ss *SQLiteSessionStore) sweep() error {
+	now := time.Now()
+	ss.lastSweep = now
+	if _, err := ss.db.Exec(`DELETE FROM sessions WHERE last_used < ?`, now.Add(-ss.ttl).UnixNano()); err != nil {
+		return fmt.Errorf("sweep sessions: %w", err)
+	}
+	if _, err := ss.db.Exec(`DELETE FROM snapshots WHERE created_at < ?`, now.Add(-DefaultSnapshotTTL).UnixNano()); err != nil {
+		return fmt.Errorf("sweep snapshots: %w", err)
+	}
+	for id, s := range ss.mem {
+		if now.Sub(s.LastUsed) > ss.ttl {
+			delete(ss.mem, id)
+		}
+	}
+	return nil
+}
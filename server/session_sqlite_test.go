@@ -0,0 +1,233 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mcp "github.com/daltoniam/switchboard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSQLiteStore(t *testing.T, path string, ttl time.Duration) *SQLiteSessionStore {
+	t.Helper()
+	if path == "" {
+		path = filepath.Join(t.TempDir(), "sessions.db")
+	}
+	ss, err := NewSQLiteSessionStore(path, ttl)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ss.Close() })
+	return ss
+}
+
+func TestSQLiteSessionStore_SaveAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	ss := newTestSQLiteStore(t, path, time.Hour)
+
+	sess := ss.GetOrCreate("test-sess")
+	sess.SetContext(map[string]any{"owner": "daltoniam", "limit": float64(5)})
+	sess.AddBreadcrumb("github_list_issues", map[string]any{"state": "open"}, `[{"id":1}]`, false)
+	sess.AddBreadcrumb("github_get_issue", nil, "not found", true)
+	h := sess.PinResult("github_list_issues", `[{"id":1,"title":"flaky deploy"}]`)
+	require.NoError(t, ss.Save(sess))
+	require.NoError(t, ss.Close())
+
+	ss2 := newTestSQLiteStore(t, path, time.Hour)
+	loaded, ok := ss2.Get("test-sess")
+	require.True(t, ok)
+	assert.Equal(t, map[string]any{"owner": "daltoniam", "limit": float64(5)}, loaded.GetContext())
+	require.Len(t, loaded.Breadcrumbs, 2)
+	assert.Equal(t, "open", loaded.Breadcrumbs[0].Args["state"])
+	assert.True(t, loaded.Breadcrumbs[1].IsError)
+
+	v, err := loaded.ResolveRef(h + ".0.title")
+	require.NoError(t, err)
+	assert.Equal(t, "flaky deploy", v)
+
+	loaded.AddBreadcrumb("github_get_repo", nil, "{}", false)
+	assert.Equal(t, 3, loaded.Breadcrumbs[2].Seq, "sequence continues after reload")
+	assert.Equal(t, "$2", loaded.PinResult("github_get_repo", `{}`), "handles continue after reload")
+}
+
+func TestSQLiteSessionStore_PagedPins(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	ss := newTestSQLiteStore(t, path, time.Hour)
+	sess := ss.GetOrCreate("test-sess")
+	h := sess.PinPaged("script", `[1,2,3,4,5,6,7,8,9,10]`, 8)
+	require.NoError(t, ss.Save(sess))
+	require.NoError(t, ss.Close())
+
+	ss2 := newTestSQLiteStore(t, path, time.Hour)
+	loaded, ok := ss2.Get("test-sess")
+	require.True(t, ok)
+	pr, ok := loaded.GetPinned(h)
+	require.True(t, ok)
+	assert.Equal(t, 8, pr.PageBytes)
+	pg, err := loaded.Page(h + "#page=2")
+	require.NoError(t, err)
+	assert.Equal(t, "[4,5,6]", pg.Data)
+}
+
+func TestSQLiteSessionStore_DeleteAndTTL(t *testing.T) {
+	ss := newTestSQLiteStore(t, "", 50*time.Millisecond)
+
+	sess := ss.GetOrCreate("gone")
+	sess.PinResult("x", `"data"`)
+	require.NoError(t, ss.Save(sess))
+	ss.Delete("gone")
+	_, ok := ss.Get("gone")
+	assert.False(t, ok)
+
+	old := ss.GetOrCreate("old")
+	require.NoError(t, ss.Save(old))
+	time.Sleep(80 * time.Millisecond)
+	require.NoError(t, ss.sweep())
+
+	var n int
+	require.NoError(t, ss.db.QueryRow(`SELECT COUNT(*) FROM sessions`).Scan(&n))
+	assert.Zero(t, n)
+	require.NoError(t, ss.db.QueryRow(`SELECT COUNT(*) FROM pins`).Scan(&n))
+	assert.Zero(t, n, "pins are removed with their session")
+	assert.Empty(t, ss.GetOrCreate("old").GetContext())
+}
+
+func TestSQLiteSessionStore_Snapshots(t *testing.T) {
+	testSnapshotStore(t, newTestSQLiteStore(t, "", time.Hour))
+}
+
+func TestSQLiteSessionStore_SearchPinned(t *testing.T) {
+	ss := newTestSQLiteStore(t, "", time.Hour)
+	sess := ss.GetOrCreate("s")
+	sess.PinResult("sentry_list_issues", `[{"title":"TypeError in checkout handler","count":42}]`)
+	sess.PinResult("github_get_pull", `{"title":"Fix checkout retries","state":"open"}`)
+	sess.PinResult("linear_get_issue", `{"title":"Billing dashboard"}`)
+	require.NoError(t, ss.Save(sess))
+
+	matches, err := ss.SearchPinned("s", "checkout", 10)
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	for _, m := range matches {
+		assert.Contains(t, m.Snippet, "«checkout»")
+		assert.True(t, m.Resident)
+	}
+
+	matches, err = ss.SearchPinned("s", "checkout TypeError", 10)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "$1", matches[0].Handle)
+
+	matches, err = ss.SearchPinned("other", "checkout", 10)
+	require.NoError(t, err)
+	assert.Empty(t, matches, "search is scoped to the session")
+
+	matches, err = ss.SearchPinned("s", `checkout" OR "billing`, 10)
+	require.NoError(t, err, "FTS operators in the query are treated literally")
+	assert.Empty(t, matches)
+}
+
+func TestSQLiteSessionStore_SearchPinnedConcurrentWithSave(t *testing.T) {
+	ss := newTestSQLiteStore(t, "", time.Hour)
+	sess := ss.GetOrCreate("s")
+	for i := range 20 {
+		sess.PinResult("sentry_list_issues", fmt.Sprintf(`{"title":"checkout error %d"}`, i))
+	}
+	require.NoError(t, ss.Save(sess))
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range 50 {
+			_, _ = ss.SearchPinned("s", "checkout", 20)
+			_ = ss.Save(ss.GetOrCreate(fmt.Sprintf("other-%d", i)))
+			ss.Get("s")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 50 {
+			_, _ = ss.SearchPinned("s", "checkout", 20)
+			_ = ss.Save(sess)
+		}
+	}()
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("SearchPinned deadlocked against the session store lock")
+	}
+}
+
+func TestSQLiteSessionStore_SearchFindsEvictedPins(t *testing.T) {
+	ss := newTestSQLiteStore(t, "", time.Hour)
+	sess := ss.GetOrCreate("s")
+	sess.PinResult("sentry_get_event", `{"message":"needle in the first result"}`)
+	require.NoError(t, ss.Save(sess))
+
+	// Push the first result out of memory.
+	big := `"` + strings.Repeat("x", MaxPinnedBytes/2) + `"`
+	for i := 0; i < 3; i++ {
+		sess.PinResult(mcp.ToolName(fmt.Sprintf("tool_%d", i)), big)
+		require.NoError(t, ss.Save(sess))
+	}
+	_, resident := sess.GetPinned("$1")
+	require.False(t, resident)
+
+	matches, err := ss.SearchPinned("s", "needle", 10)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "$1", matches[0].Handle)
+	assert.False(t, matches[0].Resident)
+}
+
+func TestHandleHistory_Query(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		store   func(t *testing.T) SessionStore
+		hasNote bool
+	}{
+		{"sqlite", func(t *testing.T) SessionStore { return newTestSQLiteStore(t, "", time.Hour) }, false},
+		{"memory", func(t *testing.T) SessionStore { return NewMemorySessionStore(time.Hour) }, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := setupTestServer(&mockIntegration{name: "test", healthy: true})
+			s.sessionStore = tc.store(t)
+
+			sess := s.sessionStore.GetOrCreate("default")
+			sess.PinResult("test_list", `[{"name":"payments-api","status":"degraded"}]`)
+			sess.PinResult("test_get", `{"name":"search-api","status":"ok"}`)
+			require.NoError(t, s.sessionStore.Save(sess))
+
+			result, err := s.handleHistory(context.Background(), sessionRequest(map[string]any{"query": "degraded"}))
+			require.NoError(t, err)
+			require.False(t, result.IsError)
+			resp := parseSessionResponse(t, result)
+			matches := resp["matches"].([]any)
+			require.Len(t, matches, 1)
+			assert.Equal(t, "$1", matches[0].(map[string]any)["handle"])
+			_, hasNote := resp["note"]
+			assert.Equal(t, tc.hasNote, hasNote)
+		})
+	}
+}
+
+func TestSession_SearchPinned(t *testing.T) {
+	sess := newSession("s")
+	sess.PinResult("a", `{"msg":"Disk FULL on node-3"}`)
+	sess.PinResult("b", `{"msg":"disk ok"}`)
+
+	matches := sess.SearchPinned("disk full", 10)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "$1", matches[0].Handle)
+	assert.Contains(t, matches[0].Snippet, "Disk FULL")
+
+	assert.Len(t, sess.SearchPinned("disk", 10), 2)
+	assert.Len(t, sess.SearchPinned("disk", 1), 1)
+	assert.Empty(t, sess.SearchPinned("  ", 10))
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+
+	mcp "github.com/daltoniam/switchboard"
+	"github.com/daltoniam/switchboard/telemetry"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceMiddleware starts a server span for every MCP request handled by an
+// endpoint ("/mcp" or a project's "/mcp/{project}"), continuing the
+// client's trace when the HTTP request carries a traceparent header.
+func traceMiddleware(endpoint string) mcpsdk.Middleware {
+	return func(next mcpsdk.MethodHandler) mcpsdk.MethodHandler {
+		return func(ctx context.Context, method string, req mcpsdk.Request) (mcpsdk.Result, error) {
+			if extra := req.GetExtra(); extra != nil && extra.Header != nil {
+				ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(extra.Header))
+			}
+			name := "mcp " + method
+			attrs := []attribute.KeyValue{
+				attribute.String("mcp.method", method),
+				attribute.String("switchboard.endpoint", endpoint),
+			}
+			if ss, ok := req.GetSession().(*mcpsdk.ServerSession); ok && ss != nil && ss.ID() != "" {
+				attrs = append(attrs, attribute.String("mcp.session_id", ss.ID()))
+			}
+			if p, ok := req.GetParams().(*mcpsdk.CallToolParamsRaw); ok && p != nil {
+				name += " " + p.Name
+				attrs = append(attrs, attribute.String("mcp.tool", p.Name))
+			}
+
+			ctx, span := telemetry.Tracer().Start(ctx, name,
+				trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+			defer span.End()
+
+			res, err := next(ctx, method, req)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			} else if r, ok := res.(*mcpsdk.CallToolResult); ok && r.IsError {
+				span.SetStatus(codes.Error, "tool returned an error")
+			}
+			return res, err
+		}
+	}
+}
+
+// endSpan records the outcome of a tool call on span and ends it.
+func endSpan(span trace.Span, result *mcp.ToolResult, err error) {
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case result != nil && result.IsError:
+		span.SetStatus(codes.Error, truncate(result.Data, 200))
+	}
+	span.End()
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	mcp "github.com/daltoniam/switchboard"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider that records spans in memory for
+// the duration of the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	sr := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return sr
+}
+
+func spansNamed(sr *tracetest.SpanRecorder, name string) []sdktrace.ReadOnlySpan {
+	var out []sdktrace.ReadOnlySpan
+	for _, s := range sr.Ended() {
+		if s.Name() == name {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func spanAttr(s sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, kv := range s.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestExecuteTool_TracesAttempts(t *testing.T) {
+	sr := recordSpans(t)
+	calls := 0
+	s := setupTestServer(&mockIntegration{
+		name:  "test",
+		tools: []mcp.ToolDefinition{{Name: "test_flaky", Description: "flaky tool"}},
+		execFn: func(_ context.Context, _ mcp.ToolName, _ map[string]any) (*mcp.ToolResult, error) {
+			calls++
+			if calls < 2 {
+				return nil, &mcp.RetryableError{StatusCode: 503, Err: fmt.Errorf("service unavailable")}
+			}
+			return &mcp.ToolResult{Data: "ok"}, nil
+		},
+		healthy: true,
+	})
+	s.retryBackoff = 0
+
+	_, _, err := s.executeTool(context.Background(), "test_flaky", map[string]any{})
+	require.NoError(t, err)
+
+	parents := spansNamed(sr, "execute_tool test_flaky")
+	require.Len(t, parents, 1)
+	parent := parents[0]
+	assert.Equal(t, "test", spanAttr(parent, "switchboard.integration").AsString())
+	assert.Equal(t, "closed", spanAttr(parent, "switchboard.breaker.state").AsString())
+	assert.Equal(t, int64(1), spanAttr(parent, "switchboard.retries").AsInt64())
+	assert.Equal(t, codes.Unset, parent.Status().Code)
+
+	attempts := spansNamed(sr, "attempt")
+	require.Len(t, attempts, 2)
+	for i, a := range attempts {
+		assert.Equal(t, parent.SpanContext().SpanID(), a.Parent().SpanID())
+		assert.Equal(t, int64(i+1), spanAttr(a, "switchboard.attempt").AsInt64())
+	}
+	assert.Equal(t, codes.Error, attempts[0].Status().Code)
+	assert.Equal(t, codes.Unset, attempts[1].Status().Code)
+}
+
+func TestRunScript_TracesAPICalls(t *testing.T) {
+	sr := recordSpans(t)
+	s := setupTestServer(&mockIntegration{
+		name:    "test",
+		tools:   []mcp.ToolDefinition{{Name: "test_get", Description: "get"}},
+		healthy: true,
+	})
+	data, _ := json.Marshal(map[string]any{"script": `api.call("test_get", {}); api.call("test_get", {});`})
+	_, err := s.handleExecute(context.Background(), &mcpsdk.CallToolRequest{
+		Params: &mcpsdk.CallToolParamsRaw{Name: "execute", Arguments: data},
+	})
+	require.NoError(t, err)
+
+	scripts := spansNamed(sr, "script")
+	require.Len(t, scripts, 1)
+	calls := spansNamed(sr, "api.call test_get")
+	require.Len(t, calls, 2)
+	for _, c := range calls {
+		assert.Equal(t, scripts[0].SpanContext().SpanID(), c.Parent().SpanID())
+	}
+	assert.Len(t, spansNamed(sr, "execute_tool test_get"), 2)
+	assert.Positive(t, spanAttr(scripts[0], "switchboard.script.intermediate_bytes").AsInt64())
+}
+
+func TestTraceMiddleware_SpanPerRequest(t *testing.T) {
+	sr := recordSpans(t)
+	s := setupTestServer(&mockIntegration{name: "test", healthy: true})
+
+	ctx := context.Background()
+	ct, st := mcpsdk.NewInMemoryTransports()
+	ss, err := s.mcpServer.Connect(ctx, st, nil)
+	require.NoError(t, err)
+	defer ss.Close() //nolint:errcheck
+	cs, err := mcpsdk.NewClient(&mcpsdk.Implementation{Name: "test"}, nil).Connect(ctx, ct, nil)
+	require.NoError(t, err)
+	defer cs.Close() //nolint:errcheck
+
+	_, err = cs.CallTool(ctx, &mcpsdk.CallToolParams{Name: "session", Arguments: map[string]any{"action": "bogus"}})
+	require.NoError(t, err)
+
+	spans := spansNamed(sr, "mcp tools/call session")
+	require.Len(t, spans, 1)
+	assert.Equal(t, "session", spanAttr(spans[0], "mcp.tool").AsString())
+	assert.Equal(t, "/mcp", spanAttr(spans[0], "switchboard.endpoint").AsString())
+	assert.Equal(t, codes.Error, spans[0].Status().Code, "tool errors mark the span")
+	assert.NotEmpty(t, spansNamed(sr, "mcp initialize"))
+}
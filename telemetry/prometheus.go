@@ -0,0 +1,108 @@
+package telemetry
+
+import (
+	"net/http"
+	"time"
+
+	mcp "github.com/daltoniam/switchboard"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus is an mcp.MetricsObserver that mirrors recorded events into
+// Prometheus collectors on its own registry. Install it with
+// Metrics.SetObserver and serve Handler at /metrics.
+type Prometheus struct {
+	registry *prometheus.Registry
+
+	toolDuration  *prometheus.HistogramVec
+	toolRetries   *prometheus.CounterVec
+	breakerTrips  *prometheus.CounterVec
+	compactionIn  *prometheus.CounterVec
+	compactionOut *prometheus.CounterVec
+	truncations   prometheus.Counter
+	searches      prometheus.Counter
+	scripts       prometheus.Counter
+}
+
+var _ mcp.MetricsObserver = (*Prometheus)(nil)
+
+// NewPrometheus creates the collectors, plus the standard Go runtime and
+// process collectors, on a fresh registry.
+func NewPrometheus() *Prometheus {
+	p := &Prometheus{
+		registry: prometheus.NewRegistry(),
+		toolDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "switchboard_tool_call_duration_seconds",
+			Help: "Tool call latency including retries, by integration, tool and outcome.",
+			// 10ms to ~20s.
+			Buckets: prometheus.ExponentialBuckets(0.01, 2, 12),
+		}, []string{"integration", "tool", "outcome"}),
+		toolRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "switchboard_tool_retries_total",
+			Help: "Retried tool call attempts.",
+		}, []string{"integration", "tool"}),
+		breakerTrips: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "switchboard_circuit_breaker_rejections_total",
+			Help: "Tool calls rejected because the integration's circuit breaker was open.",
+		}, []string{"integration"}),
+		compactionIn: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "switchboard_compaction_input_bytes_total",
+			Help: "Response bytes before compaction.",
+		}, []string{"tool"}),
+		compactionOut: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "switchboard_compaction_output_bytes_total",
+			Help: "Response bytes after compaction.",
+		}, []string{"tool"}),
+		truncations: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "switchboard_truncations_total",
+			Help: "Responses truncated for exceeding the size cap.",
+		}),
+		searches: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "switchboard_searches_total",
+			Help: "search meta-tool calls.",
+		}),
+		scripts: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "switchboard_scripts_total",
+			Help: "Scripts run through execute.",
+		}),
+	}
+	p.registry.MustRegister(
+		p.toolDuration, p.toolRetries, p.breakerTrips,
+		p.compactionIn, p.compactionOut,
+		p.truncations, p.searches, p.scripts,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return p
+}
+
+// Handler serves the registry in the Prometheus text format.
+func (p *Prometheus) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+}
+
+func (p *Prometheus) ObserveExecution(integration mcp.IntegrationName, tool mcp.ToolName, duration time.Duration, isError bool, retries int) {
+	outcome := "success"
+	if isError {
+		outcome = "error"
+	}
+	p.toolDuration.WithLabelValues(string(integration), string(tool), outcome).Observe(duration.Seconds())
+	if retries > 0 {
+		p.toolRetries.WithLabelValues(string(integration), string(tool)).Add(float64(retries))
+	}
+}
+
+func (p *Prometheus) ObserveCircuitBreak(integration mcp.IntegrationName) {
+	p.breakerTrips.WithLabelValues(string(integration)).Inc()
+}
+
+func (p *Prometheus) ObserveCompaction(tool mcp.ToolName, beforeSize, afterSize int) {
+	p.compactionIn.WithLabelValues(string(tool)).Add(float64(beforeSize))
+	p.compactionOut.WithLabelValues(string(tool)).Add(float64(afterSize))
+}
+
+func (p *Prometheus) ObserveTruncation() { p.truncations.Inc() }
+func (p *Prometheus) ObserveSearch()     { p.searches.Inc() }
+func (p *Prometheus) ObserveScript()     { p.scripts.Inc() }
@@ -0,0 +1,62 @@
+package telemetry
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mcp "github.com/daltoniam/switchboard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T, p *Prometheus) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	p.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, 200, rec.Code)
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestPrometheus_ObservesMetrics(t *testing.T) {
+	p := NewPrometheus()
+	m := mcp.NewMetrics()
+	m.SetObserver(p)
+
+	m.RecordExecution("github", "github_get_issue", 150*time.Millisecond, false, 0)
+	m.RecordExecution("github", "github_get_issue", 3*time.Second, true, 2)
+	m.RecordCircuitBreak("github")
+	m.RecordCompaction("github_get_issue", 1000, 250)
+	m.RecordTruncation()
+	m.RecordSearch()
+	m.RecordScript()
+
+	body := scrape(t, p)
+	for _, want := range []string{
+		`switchboard_tool_call_duration_seconds_count{integration="github",outcome="success",tool="github_get_issue"} 1`,
+		`switchboard_tool_call_duration_seconds_bucket{integration="github",outcome="success",tool="github_get_issue",le="0.16"} 1`,
+		`switchboard_tool_call_duration_seconds_count{integration="github",outcome="error",tool="github_get_issue"} 1`,
+		`switchboard_tool_retries_total{integration="github",tool="github_get_issue"} 2`,
+		`switchboard_circuit_breaker_rejections_total{integration="github"} 1`,
+		`switchboard_compaction_input_bytes_total{tool="github_get_issue"} 1000`,
+		`switchboard_compaction_output_bytes_total{tool="github_get_issue"} 250`,
+		"switchboard_truncations_total 1",
+		"switchboard_searches_total 1",
+		"switchboard_scripts_total 1",
+		"go_goroutines",
+	} {
+		assert.Contains(t, body, want)
+	}
+}
+
+func TestPrometheus_NoObserverAfterReset(t *testing.T) {
+	p := NewPrometheus()
+	m := mcp.NewMetrics()
+	m.SetObserver(p)
+	m.SetObserver(nil)
+	m.RecordTruncation()
+	assert.Contains(t, scrape(t, p), "switchboard_truncations_total 0")
+}
@@ -0,0 +1,73 @@
+// Package telemetry exports switchboard's traces over OTLP and its metrics
+// in the Prometheus exposition format. Both are off unless enabled in
+// Config.Telemetry.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	mcp "github.com/daltoniam/switchboard"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/daltoniam/switchboard"
+	serviceName         = "switchboard"
+)
+
+// Tracer returns switchboard's tracer. Until SetupTracing installs an
+// exporter the global provider is a no-op, so instrumented code pays almost
+// nothing when tracing is off.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// SetupTracing installs a global tracer provider that batches spans to an
+// OTLP/HTTP collector, and W3C trace-context propagation. It returns a
+// shutdown function that flushes pending spans. When cfg does not enable
+// tracing it does nothing and the shutdown function is a no-op.
+func SetupTracing(ctx context.Context, cfg *mcp.TelemetryConfig, serviceVersion string) (func(context.Context) error, error) {
+	if cfg == nil || !cfg.Tracing {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var opts []otlptracehttp.Option
+	if cfg.OTLPEndpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint+"/v1/traces"))
+	}
+	if len(cfg.OTLPHeaders) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(cfg.OTLPHeaders))
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+		attribute.String("service.version", serviceVersion),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("build trace resource: %w", err)
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if r := cfg.SampleRatio; r > 0 && r < 1 {
+		sampler = sdktrace.TraceIDRatioBased(r)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return tp.Shutdown, nil
+}
@@ -0,0 +1,41 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	mcp "github.com/daltoniam/switchboard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetupTracing_DisabledIsNoop(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	for _, cfg := range []*mcp.TelemetryConfig{nil, {Prometheus: true}} {
+		shutdown, err := SetupTracing(context.Background(), cfg, "test")
+		require.NoError(t, err)
+		require.NoError(t, shutdown(context.Background()))
+		assert.Same(t, prev, otel.GetTracerProvider())
+	}
+}
+
+func TestSetupTracing_Enabled(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	shutdown, err := SetupTracing(context.Background(), &mcp.TelemetryConfig{
+		Tracing:      true,
+		OTLPEndpoint: "http://127.0.0.1:1",
+		SampleRatio:  0.5,
+	}, "test")
+	require.NoError(t, err)
+	assert.NotSame(t, prev, otel.GetTracerProvider())
+
+	_, span := Tracer().Start(context.Background(), "probe")
+	span.End()
+	// Export to the unreachable collector fails; shutdown still returns.
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	_ = shutdown(ctx)
+}
@@ -0,0 +1,130 @@
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/dlclark/regexp2"
+)
+
+// maxPieceBytes bounds the quadratic merge loop. Pre-tokenization keeps
+// ordinary pieces short, but a JSON response can hold a long unbroken run
+// of letters (base64 blobs, minified identifiers); those are counted in
+// maxPieceBytes chunks, which can overcount by a token per chunk boundary.
+const maxPieceBytes = 512
+
+// BPE counts tokens with a byte-level BPE vocabulary in tiktoken's rank
+// format, after splitting text with the vocabulary's pre-tokenizer pattern.
+// It only counts; it never materializes token ids.
+type BPE struct {
+	name  string
+	ranks map[string]int
+	split *regexp2.Regexp
+}
+
+// NewBPE builds a tokenizer from a tiktoken rank file ("<base64 token>
+// <rank>" per line) and the pre-tokenizer regular expression that goes with
+// it. Every single byte must be in the vocabulary, as it is in tiktoken's,
+// so any input can be encoded.
+func NewBPE(name string, ranks io.Reader, pattern string) (*BPE, error) {
+	split, err := regexp2.Compile(pattern, regexp2.None)
+	if err != nil {
+		return nil, fmt.Errorf("tokenizer: %s pattern: %w", name, err)
+	}
+	r, err := parseRanks(ranks)
+	if err != nil {
+		return nil, fmt.Errorf("tokenizer: %s: %w", name, err)
+	}
+	for b := range 256 {
+		if _, ok := r[string([]byte{byte(b)})]; !ok {
+			return nil, fmt.Errorf("tokenizer: %s: vocabulary has no token for byte 0x%02x", name, b)
+		}
+	}
+	return &BPE{name: name, ranks: r, split: split}, nil
+}
+
+// Name returns the vocabulary name.
+func (t *BPE) Name() string { return t.name }
+
+// CountTokens returns the number of tokens text encodes to. Special tokens
+// such as <|endoftext|> are counted as ordinary text.
+func (t *BPE) CountTokens(text string) int {
+	n := 0
+	m, err := t.split.FindStringMatch(text)
+	for m != nil && err == nil {
+		piece := []byte(m.String())
+		for len(piece) > maxPieceBytes {
+			n += t.countPiece(piece[:maxPieceBytes])
+			piece = piece[maxPieceBytes:]
+		}
+		n += t.countPiece(piece)
+		m, err = t.split.FindNextMatch(m)
+	}
+	return n
+}
+
+// countPiece runs the BPE merge loop on one pre-tokenized piece: repeatedly
+// merge the adjacent pair with the lowest rank until no pair is in the
+// vocabulary. bounds holds the start of each current part plus len(piece).
+func (t *BPE) countPiece(piece []byte) int {
+	if len(piece) == 0 {
+		return 0
+	}
+	if _, ok := t.ranks[string(piece)]; ok {
+		return 1
+	}
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	for len(bounds) > 2 {
+		best, at := -1, -1
+		for i := 0; i+2 < len(bounds); i++ {
+			if r, ok := t.ranks[string(piece[bounds[i]:bounds[i+2]])]; ok && (best < 0 || r < best) {
+				best, at = r, i
+			}
+		}
+		if at < 0 {
+			break
+		}
+		bounds = append(bounds[:at+1], bounds[at+2:]...)
+	}
+	return len(bounds) - 1
+}
+
+func parseRanks(r io.Reader) (map[string]int, error) {
+	ranks := make(map[string]int, 1<<17)
+	sc := bufio.NewScanner(r)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := bytes.TrimSpace(sc.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		tok, rank, ok := bytes.Cut(text, []byte(" "))
+		if !ok {
+			return nil, fmt.Errorf("rank file line %d: want \"<token> <rank>\"", line)
+		}
+		b, err := base64.StdEncoding.DecodeString(string(tok))
+		if err != nil {
+			return nil, fmt.Errorf("rank file line %d: %w", line, err)
+		}
+		n, err := strconv.Atoi(string(rank))
+		if err != nil {
+			return nil, fmt.Errorf("rank file line %d: %w", line, err)
+		}
+		ranks[string(b)] = n
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read rank file: %w", err)
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("empty rank file")
+	}
+	return ranks, nil
+}
@@ -0,0 +1,114 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toyRanks is a rank file with every single byte plus the given merges,
+// ranked in order after the bytes.
+func toyRanks(merges ...string) string {
+	var b strings.Builder
+	for i := range 256 {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(i)}), i)
+	}
+	for i, m := range merges {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(m)), 256+i)
+	}
+	return b.String()
+}
+
+func TestBPE_CountTokens(t *testing.T) {
+	bpe, err := NewBPE("toy", strings.NewReader(toyRanks("ab", "abc", "bc", "aa")), cl100kPattern)
+	require.NoError(t, err)
+
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abc", 1},     // whole piece is a token
+		{"abc abc", 3}, // "abc" + " abc" → " ", "abc"
+		{"bcd", 2},     // "bc" + "d"
+		{"abd", 2},     // "ab" + "d"
+		{"aaaa", 2},
+		{"123456", 6}, // digits split in threes, then bytes
+		{"{\"id\":1}", 8},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, bpe.CountTokens(tt.text), "%q", tt.text)
+	}
+	assert.Equal(t, "toy", bpe.Name())
+}
+
+func TestBPE_MergesLowestRankFirst(t *testing.T) {
+	// With "bc" ranked first, "abcd" merges b+c, then bc+d: a, bcd.
+	bpe, err := NewBPE("toy", strings.NewReader(toyRanks("bc", "ab", "bcd")), cl100kPattern)
+	require.NoError(t, err)
+	assert.Equal(t, 2, bpe.CountTokens("abcd"))
+
+	// With "ab" ranked first, a+b merges and bcd can never form: ab, c, d.
+	bpe, err = NewBPE("toy", strings.NewReader(toyRanks("ab", "bc", "bcd")), cl100kPattern)
+	require.NoError(t, err)
+	assert.Equal(t, 3, bpe.CountTokens("abcd"))
+}
+
+func TestBPE_LongPiecesAreChunked(t *testing.T) {
+	bpe, err := NewBPE("toy", strings.NewReader(toyRanks("aa")), cl100kPattern)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, bpe.CountTokens(strings.Repeat("a", 2000)))
+}
+
+func TestNewBPE_Errors(t *testing.T) {
+	_, err := NewBPE("toy", strings.NewReader("YQ== 0\n"), cl100kPattern)
+	assert.ErrorContains(t, err, "no token for byte 0x00")
+
+	_, err = NewBPE("toy", strings.NewReader("not-a-rank-line\n"), cl100kPattern)
+	assert.ErrorContains(t, err, "line 1")
+
+	_, err = NewBPE("toy", strings.NewReader(""), cl100kPattern)
+	assert.ErrorContains(t, err, "empty rank file")
+
+	_, err = NewBPE("toy", strings.NewReader(toyRanks()), `(`)
+	assert.ErrorContains(t, err, "pattern")
+}
+
+func TestFamilies_PatternsCompile(t *testing.T) {
+	for _, f := range Families {
+		_, err := NewBPE(f.Name, strings.NewReader(toyRanks()), f.pattern)
+		assert.NoError(t, err, f.Name)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tok, err := Load(Estimate)
+	require.NoError(t, err)
+	assert.Nil(t, tok, "the estimate family means no tokenizer")
+
+	_, err = Load("gpt2")
+	assert.ErrorContains(t, err, "unknown family")
+
+	dir := t.TempDir()
+	t.Setenv(EnvDir, dir)
+	t.Setenv("HOME", t.TempDir())
+	if _, err := embedded.Open("vocab/cl100k_base.tiktoken.gz"); err == nil {
+		t.Skip("cl100k_base is embedded; the on-disk fallback is not reached")
+	}
+	assert.False(t, Available("cl100k_base"))
+	_, err = Load("cl100k_base")
+	assert.ErrorIs(t, err, ErrNoVocabulary)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cl100k_base.tiktoken"), []byte(toyRanks("ab")), 0o600))
+	assert.True(t, Available("cl100k_base"))
+	tok, err = Load("cl100k_base")
+	require.NoError(t, err)
+	assert.Equal(t, "cl100k_base", tok.Name())
+	assert.Equal(t, 2, tok.CountTokens("abc"))
+}
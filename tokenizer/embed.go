@@ -0,0 +1,11 @@
+//go:build !vocab
+
+package tokenizer
+
+import "embed"
+
+// embedded holds whatever rank files vocab/ has; a development build
+// without them falls back to the on-disk vocabularies.
+//
+//go:embed vocab
+var embedded embed.FS
@@ -0,0 +1,11 @@
+//go:build vocab
+
+package tokenizer
+
+import "embed"
+
+// embedded names every family's rank file, so a build with the vocab tag
+// fails unless `go generate ./tokenizer` has populated vocab/.
+//
+//go:embed vocab/o200k_base.tiktoken.gz vocab/cl100k_base.tiktoken.gz
+var embedded embed.FS
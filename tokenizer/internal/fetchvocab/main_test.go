@@ -0,0 +1,77 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func serveVocab(t *testing.T, body string) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	old := baseURL
+	baseURL = srv.URL + "/"
+	t.Cleanup(func() { baseURL = old })
+}
+
+func pinChecksum(t *testing.T, family, body string) {
+	t.Helper()
+	sum := sha256.Sum256([]byte(body))
+	checksums[family] = hex.EncodeToString(sum[:])
+	t.Cleanup(func() { delete(checksums, family) })
+}
+
+func TestFetch_VerifiesChecksum(t *testing.T) {
+	const body = "IQ== 0\nIg== 1\n"
+	serveVocab(t, body)
+	pinChecksum(t, "test_base", body)
+	dir := t.TempDir()
+
+	if err := fetch(dir, "test_base"); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	f, err := os.Open(filepath.Join(dir, "test_base.tiktoken.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(zr)
+	if string(got) != body {
+		t.Errorf("written vocabulary = %q, want %q", got, body)
+	}
+}
+
+func TestFetch_RejectsMismatch(t *testing.T) {
+	serveVocab(t, "tampered 0\n")
+	pinChecksum(t, "test_base", "IQ== 0\n")
+	dir := t.TempDir()
+
+	err := fetch(dir, "test_base")
+	if err == nil || !strings.Contains(err.Error(), "sha256") {
+		t.Fatalf("fetch = %v, want checksum mismatch", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("fetch left %d files behind", len(entries))
+	}
+}
+
+func TestFetch_RequiresPinnedChecksum(t *testing.T) {
+	err := fetch(t.TempDir(), "unknown_base")
+	if err == nil || !strings.Contains(err.Error(), "no pinned checksum") {
+		t.Fatalf("fetch = %v, want missing checksum error", err)
+	}
+}
@@ -0,0 +1,180 @@
+// Package tokenizer counts tokens the way a model family's tokenizer does,
+// so savings metrics can be measured instead of estimated from byte counts.
+//
+// Vocabularies are byte-level BPE rank files in tiktoken's format. They are
+// embedded into the binary from vocab/ (populate it with `go generate
+// ./tokenizer`) and, failing that, read from $SWITCHBOARD_TOKENIZER_DIR or
+// ~/.config/switchboard/tokenizers, so counting never needs the network.
+// Release builds use the vocab build tag, which fails the build when a rank
+// file is missing instead of shipping a binary without it.
+package tokenizer
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	mcp "github.com/daltoniam/switchboard"
+)
+
+//go:generate go run ./internal/fetchvocab -out vocab
+
+// EnvDir is the environment variable naming a directory of
+// <family>.tiktoken rank files, consulted when a vocabulary is not embedded.
+const EnvDir = "SWITCHBOARD_TOKENIZER_DIR"
+
+// Estimate is the family name for the byte heuristic (mcp.CharsPerToken).
+// It is the default and needs no vocabulary.
+const Estimate = "estimate"
+
+// Family is a tokenizer vocabulary that can be selected in Settings.
+type Family struct {
+	Name  string
+	Label string
+	// pattern is the pre-tokenizer regular expression from tiktoken.
+	pattern string
+}
+
+// Pre-tokenizer patterns from tiktoken's openai_public encodings.
+const (
+	cl100kPattern = `(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+`
+	o200kPattern  = `[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+(?!\S)|\s+`
+)
+
+// Families lists the BPE vocabularies switchboard knows how to load.
+// Anthropic does not publish Claude's tokenizer, so for Claude models these
+// approximate the count rather than reproduce it.
+var Families = []Family{
+	{Name: "o200k_base", Label: "o200k_base (GPT-4o, o-series)", pattern: o200kPattern},
+	{Name: "cl100k_base", Label: "cl100k_base (GPT-4, GPT-3.5)", pattern: cl100kPattern},
+}
+
+// ErrNoVocabulary reports that a family's rank file is neither embedded nor
+// present on disk.
+var ErrNoVocabulary = errors.New("tokenizer: vocabulary not available")
+
+var (
+	loadMu sync.Mutex
+	loaded = map[string]*BPE{}
+)
+
+// Load returns the tokenizer for family, loading and caching its vocabulary
+// on first use. The estimate family (or "") returns nil, meaning no
+// measurement: callers keep the byte heuristic.
+func Load(family string) (mcp.Tokenizer, error) {
+	if family == "" || family == Estimate {
+		return nil, nil
+	}
+	f, ok := lookup(family)
+	if !ok {
+		return nil, fmt.Errorf("tokenizer: unknown family %q", family)
+	}
+
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	if t, ok := loaded[family]; ok {
+		return t, nil
+	}
+	rc, err := openVocabulary(family)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+	t, err := NewBPE(family, rc, f.pattern)
+	if err != nil {
+		return nil, err
+	}
+	loaded[family] = t
+	return t, nil
+}
+
+// Available reports whether family can be loaded without error: the
+// estimate always can, BPE families when their vocabulary is present.
+func Available(family string) bool {
+	if family == "" || family == Estimate {
+		return true
+	}
+	if _, ok := lookup(family); !ok {
+		return false
+	}
+	rc, err := openVocabulary(family)
+	if err != nil {
+		return false
+	}
+	_ = rc.Close()
+	return true
+}
+
+// Configure loads family's tokenizer and installs it on m; the estimate
+// family turns measurement off. When the vocabulary cannot be loaded,
+// measurement is turned off too and the error says why.
+func Configure(m *mcp.Metrics, family string) error {
+	t, err := Load(family)
+	if err != nil {
+		m.SetTokenizer(nil)
+		return err
+	}
+	m.SetTokenizer(t)
+	return nil
+}
+
+func lookup(family string) (Family, bool) {
+	for _, f := range Families {
+		if f.Name == family {
+			return f, true
+		}
+	}
+	return Family{}, false
+}
+
+// openVocabulary finds family's rank file: embedded (gzipped) first, then
+// on disk.
+func openVocabulary(family string) (io.ReadCloser, error) {
+	if f, err := embedded.Open("vocab/" + family + ".tiktoken.gz"); err == nil {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("tokenizer: embedded %s vocabulary: %w", family, err)
+		}
+		return readCloser{zr, f}, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("tokenizer: embedded %s vocabulary: %w", family, err)
+	}
+
+	for _, dir := range vocabularyDirs() {
+		f, err := os.Open(filepath.Join(dir, family+".tiktoken")) // #nosec G304 -- family is one of Families, dir is operator-controlled
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("tokenizer: %s vocabulary: %w", family, err)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s (run `go generate ./tokenizer` before building, or put %s.tiktoken in $%s)", ErrNoVocabulary, family, family, EnvDir)
+}
+
+func vocabularyDirs() []string {
+	var dirs []string
+	if dir := os.Getenv(EnvDir); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".config", "switchboard", "tokenizers"))
+	}
+	return dirs
+}
+
+// readCloser closes both the gzip stream and the embedded file under it.
+type readCloser struct {
+	*gzip.Reader
+	f fs.File
+}
+
+func (r readCloser) Close() error {
+	return errors.Join(r.Reader.Close(), r.f.Close())
+}
@@ -9,13 +9,14 @@ import (
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	mcp "github.com/daltoniam/switchboard"
 )
 
 // TestModule_ConcurrentExecute verifies that concurrent calls into a single
 // *Module do not panic or corrupt guest memory. Wazero's api.Function.Call is
-// not goroutine-safe, so Module must serialize calls internally.
+// not goroutine-safe, so Module must never share an instance between calls.
 //
 // Reproduces the production panic at wasm/memory.go:51 where Goja scripts
 // firing many api.call() invocations in parallel hit nil pointer derefs
@@ -28,6 +29,7 @@ func TestModule_ConcurrentExecute(t *testing.T) {
 	defer srv.Close()
 
 	mod := loadTestModule(t)
+	mod.AllowHosts(srv.Listener.Addr().String())
 	if err := mod.Configure(context.Background(), mcp.Credentials{
 		"base_url": srv.URL,
 		"api_key":  "test-key",
@@ -73,7 +75,7 @@ func TestModule_ConcurrentExecute(t *testing.T) {
 }
 
 // TestModule_ConcurrentMixed exercises Execute, Tools, Name, Healthy, and
-// Metadata in parallel. All of these touch m.mod and must be serialized.
+// Metadata in parallel. Each call must run on its own pooled instance.
 func TestModule_ConcurrentMixed(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/health" {
@@ -85,6 +87,7 @@ func TestModule_ConcurrentMixed(t *testing.T) {
 	defer srv.Close()
 
 	mod := loadTestModule(t)
+	mod.AllowHosts(srv.Listener.Addr().String())
 	if err := mod.Configure(context.Background(), mcp.Credentials{
 		"base_url": srv.URL,
 		"api_key":  "test-key",
@@ -169,6 +172,7 @@ func TestModule_ConcurrentExecuteAndClose(t *testing.T) {
 	defer srv.Close()
 
 	mod := loadTestModule(t)
+	mod.AllowHosts(srv.Listener.Addr().String())
 	if err := mod.Configure(context.Background(), mcp.Credentials{
 		"base_url": srv.URL,
 		"api_key":  "test-key",
@@ -209,3 +213,112 @@ func TestModule_ConcurrentExecuteAndClose(t *testing.T) {
 type execErr struct{ data string }
 
 func (e *execErr) Error() string { return e.data }
+
+// slowCalls runs n concurrent example_http_get calls against a module whose
+// upstream sleeps for delay, and returns the wall time for all of them.
+func slowCalls(t *testing.T, mod *Module, n int) time.Duration {
+	t.Helper()
+	var wg sync.WaitGroup
+	errCh := make(chan error, n)
+	start := time.Now()
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := mod.Execute(context.Background(), "example_http_get", map[string]any{"path": "/slow"})
+			if err != nil {
+				errCh <- err
+				return
+			}
+			if result.IsError || !strings.Contains(result.Data, "widget") {
+				errCh <- &execErr{data: result.Data}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errCh)
+	for err := range errCh {
+		t.Errorf("slow call: %v", err)
+	}
+	return time.Since(start)
+}
+
+// TestModule_PoolThroughputScales proves a slow upstream call only blocks
+// its own instance: with a pool, N concurrent calls finish in roughly one
+// upstream delay instead of N, and every pooled instance sees the
+// credentials from Configure.
+func TestModule_PoolThroughputScales(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	const calls = 4
+
+	var unauthorized atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			unauthorized.Add(1)
+		}
+		time.Sleep(delay)
+		_, _ = w.Write([]byte(`[{"id":"item-1","name":"widget"}]`))
+	}))
+	defer srv.Close()
+
+	load := func(size int) *Module {
+		mod := loadTestModule(t, WithPoolSize(size))
+		mod.AllowHosts(srv.Listener.Addr().String())
+		if err := mod.Configure(context.Background(), mcp.Credentials{
+			"base_url": srv.URL,
+			"api_key":  "test-key",
+		}); err != nil {
+			t.Fatalf("Configure: %v", err)
+		}
+		return mod
+	}
+
+	serial := slowCalls(t, load(1), calls)
+	pooled := slowCalls(t, load(calls), calls)
+
+	if serial < calls*delay {
+		t.Errorf("pool of 1 took %s, want at least %s (calls must serialize)", serial, calls*delay)
+	}
+	if pooled >= serial/2 {
+		t.Errorf("pool of %d took %s, want well under the serialized %s", calls, pooled, serial)
+	}
+	if n := unauthorized.Load(); n != 0 {
+		t.Errorf("%d requests missing credentials; configure was not replayed on every instance", n)
+	}
+}
+
+// TestModule_PoolWaitRespectsContext verifies a caller waiting for a busy
+// pool gives up when its context is done.
+func TestModule_PoolWaitRespectsContext(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	mod := loadTestModule(t, WithPoolSize(1))
+	mod.AllowHosts(srv.Listener.Addr().String())
+	if err := mod.Configure(context.Background(), mcp.Credentials{
+		"base_url": srv.URL,
+		"api_key":  "test-key",
+	}); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+
+	go func() {
+		_, _ = mod.Execute(context.Background(), "example_http_get", map[string]any{"path": "/block"})
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	result, err := mod.Execute(ctx, "example_echo", map[string]any{"message": "x"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Data, "deadline exceeded") {
+		t.Errorf("result = %+v, want deadline exceeded error", result)
+	}
+}
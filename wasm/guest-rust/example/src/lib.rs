@@ -112,6 +112,7 @@ pub extern "C" fn metadata() -> u64 {
         homepage: "https://github.com/daltoniam/switchboard".into(),
         license: "MIT".into(),
         capabilities: vec!["http".into()],
+        network: None,
         credential_keys: vec!["base_url".into(), "api_key".into()],
         plain_text_keys: vec!["base_url".into()],
         optional_keys: vec![],
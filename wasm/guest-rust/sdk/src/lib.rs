@@ -40,6 +40,8 @@ pub struct PluginMetadata {
     pub license: String,
     #[serde(default, skip_serializing_if = "Vec::is_empty")]
     pub capabilities: Vec<String>,
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub network: Option<NetworkCapability>,
     #[serde(default, skip_serializing_if = "Vec::is_empty")]
     pub credential_keys: Vec<String>,
     #[serde(default, skip_serializing_if = "Vec::is_empty")]
@@ -50,6 +52,25 @@ pub struct PluginMetadata {
     pub placeholders: HashMap<String, String>,
 }
 
+/// Outbound network access a plugin needs. The host refuses any
+/// `host_http_request` call outside what is declared here.
+#[derive(Serialize, Deserialize, Clone, Default)]
+pub struct NetworkCapability {
+    /// Exact hosts, `*.example.com` wildcards, or `host:port` pairs.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub hosts: Vec<String>,
+    /// Allowed URL schemes; the host defaults to `["https"]`.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub schemes: Vec<String>,
+    /// Ceiling for the `X-Host-Timeout-Seconds` request header.
+    #[serde(default, skip_serializing_if = "is_zero")]
+    pub max_timeout_seconds: u32,
+}
+
+fn is_zero(v: &u32) -> bool {
+    *v == 0
+}
+
 /// Helper to export plugin metadata as a WASM function.
 /// Call this from your `#[no_mangle] pub extern "C" fn metadata() -> u64` export.
 pub fn leaked_metadata(meta: &PluginMetadata) -> u64 {
@@ -75,6 +96,15 @@ pub fn leaked_compact_specs(specs: &HashMap<String, Vec<String>>) -> u64 {
     leaked_result(&data)
 }
 
+/// Input to the optional `render_markdown(ptr_size) -> u64` export (ABI 2).
+/// `data` is the tool's JSON response. Return an empty result to keep JSON,
+/// or Markdown via [`leaked_string`].
+#[derive(Deserialize)]
+pub struct RenderMarkdownRequest {
+    pub tool_name: String,
+    pub data: String,
+}
+
 #[derive(Deserialize)]
 pub struct ExecuteRequest {
     pub tool_name: String,
@@ -111,6 +141,22 @@ extern "C" {
     fn host_http_request_raw(ptr_size: u64) -> u64;
     #[link_name = "host_log"]
     fn host_log_raw(ptr: u32, size: u32);
+
+    // ABI 2
+    #[link_name = "host_clock_now"]
+    fn host_clock_now_raw() -> i64;
+    #[link_name = "host_kv_get"]
+    fn host_kv_get_raw(key_ptr_size: u64) -> u64;
+    #[link_name = "host_kv_set"]
+    fn host_kv_set_raw(key_ptr_size: u64, value_ptr_size: u64) -> u64;
+    #[link_name = "host_kv_delete"]
+    fn host_kv_delete_raw(key_ptr_size: u64) -> u64;
+    #[link_name = "host_kv_list"]
+    fn host_kv_list_raw(prefix_ptr_size: u64) -> u64;
+    #[link_name = "host_secret_get"]
+    fn host_secret_get_raw(key_ptr_size: u64) -> u64;
+    #[link_name = "host_secret_set"]
+    fn host_secret_set_raw(key_ptr_size: u64, value_ptr_size: u64) -> u64;
 }
 
 pub fn host_log(msg: &str) {
@@ -193,6 +239,70 @@ pub fn host_http_request_raw_body(req: &HttpRequest) -> Result<HttpResponse, Str
     do_host_http_request(&patched)
 }
 
+// ── ABI 2 host imports ──────────────────────────────────────────────────────
+
+/// Current host wall-clock time in Unix nanoseconds (ABI 2).
+pub fn host_clock_now() -> i64 {
+    unsafe { host_clock_now_raw() }
+}
+
+/// Read a value from the plugin's key-value store. Returns `None` when the
+/// key is missing or the plugin did not declare the `kv` capability (ABI 2).
+pub fn kv_get(key: &str) -> Option<Vec<u8>> {
+    let result = unsafe { host_kv_get_raw(str_ptr_size(key)) };
+    host_bytes(result)
+}
+
+/// Store a value in the plugin's key-value store (ABI 2).
+pub fn kv_set(key: &str, value: &[u8]) -> Result<(), String> {
+    let value_ptr_size = pack_ptr_size(value.as_ptr() as u32, value.len() as u32);
+    host_status(unsafe { host_kv_set_raw(str_ptr_size(key), value_ptr_size) })
+}
+
+/// Remove a key from the plugin's key-value store (ABI 2).
+pub fn kv_delete(key: &str) -> Result<(), String> {
+    host_status(unsafe { host_kv_delete_raw(str_ptr_size(key)) })
+}
+
+/// List the keys starting with `prefix`, sorted (ABI 2).
+pub fn kv_list(prefix: &str) -> Vec<String> {
+    host_bytes(unsafe { host_kv_list_raw(str_ptr_size(prefix)) })
+        .and_then(|data| serde_json::from_slice(&data).ok())
+        .unwrap_or_default()
+}
+
+/// Read one of the plugin's configured credentials. Requires the `secrets`
+/// capability (ABI 2).
+pub fn secret_get(key: &str) -> Option<String> {
+    host_bytes(unsafe { host_secret_get_raw(str_ptr_size(key)) })
+        .map(|data| String::from_utf8_lossy(&data).into_owned())
+}
+
+/// Persist a new value for a declared credential key, e.g. a refreshed
+/// token. Requires the `secrets` capability (ABI 2).
+pub fn secret_set(key: &str, value: &str) -> Result<(), String> {
+    host_status(unsafe { host_secret_set_raw(str_ptr_size(key), str_ptr_size(value)) })
+}
+
+fn str_ptr_size(s: &str) -> u64 {
+    pack_ptr_size(s.as_ptr() as u32, s.len() as u32)
+}
+
+fn host_bytes(ptr_size: u64) -> Option<Vec<u8>> {
+    let (ptr, size) = unpack_ptr_size(ptr_size);
+    if size == 0 {
+        return None;
+    }
+    Some(unsafe { read_bytes(ptr, size) })
+}
+
+fn host_status(ptr_size: u64) -> Result<(), String> {
+    match host_bytes(ptr_size) {
+        None => Ok(()),
+        Some(msg) => Err(String::from_utf8_lossy(&msg).into_owned()),
+    }
+}
+
 fn do_host_http_request(req: &HttpRequest) -> Result<HttpResponse, String> {
     let req_json = serde_json::to_vec(req).map_err(|e| e.to_string())?;
     let ptr_size = pack_ptr_size(req_json.as_ptr() as u32, req_json.len() as u32);
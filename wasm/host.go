@@ -11,6 +11,7 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
@@ -54,21 +55,84 @@ const (
 	maxHostHTTPTimeout     = 300 * time.Second
 )
 
+// hostHTTPClient and h2cClient serve requests whose context carries no
+// network policy; every policy has its own pair.
 var (
-	hostHTTPClient = &http.Client{Timeout: defaultHostHTTPTimeout}
+	hostHTTPClient = newHostHTTPClient()
+	h2cClient      = newH2CClient()
+)
+
+func newHostHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout:       defaultHostHTTPTimeout,
+		Transport:     sandboxTransport(),
+		CheckRedirect: checkSandboxRedirect,
+	}
+}
 
-	// h2cClient uses an http2.Transport configured for cleartext (no TLS)
-	// connections. Plugins opt in by setting the X-H2C header.
-	h2cClient = &http.Client{
-		Timeout: defaultHostHTTPTimeout,
+// newH2CClient uses an http2.Transport configured for cleartext (no TLS)
+// connections. Plugins opt in by setting the X-H2C header.
+func newH2CClient() *http.Client {
+	return &http.Client{
+		Timeout:       defaultHostHTTPTimeout,
+		CheckRedirect: checkSandboxRedirect,
 		Transport: &http2.Transport{
 			AllowHTTP: true,
 			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
-				return (&net.Dialer{}).DialContext(ctx, network, addr)
+				return sandboxDialContext(ctx, network, addr)
 			},
 		},
 	}
-)
+}
+
+// sandboxTransport clones the default transport with a dialer that enforces
+// the calling plugin's network policy on every connection. It never uses a
+// proxy: the dialer would only see the proxy's address, and the proxy would
+// connect to whatever the plugin asked for.
+func sandboxTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	t.DialContext = sandboxDialContext
+	return t
+}
+
+// httpClient returns the client for a request under p. Each policy owns
+// its clients: the private-address check runs only when a connection is
+// dialed, so a keep-alive connection one plugin was allowed to open must
+// not be handed to another.
+func (p *networkPolicy) httpClient(useH2C bool) *http.Client {
+	switch {
+	case p == nil && useH2C:
+		return h2cClient
+	case p == nil:
+		return hostHTTPClient
+	case useH2C:
+		return p.h2cClient
+	}
+	return p.client
+}
+
+// closeIdleConnections drops the policy's pooled connections once its
+// module is closed.
+func (p *networkPolicy) closeIdleConnections() {
+	p.client.CloseIdleConnections()
+	p.h2cClient.CloseIdleConnections()
+}
+
+// checkSandboxRedirect re-checks each redirect target against the plugin's
+// network policy so an allowed host cannot bounce a request elsewhere.
+func checkSandboxRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return fmt.Errorf("stopped after 10 redirects")
+	}
+	if p := networkPolicyFrom(req.Context()); p != nil {
+		if err := p.checkURL(req.URL); err != nil {
+			p.logViolation(req.URL.String(), err.Error())
+			return err
+		}
+	}
+	return nil
+}
 
 func hostHTTPRequest(ctx context.Context, mod api.Module, ptrSize uint64) uint64 {
 	ptr, size := unpackPtrSize(ptrSize)
@@ -83,6 +147,13 @@ func hostHTTPRequest(ctx context.Context, mod api.Module, ptrSize uint64) uint64
 		return writeErrorResponse(ctx, mod, fmt.Sprintf("parse request: %v", err))
 	}
 
+	// Guest calls always carry the module's policy; a missing one means the
+	// call did not come through Module and is refused outright.
+	if networkPolicyFrom(ctx) == nil {
+		slog.Warn("wasm: host_http_request without network policy", "url", req.URL)
+		return writeErrorResponse(ctx, mod, "http error: network access denied")
+	}
+
 	result, err := doHostHTTP(ctx, &req)
 	if err != nil {
 		return writeErrorResponse(ctx, mod, fmt.Sprintf("http error: %v", err))
@@ -102,8 +173,22 @@ func hostHTTPRequest(ctx context.Context, mod api.Module, ptrSize uint64) uint64
 
 // doHostHTTP executes an HTTP request on behalf of a WASM guest.
 // If the request includes an X-H2C header, the h2c (HTTP/2 cleartext) client
-// is used instead of the default HTTP/1.1 client.
+// is used instead of the default HTTP/1.1 client. When ctx carries a network
+// policy, the URL, every redirect, and every dialed address are checked
+// against it and the timeout override is clamped to its ceiling.
 func doHostHTTP(ctx context.Context, req *httpRequest) (*httpResponse, error) {
+	policy := networkPolicyFrom(ctx)
+	if policy != nil {
+		u, err := url.Parse(req.URL)
+		if err != nil {
+			return nil, fmt.Errorf("parse url: %w", err)
+		}
+		if err := policy.checkURL(u); err != nil {
+			policy.logViolation(req.URL, err.Error())
+			return nil, err
+		}
+	}
+
 	var bodyReader io.Reader
 	if req.BodyBase64 != "" {
 		decoded, err := base64.StdEncoding.DecodeString(req.BodyBase64)
@@ -124,6 +209,9 @@ func doHostHTTP(ctx context.Context, req *httpRequest) (*httpResponse, error) {
 	useH2C := req.Headers[h2cHeaderKey] != ""
 	rawBody := req.Headers[rawBodyHeaderKey] != ""
 	timeout := hostHTTPTimeout(req.Headers[hostTimeoutHeaderKey])
+	if policy != nil {
+		timeout = policy.timeout(timeout)
+	}
 	for k, v := range req.Headers {
 		if k == h2cHeaderKey || k == rawBodyHeaderKey || k == hostTimeoutHeaderKey {
 			continue
@@ -131,11 +219,7 @@ func doHostHTTP(ctx context.Context, req *httpRequest) (*httpResponse, error) {
 		httpReq.Header.Set(k, v)
 	}
 
-	client := hostHTTPClient
-	if useH2C {
-		client = h2cClient
-	}
-	clientCopy := *client
+	clientCopy := *policy.httpClient(useH2C)
 	clientCopy.Timeout = timeout
 
 	resp, err := clientCopy.Do(httpReq)
@@ -200,3 +284,58 @@ func hostLog(_ context.Context, mod api.Module, ptr, size uint32) {
 	}
 	slog.Info("wasm guest", "msg", string(data))
 }
+
+// hostClockNow returns the host's wall-clock time in Unix nanoseconds, for
+// guests built without a WASI clock.
+func hostClockNow(context.Context) int64 {
+	return time.Now().UnixNano()
+}
+
+type hostModuleKey struct{}
+
+// withHostModule records the module making a guest call so host functions
+// can reach its storage, credentials and granted capabilities.
+func withHostModule(ctx context.Context, m *Module) context.Context {
+	return context.WithValue(ctx, hostModuleKey{}, m)
+}
+
+func hostModuleFrom(ctx context.Context) *Module {
+	m, _ := ctx.Value(hostModuleKey{}).(*Module)
+	return m
+}
+
+// logHostDenied logs a host import the calling plugin is not allowed to use.
+func logHostDenied(ctx context.Context, fn string, err error) {
+	plugin := ""
+	if m := hostModuleFrom(ctx); m != nil {
+		plugin = m.pluginName()
+	}
+	slog.Warn("wasm: host import denied", "plugin", plugin, "import", fn, "reason", err)
+}
+
+// readGuestString reads the string at a packed (ptr, size) in guest memory.
+func readGuestString(mod api.Module, ptrSize uint64) (string, error) {
+	ptr, size := unpackPtrSize(ptrSize)
+	data, err := readFromGuest(mod, ptr, size)
+	return string(data), err
+}
+
+// writeGuestResult copies data into guest memory and returns its packed
+// (ptr, size), or 0 when it is empty or cannot be written.
+func writeGuestResult(ctx context.Context, mod api.Module, data []byte) uint64 {
+	ptr, size, err := writeToGuest(ctx, mod, data)
+	if err != nil {
+		slog.Error("wasm: failed to write host result to guest", "err", err)
+		return 0
+	}
+	return packPtrSize(ptr, size)
+}
+
+// writeGuestError returns 0 for a nil error, otherwise the error message
+// written into guest memory.
+func writeGuestError(ctx context.Context, mod api.Module, err error) uint64 {
+	if err == nil {
+		return 0
+	}
+	return writeGuestResult(ctx, mod, []byte(err.Error()))
+}
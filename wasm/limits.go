@@ -0,0 +1,139 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+const (
+	// DefaultMemoryLimitMB caps each instance's linear memory when no limit
+	// is configured.
+	DefaultMemoryLimitMB = 512
+
+	// DefaultCallTimeout bounds a single guest call when no budget is
+	// configured. It leaves room for a plugin making a few outbound requests
+	// at the maximum host HTTP timeout.
+	DefaultCallTimeout = 10 * time.Minute
+
+	wasmPageSize = 64 * 1024
+)
+
+// ErrResourceLimit is wrapped by errors returned when a plugin exceeds its
+// memory limit or call budget. The instance that tripped the limit is
+// discarded; the next call gets a fresh one.
+var ErrResourceLimit = errors.New("wasm: resource limit exceeded")
+
+// WithMemoryLimitMB caps each instance's linear memory at mb mebibytes.
+// Guest attempts to grow past it fail as if the host were out of memory.
+// Values below 1 fall back to DefaultMemoryLimitMB.
+func WithMemoryLimitMB(mb int) ModuleOption {
+	return func(m *Module) {
+		if mb > 0 {
+			m.memoryLimit = uint64(mb) << 20
+		}
+	}
+}
+
+// WithCallTimeout bounds the wall-clock time of each guest call. The runtime
+// interrupts a guest still running when it expires, including one spinning
+// in a loop. Values of zero or less fall back to DefaultCallTimeout.
+func WithCallTimeout(d time.Duration) ModuleOption {
+	return func(m *Module) {
+		if d > 0 {
+			m.callTimeout = d
+		}
+	}
+}
+
+// limitedMemory backs a guest linear memory and refuses to grow it past max.
+// wazero reports the refusal to the guest as a failed memory.grow.
+type limitedMemory struct {
+	buf     []byte
+	max     uint64
+	tripped atomic.Bool
+}
+
+func (l *limitedMemory) Reallocate(size uint64) []byte {
+	// The initial allocation is always honoured; LoadModule rejects modules
+	// whose minimum memory exceeds the limit before instantiating them.
+	if l.buf != nil && size > l.max {
+		l.tripped.Store(true)
+		return nil
+	}
+	if size <= uint64(cap(l.buf)) {
+		l.buf = l.buf[:size]
+		return l.buf
+	}
+	grown := make([]byte, size, min(max(size, 2*uint64(cap(l.buf))), max(l.max, size)))
+	copy(grown, l.buf)
+	l.buf = grown
+	return l.buf
+}
+
+func (l *limitedMemory) Free() {
+	l.buf = nil
+}
+
+// withMemoryLimit returns a context that makes wazero back the next
+// instantiated memory with a limitedMemory, stored into *mem.
+func withMemoryLimit(ctx context.Context, limit uint64, mem **limitedMemory) context.Context {
+	return experimental.WithMemoryAllocator(ctx, experimental.MemoryAllocatorFunc(func(_, _ uint64) experimental.LinearMemory {
+		lm := &limitedMemory{max: limit}
+		*mem = lm
+		return lm
+	}))
+}
+
+// checkMinimumMemory rejects a compiled module whose declared minimum memory
+// already exceeds the limit.
+func checkMinimumMemory(compiled wazero.CompiledModule, limit uint64) error {
+	for _, def := range compiled.ExportedMemories() {
+		if need := uint64(def.Min()) * wasmPageSize; need > limit {
+			return fmt.Errorf("%w: module needs %d MiB of memory, limit is %d MiB", ErrResourceLimit, need>>20, limit>>20)
+		}
+	}
+	return nil
+}
+
+// limitError translates a failed guest call into a clear resource-limit
+// error when the instance tripped its memory limit or ran out of budget.
+// ctx is the caller's context: a deadline that fired while it is still live
+// is the module's own call budget. Other errors are returned unchanged.
+func (m *Module) limitError(ctx context.Context, inst *instance, err error) error {
+	if inst.memory != nil && inst.memory.tripped.Load() {
+		return fmt.Errorf("%w: plugin %q exceeded its %d MiB memory limit", ErrResourceLimit, m.pluginName(), m.memoryLimit>>20)
+	}
+	var exitErr *sys.ExitError
+	if errors.As(err, &exitErr) {
+		switch exitErr.ExitCode() {
+		case sys.ExitCodeDeadlineExceeded:
+			if ctx.Err() == nil {
+				return fmt.Errorf("%w: plugin %q exceeded its %s call budget", ErrResourceLimit, m.pluginName(), m.callTimeout)
+			}
+			return fmt.Errorf("wasm: call interrupted: %w", ctx.Err())
+		case sys.ExitCodeContextCanceled:
+			return fmt.Errorf("wasm: call interrupted: %w", context.Canceled)
+		}
+	}
+	return err
+}
+
+// pluginName names the module in limit errors and logs without calling
+// into the guest, which may be the very instance that just failed or hold
+// the pool's last instance.
+func (m *Module) pluginName() string {
+	switch {
+	case m.nameOverride != "":
+		return m.nameOverride
+	case m.policy != nil && m.policy.plugin != "":
+		return m.policy.plugin
+	}
+	return "wasm"
+}
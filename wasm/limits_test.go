@@ -0,0 +1,183 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mcp "github.com/daltoniam/switchboard"
+)
+
+func TestLimitedMemory_RefusesGrowthPastMax(t *testing.T) {
+	lm := &limitedMemory{max: 4 * wasmPageSize}
+	if buf := lm.Reallocate(2 * wasmPageSize); len(buf) != 2*wasmPageSize {
+		t.Fatalf("initial allocation = %d bytes, want %d", len(buf), 2*wasmPageSize)
+	}
+	buf := lm.Reallocate(4 * wasmPageSize)
+	if len(buf) != 4*wasmPageSize {
+		t.Fatalf("growth to the limit = %d bytes, want %d", len(buf), 4*wasmPageSize)
+	}
+	if lm.tripped.Load() {
+		t.Fatal("growing to exactly the limit must not trip it")
+	}
+	if buf := lm.Reallocate(5 * wasmPageSize); buf != nil {
+		t.Fatalf("growth past the limit returned %d bytes, want nil", len(buf))
+	}
+	if !lm.tripped.Load() {
+		t.Error("growth past the limit should trip")
+	}
+}
+
+func TestLoadModule_MinimumMemoryOverLimit(t *testing.T) {
+	ctx := context.Background()
+	rt, err := NewRuntime(ctx)
+	if err != nil {
+		t.Fatalf("NewRuntime: %v", err)
+	}
+	defer rt.Close(ctx)
+
+	// The example module's minimum memory is just over 1 MiB.
+	_, err = rt.LoadModule(ctx, exampleWasm, WithMemoryLimitMB(1))
+	if !errors.Is(err, ErrResourceLimit) {
+		t.Fatalf("LoadModule = %v, want ErrResourceLimit", err)
+	}
+}
+
+func TestExecute_MemoryLimitTrips(t *testing.T) {
+	mod := loadTestModule(t, WithMemoryLimitMB(4), WithPoolSize(1))
+
+	result, err := mod.Execute(context.Background(), "example_echo", map[string]any{
+		"message": strings.Repeat("x", 8<<20),
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Data, "4 MiB memory limit") {
+		t.Fatalf("result = %+v, want memory limit error", result)
+	}
+
+	// The broken instance is replaced, so the module keeps serving.
+	result, err = mod.Execute(context.Background(), "example_echo", map[string]any{"message": "after"})
+	if err != nil {
+		t.Fatalf("Execute after limit: %v", err)
+	}
+	if result.IsError || !strings.Contains(result.Data, "after") {
+		t.Errorf("result after limit = %+v, want echo", result)
+	}
+}
+
+// TestExecute_ReplayFailureOnReplacementDoesNotDeadlock covers a failed
+// configure replay on the instance that replaced a broken one: logging it
+// must not call into the guest, since the pool's only instance is in use.
+func TestExecute_ReplayFailureOnReplacementDoesNotDeadlock(t *testing.T) {
+	mod := loadTestModule(t, WithMemoryLimitMB(4), WithPoolSize(1))
+	if err := mod.Configure(context.Background(), mcp.Credentials{"base_url": "https://example.com"}); err == nil {
+		t.Fatal("Configure without api_key succeeded; the replay below would not fail")
+	}
+	result, err := mod.Execute(context.Background(), "example_echo", map[string]any{
+		"message": strings.Repeat("x", 8<<20),
+	})
+	if err != nil || !result.IsError {
+		t.Fatalf("Execute = %+v, %v; want memory limit error", result, err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = mod.Execute(context.Background(), "example_echo", map[string]any{"message": "after"})
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Execute on the replacement instance hung logging the failed replay")
+	}
+}
+
+func TestExecute_CallBudgetInterruptsGuest(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		select {
+		case <-release:
+		case <-time.After(5 * time.Second):
+		}
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	mod := loadTestModule(t, WithCallTimeout(100*time.Millisecond), WithPoolSize(1))
+	mod.AllowHosts(srv.Listener.Addr().String())
+	if err := mod.Configure(context.Background(), mcp.Credentials{
+		"base_url": srv.URL,
+		"api_key":  "test-key",
+	}); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+
+	start := time.Now()
+	result, err := mod.Execute(context.Background(), "example_http_get", map[string]any{"path": "/hang"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Execute took %s, want it interrupted near the 100ms budget", elapsed)
+	}
+	if !result.IsError || !strings.Contains(result.Data, "call budget") {
+		t.Fatalf("result = %+v, want call budget error", result)
+	}
+
+	result, err = mod.Execute(context.Background(), "example_echo", map[string]any{"message": "after"})
+	if err != nil {
+		t.Fatalf("Execute after budget: %v", err)
+	}
+	if result.IsError || !strings.Contains(result.Data, "after") {
+		t.Errorf("result after budget = %+v, want echo", result)
+	}
+}
+
+func TestExecute_CallerDeadlineIsNotABudgetError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	mod := loadTestModule(t)
+	mod.AllowHosts(srv.Listener.Addr().String())
+	if err := mod.Configure(context.Background(), mcp.Credentials{
+		"base_url": srv.URL,
+		"api_key":  "test-key",
+	}); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := mod.Execute(ctx, "example_http_get", map[string]any{"path": "/hang"})
+	if err == nil || errors.Is(err, ErrResourceLimit) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Execute = %v, want caller deadline error", err)
+	}
+}
+
+func TestWasmModuleOptions(t *testing.T) {
+	apply := func(wc mcp.WasmModuleConfig) *Module {
+		m := &Module{poolSize: DefaultPoolSize, memoryLimit: DefaultMemoryLimitMB << 20, callTimeout: DefaultCallTimeout}
+		for _, opt := range wasmModuleOptions(wc) {
+			opt(m)
+		}
+		return m
+	}
+
+	m := apply(mcp.WasmModuleConfig{PoolSize: 2, MemoryLimitMB: 64, CallTimeout: "30s"})
+	if m.poolSize != 2 || m.memoryLimit != 64<<20 || m.callTimeout != 30*time.Second {
+		t.Errorf("options = pool %d, memory %d, timeout %s", m.poolSize, m.memoryLimit, m.callTimeout)
+	}
+
+	m = apply(mcp.WasmModuleConfig{CallTimeout: "soon"})
+	if m.poolSize != DefaultPoolSize || m.memoryLimit != DefaultMemoryLimitMB<<20 || m.callTimeout != DefaultCallTimeout {
+		t.Errorf("defaults = pool %d, memory %d, timeout %s", m.poolSize, m.memoryLimit, m.callTimeout)
+	}
+}
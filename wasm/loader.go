@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"sync"
+	"time"
 
 	mcp "github.com/daltoniam/switchboard"
+	"github.com/daltoniam/switchboard/marketplace"
 )
 
 // Loader manages live-loading and unloading of WASM plugins. It holds the
@@ -48,7 +51,7 @@ func (l *Loader) LoadPlugin(ctx context.Context, path string, nameOverride strin
 		return fmt.Errorf("read WASM module %q: %w", path, err)
 	}
 
-	mod, err := l.rt.LoadModule(ctx, wasmBytes)
+	mod, err := l.rt.LoadModule(ctx, wasmBytes, l.moduleOptions(path)...)
 	if err != nil {
 		return fmt.Errorf("load WASM module %q: %w", path, err)
 	}
@@ -111,6 +114,77 @@ func (l *Loader) LoadPlugin(ctx context.Context, path string, nameOverride strin
 	return nil
 }
 
+// moduleOptions returns load options for the plugin at path: its
+// key-value storage lives in a data directory beside the binary, credential
+// updates are saved to the config, and the wasm_modules config entry for
+// path, if there is one, supplies the rest.
+func (l *Loader) moduleOptions(path string) []ModuleOption {
+	opts := []ModuleOption{
+		WithStorageDir(filepath.Join(filepath.Dir(path), "data")),
+		WithCredentialSaver(l.saveCredentials),
+	}
+	cfg := l.cfgMgr.Get()
+	if cfg == nil {
+		return opts
+	}
+	for _, wc := range cfg.WasmModules {
+		if wc.Path == path {
+			return append(opts, wasmModuleOptions(wc)...)
+		}
+	}
+	return opts
+}
+
+// saveCredentials merges credentials a plugin updated at runtime into its
+// integration config.
+func (l *Loader) saveCredentials(plugin string, creds mcp.Credentials) error {
+	ic := &mcp.IntegrationConfig{Enabled: true}
+	merged := mcp.Credentials{}
+	if existing, ok := l.cfgMgr.GetIntegration(plugin); ok {
+		cp := *existing
+		ic = &cp
+		for k, v := range existing.Credentials {
+			merged[k] = v
+		}
+	}
+	for k, v := range creds {
+		merged[k] = v
+	}
+	ic.Credentials = merged
+	return l.cfgMgr.SetIntegration(plugin, ic)
+}
+
+// wasmModuleOptions converts a wasm_modules config entry into load options.
+// An unparsable call_timeout is logged and the default budget kept.
+func wasmModuleOptions(wc mcp.WasmModuleConfig) []ModuleOption {
+	opts := []ModuleOption{WithPoolSize(wc.PoolSize), WithMemoryLimitMB(wc.MemoryLimitMB)}
+	if wc.CallTimeout != "" {
+		d, err := time.ParseDuration(wc.CallTimeout)
+		if err != nil {
+			log.Printf("WARN: wasm module %q: invalid call_timeout %q: %v", wc.Path, wc.CallTimeout, err)
+		} else {
+			opts = append(opts, WithCallTimeout(d))
+		}
+	}
+	return opts
+}
+
+// InspectPlugin instantiates the WASM file at path just long enough to read
+// its metadata, without configuring or registering it. The web UI uses it to
+// show the capabilities a plugin requests before the user approves loading.
+func (l *Loader) InspectPlugin(ctx context.Context, path string) (*marketplace.PluginMetadata, error) {
+	wasmBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read WASM module %q: %w", path, err)
+	}
+	mod, err := l.rt.LoadModule(ctx, wasmBytes, WithPoolSize(1))
+	if err != nil {
+		return nil, fmt.Errorf("load WASM module %q: %w", path, err)
+	}
+	defer mod.Close(ctx) //nolint:errcheck
+	return mod.Metadata(), nil
+}
+
 // UnloadPlugin removes a WASM module from the registry and closes it.
 func (l *Loader) UnloadPlugin(ctx context.Context, name string) error {
 	l.mu.Lock()
@@ -3,17 +3,48 @@ package wasm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 
 	mcp "github.com/daltoniam/switchboard"
 	"github.com/daltoniam/switchboard/marketplace"
 )
 
-var _ mcp.FieldCompactionIntegration = (*Module)(nil)
+var (
+	_ mcp.FieldCompactionIntegration = (*Module)(nil)
+	_ mcp.MarkdownIntegration        = (*Module)(nil)
+)
 
 // SetName overrides the name returned by the WASM module's name() export.
 func (m *Module) SetName(name string) {
 	m.nameOverride = name
+	if name != "" && m.policy != nil {
+		m.policy.plugin = name
+	}
+	m.bindStorage(name)
+}
+
+// AllowHosts grants the module network access to hosts beyond what its
+// metadata declares, using the same syntax as marketplace.NetworkCapability
+// hosts. Operator-granted hosts may be reached over http or https and may
+// resolve to private addresses; the declared hosts keep their own schemes.
+// Call it before the module is registered; it is not safe to race with guest calls.
+func (m *Module) AllowHosts(hosts ...string) {
+	if m.policy == nil {
+		m.policy = newNetworkPolicy(m.Name(), nil)
+	}
+	m.policy.allowHosts(hosts...)
+}
+
+// callCtx attaches the module and its network policy to ctx so host
+// functions invoked during the guest call can enforce them.
+func (m *Module) callCtx(ctx context.Context) context.Context {
+	ctx = withHostModule(ctx, m)
+	if m.policy == nil {
+		return ctx
+	}
+	return withNetworkPolicy(ctx, m.policy)
 }
 
 // Name implements mcp.Integration.
@@ -21,99 +52,55 @@ func (m *Module) Name() string {
 	if m.nameOverride != "" {
 		return m.nameOverride
 	}
-	if m.closed.Load() {
-		return "unknown"
-	}
-	m.callMu.Lock()
-	defer m.callMu.Unlock()
-	if m.closed.Load() {
-		return "unknown"
-	}
 	ctx := context.Background()
-	results, err := m.fnName.Call(ctx)
-	if err != nil {
-		return "unknown"
-	}
-	if len(results) == 0 {
-		return "unknown"
-	}
-
-	ptr, size := unpackPtrSize(results[0])
-	data, err := readFromGuest(m.mod, ptr, size)
-	freeInGuest(ctx, m.mod, ptr)
+	var data []byte
+	err := m.withInstance(ctx, func(ctx context.Context, inst *instance) error {
+		var callErr error
+		data, callErr = inst.callResult(ctx, inst.fnName)
+		return callErr
+	})
 	if err != nil {
 		return "unknown"
 	}
 	return string(data)
 }
 
-// Configure implements mcp.Integration.
+// Configure implements mcp.Integration. The credentials are applied to one
+// instance immediately, and replayed on every other pooled instance before
+// its next call.
 func (m *Module) Configure(ctx context.Context, creds mcp.Credentials) error {
 	credsJSON, err := json.Marshal(creds)
 	if err != nil {
 		return fmt.Errorf("wasm: marshal credentials: %w", err)
 	}
 
-	if m.closed.Load() {
-		return ErrModuleClosed
-	}
-	m.callMu.Lock()
-	defer m.callMu.Unlock()
-	if m.closed.Load() {
-		return ErrModuleClosed
-	}
-
-	ptr, size, err := writeToGuest(ctx, m.mod, credsJSON)
+	inst, err := m.acquire(ctx)
 	if err != nil {
-		return fmt.Errorf("wasm: write credentials: %w", err)
+		return err
 	}
-	defer freeInGuest(ctx, m.mod, ptr)
+	defer m.release(inst)
 
-	results, err := m.fnConfig.Call(ctx, packPtrSize(ptr, size))
-	if err != nil {
-		return fmt.Errorf("wasm: configure call failed: %w", err)
-	}
-	if len(results) == 0 {
-		return nil
-	}
+	m.cfgMu.Lock()
+	m.credsJSON = credsJSON
+	m.cfgGen++
+	gen := m.cfgGen
+	m.cfgMu.Unlock()
 
-	rPtr, rSize := unpackPtrSize(results[0])
-	if rSize == 0 {
-		return nil
-	}
-	errData, readErr := readFromGuest(m.mod, rPtr, rSize)
-	freeInGuest(ctx, m.mod, rPtr)
-	if readErr != nil {
-		return fmt.Errorf("wasm: read configure result: %w", readErr)
-	}
-	if len(errData) > 0 {
-		return fmt.Errorf("%s", string(errData))
-	}
-	return nil
+	inst.cfgGen = gen
+	return m.call(ctx, inst, func(callCtx context.Context) error {
+		return inst.configure(callCtx, credsJSON)
+	})
 }
 
 // Tools implements mcp.Integration.
 func (m *Module) Tools() []mcp.ToolDefinition {
-	if m.closed.Load() {
-		return nil
-	}
-	m.callMu.Lock()
-	defer m.callMu.Unlock()
-	if m.closed.Load() {
-		return nil
-	}
 	ctx := context.Background()
-	results, err := m.fnTools.Call(ctx)
-	if err != nil {
-		return nil
-	}
-	if len(results) == 0 {
-		return nil
-	}
-
-	ptr, size := unpackPtrSize(results[0])
-	data, err := readFromGuest(m.mod, ptr, size)
-	freeInGuest(ctx, m.mod, ptr)
+	var data []byte
+	err := m.withInstance(ctx, func(ctx context.Context, inst *instance) error {
+		var callErr error
+		data, callErr = inst.callResult(ctx, inst.fnTools)
+		return callErr
+	})
 	if err != nil {
 		return nil
 	}
@@ -140,34 +127,31 @@ func (m *Module) Execute(ctx context.Context, toolName mcp.ToolName, args map[st
 		return &mcp.ToolResult{Data: err.Error(), IsError: true}, nil
 	}
 
-	if m.closed.Load() {
-		return &mcp.ToolResult{Data: ErrModuleClosed.Error(), IsError: true}, nil
-	}
-	m.callMu.Lock()
-	defer m.callMu.Unlock()
-	if m.closed.Load() {
-		return &mcp.ToolResult{Data: ErrModuleClosed.Error(), IsError: true}, nil
-	}
+	var (
+		data    []byte
+		callErr error
+	)
+	err = m.withInstance(ctx, func(ctx context.Context, inst *instance) error {
+		ptr, size, err := writeToGuest(ctx, inst.mod, reqJSON)
+		if err != nil {
+			return err
+		}
+		defer freeInGuest(ctx, inst.mod, ptr)
 
-	ptr, size, err := writeToGuest(ctx, m.mod, reqJSON)
-	if err != nil {
-		return &mcp.ToolResult{Data: err.Error(), IsError: true}, nil
+		data, callErr = inst.callResult(ctx, inst.fnExec, packPtrSize(ptr, size))
+		if errors.Is(callErr, errEmptyResult) {
+			return nil
+		}
+		return callErr
+	})
+	if errors.Is(callErr, errEmptyResult) {
+		return &mcp.ToolResult{Data: "empty response from wasm module", IsError: true}, nil
 	}
-	defer freeInGuest(ctx, m.mod, ptr)
-
-	results, err := m.fnExec.Call(ctx, packPtrSize(ptr, size))
-	if err != nil {
+	if callErr != nil && !errors.Is(err, ErrResourceLimit) {
 		return nil, fmt.Errorf("wasm: execute call failed: %w", err)
 	}
-	if len(results) == 0 {
-		return &mcp.ToolResult{Data: "empty response from wasm module", IsError: true}, nil
-	}
-
-	rPtr, rSize := unpackPtrSize(results[0])
-	data, readErr := readFromGuest(m.mod, rPtr, rSize)
-	freeInGuest(ctx, m.mod, rPtr)
-	if readErr != nil {
-		return &mcp.ToolResult{Data: readErr.Error(), IsError: true}, nil
+	if err != nil {
+		return &mcp.ToolResult{Data: err.Error(), IsError: true}, nil
 	}
 
 	var result mcp.ToolResult
@@ -179,50 +163,28 @@ func (m *Module) Execute(ctx context.Context, toolName mcp.ToolName, args map[st
 
 // Healthy implements mcp.Integration.
 func (m *Module) Healthy(ctx context.Context) bool {
-	if m.closed.Load() {
-		return false
-	}
-	m.callMu.Lock()
-	defer m.callMu.Unlock()
-	if m.closed.Load() {
-		return false
-	}
-	results, err := m.fnHealthy.Call(ctx)
-	if err != nil {
-		return false
-	}
-	if len(results) == 0 {
-		return false
-	}
-	return results[0] == 1
+	healthy := false
+	_ = m.withInstance(ctx, func(ctx context.Context, inst *instance) error {
+		results, err := inst.fnHealthy.Call(ctx)
+		if err != nil {
+			return err
+		}
+		healthy = len(results) > 0 && results[0] == 1
+		return nil
+	})
+	return healthy
 }
 
 // Metadata returns plugin metadata from the WASM module's `metadata()` export.
 func (m *Module) Metadata() *marketplace.PluginMetadata {
-	if m.closed.Load() {
-		return nil
-	}
-	m.callMu.Lock()
-	defer m.callMu.Unlock()
-	if m.closed.Load() {
-		return nil
-	}
 	ctx := context.Background()
-	results, err := m.fnMetadata.Call(ctx)
-	if err != nil {
-		return nil
-	}
-	if len(results) == 0 {
-		return nil
-	}
-
-	ptr, size := unpackPtrSize(results[0])
-	if size == 0 {
-		return nil
-	}
-	data, err := readFromGuest(m.mod, ptr, size)
-	freeInGuest(ctx, m.mod, ptr)
-	if err != nil {
+	var data []byte
+	err := m.withInstance(ctx, func(ctx context.Context, inst *instance) error {
+		var callErr error
+		data, callErr = inst.callResult(ctx, inst.fnMetadata)
+		return callErr
+	})
+	if err != nil || len(data) == 0 {
 		return nil
 	}
 
@@ -272,3 +234,41 @@ func (m *Module) CredentialKeys() []string {
 	}
 	return meta.CredentialKeys
 }
+
+// RenderMarkdown implements mcp.MarkdownIntegration by calling the guest's
+// optional render_markdown export with {"tool_name", "data"}. An empty
+// result, a missing export, or a failed call leaves the response as JSON.
+func (m *Module) RenderMarkdown(toolName mcp.ToolName, data []byte) (mcp.Markdown, bool) {
+	if !m.hasRenderMarkdown {
+		return "", false
+	}
+	reqJSON, err := json.Marshal(struct {
+		ToolName string `json:"tool_name"`
+		Data     string `json:"data"`
+	}{ToolName: string(toolName), Data: string(data)})
+	if err != nil {
+		return "", false
+	}
+
+	ctx := context.Background()
+	var out []byte
+	err = m.withInstance(ctx, func(ctx context.Context, inst *instance) error {
+		ptr, size, err := writeToGuest(ctx, inst.mod, reqJSON)
+		if err != nil {
+			return err
+		}
+		defer freeInGuest(ctx, inst.mod, ptr)
+
+		var callErr error
+		out, callErr = inst.callResult(ctx, inst.fnRenderMarkdown, packPtrSize(ptr, size))
+		return callErr
+	})
+	if err != nil {
+		slog.Warn("wasm: render_markdown call failed", "plugin", m.pluginName(), "tool", toolName, "err", err)
+		return "", false
+	}
+	if len(out) == 0 {
+		return "", false
+	}
+	return mcp.Markdown(out), true
+}
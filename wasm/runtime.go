@@ -6,10 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	mcp "github.com/daltoniam/switchboard"
+	"github.com/daltoniam/switchboard/marketplace"
 	"github.com/tetratelabs/wazero"
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
@@ -27,8 +30,10 @@ type Runtime struct {
 }
 
 // NewRuntime creates a new wazero runtime with WASI support and host functions.
+// Guest calls are interrupted when their context is done, which is how
+// per-call budgets and caller deadlines stop a runaway plugin.
 func NewRuntime(ctx context.Context) (*Runtime, error) {
-	rt := wazero.NewRuntime(ctx)
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true))
 
 	wasi_snapshot_preview1.MustInstantiate(ctx, rt)
 
@@ -41,6 +46,34 @@ func NewRuntime(ctx context.Context) (*Runtime, error) {
 		WithFunc(hostLog).
 		WithParameterNames("ptr", "size").
 		Export("host_log").
+		// ABI 2 imports.
+		NewFunctionBuilder().
+		WithFunc(hostClockNow).
+		Export("host_clock_now").
+		NewFunctionBuilder().
+		WithFunc(hostKVGet).
+		WithParameterNames("key_ptr_size").
+		Export("host_kv_get").
+		NewFunctionBuilder().
+		WithFunc(hostKVSet).
+		WithParameterNames("key_ptr_size", "value_ptr_size").
+		Export("host_kv_set").
+		NewFunctionBuilder().
+		WithFunc(hostKVDelete).
+		WithParameterNames("key_ptr_size").
+		Export("host_kv_delete").
+		NewFunctionBuilder().
+		WithFunc(hostKVList).
+		WithParameterNames("prefix_ptr_size").
+		Export("host_kv_list").
+		NewFunctionBuilder().
+		WithFunc(hostSecretGet).
+		WithParameterNames("key_ptr_size").
+		Export("host_secret_get").
+		NewFunctionBuilder().
+		WithFunc(hostSecretSet).
+		WithParameterNames("key_ptr_size", "value_ptr_size").
+		Export("host_secret_set").
 		Instantiate(ctx)
 	if err != nil {
 		rt.Close(ctx) //nolint:errcheck
@@ -50,20 +83,188 @@ func NewRuntime(ctx context.Context) (*Runtime, error) {
 	return &Runtime{rt: rt}, nil
 }
 
-// LoadModule compiles and instantiates a WASM binary, returning a Module
-// that implements mcp.Integration.
-func (r *Runtime) LoadModule(ctx context.Context, wasmBytes []byte) (*Module, error) {
+// DefaultPoolSize is the maximum number of instances a Module keeps when no
+// pool size is configured. Instances are created on demand, so an idle
+// plugin only ever holds one.
+const DefaultPoolSize = 4
+
+// ModuleOption configures a Module at load time.
+type ModuleOption func(*Module)
+
+// WithPoolSize sets the maximum number of concurrent instances of the
+// module. Values below 1 fall back to DefaultPoolSize.
+func WithPoolSize(n int) ModuleOption {
+	return func(m *Module) {
+		if n > 0 {
+			m.poolSize = n
+		}
+	}
+}
+
+// LoadModule compiles a WASM binary and instantiates its first instance,
+// returning a Module that implements mcp.Integration. Further instances are
+// instantiated from the same compiled module as concurrent calls need them.
+func (r *Runtime) LoadModule(ctx context.Context, wasmBytes []byte, opts ...ModuleOption) (*Module, error) {
 	compiled, err := r.rt.CompileModule(ctx, wasmBytes)
 	if err != nil {
 		return nil, fmt.Errorf("wasm: compile module: %w", err)
 	}
 
-	mod, err := r.rt.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().
+	m := &Module{
+		rt:          r.rt,
+		compiled:    compiled,
+		poolSize:    DefaultPoolSize,
+		memoryLimit: DefaultMemoryLimitMB << 20,
+		callTimeout: DefaultCallTimeout,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if err := checkMinimumMemory(compiled, m.memoryLimit); err != nil {
+		compiled.Close(ctx) //nolint:errcheck
+		return nil, err
+	}
+	m.idle = make(chan *instance, m.poolSize)
+
+	inst, err := m.newInstance(ctx)
+	if err != nil {
+		compiled.Close(ctx) //nolint:errcheck
+		return nil, err
+	}
+	m.created = 1
+	m.hasCompactSpecs = inst.fnCompactSpecs != nil
+	m.hasRenderMarkdown = inst.fnRenderMarkdown != nil
+	m.idle <- inst
+
+	// Metadata runs before the policy exists, so the guest has no network
+	// access until its declared capabilities are known.
+	meta := m.Metadata()
+	name := ""
+	if meta != nil {
+		name = meta.Name
+	}
+	m.policy = newNetworkPolicy(name, meta)
+	m.grantCapabilities(name, meta)
+	m.loadCompactSpecs(ctx)
+
+	return m, nil
+}
+
+// Close releases all runtime resources.
+func (r *Runtime) Close(ctx context.Context) error {
+	return r.rt.Close(ctx)
+}
+
+// Module wraps a compiled WASM module and a pool of its instances, and
+// implements mcp.Integration.
+//
+// Concurrency: wazero's api.Function.Call is explicitly not goroutine-safe
+// (see api.Function.Call docs). An instance shares one linear memory and one
+// malloc/free heap, so every host -> guest call sequence
+// (malloc -> Memory().Write -> Call -> Memory().Read -> free) must run on an
+// instance no other goroutine holds. Each call checks an instance out of
+// idle, growing the pool up to poolSize, and returns it afterwards; a slow
+// upstream request therefore only blocks its own instance. closed is set by
+// Close so racing callers return ErrModuleClosed instead of touching a
+// freed instance.
+//
+// Every call runs under callTimeout and every instance's memory is capped at
+// memoryLimit. An instance that trips either limit, or is otherwise killed
+// by the runtime, is discarded and replaced on its next checkout.
+type Module struct {
+	closed atomic.Bool
+
+	rt       wazero.Runtime
+	compiled wazero.CompiledModule
+	poolSize int
+
+	memoryLimit uint64        // bytes of linear memory per instance
+	callTimeout time.Duration // wall-clock budget per guest call
+
+	poolMu  sync.Mutex // guards created and serializes instance creation
+	created int
+	idle    chan *instance
+
+	// Credentials from the last Configure, replayed on instances whose
+	// cfgGen lags behind so every instance sees the same configuration.
+	cfgMu     sync.RWMutex
+	credsJSON []byte
+	cfgGen    uint64
+
+	nameOverride string
+
+	hasCompactSpecs bool                                // compact_specs export present
+	compactSpecs    map[mcp.ToolName][]mcp.CompactField // parsed once after load
+
+	hasRenderMarkdown bool // render_markdown export present
+
+	policy *networkPolicy // outbound network sandbox, derived from metadata at load
+
+	grants          hostGrants // ABI 2 host imports the plugin declared
+	storageDir      string     // parent of per-plugin key-value namespaces
+	storage         *kvStore   // nil unless the kv capability is granted
+	saveCredentials func(plugin string, creds mcp.Credentials) error
+}
+
+// hostGrants records which capability-gated host imports a plugin may use.
+type hostGrants struct {
+	kv             bool
+	secrets        bool
+	credentialKeys []string // keys host_secret_set may write
+}
+
+// grantCapabilities enables the host imports the plugin declared in its
+// metadata and opens its key-value namespace.
+func (m *Module) grantCapabilities(name string, meta *marketplace.PluginMetadata) {
+	if meta == nil {
+		return
+	}
+	m.grants = hostGrants{
+		kv:             slices.Contains(meta.Capabilities, marketplace.CapabilityKV),
+		secrets:        slices.Contains(meta.Capabilities, marketplace.CapabilitySecrets),
+		credentialKeys: meta.CredentialKeys,
+	}
+	m.bindStorage(name)
+}
+
+// bindStorage points the key-value store at the namespace for name.
+func (m *Module) bindStorage(name string) {
+	if m.grants.kv && m.storageDir != "" && name != "" {
+		m.storage = newKVStore(m.storageDir, name)
+	}
+}
+
+// instance is one instantiation of the compiled module with its own linear
+// memory. It is only ever used by the goroutine that checked it out.
+type instance struct {
+	mod        api.Module
+	fnName     api.Function
+	fnTools    api.Function
+	fnConfig   api.Function
+	fnExec     api.Function
+	fnHealthy  api.Function
+	fnMetadata api.Function
+
+	fnCompactSpecs   api.Function // optional: compact_specs() -> u64
+	fnRenderMarkdown api.Function // optional: render_markdown(ptr_size) -> u64
+
+	cfgGen uint64 // Module.cfgGen this instance was last configured for
+
+	memory *limitedMemory // backing linear memory, records limit trips
+	dead   bool           // closed after a failure; replaced on next checkout
+}
+
+// newInstance instantiates and initializes a fresh instance of the compiled
+// module.
+func (m *Module) newInstance(ctx context.Context) (*instance, error) {
+	inst := &instance{}
+	mod, err := m.rt.InstantiateModule(withMemoryLimit(ctx, m.memoryLimit, &inst.memory), m.compiled, wazero.NewModuleConfig().
 		WithStartFunctions().
 		WithName(""))
 	if err != nil {
 		return nil, fmt.Errorf("wasm: instantiate module: %w", err)
 	}
+	inst.mod = mod
 
 	// Standard Go wasip1 modules export _rt0_wasm_wasip1 as the entry point.
 	// We call it manually to initialize the Go runtime without calling main().
@@ -73,81 +274,214 @@ func (r *Runtime) LoadModule(ctx context.Context, wasmBytes []byte) (*Module, er
 		initFn = mod.ExportedFunction("_initialize")
 	}
 	if initFn != nil {
-		if _, err := initFn.Call(ctx); err != nil {
+		initCtx, cancel := context.WithTimeout(ctx, m.callTimeout)
+		_, err := initFn.Call(initCtx)
+		cancel()
+		if err != nil {
 			mod.Close(ctx) //nolint:errcheck
-			return nil, fmt.Errorf("wasm: initialize module: %w", err)
+			return nil, fmt.Errorf("wasm: initialize module: %w", m.limitError(ctx, inst, err))
 		}
 	}
 
-	m := &Module{mod: mod}
-	if err := m.resolveExports(); err != nil {
+	if err := inst.resolveExports(); err != nil {
 		mod.Close(ctx) //nolint:errcheck
 		return nil, err
 	}
-	m.loadCompactSpecs(ctx)
+	return inst, nil
+}
 
-	return m, nil
+// acquire checks an instance out of the pool, instantiating a new one when
+// all are busy and the pool has room, or waiting for one to be released.
+func (m *Module) acquire(ctx context.Context) (*instance, error) {
+	if m.closed.Load() {
+		return nil, ErrModuleClosed
+	}
+	select {
+	case inst := <-m.idle:
+		return m.checkOpen(ctx, inst)
+	default:
+	}
+
+	m.poolMu.Lock()
+	if m.closed.Load() {
+		m.poolMu.Unlock()
+		return nil, ErrModuleClosed
+	}
+	if m.created < m.poolSize {
+		inst, err := m.newInstance(ctx)
+		if err == nil {
+			m.created++
+		}
+		m.poolMu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		return inst, nil
+	}
+	m.poolMu.Unlock()
+
+	select {
+	case inst := <-m.idle:
+		return m.checkOpen(ctx, inst)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 }
 
-// Close releases all runtime resources.
-func (r *Runtime) Close(ctx context.Context) error {
-	return r.rt.Close(ctx)
+// checkOpen hands inst back when the module was closed while the caller
+// waited, so Close can collect it. A dead instance is replaced in place, so
+// the pool keeps its size.
+func (m *Module) checkOpen(ctx context.Context, inst *instance) (*instance, error) {
+	if m.closed.Load() {
+		m.release(inst)
+		return nil, ErrModuleClosed
+	}
+	if inst.dead {
+		fresh, err := m.newInstance(ctx)
+		if err != nil {
+			m.release(inst)
+			return nil, err
+		}
+		return fresh, nil
+	}
+	return inst, nil
 }
 
-// Module wraps an instantiated WASM module and implements mcp.Integration.
-//
-// Concurrency: wazero's api.Function.Call is explicitly not goroutine-safe
-// (see api.Function.Call docs). A single Module instance shares one linear
-// memory and one malloc/free heap, so every host -> guest call sequence
-// (malloc -> Memory().Write -> Call -> Memory().Read -> free) must run as
-// one atomic critical section. callMu serializes all exported methods that
-// touch m.mod or m.fn*. closed is set by Close so racing callers return
-// ErrModuleClosed instead of dereferencing a freed memory instance.
-type Module struct {
-	callMu sync.Mutex
-	closed atomic.Bool
+func (m *Module) release(inst *instance) {
+	m.idle <- inst
+}
 
-	mod          api.Module
-	nameOverride string
-	fnName       api.Function
-	fnTools      api.Function
-	fnConfig     api.Function
-	fnExec       api.Function
-	fnHealthy    api.Function
-	fnMetadata   api.Function
+// withInstance runs fn on a checked-out instance that has been brought up to
+// date with the latest Configure call. fn receives the call context, which
+// carries the network policy and the per-call budget.
+func (m *Module) withInstance(ctx context.Context, fn func(ctx context.Context, inst *instance) error) error {
+	inst, err := m.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer m.release(inst)
+	return m.call(ctx, inst, func(callCtx context.Context) error {
+		m.syncConfig(callCtx, inst)
+		return fn(callCtx, inst)
+	})
+}
 
-	fnCompactSpecs api.Function                        // optional: compact_specs() -> u64
-	compactSpecs   map[mcp.ToolName][]mcp.CompactField // parsed once after load
+// call runs fn on a checked-out inst under the module's call budget. When
+// the guest fails in a way that leaves the instance unusable it is closed
+// and marked dead, and limit violations are reported as ErrResourceLimit.
+func (m *Module) call(ctx context.Context, inst *instance, fn func(callCtx context.Context) error) error {
+	callCtx, cancel := context.WithTimeout(m.callCtx(ctx), m.callTimeout)
+	defer cancel()
+	err := fn(callCtx)
+	if err == nil {
+		return nil
+	}
+	err = m.limitError(ctx, inst, err)
+	if inst.broken() {
+		inst.mod.Close(context.Background()) //nolint:errcheck
+		inst.dead = true
+	}
+	return err
 }
 
-func (m *Module) resolveExports() error {
-	m.fnName = m.mod.ExportedFunction("name")
-	if m.fnName == nil {
+// broken reports whether inst can no longer serve calls: the runtime closed
+// it (deadline, cancellation or guest exit) or it ran out of memory, which
+// leaves the guest allocator in an unknown state.
+func (inst *instance) broken() bool {
+	return inst.mod.IsClosed() || (inst.memory != nil && inst.memory.tripped.Load())
+}
+
+// syncConfig replays the most recent credentials on inst if it has not seen
+// them yet. Failures are logged; Configure already reported them to its
+// caller on the instance it ran on.
+func (m *Module) syncConfig(ctx context.Context, inst *instance) {
+	m.cfgMu.RLock()
+	gen, creds := m.cfgGen, m.credsJSON
+	m.cfgMu.RUnlock()
+	if inst.cfgGen == gen {
+		return
+	}
+	if err := inst.configure(ctx, creds); err != nil {
+		slog.Warn("wasm: replay configure on pooled instance", "plugin", m.pluginName(), "err", err)
+	}
+	inst.cfgGen = gen
+}
+
+func (inst *instance) resolveExports() error {
+	inst.fnName = inst.mod.ExportedFunction("name")
+	if inst.fnName == nil {
 		return fmt.Errorf("wasm: module does not export 'name'")
 	}
-	m.fnTools = m.mod.ExportedFunction("tools")
-	if m.fnTools == nil {
+	inst.fnTools = inst.mod.ExportedFunction("tools")
+	if inst.fnTools == nil {
 		return fmt.Errorf("wasm: module does not export 'tools'")
 	}
-	m.fnConfig = m.mod.ExportedFunction("configure")
-	if m.fnConfig == nil {
+	inst.fnConfig = inst.mod.ExportedFunction("configure")
+	if inst.fnConfig == nil {
 		return fmt.Errorf("wasm: module does not export 'configure'")
 	}
-	m.fnExec = m.mod.ExportedFunction("execute")
-	if m.fnExec == nil {
+	inst.fnExec = inst.mod.ExportedFunction("execute")
+	if inst.fnExec == nil {
 		return fmt.Errorf("wasm: module does not export 'execute'")
 	}
-	m.fnHealthy = m.mod.ExportedFunction("healthy")
-	if m.fnHealthy == nil {
+	inst.fnHealthy = inst.mod.ExportedFunction("healthy")
+	if inst.fnHealthy == nil {
 		return fmt.Errorf("wasm: module does not export 'healthy'")
 	}
-	m.fnMetadata = m.mod.ExportedFunction("metadata")
-	if m.fnMetadata == nil {
+	inst.fnMetadata = inst.mod.ExportedFunction("metadata")
+	if inst.fnMetadata == nil {
 		return fmt.Errorf("wasm: module does not export 'metadata'")
 	}
 
 	// compact_specs is optional — modules without it simply skip compaction.
-	m.fnCompactSpecs = m.mod.ExportedFunction("compact_specs")
+	inst.fnCompactSpecs = inst.mod.ExportedFunction("compact_specs")
+	// render_markdown is optional too (ABI 2); without it results stay JSON.
+	inst.fnRenderMarkdown = inst.mod.ExportedFunction("render_markdown")
+	return nil
+}
+
+// callResult invokes fn and copies the packed (ptr, size) result out of guest
+// memory, freeing the guest buffer. A zero-size result yields nil data.
+func (inst *instance) callResult(ctx context.Context, fn api.Function, params ...uint64) ([]byte, error) {
+	results, err := fn.Call(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, errEmptyResult
+	}
+	ptr, size := unpackPtrSize(results[0])
+	if size == 0 {
+		return nil, nil
+	}
+	data, err := readFromGuest(inst.mod, ptr, size)
+	freeInGuest(ctx, inst.mod, ptr)
+	return data, err
+}
+
+// errEmptyResult is returned by callResult when a guest export returns no
+// values at all.
+var errEmptyResult = errors.New("wasm: empty result from guest")
+
+// configure passes credentials JSON to the guest configure() export and
+// returns the guest-reported error, if any.
+func (inst *instance) configure(ctx context.Context, credsJSON []byte) error {
+	ptr, size, err := writeToGuest(ctx, inst.mod, credsJSON)
+	if err != nil {
+		return fmt.Errorf("wasm: write credentials: %w", err)
+	}
+	defer freeInGuest(ctx, inst.mod, ptr)
+
+	errData, err := inst.callResult(ctx, inst.fnConfig, packPtrSize(ptr, size))
+	if errors.Is(err, errEmptyResult) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("wasm: configure call failed: %w", err)
+	}
+	if len(errData) > 0 {
+		return fmt.Errorf("%s", string(errData))
+	}
 	return nil
 }
 
@@ -155,26 +489,20 @@ func (m *Module) resolveExports() error {
 // parses the returned JSON map into pre-compiled CompactField slices,
 // and caches them for the lifetime of the module.
 func (m *Module) loadCompactSpecs(ctx context.Context) {
-	if m.fnCompactSpecs == nil {
+	if !m.hasCompactSpecs {
 		return
 	}
-	results, err := m.fnCompactSpecs.Call(ctx)
+	var data []byte
+	err := m.withInstance(ctx, func(ctx context.Context, inst *instance) error {
+		var callErr error
+		data, callErr = inst.callResult(ctx, inst.fnCompactSpecs)
+		return callErr
+	})
 	if err != nil {
 		slog.Warn("wasm: compact_specs call failed", "err", err)
 		return
 	}
-	if len(results) == 0 {
-		return
-	}
-
-	ptr, size := unpackPtrSize(results[0])
-	if size == 0 {
-		return
-	}
-	data, err := readFromGuest(m.mod, ptr, size)
-	freeInGuest(ctx, m.mod, ptr)
-	if err != nil {
-		slog.Warn("wasm: read compact_specs", "err", err)
+	if len(data) == 0 {
 		return
 	}
 
@@ -205,18 +533,32 @@ func (m *Module) CompactSpec(toolName mcp.ToolName) ([]mcp.CompactField, bool) {
 	return fields, ok
 }
 
-// Close releases the WASM module instance. Safe to call multiple times.
-// After Close, all other Module methods return ErrModuleClosed (or, for the
-// nil-returning interface methods, an empty result) without touching the
-// underlying wazero module.
+// Close releases every instance of the module and the compiled module.
+// Safe to call multiple times. After Close, all other Module methods return
+// ErrModuleClosed (or, for the nil-returning interface methods, an empty
+// result) without touching the underlying wazero modules.
 func (m *Module) Close(ctx context.Context) error {
-	// Set the flag before acquiring the lock so in-flight callers fail fast
-	// when they re-check it. The lock then waits for any active Call to
-	// complete before we hand the module to wazero for teardown.
+	// Set the flag first so callers fail fast, then take poolMu so no new
+	// instance is created while we count. In-flight calls keep their
+	// instance until they release it; collecting all of them from idle
+	// waits for those calls to complete before teardown.
 	if !m.closed.CompareAndSwap(false, true) {
 		return nil
 	}
-	m.callMu.Lock()
-	defer m.callMu.Unlock()
-	return m.mod.Close(ctx)
+	m.poolMu.Lock()
+	n := m.created
+	m.poolMu.Unlock()
+
+	var errs []error
+	for range n {
+		inst := <-m.idle
+		if !inst.dead {
+			errs = append(errs, inst.mod.Close(ctx))
+		}
+	}
+	errs = append(errs, m.compiled.Close(ctx))
+	if m.policy != nil {
+		m.policy.closeIdleConnections()
+	}
+	return errors.Join(errs...)
 }
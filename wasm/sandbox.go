@@ -0,0 +1,186 @@
+package wasm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/daltoniam/switchboard/marketplace"
+)
+
+// networkPolicy is the per-module outbound network sandbox derived from the
+// plugin's declared capabilities. Module attaches it to the context of every
+// guest call so host_http_request can enforce it.
+type networkPolicy struct {
+	plugin     string
+	anyHost    bool     // legacy "http" capability: any public host
+	hosts      []string // declared hosts, reachable over schemes
+	schemes    []string
+	granted    []string // operator-granted hosts, reachable over http and https
+	maxTimeout time.Duration
+
+	// Pooled connections are per policy; see httpClient.
+	client    *http.Client
+	h2cClient *http.Client
+}
+
+type networkPolicyKey struct{}
+
+func withNetworkPolicy(ctx context.Context, p *networkPolicy) context.Context {
+	return context.WithValue(ctx, networkPolicyKey{}, p)
+}
+
+func networkPolicyFrom(ctx context.Context) *networkPolicy {
+	p, _ := ctx.Value(networkPolicyKey{}).(*networkPolicy)
+	return p
+}
+
+// newNetworkPolicy builds the sandbox for a plugin. A declared Network
+// capability wins over the legacy "http" capability; a plugin declaring
+// neither gets no network access at all.
+func newNetworkPolicy(name string, meta *marketplace.PluginMetadata) *networkPolicy {
+	p := &networkPolicy{
+		plugin:     name,
+		maxTimeout: defaultHostHTTPTimeout,
+		client:     newHostHTTPClient(),
+		h2cClient:  newH2CClient(),
+	}
+	if meta == nil {
+		return p
+	}
+	switch {
+	case meta.Network != nil:
+		for _, h := range meta.Network.Hosts {
+			p.hosts = append(p.hosts, strings.ToLower(strings.TrimSpace(h)))
+		}
+		p.schemes = meta.Network.EffectiveSchemes()
+		if s := meta.Network.MaxTimeoutSeconds; s > 0 {
+			p.maxTimeout = min(time.Duration(s)*time.Second, maxHostHTTPTimeout)
+		}
+	case slices.Contains(meta.Capabilities, marketplace.CapabilityHTTP):
+		p.anyHost = true
+		p.schemes = []string{"http", "https"}
+	}
+	return p
+}
+
+// allowHosts extends the policy with operator-granted hosts. Granted hosts
+// behave like declared ones, including private-address access, and may be
+// reached over http or https; declared hosts keep their declared schemes.
+func (p *networkPolicy) allowHosts(hosts ...string) {
+	for _, h := range hosts {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h == "" || slices.Contains(p.granted, h) {
+			continue
+		}
+		p.granted = append(p.granted, h)
+	}
+}
+
+// checkURL reports whether the plugin may send a request to u.
+func (p *networkPolicy) checkURL(u *url.URL) error {
+	scheme := strings.ToLower(u.Scheme)
+	if (scheme == "http" || scheme == "https") && matchHost(p.granted, u) {
+		return nil
+	}
+	if !slices.Contains(p.schemes, scheme) {
+		if len(p.schemes) == 0 && len(p.granted) == 0 {
+			return fmt.Errorf("plugin %q did not declare network access", p.plugin)
+		}
+		if len(p.schemes) > 0 && (p.anyHost || matchHost(p.hosts, u)) {
+			return fmt.Errorf("scheme %q not allowed for plugin %q", u.Scheme, p.plugin)
+		}
+	} else if p.anyHost || matchHost(p.hosts, u) {
+		return nil
+	}
+	return fmt.Errorf("host %q not in the declared network capabilities of plugin %q", u.Host, p.plugin)
+}
+
+func matchHost(hosts []string, u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	hostPort := host
+	if port := u.Port(); port != "" {
+		hostPort = net.JoinHostPort(host, port)
+	}
+	for _, allowed := range hosts {
+		switch {
+		case strings.HasPrefix(allowed, "*."):
+			if strings.HasSuffix(host, allowed[1:]) {
+				return true
+			}
+		case allowed == host || allowed == hostPort:
+			return true
+		}
+	}
+	return false
+}
+
+// allowsPrivate reports whether the plugin explicitly named host (exactly,
+// not through a wildcard) and may therefore reach it on a private address.
+func (p *networkPolicy) allowsPrivate(host, hostPort string) bool {
+	host, hostPort = strings.ToLower(host), strings.ToLower(hostPort)
+	for _, hosts := range [][]string{p.hosts, p.granted} {
+		if slices.Contains(hosts, host) || slices.Contains(hosts, hostPort) {
+			return true
+		}
+	}
+	return false
+}
+
+// timeout clamps a requested per-call timeout to the plugin's ceiling.
+func (p *networkPolicy) timeout(requested time.Duration) time.Duration {
+	if requested > p.maxTimeout {
+		return p.maxTimeout
+	}
+	return requested
+}
+
+func (p *networkPolicy) logViolation(target, reason string) {
+	slog.Warn("wasm: network capability violation", "plugin", p.plugin, "target", target, "reason", reason)
+}
+
+// isPrivateAddr reports whether addr is loopback, private, link-local (which
+// covers cloud metadata endpoints), CGNAT, or unspecified.
+func isPrivateAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsUnspecified() || cgnatPrefix.Contains(addr)
+}
+
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// sandboxDialContext dials through a net.Dialer whose Control hook checks
+// the address actually being connected to, so DNS answers pointing at
+// private ranges (including rebinding) are refused unless the plugin named
+// the host exactly. Contexts without a policy dial unrestricted.
+func sandboxDialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	d := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	p := networkPolicyFrom(ctx)
+	if p == nil {
+		return d.DialContext(ctx, network, addr)
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	d.Control = func(_, address string, _ syscall.RawConn) error {
+		ap, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return err
+		}
+		if isPrivateAddr(ap.Addr()) && !p.allowsPrivate(host, addr) {
+			p.logViolation(addr, "private address "+ap.Addr().String())
+			return fmt.Errorf("plugin %q may not connect to private address %s", p.plugin, ap.Addr())
+		}
+		return nil
+	}
+	return d.DialContext(ctx, network, addr)
+}
@@ -0,0 +1,263 @@
+package wasm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/daltoniam/switchboard/marketplace"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func TestNewNetworkPolicy_NoCapabilitiesDeniesAll(t *testing.T) {
+	p := newNetworkPolicy("quiet", &marketplace.PluginMetadata{Name: "quiet"})
+	u, _ := url.Parse("https://api.example.com/x")
+	err := p.checkURL(u)
+	if err == nil || !strings.Contains(err.Error(), "did not declare network access") {
+		t.Fatalf("checkURL = %v, want undeclared-access error", err)
+	}
+}
+
+func TestNewNetworkPolicy_LegacyHTTPAllowsAnyHost(t *testing.T) {
+	p := newNetworkPolicy("legacy", &marketplace.PluginMetadata{Capabilities: []string{"http"}})
+	for _, raw := range []string{"https://api.example.com/x", "http://other.test/y"} {
+		u, _ := url.Parse(raw)
+		if err := p.checkURL(u); err != nil {
+			t.Errorf("checkURL(%s) = %v, want nil", raw, err)
+		}
+	}
+	u, _ := url.Parse("ftp://files.example.com/")
+	if err := p.checkURL(u); err == nil {
+		t.Error("expected ftp scheme to be refused")
+	}
+}
+
+func TestNetworkPolicy_DeclaredHosts(t *testing.T) {
+	p := newNetworkPolicy("scoped", &marketplace.PluginMetadata{
+		Capabilities: []string{"http"},
+		Network: &marketplace.NetworkCapability{
+			Hosts: []string{"api.example.com", "*.svc.example.org", "localhost:8080"},
+		},
+	})
+	cases := []struct {
+		url   string
+		allow bool
+	}{
+		{"https://api.example.com/v1", true},
+		{"https://API.example.com/v1", true},
+		{"http://api.example.com/v1", false}, // https only by default
+		{"https://a.svc.example.org/", true},
+		{"https://svc.example.org/", false},
+		{"https://evilsvc.example.org/", false},
+		{"https://localhost:8080/", true},
+		{"https://localhost:9090/", false},
+		{"https://other.example.com/", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.url, func(t *testing.T) {
+			u, _ := url.Parse(tc.url)
+			err := p.checkURL(u)
+			if tc.allow && err != nil {
+				t.Errorf("checkURL = %v, want allowed", err)
+			}
+			if !tc.allow && err == nil {
+				t.Error("checkURL = nil, want refused")
+			}
+		})
+	}
+}
+
+func TestNetworkPolicy_GrantedHostsKeepDeclaredSchemes(t *testing.T) {
+	p := newNetworkPolicy("scoped", &marketplace.PluginMetadata{
+		Network: &marketplace.NetworkCapability{Hosts: []string{"api.example.com"}},
+	})
+	p.allowHosts("127.0.0.1:9000")
+	cases := []struct {
+		url   string
+		allow bool
+	}{
+		{"https://api.example.com/v1", true},
+		{"http://api.example.com/v1", false}, // declared https only
+		{"http://127.0.0.1:9000/", true},
+		{"https://127.0.0.1:9000/", true},
+		{"ftp://127.0.0.1:9000/", false},
+		{"http://127.0.0.1:9001/", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.url, func(t *testing.T) {
+			u, _ := url.Parse(tc.url)
+			err := p.checkURL(u)
+			if tc.allow && err != nil {
+				t.Errorf("checkURL = %v, want allowed", err)
+			}
+			if !tc.allow && err == nil {
+				t.Error("checkURL = nil, want refused")
+			}
+		})
+	}
+}
+
+func TestNetworkPolicy_Timeout(t *testing.T) {
+	p := newNetworkPolicy("slow", &marketplace.PluginMetadata{
+		Network: &marketplace.NetworkCapability{Hosts: []string{"a.test"}, MaxTimeoutSeconds: 120},
+	})
+	if got := p.timeout(300 * time.Second); got != 120*time.Second {
+		t.Errorf("timeout = %s, want 120s", got)
+	}
+	legacy := newNetworkPolicy("legacy", &marketplace.PluginMetadata{Capabilities: []string{"http"}})
+	if got := legacy.timeout(300 * time.Second); got != defaultHostHTTPTimeout {
+		t.Errorf("legacy timeout = %s, want %s", got, defaultHostHTTPTimeout)
+	}
+}
+
+func TestIsPrivateAddr(t *testing.T) {
+	cases := map[string]bool{
+		"127.0.0.1":       true,
+		"10.1.2.3":        true,
+		"192.168.0.10":    true,
+		"169.254.169.254": true,
+		"100.64.0.1":      true,
+		"0.0.0.0":         true,
+		"::1":             true,
+		"fd00::1":         true,
+		"::ffff:10.0.0.1": true,
+		"8.8.8.8":         false,
+		"2606:4700::1111": false,
+	}
+	for addr, want := range cases {
+		if got := isPrivateAddr(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("isPrivateAddr(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestDoHostHTTP_PolicyBlocksPrivateAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		t.Error("request should not reach a private address")
+	}))
+	defer srv.Close()
+
+	p := newNetworkPolicy("legacy", &marketplace.PluginMetadata{Capabilities: []string{"http"}})
+	ctx := withNetworkPolicy(context.Background(), p)
+	_, err := doHostHTTP(ctx, &httpRequest{Method: "GET", URL: srv.URL})
+	if err == nil || !strings.Contains(err.Error(), "private address") {
+		t.Fatalf("doHostHTTP = %v, want private address error", err)
+	}
+}
+
+func TestDoHostHTTP_PolicyIgnoresEnvironmentProxy(t *testing.T) {
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		proxied.Add(1)
+		_, _ = w.Write([]byte("via proxy"))
+	}))
+	defer proxy.Close()
+	t.Setenv("HTTP_PROXY", proxy.URL)
+	t.Setenv("HTTPS_PROXY", proxy.URL)
+
+	if tr := newHostHTTPClient().Transport.(*http.Transport); tr.Proxy != nil {
+		t.Fatal("sandbox transport uses a proxy")
+	}
+
+	// Even with the proxy's own address granted, the request must be dialed
+	// directly so the private-address check sees the real target.
+	p := newNetworkPolicy("legacy", &marketplace.PluginMetadata{Capabilities: []string{"http"}})
+	p.allowHosts(proxy.Listener.Addr().String())
+	ctx := withNetworkPolicy(context.Background(), p)
+	_, err := doHostHTTP(ctx, &httpRequest{Method: "GET", URL: "http://169.254.169.254/latest/meta-data/"})
+	if err == nil || !strings.Contains(err.Error(), "private address") {
+		t.Fatalf("doHostHTTP = %v, want private address error", err)
+	}
+	if n := proxied.Load(); n != 0 {
+		t.Errorf("proxy reached %d times, want 0", n)
+	}
+}
+
+func TestDoHostHTTP_PolicyAllowsExplicitPrivateHost(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	p := newNetworkPolicy("local", &marketplace.PluginMetadata{
+		Network: &marketplace.NetworkCapability{
+			Hosts:   []string{srv.Listener.Addr().String()},
+			Schemes: []string{"http"},
+		},
+	})
+	ctx := withNetworkPolicy(context.Background(), p)
+	resp, err := doHostHTTP(ctx, &httpRequest{Method: "GET", URL: srv.URL})
+	if err != nil {
+		t.Fatalf("doHostHTTP: %v", err)
+	}
+	if resp.Body != "ok" {
+		t.Errorf("body = %q, want ok", resp.Body)
+	}
+}
+
+func TestDoHostHTTP_PoliciesDoNotShareConnections(t *testing.T) {
+	var reached atomic.Int32
+	srv := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		reached.Add(1)
+		_, _ = w.Write([]byte("ok"))
+	}), &http2.Server{}))
+	defer srv.Close()
+
+	local := newNetworkPolicy("local", &marketplace.PluginMetadata{
+		Network: &marketplace.NetworkCapability{
+			Hosts:   []string{srv.Listener.Addr().String()},
+			Schemes: []string{"http"},
+		},
+	})
+	legacy := newNetworkPolicy("legacy", &marketplace.PluginMetadata{Capabilities: []string{"http"}})
+
+	for _, h2c := range []bool{false, true} {
+		headers := map[string]string{}
+		if h2c {
+			headers[h2cHeaderKey] = "1"
+		}
+		req := &httpRequest{Method: "GET", URL: srv.URL, Headers: headers}
+		if _, err := doHostHTTP(withNetworkPolicy(context.Background(), local), req); err != nil {
+			t.Fatalf("doHostHTTP(local, h2c=%v): %v", h2c, err)
+		}
+		// The local plugin's keep-alive connection must not carry the
+		// legacy plugin's request past the private-address check.
+		_, err := doHostHTTP(withNetworkPolicy(context.Background(), legacy), req)
+		if err == nil || !strings.Contains(err.Error(), "private address") {
+			t.Fatalf("doHostHTTP(legacy, h2c=%v) = %v, want private address error", h2c, err)
+		}
+	}
+	if n := reached.Load(); n != 2 {
+		t.Errorf("server reached %d times, want 2", n)
+	}
+}
+
+func TestDoHostHTTP_PolicyChecksRedirects(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		t.Error("redirect target outside the policy should not be reached")
+	}))
+	defer target.Close()
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, strings.Replace(target.URL, "127.0.0.1", "localhost", 1), http.StatusFound)
+	}))
+	defer origin.Close()
+
+	p := newNetworkPolicy("redirect", &marketplace.PluginMetadata{
+		Network: &marketplace.NetworkCapability{
+			Hosts:   []string{origin.Listener.Addr().String()},
+			Schemes: []string{"http"},
+		},
+	})
+	ctx := withNetworkPolicy(context.Background(), p)
+	_, err := doHostHTTP(ctx, &httpRequest{Method: "GET", URL: origin.URL})
+	if err == nil || !strings.Contains(err.Error(), "not in the declared network capabilities") {
+		t.Fatalf("doHostHTTP = %v, want redirect refused", err)
+	}
+}
@@ -0,0 +1,110 @@
+package wasm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	mcp "github.com/daltoniam/switchboard"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// WithCredentialSaver sets the function that persists credentials a plugin
+// updates through host_secret_set, such as a refreshed OAuth token. Without
+// one, updates only last until the module is reloaded.
+func WithCredentialSaver(fn func(plugin string, creds mcp.Credentials) error) ModuleOption {
+	return func(m *Module) {
+		m.saveCredentials = fn
+	}
+}
+
+// credentials returns a copy of the credentials from the last Configure.
+func (m *Module) credentials() mcp.Credentials {
+	m.cfgMu.RLock()
+	raw := m.credsJSON
+	m.cfgMu.RUnlock()
+	creds := mcp.Credentials{}
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &creds)
+	}
+	return creds
+}
+
+// setCredential updates one credential, marks every pooled instance for a
+// configure replay, and persists the result through saveCredentials.
+func (m *Module) setCredential(key, value string) error {
+	if !slices.Contains(m.grants.credentialKeys, key) {
+		return fmt.Errorf("%q is not a declared credential key", key)
+	}
+
+	m.cfgMu.Lock()
+	creds := mcp.Credentials{}
+	if len(m.credsJSON) > 0 {
+		_ = json.Unmarshal(m.credsJSON, &creds)
+	}
+	creds[key] = value
+	raw, err := json.Marshal(creds)
+	if err != nil {
+		m.cfgMu.Unlock()
+		return fmt.Errorf("marshal credentials: %w", err)
+	}
+	m.credsJSON = raw
+	m.cfgGen++
+	m.cfgMu.Unlock()
+
+	if m.saveCredentials == nil {
+		return nil
+	}
+	if err := m.saveCredentials(m.pluginName(), creds); err != nil {
+		return fmt.Errorf("save credentials: %w", err)
+	}
+	return nil
+}
+
+// secretsFrom returns the module making the current guest call when it
+// declared the secrets capability.
+func secretsFrom(ctx context.Context) (*Module, error) {
+	m := hostModuleFrom(ctx)
+	if m == nil || !m.grants.secrets {
+		return nil, errors.New("secrets capability not declared")
+	}
+	return m, nil
+}
+
+func hostSecretGet(ctx context.Context, mod api.Module, keyPtrSize uint64) uint64 {
+	key, err := readGuestString(mod, keyPtrSize)
+	if err != nil {
+		slog.Warn("wasm: host_secret_get read failed", "err", err)
+		return 0
+	}
+	m, err := secretsFrom(ctx)
+	if err != nil {
+		logHostDenied(ctx, "host_secret_get", err)
+		return 0
+	}
+	value := m.credentials()[key]
+	if value == "" {
+		return 0
+	}
+	return writeGuestResult(ctx, mod, []byte(value))
+}
+
+func hostSecretSet(ctx context.Context, mod api.Module, keyPtrSize, valuePtrSize uint64) uint64 {
+	key, err := readGuestString(mod, keyPtrSize)
+	if err != nil {
+		return writeGuestError(ctx, mod, fmt.Errorf("read key: %w", err))
+	}
+	value, err := readGuestString(mod, valuePtrSize)
+	if err != nil {
+		return writeGuestError(ctx, mod, fmt.Errorf("read value: %w", err))
+	}
+	m, err := secretsFrom(ctx)
+	if err != nil {
+		logHostDenied(ctx, "host_secret_set", err)
+		return writeGuestError(ctx, mod, err)
+	}
+	return writeGuestError(ctx, mod, m.setCredential(key, value))
+}
@@ -0,0 +1,270 @@
+package wasm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+const (
+	maxKVKeyBytes   = 512
+	maxKVValueBytes = 1 << 20
+	maxKVTotalBytes = 16 << 20
+)
+
+// WithStorageDir sets the directory under which the module's key-value
+// store lives. Each plugin gets its own subdirectory named after it. Without
+// a storage directory the host_kv_* imports report storage as unavailable.
+func WithStorageDir(dir string) ModuleOption {
+	return func(m *Module) {
+		m.storageDir = dir
+	}
+}
+
+// kvStore is a plugin's persistent key-value namespace, kept in memory and
+// written through to a single JSON file on every change. It is shared by all
+// instances of the module.
+type kvStore struct {
+	mu     sync.Mutex
+	path   string
+	data   map[string][]byte
+	size   int
+	loaded bool
+}
+
+func newKVStore(dir, plugin string) *kvStore {
+	return &kvStore{path: filepath.Join(dir, storageName(plugin), "kv.json")}
+}
+
+// storageName turns a plugin name into a single safe path element.
+func storageName(plugin string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, plugin)
+	if name == "" || name == "." || name == ".." {
+		return "_"
+	}
+	return name
+}
+
+// loadLocked reads the backing file on first use. Callers hold s.mu.
+func (s *kvStore) loadLocked() error {
+	if s.loaded {
+		return nil
+	}
+	s.data = make(map[string][]byte)
+	raw, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.loaded = true
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read kv store: %w", err)
+	}
+	if err := json.Unmarshal(raw, &s.data); err != nil {
+		return fmt.Errorf("parse kv store: %w", err)
+	}
+	for k, v := range s.data {
+		s.size += len(k) + len(v)
+	}
+	s.loaded = true
+	return nil
+}
+
+// saveLocked writes the store atomically via a temp file. Callers hold s.mu.
+func (s *kvStore) saveLocked() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("create kv dir: %w", err)
+	}
+	raw, err := json.Marshal(s.data)
+	if err != nil {
+		return fmt.Errorf("marshal kv store: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0600); err != nil {
+		return fmt.Errorf("write kv store: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Get returns the value stored under key.
+func (s *kvStore) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, false, err
+	}
+	v, ok := s.data[key]
+	return v, ok, nil
+}
+
+// Set stores value under key, enforcing the per-key, per-value and
+// per-plugin size limits.
+func (s *kvStore) Set(key string, value []byte) error {
+	if key == "" || len(key) > maxKVKeyBytes {
+		return fmt.Errorf("key must be 1-%d bytes", maxKVKeyBytes)
+	}
+	if len(value) > maxKVValueBytes {
+		return fmt.Errorf("value exceeds %d bytes", maxKVValueBytes)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return err
+	}
+	size := s.size + len(key) + len(value)
+	if old, ok := s.data[key]; ok {
+		size -= len(key) + len(old)
+	}
+	if size > maxKVTotalBytes {
+		return fmt.Errorf("store would exceed %d bytes", maxKVTotalBytes)
+	}
+	prev, had := s.data[key]
+	s.data[key] = value
+	if err := s.saveLocked(); err != nil {
+		if had {
+			s.data[key] = prev
+		} else {
+			delete(s.data, key)
+		}
+		return err
+	}
+	s.size = size
+	return nil
+}
+
+// Delete removes key. Deleting a missing key is not an error.
+func (s *kvStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return err
+	}
+	old, ok := s.data[key]
+	if !ok {
+		return nil
+	}
+	delete(s.data, key)
+	if err := s.saveLocked(); err != nil {
+		s.data[key] = old
+		return err
+	}
+	s.size -= len(key) + len(old)
+	return nil
+}
+
+// List returns the keys starting with prefix, sorted.
+func (s *kvStore) List(prefix string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	keys := []string{}
+	for k := range s.data {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// kvFrom returns the key-value store of the module making the current guest
+// call, or an error the guest can report when it has none.
+func kvFrom(ctx context.Context) (*kvStore, error) {
+	m := hostModuleFrom(ctx)
+	if m == nil || !m.grants.kv {
+		return nil, errors.New("kv capability not declared")
+	}
+	if m.storage == nil {
+		return nil, errors.New("kv storage unavailable")
+	}
+	return m.storage, nil
+}
+
+func hostKVGet(ctx context.Context, mod api.Module, keyPtrSize uint64) uint64 {
+	key, err := readGuestString(mod, keyPtrSize)
+	if err != nil {
+		slog.Warn("wasm: host_kv_get read failed", "err", err)
+		return 0
+	}
+	kv, err := kvFrom(ctx)
+	if err != nil {
+		logHostDenied(ctx, "host_kv_get", err)
+		return 0
+	}
+	value, ok, err := kv.Get(key)
+	if err != nil {
+		slog.Warn("wasm: host_kv_get failed", "key", key, "err", err)
+		return 0
+	}
+	if !ok {
+		return 0
+	}
+	return writeGuestResult(ctx, mod, value)
+}
+
+func hostKVSet(ctx context.Context, mod api.Module, keyPtrSize, valuePtrSize uint64) uint64 {
+	key, err := readGuestString(mod, keyPtrSize)
+	if err != nil {
+		return writeGuestError(ctx, mod, fmt.Errorf("read key: %w", err))
+	}
+	vPtr, vSize := unpackPtrSize(valuePtrSize)
+	value, err := readFromGuest(mod, vPtr, vSize)
+	if err != nil {
+		return writeGuestError(ctx, mod, fmt.Errorf("read value: %w", err))
+	}
+	kv, err := kvFrom(ctx)
+	if err != nil {
+		logHostDenied(ctx, "host_kv_set", err)
+		return writeGuestError(ctx, mod, err)
+	}
+	return writeGuestError(ctx, mod, kv.Set(key, value))
+}
+
+func hostKVDelete(ctx context.Context, mod api.Module, keyPtrSize uint64) uint64 {
+	key, err := readGuestString(mod, keyPtrSize)
+	if err != nil {
+		return writeGuestError(ctx, mod, fmt.Errorf("read key: %w", err))
+	}
+	kv, err := kvFrom(ctx)
+	if err != nil {
+		logHostDenied(ctx, "host_kv_delete", err)
+		return writeGuestError(ctx, mod, err)
+	}
+	return writeGuestError(ctx, mod, kv.Delete(key))
+}
+
+func hostKVList(ctx context.Context, mod api.Module, prefixPtrSize uint64) uint64 {
+	prefix, err := readGuestString(mod, prefixPtrSize)
+	if err != nil {
+		slog.Warn("wasm: host_kv_list read failed", "err", err)
+		return 0
+	}
+	kv, err := kvFrom(ctx)
+	if err != nil {
+		logHostDenied(ctx, "host_kv_list", err)
+		return 0
+	}
+	keys, err := kv.List(prefix)
+	if err != nil {
+		slog.Warn("wasm: host_kv_list failed", "err", err)
+		return 0
+	}
+	data, _ := json.Marshal(keys)
+	return writeGuestResult(ctx, mod, data)
+}
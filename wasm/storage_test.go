@@ -0,0 +1,196 @@
+package wasm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	mcp "github.com/daltoniam/switchboard"
+)
+
+func TestKVStore_PersistsAcrossReopen(t *testing.T) {
+	dir := t.TempDir()
+	kv := newKVStore(dir, "example")
+	if err := kv.Set("cursor", []byte("page-2")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := kv.Set("token", []byte{0xff, 0x00}); err != nil {
+		t.Fatalf("Set binary: %v", err)
+	}
+
+	reopened := newKVStore(dir, "example")
+	v, ok, err := reopened.Get("cursor")
+	if err != nil || !ok || string(v) != "page-2" {
+		t.Fatalf("Get after reopen = %q, %v, %v", v, ok, err)
+	}
+	v, _, _ = reopened.Get("token")
+	if string(v) != "\xff\x00" {
+		t.Errorf("binary value = %q, want round trip", v)
+	}
+
+	if err := reopened.Delete("cursor"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	keys, err := reopened.List("")
+	if err != nil || len(keys) != 1 || keys[0] != "token" {
+		t.Errorf("List = %v, %v, want [token]", keys, err)
+	}
+}
+
+func TestKVStore_Limits(t *testing.T) {
+	kv := newKVStore(t.TempDir(), "example")
+	if err := kv.Set("", []byte("x")); err == nil {
+		t.Error("empty key should be refused")
+	}
+	if err := kv.Set(strings.Repeat("k", maxKVKeyBytes+1), nil); err == nil {
+		t.Error("oversized key should be refused")
+	}
+	if err := kv.Set("big", make([]byte, maxKVValueBytes+1)); err == nil {
+		t.Error("oversized value should be refused")
+	}
+	for i := range maxKVTotalBytes / maxKVValueBytes {
+		if err := kv.Set(strings.Repeat("a", i+1), make([]byte, maxKVValueBytes-i-1)); err != nil {
+			t.Fatalf("Set %d: %v", i, err)
+		}
+	}
+	if err := kv.Set("overflow", []byte("x")); err == nil {
+		t.Error("store past the total limit should be refused")
+	}
+}
+
+func TestStorageName(t *testing.T) {
+	cases := map[string]string{
+		"example":      "example",
+		"../escape":    ".._escape",
+		"a/b":          "a_b",
+		"..":           "_",
+		"":             "_",
+		"my plugin v2": "my_plugin_v2",
+	}
+	for in, want := range cases {
+		if got := storageName(in); got != want {
+			t.Errorf("storageName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// guestArg writes s into inst's memory and returns its packed (ptr, size).
+func guestArg(t *testing.T, ctx context.Context, inst *instance, s string) uint64 {
+	t.Helper()
+	ptr, size, err := writeToGuest(ctx, inst.mod, []byte(s))
+	if err != nil {
+		t.Fatalf("writeToGuest: %v", err)
+	}
+	return packPtrSize(ptr, size)
+}
+
+// guestResult reads a packed (ptr, size) returned by a host import.
+func guestResult(t *testing.T, inst *instance, ptrSize uint64) string {
+	t.Helper()
+	s, err := readGuestString(inst.mod, ptrSize)
+	if err != nil {
+		t.Fatalf("readGuestString: %v", err)
+	}
+	return s
+}
+
+func TestHostKV_RoundTripThroughGuestMemory(t *testing.T) {
+	mod := loadTestModule(t)
+	mod.grants.kv = true
+	mod.storage = newKVStore(t.TempDir(), "example")
+
+	ctx := context.Background()
+	inst, err := mod.acquire(ctx)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer mod.release(inst)
+	callCtx := mod.callCtx(ctx)
+
+	if res := hostKVSet(callCtx, inst.mod, guestArg(t, ctx, inst, "cursor"), guestArg(t, ctx, inst, "abc")); res != 0 {
+		t.Fatalf("host_kv_set error: %s", guestResult(t, inst, res))
+	}
+	if got := guestResult(t, inst, hostKVGet(callCtx, inst.mod, guestArg(t, ctx, inst, "cursor"))); got != "abc" {
+		t.Errorf("host_kv_get = %q, want abc", got)
+	}
+	if got := guestResult(t, inst, hostKVList(callCtx, inst.mod, guestArg(t, ctx, inst, "cur"))); got != `["cursor"]` {
+		t.Errorf("host_kv_list = %s, want [\"cursor\"]", got)
+	}
+	if res := hostKVDelete(callCtx, inst.mod, guestArg(t, ctx, inst, "cursor")); res != 0 {
+		t.Fatalf("host_kv_delete error: %s", guestResult(t, inst, res))
+	}
+	if res := hostKVGet(callCtx, inst.mod, guestArg(t, ctx, inst, "cursor")); res != 0 {
+		t.Errorf("host_kv_get after delete = %q, want missing", guestResult(t, inst, res))
+	}
+}
+
+func TestHostKV_DeniedWithoutCapability(t *testing.T) {
+	mod := loadTestModule(t, WithStorageDir(t.TempDir()))
+	if mod.storage != nil {
+		t.Fatal("storage opened for a plugin that did not declare kv")
+	}
+
+	ctx := context.Background()
+	inst, err := mod.acquire(ctx)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer mod.release(inst)
+
+	res := hostKVSet(mod.callCtx(ctx), inst.mod, guestArg(t, ctx, inst, "k"), guestArg(t, ctx, inst, "v"))
+	if got := guestResult(t, inst, res); !strings.Contains(got, "kv capability not declared") {
+		t.Errorf("host_kv_set = %q, want capability error", got)
+	}
+}
+
+func TestHostSecret_GetAndSet(t *testing.T) {
+	var saved mcp.Credentials
+	mod := loadTestModule(t, WithCredentialSaver(func(plugin string, creds mcp.Credentials) error {
+		if plugin != "example" {
+			t.Errorf("saved plugin = %q, want example", plugin)
+		}
+		saved = creds
+		return nil
+	}))
+	if err := mod.Configure(context.Background(), mcp.Credentials{
+		"base_url": "https://api.example.com",
+		"api_key":  "old",
+	}); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	mod.grants.secrets = true
+
+	ctx := context.Background()
+	inst, err := mod.acquire(ctx)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer mod.release(inst)
+	callCtx := mod.callCtx(ctx)
+
+	if got := guestResult(t, inst, hostSecretGet(callCtx, inst.mod, guestArg(t, ctx, inst, "api_key"))); got != "old" {
+		t.Errorf("host_secret_get = %q, want old", got)
+	}
+	res := hostSecretSet(callCtx, inst.mod, guestArg(t, ctx, inst, "api_key"), guestArg(t, ctx, inst, "new"))
+	if res != 0 {
+		t.Fatalf("host_secret_set error: %s", guestResult(t, inst, res))
+	}
+	if saved["api_key"] != "new" || saved["base_url"] != "https://api.example.com" {
+		t.Errorf("saved = %v, want merged credentials with the new key", saved)
+	}
+	if got := mod.credentials()["api_key"]; got != "new" {
+		t.Errorf("credentials after set = %q, want new", got)
+	}
+
+	res = hostSecretSet(callCtx, inst.mod, guestArg(t, ctx, inst, "undeclared"), guestArg(t, ctx, inst, "x"))
+	if got := guestResult(t, inst, res); !strings.Contains(got, "not a declared credential key") {
+		t.Errorf("host_secret_set undeclared = %q, want refusal", got)
+	}
+}
+
+func TestRenderMarkdown_WithoutExport(t *testing.T) {
+	mod := loadTestModule(t)
+	if md, ok := mod.RenderMarkdown("example_echo", []byte(`{"message":"hi"}`)); ok || md != "" {
+		t.Errorf("RenderMarkdown = %q, %v, want not rendered", md, ok)
+	}
+}
@@ -14,7 +14,7 @@ import (
 //go:embed testdata/example.wasm
 var exampleWasm []byte
 
-func loadTestModule(t *testing.T) *Module {
+func loadTestModule(t *testing.T, opts ...ModuleOption) *Module {
 	t.Helper()
 	ctx := context.Background()
 	rt, err := NewRuntime(ctx)
@@ -23,7 +23,7 @@ func loadTestModule(t *testing.T) *Module {
 	}
 	t.Cleanup(func() { rt.Close(ctx) })
 
-	mod, err := rt.LoadModule(ctx, exampleWasm)
+	mod, err := rt.LoadModule(ctx, exampleWasm, opts...)
 	if err != nil {
 		t.Fatalf("LoadModule: %v", err)
 	}
@@ -194,6 +194,7 @@ func TestExecute_HTTPGet(t *testing.T) {
 	defer srv.Close()
 
 	mod := loadTestModule(t)
+	mod.AllowHosts(srv.Listener.Addr().String())
 	_ = mod.Configure(context.Background(), mcp.Credentials{
 		"base_url": srv.URL,
 		"api_key":  "test-key",
@@ -223,6 +224,7 @@ func TestExecute_ListItems(t *testing.T) {
 	defer srv.Close()
 
 	mod := loadTestModule(t)
+	mod.AllowHosts(srv.Listener.Addr().String())
 	_ = mod.Configure(context.Background(), mcp.Credentials{
 		"base_url": srv.URL,
 		"api_key":  "test-key",
@@ -248,6 +250,7 @@ func TestExecute_HTTPError(t *testing.T) {
 	defer srv.Close()
 
 	mod := loadTestModule(t)
+	mod.AllowHosts(srv.Listener.Addr().String())
 	_ = mod.Configure(context.Background(), mcp.Credentials{
 		"base_url": srv.URL,
 		"api_key":  "test-key",
@@ -274,6 +277,7 @@ func TestHealthy(t *testing.T) {
 	defer srv.Close()
 
 	mod := loadTestModule(t)
+	mod.AllowHosts(srv.Listener.Addr().String())
 	_ = mod.Configure(context.Background(), mcp.Credentials{
 		"base_url": srv.URL,
 		"api_key":  "test-key",
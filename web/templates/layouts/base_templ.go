@@ -32,6 +32,8 @@ func navItems() []NavItem {
 	return []NavItem{
 		{Path: "/", Label: "Dashboard", Icon: "⚡"},
 		{Path: "/integrations", Label: "Integrations", Icon: "🔌"},
+		{Path: "/compaction", Label: "Compaction", Icon: "🗜"},
+		{Path: "/projects", Label: "Projects", Icon: "📁"},
 		{Path: "/plugins", Label: "Plugin Marketplace", Icon: "🏪"},
 		{Path: "/settings", Label: "Settings", Icon: "⚙"},
 	}
@@ -72,13 +74,13 @@ func Base(data PageData) templ.Component {
 		var templ_7745c5c3_Var2 string
 		templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(data.Title)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 45, Col: 22}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 47, Col: 22}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, " — Switchboard</title><style>\n\t\t\t\t:root {\n\t\t\t\t\t--bg:             oklch(13.5% 0.01 250);\n\t\t\t\t\t--surface:        oklch(17% 0.01 250);\n\t\t\t\t\t--surface-hover:  oklch(20% 0.012 250);\n\t\t\t\t\t--border:         oklch(28% 0.01 250);\n\t\t\t\t\t--border-subtle:  oklch(23% 0.008 250);\n\t\t\t\t\t--text:           oklch(90% 0.01 250);\n\t\t\t\t\t--text-secondary: oklch(65% 0.015 250);\n\t\t\t\t\t--text-muted:     oklch(52% 0.01 250);\n\t\t\t\t\t--accent:         oklch(68% 0.14 250);\n\t\t\t\t\t--accent-dim:     oklch(68% 0.14 250 / 0.12);\n\t\t\t\t\t--green:          oklch(65% 0.17 155);\n\t\t\t\t\t--green-dim:      oklch(65% 0.17 155 / 0.12);\n\t\t\t\t\t--red:            oklch(62% 0.2 25);\n\t\t\t\t\t--red-dim:        oklch(62% 0.2 25 / 0.12);\n\t\t\t\t\t--yellow:         oklch(72% 0.15 80);\n\t\t\t\t\t--yellow-dim:     oklch(72% 0.15 80 / 0.12);\n\t\t\t\t\t--radius:         6px;\n\t\t\t\t\t--font-mono:      'SF Mono', ui-monospace, 'Cascadia Code', 'Fira Code', Menlo, monospace;\n\t\t\t\t}\n\t\t\t\t* { margin: 0; padding: 0; box-sizing: border-box; }\n\t\t\t\tbody {\n\t\t\t\t\tfont-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', system-ui, Helvetica, Arial, sans-serif;\n\t\t\t\t\tbackground: var(--bg);\n\t\t\t\t\tcolor: var(--text);\n\t\t\t\t\tfont-size: 14px;\n\t\t\t\t\tline-height: 1.5;\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\tmin-height: 100vh;\n\t\t\t\t\tfont-weight: 350;\n\t\t\t\t\t-webkit-font-smoothing: antialiased;\n\t\t\t\t}\n\n\t\t\t\t/* --- Sidebar --- */\n\t\t\t\t.sidebar {\n\t\t\t\t\twidth: 200px;\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tborder-right: 1px solid var(--border-subtle);\n\t\t\t\t\tpadding: 1.25rem 0;\n\t\t\t\t\tflex-shrink: 0;\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\tflex-direction: column;\n\t\t\t\t}\n\t\t\t\t.sidebar-brand {\n\t\t\t\t\tpadding: 0 1rem 1rem;\n\t\t\t\t\tborder-bottom: 1px solid var(--border-subtle);\n\t\t\t\t\tmargin-bottom: 0.5rem;\n\t\t\t\t}\n\t\t\t\t.sidebar-brand-name {\n\t\t\t\t\tfont-size: 0.875rem;\n\t\t\t\t\tfont-weight: 600;\n\t\t\t\t\tcolor: var(--text);\n\t\t\t\t\tletter-spacing: -0.01em;\n\t\t\t\t}\n\t\t\t\t.sidebar-brand-sub {\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tdisplay: block;\n\t\t\t\t\tmargin-top: 0.125rem;\n\t\t\t\t}\n\t\t\t\t.nav-item {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tgap: 0.5rem;\n\t\t\t\t\tpadding: 0.4375rem 1rem;\n\t\t\t\t\tcolor: var(--text-secondary);\n\t\t\t\t\ttext-decoration: none;\n\t\t\t\t\tfont-size: 0.8125rem;\n\t\t\t\t\tborder-left: 2px solid transparent;\n\t\t\t\t\ttransition: color 0.1s, background 0.1s;\n\t\t\t\t}\n\t\t\t\t.nav-item:hover {\n\t\t\t\t\tbackground: var(--surface-hover);\n\t\t\t\t\tcolor: var(--text);\n\t\t\t\t}\n\t\t\t\t.nav-item.active {\n\t\t\t\t\tcolor: var(--accent);\n\t\t\t\t\tborder-left-color: var(--accent);\n\t\t\t\t\tbackground: var(--accent-dim);\n\t\t\t\t}\n\t\t\t\t.sidebar-version {\n\t\t\t\t\tmargin-top: auto;\n\t\t\t\t\tpadding: 0.75rem 1rem;\n\t\t\t\t\tborder-top: 1px solid var(--border-subtle);\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tfont-size: 0.625rem;\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t}\n\n\t\t\t\t/* --- Main content --- */\n\t\t\t\t.main {\n\t\t\t\t\tflex: 1;\n\t\t\t\t\tpadding: 2rem 2.5rem;\n\t\t\t\t\tmax-width: 960px;\n\t\t\t\t\tmin-width: 0;\n\t\t\t\t}\n\n\t\t\t\t/* --- Typography --- */\n\t\t\t\t.page-title {\n\t\t\t\t\tfont-size: 1.25rem;\n\t\t\t\t\tfont-weight: 600;\n\t\t\t\t\tmargin-bottom: 1.5rem;\n\t\t\t\t\tletter-spacing: -0.015em;\n\t\t\t\t}\n\t\t\t\t.section-title {\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tfont-weight: 600;\n\t\t\t\t\ttext-transform: uppercase;\n\t\t\t\t\tletter-spacing: 0.06em;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tmargin-bottom: 0.625rem;\n\t\t\t\t}\n\n\t\t\t\t/* --- Flash messages --- */\n\t\t\t\t.flash {\n\t\t\t\t\tpadding: 0.5rem 0.75rem;\n\t\t\t\t\tborder-radius: var(--radius);\n\t\t\t\t\tmargin-bottom: 1rem;\n\t\t\t\t\tfont-size: 0.8125rem;\n\t\t\t\t\tborder: 1px solid;\n\t\t\t\t}\n\t\t\t\t.flash-success {\n\t\t\t\t\tbackground: var(--green-dim);\n\t\t\t\t\tborder-color: oklch(65% 0.17 155 / 0.25);\n\t\t\t\t\tcolor: var(--green);\n\t\t\t\t}\n\t\t\t\t.flash-error {\n\t\t\t\t\tbackground: var(--red-dim);\n\t\t\t\t\tborder-color: oklch(62% 0.2 25 / 0.25);\n\t\t\t\t\tcolor: var(--red);\n\t\t\t\t}\n\n\t\t\t\t/* --- Cards --- */\n\t\t\t\t.card {\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tborder: 1px solid var(--border-subtle);\n\t\t\t\t\tborder-radius: var(--radius);\n\t\t\t\t\tpadding: 1rem;\n\t\t\t\t\tmargin-bottom: 0.75rem;\n\t\t\t\t}\n\t\t\t\t.card-header {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tjustify-content: space-between;\n\t\t\t\t\tmargin-bottom: 0.75rem;\n\t\t\t\t}\n\t\t\t\t.card-title {\n\t\t\t\t\tfont-size: 0.875rem;\n\t\t\t\t\tfont-weight: 600;\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tgap: 0.5rem;\n\t\t\t\t}\n\n\t\t\t\t/* --- Badges --- */\n\t\t\t\t.badge {\n\t\t\t\t\tdisplay: inline-block;\n\t\t\t\t\tfont-size: 0.625rem;\n\t\t\t\t\tpadding: 1px 7px;\n\t\t\t\t\tborder-radius: 3px;\n\t\t\t\t\tfont-weight: 500;\n\t\t\t\t\ttext-transform: uppercase;\n\t\t\t\t\tletter-spacing: 0.03em;\n\t\t\t\t}\n\t\t\t\t.badge-green { background: var(--green-dim); color: var(--green); }\n\t\t\t\t.badge-red { background: var(--red-dim); color: var(--red); }\n\t\t\t\t.badge-yellow { background: var(--yellow-dim); color: var(--yellow); }\n\t\t\t\t.badge-muted { background: oklch(52% 0.01 250 / 0.12); color: var(--text-muted); }\n\n\t\t\t\t/* --- Forms --- */\n\t\t\t\t.form-group { margin-bottom: 0.625rem; }\n\t\t\t\t.form-label {\n\t\t\t\t\tdisplay: block;\n\t\t\t\t\tfont-size: 0.75rem;\n\t\t\t\t\tcolor: var(--text-secondary);\n\t\t\t\t\tmargin-bottom: 0.25rem;\n\t\t\t\t\ttext-transform: capitalize;\n\t\t\t\t}\n\t\t\t\t.form-input {\n\t\t\t\t\twidth: 100%;\n\t\t\t\t\tbackground: var(--bg);\n\t\t\t\t\tborder: 1px solid var(--border);\n\t\t\t\t\tborder-radius: 4px;\n\t\t\t\t\tcolor: var(--text);\n\t\t\t\t\tpadding: 0.4375rem 0.625rem;\n\t\t\t\t\tfont-size: 0.8125rem;\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t\ttransition: border-color 0.1s;\n\t\t\t\t}\n\t\t\t\t.form-input:focus {\n\t\t\t\t\toutline: none;\n\t\t\t\t\tborder-color: var(--accent);\n\t\t\t\t\tbox-shadow: 0 0 0 2px var(--accent-dim);\n\t\t\t\t}\n\t\t\t\t.input-secret {\n\t\t\t\t\tposition: relative;\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t}\n\t\t\t\t.input-secret .form-input {\n\t\t\t\t\tpadding-right: 2.25rem;\n\t\t\t\t}\n\t\t\t\t.secret-toggle {\n\t\t\t\t\tposition: absolute;\n\t\t\t\t\tright: 1px;\n\t\t\t\t\ttop: 1px;\n\t\t\t\t\tbottom: 1px;\n\t\t\t\t\twidth: 2rem;\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tjustify-content: center;\n\t\t\t\t\tbackground: transparent;\n\t\t\t\t\tborder: none;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tcursor: pointer;\n\t\t\t\t\tborder-radius: 0 3px 3px 0;\n\t\t\t\t\ttransition: color 0.1s;\n\t\t\t\t}\n\t\t\t\t.secret-toggle:hover {\n\t\t\t\t\tcolor: var(--text-secondary);\n\t\t\t\t}\n\n\t\t\t\t/* --- Toggle --- */\n\t\t\t\t.toggle {\n\t\t\t\t\tposition: relative;\n\t\t\t\t\twidth: 36px;\n\t\t\t\t\theight: 20px;\n\t\t\t\t\tcursor: pointer;\n\t\t\t\t\tdisplay: inline-block;\n\t\t\t\t}\n\t\t\t\t.toggle input { opacity: 0; width: 0; height: 0; }\n\t\t\t\t.toggle .slider {\n\t\t\t\t\tposition: absolute;\n\t\t\t\t\tinset: 0;\n\t\t\t\t\tbackground: var(--border);\n\t\t\t\t\tborder-radius: 10px;\n\t\t\t\t\ttransition: background 0.15s;\n\t\t\t\t}\n\t\t\t\t.toggle .slider:before {\n\t\t\t\t\tcontent: \"\";\n\t\t\t\t\tposition: absolute;\n\t\t\t\t\theight: 14px;\n\t\t\t\t\twidth: 14px;\n\t\t\t\t\tleft: 3px;\n\t\t\t\t\tbottom: 3px;\n\t\t\t\t\tbackground: var(--text);\n\t\t\t\t\tborder-radius: 50%;\n\t\t\t\t\ttransition: transform 0.15s;\n\t\t\t\t}\n\t\t\t\t.toggle input:checked + .slider { background: var(--accent); }\n\t\t\t\t.toggle input:checked + .slider:before { transform: translateX(16px); }\n\n\t\t\t\t/* --- Buttons --- */\n\t\t\t\t.btn {\n\t\t\t\t\tbackground: var(--accent);\n\t\t\t\t\tcolor: oklch(100% 0 0);\n\t\t\t\t\tborder: none;\n\t\t\t\t\tborder-radius: var(--radius);\n\t\t\t\t\tpadding: 0.4375rem 1rem;\n\t\t\t\t\tfont-size: 0.8125rem;\n\t\t\t\t\tcursor: pointer;\n\t\t\t\t\tfont-weight: 500;\n\t\t\t\t\ttext-decoration: none;\n\t\t\t\t\tdisplay: inline-flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tgap: 0.375rem;\n\t\t\t\t\ttransition: opacity 0.1s;\n\t\t\t\t}\n\t\t\t\t.btn:hover { opacity: 0.85; }\n\t\t\t\t.btn:focus-visible { outline: 2px solid var(--accent); outline-offset: 2px; }\n\t\t\t\t.btn-sm { padding: 0.25rem 0.625rem; font-size: 0.75rem; }\n\t\t\t\t.btn-outline {\n\t\t\t\t\tbackground: transparent;\n\t\t\t\t\tborder: 1px solid var(--border);\n\t\t\t\t\tcolor: var(--text-secondary);\n\t\t\t\t}\n\t\t\t\t.btn-outline:hover { border-color: var(--text-muted); color: var(--text); background: var(--surface-hover); }\n\t\t\t\t.btn-green { background: var(--green); }\n\n\t\t\t\t/* --- Stat cards --- */\n\t\t\t\t.stats-grid {\n\t\t\t\t\tdisplay: grid;\n\t\t\t\t\tgrid-template-columns: repeat(auto-fit, minmax(140px, 1fr));\n\t\t\t\t\tgap: 0.75rem;\n\t\t\t\t\tmargin-bottom: 1.5rem;\n\t\t\t\t}\n\t\t\t\t.stat-card {\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tborder: 1px solid var(--border-subtle);\n\t\t\t\t\tborder-radius: var(--radius);\n\t\t\t\t\tpadding: 0.75rem 1rem;\n\t\t\t\t}\n\t\t\t\t.stat-value {\n\t\t\t\t\tfont-size: 1.5rem;\n\t\t\t\t\tfont-weight: 700;\n\t\t\t\t\tfont-variant-numeric: tabular-nums;\n\t\t\t\t\tletter-spacing: -0.02em;\n\t\t\t\t}\n\t\t\t\t.stat-label { font-size: 0.6875rem; color: var(--text-muted); margin-top: 0.0625rem; }\n\t\t\t\t.stat-card-green { border-color: oklch(65% 0.17 155 / 0.25); }\n\t\t\t\t.stat-card-green .stat-value { color: var(--green); }\n\t\t\t\t.stat-card-yellow { border-color: oklch(72% 0.15 80 / 0.25); }\n\t\t\t\t.stat-card-yellow .stat-value { color: var(--yellow); }\n\t\t\t\t.stat-card-muted .stat-value { color: var(--text-muted); }\n\n\t\t\t\t/* --- Integration links --- */\n\t\t\t\t.integration-link {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tjustify-content: space-between;\n\t\t\t\t\ttext-decoration: none;\n\t\t\t\t\tcolor: inherit;\n\t\t\t\t\tpadding: 0.75rem 1rem;\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tborder: 1px solid var(--border-subtle);\n\t\t\t\t\tborder-radius: var(--radius);\n\t\t\t\t\tmargin-bottom: 0.375rem;\n\t\t\t\t\ttransition: border-color 0.1s;\n\t\t\t\t}\n\t\t\t\t.integration-link:hover { border-color: var(--accent); }\n\t\t\t\t.integration-link:focus-visible { outline: 2px solid var(--accent); outline-offset: 1px; }\n\t\t\t\t.integration-link .name {\n\t\t\t\t\tfont-weight: 600;\n\t\t\t\t\tfont-size: 0.875rem;\n\t\t\t\t\ttext-transform: capitalize;\n\t\t\t\t}\n\t\t\t\t.integration-link .meta {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tgap: 0.5rem;\n\t\t\t\t\tfont-size: 0.75rem;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t}\n\t\t\t\t.last-check { color: var(--text-muted); font-size: 0.625rem; margin-left: 0.5rem; }\n\n\t\t\t\t/* --- Integration grid (cards) --- */\n\t\t\t\t.integration-grid {\n\t\t\t\t\tdisplay: grid;\n\t\t\t\t\tgrid-template-columns: repeat(3, 1fr);\n\t\t\t\t\tgap: 0.625rem;\n\t\t\t\t\tmargin-bottom: 0.5rem;\n\t\t\t\t}\n\t\t\t\t.integration-card {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\tflex-direction: column;\n\t\t\t\t\tjustify-content: space-between;\n\t\t\t\t\ttext-decoration: none;\n\t\t\t\t\tcolor: inherit;\n\t\t\t\t\tpadding: 0.75rem 1rem;\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tborder: 1px solid var(--border-subtle);\n\t\t\t\t\tborder-radius: var(--radius);\n\t\t\t\t\ttransition: border-color 0.1s;\n\t\t\t\t\tmin-height: 72px;\n\t\t\t\t}\n\t\t\t\t.integration-card:hover { border-color: var(--accent); }\n\t\t\t\t.integration-card:focus-visible { outline: 2px solid var(--accent); outline-offset: 1px; }\n\t\t\t\t.integration-card-header {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tgap: 0.5rem;\n\t\t\t\t\tmargin-bottom: 0.5rem;\n\t\t\t\t}\n\t\t\t\t.integration-card .name {\n\t\t\t\t\tfont-weight: 600;\n\t\t\t\t\tfont-size: 0.875rem;\n\t\t\t\t\ttext-transform: capitalize;\n\t\t\t\t}\n\t\t\t\t.integration-card-footer {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tgap: 0.5rem;\n\t\t\t\t\tfont-size: 0.75rem;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t}\n\t\t\t\t.integration-card-tools {\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t}\n\n\t\t\t\t/* --- Tools list --- */\n\t\t\t\t.tools-rows {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\tflex-direction: column;\n\t\t\t\t\tgap: 0.25rem;\n\t\t\t\t\tmargin-top: 0.5rem;\n\t\t\t\t}\n\t\t\t\t.tool-row {\n\t\t\t\t\tdisplay: grid;\n\t\t\t\t\tgrid-template-columns: minmax(180px, 240px) 1fr;\n\t\t\t\t\tgap: 0.875rem;\n\t\t\t\t\talign-items: baseline;\n\t\t\t\t\tpadding: 0.4375rem 0;\n\t\t\t\t\tborder-bottom: 1px solid var(--border-subtle);\n\t\t\t\t}\n\t\t\t\t.tool-row:last-child { border-bottom: none; }\n\t\t\t\t.tool-row-name {\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t\tfont-size: 0.75rem;\n\t\t\t\t\tcolor: var(--text-secondary);\n\t\t\t\t\tword-break: break-all;\n\t\t\t\t}\n\t\t\t\t.tool-row-desc {\n\t\t\t\t\tfont-size: 0.75rem;\n\t\t\t\t\tline-height: 1.45;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t}\n\t\t\t\t.tool-row-desc-empty { font-style: italic; }\n\t\t\t\t.tools-hint {\n\t\t\t\t\tfont-size: 0.75rem;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tmargin-top: 0.25rem;\n\t\t\t\t}\n\t\t\t\t@media (max-width: 560px) {\n\t\t\t\t\t.tool-row { grid-template-columns: 1fr; gap: 0.125rem; }\n\t\t\t\t}\n\n\t\t\t\t/* --- Footer --- */\n\t\t\t\t.footer {\n\t\t\t\t\tmargin-top: 2rem;\n\t\t\t\t\tpadding-top: 0.75rem;\n\t\t\t\t\tborder-top: 1px solid var(--border-subtle);\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t}\n\t\t\t\t.footer code {\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tpadding: 2px 6px;\n\t\t\t\t\tborder-radius: 3px;\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t\tfont-size: 0.625rem;\n\t\t\t\t}\n\n\t\t\t\t/* --- Metrics: Dashboard header --- */\n\t\t\t\t.dash-header {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tgap: 0.625rem;\n\t\t\t\t\tmargin-bottom: 1.5rem;\n\t\t\t\t}\n\t\t\t\t.dash-header .page-title { margin-bottom: 0; }\n\t\t\t\t.uptime-badge {\n\t\t\t\t\tfont-size: 0.625rem;\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t\tcolor: var(--green);\n\t\t\t\t\tbackground: var(--green-dim);\n\t\t\t\t\tpadding: 1px 8px;\n\t\t\t\t\tborder-radius: 3px;\n\t\t\t\t\tfont-weight: 500;\n\t\t\t\t}\n\n\t\t\t\t/* --- Metrics sections --- */\n\t\t\t\t.metrics-section { margin-bottom: 1.5rem; }\n\t\t\t\t.metrics-grid {\n\t\t\t\t\tdisplay: grid;\n\t\t\t\t\tgrid-template-columns: repeat(4, 1fr);\n\t\t\t\t\tgap: 0.625rem;\n\t\t\t\t}\n\t\t\t\t.metric-card {\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tborder: 1px solid var(--border-subtle);\n\t\t\t\t\tborder-radius: var(--radius);\n\t\t\t\t\tpadding: 0.75rem 1rem;\n\t\t\t\t}\n\t\t\t\t.metric-value {\n\t\t\t\t\tfont-size: 1.25rem;\n\t\t\t\t\tfont-weight: 700;\n\t\t\t\t\tfont-variant-numeric: tabular-nums;\n\t\t\t\t\tletter-spacing: -0.02em;\n\t\t\t\t}\n\t\t\t\t.metric-label {\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tmargin-top: 0.0625rem;\n\t\t\t\t}\n\t\t\t\t.err-rate-warn { color: var(--red); }\n\n\t\t\t\t/* --- Metrics: two-column row --- */\n\t\t\t\t.metrics-row {\n\t\t\t\t\tdisplay: grid;\n\t\t\t\t\tgrid-template-columns: 1fr 1fr;\n\t\t\t\t\tgap: 0.75rem;\n\t\t\t\t}\n\t\t\t\t.metrics-half { min-width: 0; }\n\n\t\t\t\t/* --- Metrics: data table --- */\n\t\t\t\t.table-wrap { overflow-x: auto; }\n\t\t\t\t.metrics-table {\n\t\t\t\t\twidth: 100%;\n\t\t\t\t\tborder-collapse: collapse;\n\t\t\t\t\tfont-size: 0.8125rem;\n\t\t\t\t}\n\t\t\t\t.metrics-table th {\n\t\t\t\t\ttext-align: left;\n\t\t\t\t\tfont-weight: 500;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tpadding: 0.375rem 0.75rem;\n\t\t\t\t\tborder-bottom: 1px solid var(--border);\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\ttext-transform: uppercase;\n\t\t\t\t\tletter-spacing: 0.04em;\n\t\t\t\t}\n\t\t\t\t.metrics-table td {\n\t\t\t\t\tpadding: 0.375rem 0.75rem;\n\t\t\t\t\tborder-bottom: 1px solid var(--border-subtle);\n\t\t\t\t}\n\t\t\t\t.metrics-table tr:last-child td { border-bottom: none; }\n\t\t\t\t.metrics-table .num {\n\t\t\t\t\ttext-align: right;\n\t\t\t\t\tfont-variant-numeric: tabular-nums;\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t\tfont-size: 0.75rem;\n\t\t\t\t}\n\t\t\t\t.metrics-table .latency { color: var(--text-secondary); }\n\t\t\t\t.metrics-table .has-errors { color: var(--red); }\n\t\t\t\t.integration-name-cell { text-transform: capitalize; }\n\t\t\t\t.integration-name-cell a {\n\t\t\t\t\tcolor: var(--accent);\n\t\t\t\t\ttext-decoration: none;\n\t\t\t\t}\n\t\t\t\t.integration-name-cell a:hover { text-decoration: underline; }\n\n\t\t\t\t/* --- Metrics: top tools --- */\n\t\t\t\t.top-tools-list {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\tflex-direction: column;\n\t\t\t\t\tgap: 0.25rem;\n\t\t\t\t}\n\t\t\t\t.top-tool-item {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tgap: 0.5rem;\n\t\t\t\t\tpadding: 0.375rem 0.625rem;\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tborder: 1px solid var(--border-subtle);\n\t\t\t\t\tborder-radius: 4px;\n\t\t\t\t\tfont-size: 0.8125rem;\n\t\t\t\t}\n\t\t\t\t.top-tool-rank {\n\t\t\t\t\twidth: 18px;\n\t\t\t\t\theight: 18px;\n\t\t\t\t\tbackground: var(--accent-dim);\n\t\t\t\t\tcolor: var(--accent);\n\t\t\t\t\tborder-radius: 3px;\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tjustify-content: center;\n\t\t\t\t\tfont-size: 0.625rem;\n\t\t\t\t\tfont-weight: 600;\n\t\t\t\t\tflex-shrink: 0;\n\t\t\t\t}\n\t\t\t\t.top-tool-name {\n\t\t\t\t\tflex: 1;\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\toverflow: hidden;\n\t\t\t\t\ttext-overflow: ellipsis;\n\t\t\t\t\twhite-space: nowrap;\n\t\t\t\t\tcolor: var(--text-secondary);\n\t\t\t\t}\n\t\t\t\t.top-tool-calls {\n\t\t\t\t\tfont-variant-numeric: tabular-nums;\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t}\n\n\t\t\t\t/* --- Metrics: efficiency --- */\n\t\t\t\t.efficiency-grid {\n\t\t\t\t\tdisplay: grid;\n\t\t\t\t\tgrid-template-columns: 1fr 1fr;\n\t\t\t\t\tgap: 0.375rem;\n\t\t\t\t}\n\t\t\t\t.efficiency-item {\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tborder: 1px solid var(--border-subtle);\n\t\t\t\t\tborder-radius: 4px;\n\t\t\t\t\tpadding: 0.625rem;\n\t\t\t\t}\n\t\t\t\t.efficiency-value {\n\t\t\t\t\tfont-size: 1rem;\n\t\t\t\t\tfont-weight: 700;\n\t\t\t\t\tfont-variant-numeric: tabular-nums;\n\t\t\t\t\tletter-spacing: -0.01em;\n\t\t\t\t\tdisplay: block;\n\t\t\t\t}\n\t\t\t\t.efficiency-label {\n\t\t\t\t\tfont-size: 0.625rem;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tdisplay: block;\n\t\t\t\t}\n\n\t\t\t\t/* --- Savings hero: headline value-prop card --- */\n\t\t\t\t.savings-hero {\n\t\t\t\t\tbackground: linear-gradient(135deg,\n\t\t\t\t\t\toklch(22% 0.04 250) 0%,\n\t\t\t\t\t\toklch(18% 0.025 250) 100%);\n\t\t\t\t\tborder: 1px solid var(--accent-dim);\n\t\t\t\t\tborder-radius: var(--radius);\n\t\t\t\t\tpadding: 1.25rem 1.5rem;\n\t\t\t\t\tmargin-bottom: 1.5rem;\n\t\t\t\t\tposition: relative;\n\t\t\t\t\toverflow: hidden;\n\t\t\t\t}\n\t\t\t\t.savings-hero::before {\n\t\t\t\t\tcontent: \"\";\n\t\t\t\t\tposition: absolute;\n\t\t\t\t\ttop: -40%;\n\t\t\t\t\tright: -10%;\n\t\t\t\t\twidth: 320px;\n\t\t\t\t\theight: 320px;\n\t\t\t\t\tbackground: radial-gradient(circle, var(--accent-dim) 0%, transparent 70%);\n\t\t\t\t\tpointer-events: none;\n\t\t\t\t}\n\t\t\t\t.savings-hero-head {\n\t\t\t\t\tposition: relative;\n\t\t\t\t\tmargin-bottom: 1rem;\n\t\t\t\t}\n\t\t\t\t.savings-hero-label {\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\ttext-transform: uppercase;\n\t\t\t\t\tletter-spacing: 0.08em;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tfont-weight: 500;\n\t\t\t\t}\n\t\t\t\t.savings-hero-value {\n\t\t\t\t\tfont-size: 2.5rem;\n\t\t\t\t\tfont-weight: 700;\n\t\t\t\t\tfont-variant-numeric: tabular-nums;\n\t\t\t\t\tletter-spacing: -0.03em;\n\t\t\t\t\tcolor: var(--text);\n\t\t\t\t\tline-height: 1.1;\n\t\t\t\t\tmargin-top: 0.25rem;\n\t\t\t\t}\n\t\t\t\t.savings-hero-unit {\n\t\t\t\t\tfont-size: 1rem;\n\t\t\t\t\tfont-weight: 500;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tmargin-left: 0.375rem;\n\t\t\t\t\tletter-spacing: 0;\n\t\t\t\t}\n\t\t\t\t.savings-hero-sub {\n\t\t\t\t\tfont-size: 0.8125rem;\n\t\t\t\t\tcolor: var(--text-secondary);\n\t\t\t\t\tmargin-top: 0.25rem;\n\t\t\t\t}\n\t\t\t\t.savings-hero-dollars {\n\t\t\t\t\tcolor: var(--green);\n\t\t\t\t\tfont-weight: 500;\n\t\t\t\t}\n\t\t\t\t.savings-buckets {\n\t\t\t\t\tposition: relative;\n\t\t\t\t\tdisplay: grid;\n\t\t\t\t\tgrid-template-columns: repeat(4, 1fr);\n\t\t\t\t\tgap: 0.625rem;\n\t\t\t\t}\n\t\t\t\t.savings-bucket {\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tborder: 1px solid var(--border-subtle);\n\t\t\t\t\tborder-radius: 6px;\n\t\t\t\t\tpadding: 0.75rem 0.875rem;\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\tflex-direction: column;\n\t\t\t\t\tgap: 0.25rem;\n\t\t\t\t}\n\t\t\t\t.savings-bucket-head {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\tjustify-content: space-between;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tgap: 0.5rem;\n\t\t\t\t}\n\t\t\t\t.savings-bucket-label {\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tcolor: var(--text-secondary);\n\t\t\t\t\tfont-weight: 500;\n\t\t\t\t\ttext-transform: uppercase;\n\t\t\t\t\tletter-spacing: 0.04em;\n\t\t\t\t}\n\t\t\t\t.savings-bucket-pct {\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tfont-variant-numeric: tabular-nums;\n\t\t\t\t\tcolor: var(--accent);\n\t\t\t\t\tbackground: var(--accent-dim);\n\t\t\t\t\tpadding: 1px 6px;\n\t\t\t\t\tborder-radius: 3px;\n\t\t\t\t\tfont-weight: 600;\n\t\t\t\t}\n\t\t\t\t.savings-bucket-value {\n\t\t\t\t\tfont-size: 1.25rem;\n\t\t\t\t\tfont-weight: 700;\n\t\t\t\t\tfont-variant-numeric: tabular-nums;\n\t\t\t\t\tletter-spacing: -0.02em;\n\t\t\t\t\tcolor: var(--text);\n\t\t\t\t}\n\t\t\t\t.savings-bucket-unit {\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tfont-weight: 500;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tmargin-left: 0.25rem;\n\t\t\t\t}\n\t\t\t\t.savings-bucket-meta {\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tfont-variant-numeric: tabular-nums;\n\t\t\t\t}\n\t\t\t\t.savings-bucket-blurb {\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tline-height: 1.4;\n\t\t\t\t\tmargin-top: 0.125rem;\n\t\t\t\t}\n\t\t\t\t.savings-hero-foot {\n\t\t\t\t\tposition: relative;\n\t\t\t\t\tfont-size: 0.625rem;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tmargin-top: 0.875rem;\n\t\t\t\t\tpadding-top: 0.625rem;\n\t\t\t\t\tborder-top: 1px solid var(--border-subtle);\n\t\t\t\t}\n\n\t\t\t\t/* --- Slack/setup-specific --- */\n\t\t\t\t.slack-desc { color: var(--text-secondary); font-size: 0.8125rem; line-height: 1.6; }\n\t\t\t\t.slack-desc code { background: var(--bg); padding: 2px 6px; border-radius: 3px; font-size: 0.75rem; font-family: var(--font-mono); }\n\t\t\t\t.slack-steps { margin-top: 0.75rem; display: flex; flex-direction: column; gap: 0.75rem; }\n\t\t\t\t.slack-step { display: flex; gap: 0.625rem; align-items: flex-start; }\n\t\t\t\t.slack-step-num {\n\t\t\t\t\tflex-shrink: 0;\n\t\t\t\t\twidth: 24px; height: 24px;\n\t\t\t\t\tbackground: var(--accent);\n\t\t\t\t\tcolor: oklch(100% 0 0);\n\t\t\t\t\tborder-radius: 50%;\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tjustify-content: center;\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tfont-weight: 600;\n\t\t\t\t}\n\t\t\t\t.slack-step-detail { color: var(--text-secondary); font-size: 0.8125rem; margin-top: 0.125rem; }\n\t\t\t\t.slack-step-detail kbd {\n\t\t\t\t\tbackground: var(--bg);\n\t\t\t\t\tborder: 1px solid var(--border);\n\t\t\t\t\tborder-radius: 3px;\n\t\t\t\t\tpadding: 1px 5px;\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t}\n\t\t\t\t.slack-link { color: var(--accent); text-decoration: none; }\n\t\t\t\t.slack-link:hover { text-decoration: underline; }\n\t\t\t\t.slack-code-block {\n\t\t\t\t\tbackground: var(--bg);\n\t\t\t\t\tborder: 1px solid var(--border-subtle);\n\t\t\t\t\tborder-radius: var(--radius);\n\t\t\t\t\tpadding: 0.625rem;\n\t\t\t\t\tmargin-top: 0.375rem;\n\t\t\t\t\tposition: relative;\n\t\t\t\t}\n\t\t\t\t.slack-code-block pre { overflow-x: auto; margin: 0; }\n\t\t\t\t.slack-code-block code { font-size: 0.6875rem; font-family: var(--font-mono); color: var(--text-muted); white-space: pre-wrap; word-break: break-all; }\n\t\t\t\t.slack-code-block .btn { position: absolute; top: 0.375rem; right: 0.375rem; }\n\t\t\t\t.slack-textarea {\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t\tfont-size: 0.8125rem;\n\t\t\t\t\tresize: vertical;\n\t\t\t\t\tmin-height: 56px;\n\t\t\t\t}\n\t\t\t\t.slack-token-meta {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\tgap: 1.25rem;\n\t\t\t\t\tfont-size: 0.8125rem;\n\t\t\t\t\tcolor: var(--text-secondary);\n\t\t\t\t}\n\t\t\t\t.slack-token-meta strong { color: var(--text); font-weight: 600; }\n\n\t\t\t\t/* --- Spinner --- */\n\t\t\t\t.spinner {\n\t\t\t\t\tdisplay: inline-block;\n\t\t\t\t\twidth: 12px;\n\t\t\t\t\theight: 12px;\n\t\t\t\t\tborder: 2px solid var(--border);\n\t\t\t\t\tborder-top-color: var(--accent);\n\t\t\t\t\tborder-radius: 50%;\n\t\t\t\t\tanimation: spin 0.6s linear infinite;\n\t\t\t\t\tvertical-align: middle;\n\t\t\t\t}\n\t\t\t\t@keyframes spin { to { transform: rotate(360deg); } }\n\n\t\t\t\t/* --- Responsive --- */\n\t\t\t\t@media (max-width: 768px) {\n\t\t\t\t\t.sidebar { width: 180px; }\n\t\t\t\t\t.main { padding: 1.5rem; }\n\t\t\t\t\t.metrics-grid { grid-template-columns: repeat(2, 1fr); }\n\t\t\t\t\t.metrics-row { grid-template-columns: 1fr; }\n\t\t\t\t\t.stats-grid { grid-template-columns: repeat(2, 1fr); }\n\t\t\t\t\t.integration-grid { grid-template-columns: repeat(2, 1fr); }\n\t\t\t\t\t.savings-buckets { grid-template-columns: repeat(2, 1fr); }\n\t\t\t\t\t.savings-hero { padding: 1rem; }\n\t\t\t\t\t.savings-hero-value { font-size: 2rem; }\n\t\t\t\t}\n\t\t\t\t@media (max-width: 560px) {\n\t\t\t\t\tbody { flex-direction: column; }\n\t\t\t\t\t.sidebar {\n\t\t\t\t\t\twidth: 100%;\n\t\t\t\t\t\tflex-direction: row;\n\t\t\t\t\t\tpadding: 0.5rem 0;\n\t\t\t\t\t\tborder-right: none;\n\t\t\t\t\t\tborder-bottom: 1px solid var(--border-subtle);\n\t\t\t\t\t\toverflow-x: auto;\n\t\t\t\t\t}\n\t\t\t\t\t.sidebar-brand { display: none; }\n\t\t\t\t\t.nav-item {\n\t\t\t\t\t\tborder-left: none;\n\t\t\t\t\t\tborder-bottom: 2px solid transparent;\n\t\t\t\t\t\tpadding: 0.375rem 0.75rem;\n\t\t\t\t\t\twhite-space: nowrap;\n\t\t\t\t\t}\n\t\t\t\t\t.nav-item.active { border-left-color: transparent; border-bottom-color: var(--accent); }\n\t\t\t\t\t.main { padding: 1rem; }\n\t\t\t\t\t.integration-grid { grid-template-columns: 1fr; }\n\t\t\t\t\t.savings-buckets { grid-template-columns: 1fr; }\n\t\t\t\t}\n\t\t\t</style></head><body><nav class=\"sidebar\"><div class=\"sidebar-brand\"><div class=\"sidebar-brand-name\">⚙ Switchboard</div><span class=\"sidebar-brand-sub\">Server Configuration</span></div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, " — Switchboard</title><style>\n\t\t\t\t:root {\n\t\t\t\t\t--bg:             oklch(13.5% 0.01 250);\n\t\t\t\t\t--surface:        oklch(17% 0.01 250);\n\t\t\t\t\t--surface-hover:  oklch(20% 0.012 250);\n\t\t\t\t\t--border:         oklch(28% 0.01 250);\n\t\t\t\t\t--border-subtle:  oklch(23% 0.008 250);\n\t\t\t\t\t--text:           oklch(90% 0.01 250);\n\t\t\t\t\t--text-secondary: oklch(65% 0.015 250);\n\t\t\t\t\t--text-muted:     oklch(52% 0.01 250);\n\t\t\t\t\t--accent:         oklch(68% 0.14 250);\n\t\t\t\t\t--accent-dim:     oklch(68% 0.14 250 / 0.12);\n\t\t\t\t\t--green:          oklch(65% 0.17 155);\n\t\t\t\t\t--green-dim:      oklch(65% 0.17 155 / 0.12);\n\t\t\t\t\t--red:            oklch(62% 0.2 25);\n\t\t\t\t\t--red-dim:        oklch(62% 0.2 25 / 0.12);\n\t\t\t\t\t--yellow:         oklch(72% 0.15 80);\n\t\t\t\t\t--yellow-dim:     oklch(72% 0.15 80 / 0.12);\n\t\t\t\t\t--radius:         6px;\n\t\t\t\t\t--font-mono:      'SF Mono', ui-monospace, 'Cascadia Code', 'Fira Code', Menlo, monospace;\n\t\t\t\t}\n\t\t\t\t* { margin: 0; padding: 0; box-sizing: border-box; }\n\t\t\t\tbody {\n\t\t\t\t\tfont-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', system-ui, Helvetica, Arial, sans-serif;\n\t\t\t\t\tbackground: var(--bg);\n\t\t\t\t\tcolor: var(--text);\n\t\t\t\t\tfont-size: 14px;\n\t\t\t\t\tline-height: 1.5;\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\tmin-height: 100vh;\n\t\t\t\t\tfont-weight: 350;\n\t\t\t\t\t-webkit-font-smoothing: antialiased;\n\t\t\t\t}\n\n\t\t\t\t/* --- Sidebar --- */\n\t\t\t\t.sidebar {\n\t\t\t\t\twidth: 200px;\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tborder-right: 1px solid var(--border-subtle);\n\t\t\t\t\tpadding: 1.25rem 0;\n\t\t\t\t\tflex-shrink: 0;\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\tflex-direction: column;\n\t\t\t\t}\n\t\t\t\t.sidebar-brand {\n\t\t\t\t\tpadding: 0 1rem 1rem;\n\t\t\t\t\tborder-bottom: 1px solid var(--border-subtle);\n\t\t\t\t\tmargin-bottom: 0.5rem;\n\t\t\t\t}\n\t\t\t\t.sidebar-brand-name {\n\t\t\t\t\tfont-size: 0.875rem;\n\t\t\t\t\tfont-weight: 600;\n\t\t\t\t\tcolor: var(--text);\n\t\t\t\t\tletter-spacing: -0.01em;\n\t\t\t\t}\n\t\t\t\t.sidebar-brand-sub {\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tdisplay: block;\n\t\t\t\t\tmargin-top: 0.125rem;\n\t\t\t\t}\n\t\t\t\t.nav-item {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tgap: 0.5rem;\n\t\t\t\t\tpadding: 0.4375rem 1rem;\n\t\t\t\t\tcolor: var(--text-secondary);\n\t\t\t\t\ttext-decoration: none;\n\t\t\t\t\tfont-size: 0.8125rem;\n\t\t\t\t\tborder-left: 2px solid transparent;\n\t\t\t\t\ttransition: color 0.1s, background 0.1s;\n\t\t\t\t}\n\t\t\t\t.nav-item:hover {\n\t\t\t\t\tbackground: var(--surface-hover);\n\t\t\t\t\tcolor: var(--text);\n\t\t\t\t}\n\t\t\t\t.nav-item.active {\n\t\t\t\t\tcolor: var(--accent);\n\t\t\t\t\tborder-left-color: var(--accent);\n\t\t\t\t\tbackground: var(--accent-dim);\n\t\t\t\t}\n\t\t\t\t.sidebar-version {\n\t\t\t\t\tmargin-top: auto;\n\t\t\t\t\tpadding: 0.75rem 1rem;\n\t\t\t\t\tborder-top: 1px solid var(--border-subtle);\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tfont-size: 0.625rem;\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t}\n\n\t\t\t\t/* --- Main content --- */\n\t\t\t\t.main {\n\t\t\t\t\tflex: 1;\n\t\t\t\t\tpadding: 2rem 2.5rem;\n\t\t\t\t\tmax-width: 960px;\n\t\t\t\t\tmin-width: 0;\n\t\t\t\t}\n\n\t\t\t\t/* --- Typography --- */\n\t\t\t\t.page-title {\n\t\t\t\t\tfont-size: 1.25rem;\n\t\t\t\t\tfont-weight: 600;\n\t\t\t\t\tmargin-bottom: 1.5rem;\n\t\t\t\t\tletter-spacing: -0.015em;\n\t\t\t\t}\n\t\t\t\t.section-title {\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tfont-weight: 600;\n\t\t\t\t\ttext-transform: uppercase;\n\t\t\t\t\tletter-spacing: 0.06em;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tmargin-bottom: 0.625rem;\n\t\t\t\t}\n\n\t\t\t\t/* --- Flash messages --- */\n\t\t\t\t.flash {\n\t\t\t\t\tpadding: 0.5rem 0.75rem;\n\t\t\t\t\tborder-radius: var(--radius);\n\t\t\t\t\tmargin-bottom: 1rem;\n\t\t\t\t\tfont-size: 0.8125rem;\n\t\t\t\t\tborder: 1px solid;\n\t\t\t\t}\n\t\t\t\t.flash-success {\n\t\t\t\t\tbackground: var(--green-dim);\n\t\t\t\t\tborder-color: oklch(65% 0.17 155 / 0.25);\n\t\t\t\t\tcolor: var(--green);\n\t\t\t\t}\n\t\t\t\t.flash-error {\n\t\t\t\t\tbackground: var(--red-dim);\n\t\t\t\t\tborder-color: oklch(62% 0.2 25 / 0.25);\n\t\t\t\t\tcolor: var(--red);\n\t\t\t\t}\n\n\t\t\t\t/* --- Cards --- */\n\t\t\t\t.card {\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tborder: 1px solid var(--border-subtle);\n\t\t\t\t\tborder-radius: var(--radius);\n\t\t\t\t\tpadding: 1rem;\n\t\t\t\t\tmargin-bottom: 0.75rem;\n\t\t\t\t}\n\t\t\t\t.card-header {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tjustify-content: space-between;\n\t\t\t\t\tmargin-bottom: 0.75rem;\n\t\t\t\t}\n\t\t\t\t.card-title {\n\t\t\t\t\tfont-size: 0.875rem;\n\t\t\t\t\tfont-weight: 600;\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tgap: 0.5rem;\n\t\t\t\t}\n\n\t\t\t\t/* --- Badges --- */\n\t\t\t\t.badge {\n\t\t\t\t\tdisplay: inline-block;\n\t\t\t\t\tfont-size: 0.625rem;\n\t\t\t\t\tpadding: 1px 7px;\n\t\t\t\t\tborder-radius: 3px;\n\t\t\t\t\tfont-weight: 500;\n\t\t\t\t\ttext-transform: uppercase;\n\t\t\t\t\tletter-spacing: 0.03em;\n\t\t\t\t}\n\t\t\t\t.badge-green { background: var(--green-dim); color: var(--green); }\n\t\t\t\t.badge-red { background: var(--red-dim); color: var(--red); }\n\t\t\t\t.badge-yellow { background: var(--yellow-dim); color: var(--yellow); }\n\t\t\t\t.badge-muted { background: oklch(52% 0.01 250 / 0.12); color: var(--text-muted); }\n\n\t\t\t\t/* --- Forms --- */\n\t\t\t\t.form-group { margin-bottom: 0.625rem; }\n\t\t\t\t.form-label {\n\t\t\t\t\tdisplay: block;\n\t\t\t\t\tfont-size: 0.75rem;\n\t\t\t\t\tcolor: var(--text-secondary);\n\t\t\t\t\tmargin-bottom: 0.25rem;\n\t\t\t\t\ttext-transform: capitalize;\n\t\t\t\t}\n\t\t\t\t.form-input {\n\t\t\t\t\twidth: 100%;\n\t\t\t\t\tbackground: var(--bg);\n\t\t\t\t\tborder: 1px solid var(--border);\n\t\t\t\t\tborder-radius: 4px;\n\t\t\t\t\tcolor: var(--text);\n\t\t\t\t\tpadding: 0.4375rem 0.625rem;\n\t\t\t\t\tfont-size: 0.8125rem;\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t\ttransition: border-color 0.1s;\n\t\t\t\t}\n\t\t\t\t.form-input:focus {\n\t\t\t\t\toutline: none;\n\t\t\t\t\tborder-color: var(--accent);\n\t\t\t\t\tbox-shadow: 0 0 0 2px var(--accent-dim);\n\t\t\t\t}\n\t\t\t\t.input-secret {\n\t\t\t\t\tposition: relative;\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t}\n\t\t\t\t.input-secret .form-input {\n\t\t\t\t\tpadding-right: 2.25rem;\n\t\t\t\t}\n\t\t\t\t.secret-toggle {\n\t\t\t\t\tposition: absolute;\n\t\t\t\t\tright: 1px;\n\t\t\t\t\ttop: 1px;\n\t\t\t\t\tbottom: 1px;\n\t\t\t\t\twidth: 2rem;\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tjustify-content: center;\n\t\t\t\t\tbackground: transparent;\n\t\t\t\t\tborder: none;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tcursor: pointer;\n\t\t\t\t\tborder-radius: 0 3px 3px 0;\n\t\t\t\t\ttransition: color 0.1s;\n\t\t\t\t}\n\t\t\t\t.secret-toggle:hover {\n\t\t\t\t\tcolor: var(--text-secondary);\n\t\t\t\t}\n\n\t\t\t\t/* --- Toggle --- */\n\t\t\t\t.toggle {\n\t\t\t\t\tposition: relative;\n\t\t\t\t\twidth: 36px;\n\t\t\t\t\theight: 20px;\n\t\t\t\t\tcursor: pointer;\n\t\t\t\t\tdisplay: inline-block;\n\t\t\t\t}\n\t\t\t\t.toggle input { opacity: 0; width: 0; height: 0; }\n\t\t\t\t.toggle .slider {\n\t\t\t\t\tposition: absolute;\n\t\t\t\t\tinset: 0;\n\t\t\t\t\tbackground: var(--border);\n\t\t\t\t\tborder-radius: 10px;\n\t\t\t\t\ttransition: background 0.15s;\n\t\t\t\t}\n\t\t\t\t.toggle .slider:before {\n\t\t\t\t\tcontent: \"\";\n\t\t\t\t\tposition: absolute;\n\t\t\t\t\theight: 14px;\n\t\t\t\t\twidth: 14px;\n\t\t\t\t\tleft: 3px;\n\t\t\t\t\tbottom: 3px;\n\t\t\t\t\tbackground: var(--text);\n\t\t\t\t\tborder-radius: 50%;\n\t\t\t\t\ttransition: transform 0.15s;\n\t\t\t\t}\n\t\t\t\t.toggle input:checked + .slider { background: var(--accent); }\n\t\t\t\t.toggle input:checked + .slider:before { transform: translateX(16px); }\n\n\t\t\t\t/* --- Buttons --- */\n\t\t\t\t.btn {\n\t\t\t\t\tbackground: var(--accent);\n\t\t\t\t\tcolor: oklch(100% 0 0);\n\t\t\t\t\tborder: none;\n\t\t\t\t\tborder-radius: var(--radius);\n\t\t\t\t\tpadding: 0.4375rem 1rem;\n\t\t\t\t\tfont-size: 0.8125rem;\n\t\t\t\t\tcursor: pointer;\n\t\t\t\t\tfont-weight: 500;\n\t\t\t\t\ttext-decoration: none;\n\t\t\t\t\tdisplay: inline-flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tgap: 0.375rem;\n\t\t\t\t\ttransition: opacity 0.1s;\n\t\t\t\t}\n\t\t\t\t.btn:hover { opacity: 0.85; }\n\t\t\t\t.btn:focus-visible { outline: 2px solid var(--accent); outline-offset: 2px; }\n\t\t\t\t.btn-sm { padding: 0.25rem 0.625rem; font-size: 0.75rem; }\n\t\t\t\t.btn-outline {\n\t\t\t\t\tbackground: transparent;\n\t\t\t\t\tborder: 1px solid var(--border);\n\t\t\t\t\tcolor: var(--text-secondary);\n\t\t\t\t}\n\t\t\t\t.btn-outline:hover { border-color: var(--text-muted); color: var(--text); background: var(--surface-hover); }\n\t\t\t\t.btn-green { background: var(--green); }\n\n\t\t\t\t/* --- Stat cards --- */\n\t\t\t\t.stats-grid {\n\t\t\t\t\tdisplay: grid;\n\t\t\t\t\tgrid-template-columns: repeat(auto-fit, minmax(140px, 1fr));\n\t\t\t\t\tgap: 0.75rem;\n\t\t\t\t\tmargin-bottom: 1.5rem;\n\t\t\t\t}\n\t\t\t\t.stat-card {\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tborder: 1px solid var(--border-subtle);\n\t\t\t\t\tborder-radius: var(--radius);\n\t\t\t\t\tpadding: 0.75rem 1rem;\n\t\t\t\t}\n\t\t\t\t.stat-value {\n\t\t\t\t\tfont-size: 1.5rem;\n\t\t\t\t\tfont-weight: 700;\n\t\t\t\t\tfont-variant-numeric: tabular-nums;\n\t\t\t\t\tletter-spacing: -0.02em;\n\t\t\t\t}\n\t\t\t\t.stat-label { font-size: 0.6875rem; color: var(--text-muted); margin-top: 0.0625rem; }\n\t\t\t\t.stat-card-green { border-color: oklch(65% 0.17 155 / 0.25); }\n\t\t\t\t.stat-card-green .stat-value { color: var(--green); }\n\t\t\t\t.stat-card-yellow { border-color: oklch(72% 0.15 80 / 0.25); }\n\t\t\t\t.stat-card-yellow .stat-value { color: var(--yellow); }\n\t\t\t\t.stat-card-muted .stat-value { color: var(--text-muted); }\n\n\t\t\t\t/* --- Integration links --- */\n\t\t\t\t.integration-link {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tjustify-content: space-between;\n\t\t\t\t\ttext-decoration: none;\n\t\t\t\t\tcolor: inherit;\n\t\t\t\t\tpadding: 0.75rem 1rem;\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tborder: 1px solid var(--border-subtle);\n\t\t\t\t\tborder-radius: var(--radius);\n\t\t\t\t\tmargin-bottom: 0.375rem;\n\t\t\t\t\ttransition: border-color 0.1s;\n\t\t\t\t}\n\t\t\t\t.integration-link:hover { border-color: var(--accent); }\n\t\t\t\t.integration-link:focus-visible { outline: 2px solid var(--accent); outline-offset: 1px; }\n\t\t\t\t.integration-link .name {\n\t\t\t\t\tfont-weight: 600;\n\t\t\t\t\tfont-size: 0.875rem;\n\t\t\t\t\ttext-transform: capitalize;\n\t\t\t\t}\n\t\t\t\t.integration-link .meta {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tgap: 0.5rem;\n\t\t\t\t\tfont-size: 0.75rem;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t}\n\t\t\t\t.last-check { color: var(--text-muted); font-size: 0.625rem; margin-left: 0.5rem; }\n\n\t\t\t\t/* --- Integration grid (cards) --- */\n\t\t\t\t.integration-grid {\n\t\t\t\t\tdisplay: grid;\n\t\t\t\t\tgrid-template-columns: repeat(3, 1fr);\n\t\t\t\t\tgap: 0.625rem;\n\t\t\t\t\tmargin-bottom: 0.5rem;\n\t\t\t\t}\n\t\t\t\t.integration-card {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\tflex-direction: column;\n\t\t\t\t\tjustify-content: space-between;\n\t\t\t\t\ttext-decoration: none;\n\t\t\t\t\tcolor: inherit;\n\t\t\t\t\tpadding: 0.75rem 1rem;\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tborder: 1px solid var(--border-subtle);\n\t\t\t\t\tborder-radius: var(--radius);\n\t\t\t\t\ttransition: border-color 0.1s;\n\t\t\t\t\tmin-height: 72px;\n\t\t\t\t}\n\t\t\t\t.integration-card:hover { border-color: var(--accent); }\n\t\t\t\t.integration-card:focus-visible { outline: 2px solid var(--accent); outline-offset: 1px; }\n\t\t\t\t.integration-card-header {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tgap: 0.5rem;\n\t\t\t\t\tmargin-bottom: 0.5rem;\n\t\t\t\t}\n\t\t\t\t.integration-card .name {\n\t\t\t\t\tfont-weight: 600;\n\t\t\t\t\tfont-size: 0.875rem;\n\t\t\t\t\ttext-transform: capitalize;\n\t\t\t\t}\n\t\t\t\t.integration-card-footer {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tgap: 0.5rem;\n\t\t\t\t\tfont-size: 0.75rem;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t}\n\t\t\t\t.integration-card-tools {\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t}\n\n\t\t\t\t/* --- Tools list --- */\n\t\t\t\t.tools-rows {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\tflex-direction: column;\n\t\t\t\t\tgap: 0.25rem;\n\t\t\t\t\tmargin-top: 0.5rem;\n\t\t\t\t}\n\t\t\t\t.tool-row {\n\t\t\t\t\tdisplay: grid;\n\t\t\t\t\tgrid-template-columns: minmax(180px, 240px) 1fr;\n\t\t\t\t\tgap: 0.875rem;\n\t\t\t\t\talign-items: baseline;\n\t\t\t\t\tpadding: 0.4375rem 0;\n\t\t\t\t\tborder-bottom: 1px solid var(--border-subtle);\n\t\t\t\t}\n\t\t\t\t.tool-row:last-child { border-bottom: none; }\n\t\t\t\t.tool-row-name {\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t\tfont-size: 0.75rem;\n\t\t\t\t\tcolor: var(--text-secondary);\n\t\t\t\t\tword-break: break-all;\n\t\t\t\t}\n\t\t\t\t.tool-row-desc {\n\t\t\t\t\tfont-size: 0.75rem;\n\t\t\t\t\tline-height: 1.45;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t}\n\t\t\t\t.tool-row-desc-empty { font-style: italic; }\n\t\t\t\t.tools-hint {\n\t\t\t\t\tfont-size: 0.75rem;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tmargin-top: 0.25rem;\n\t\t\t\t}\n\t\t\t\t@media (max-width: 560px) {\n\t\t\t\t\t.tool-row { grid-template-columns: 1fr; gap: 0.125rem; }\n\t\t\t\t}\n\n\t\t\t\t/* --- Footer --- */\n\t\t\t\t.footer {\n\t\t\t\t\tmargin-top: 2rem;\n\t\t\t\t\tpadding-top: 0.75rem;\n\t\t\t\t\tborder-top: 1px solid var(--border-subtle);\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t}\n\t\t\t\t.footer code {\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tpadding: 2px 6px;\n\t\t\t\t\tborder-radius: 3px;\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t\tfont-size: 0.625rem;\n\t\t\t\t}\n\n\t\t\t\t/* --- Metrics: Dashboard header --- */\n\t\t\t\t.dash-header {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tgap: 0.625rem;\n\t\t\t\t\tmargin-bottom: 1.5rem;\n\t\t\t\t}\n\t\t\t\t.dash-header .page-title { margin-bottom: 0; }\n\t\t\t\t.uptime-badge {\n\t\t\t\t\tfont-size: 0.625rem;\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t\tcolor: var(--green);\n\t\t\t\t\tbackground: var(--green-dim);\n\t\t\t\t\tpadding: 1px 8px;\n\t\t\t\t\tborder-radius: 3px;\n\t\t\t\t\tfont-weight: 500;\n\t\t\t\t}\n\n\t\t\t\t/* --- Metrics sections --- */\n\t\t\t\t.metrics-section { margin-bottom: 1.5rem; }\n\t\t\t\t.metrics-grid {\n\t\t\t\t\tdisplay: grid;\n\t\t\t\t\tgrid-template-columns: repeat(4, 1fr);\n\t\t\t\t\tgap: 0.625rem;\n\t\t\t\t}\n\t\t\t\t.metric-card {\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tborder: 1px solid var(--border-subtle);\n\t\t\t\t\tborder-radius: var(--radius);\n\t\t\t\t\tpadding: 0.75rem 1rem;\n\t\t\t\t}\n\t\t\t\t.metric-value {\n\t\t\t\t\tfont-size: 1.25rem;\n\t\t\t\t\tfont-weight: 700;\n\t\t\t\t\tfont-variant-numeric: tabular-nums;\n\t\t\t\t\tletter-spacing: -0.02em;\n\t\t\t\t}\n\t\t\t\t.metric-label {\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tmargin-top: 0.0625rem;\n\t\t\t\t}\n\t\t\t\t.err-rate-warn { color: var(--red); }\n\n\t\t\t\t/* --- Metrics: history charts --- */\n\t\t\t\t.history-head {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tjustify-content: space-between;\n\t\t\t\t\tgap: 1rem;\n\t\t\t\t}\n\t\t\t\t.history-filter .form-input { width: auto; padding: 0.25rem 0.5rem; font-size: 0.75rem; }\n\t\t\t\t.history-grid {\n\t\t\t\t\tdisplay: grid;\n\t\t\t\t\tgrid-template-columns: repeat(3, 1fr);\n\t\t\t\t\tgap: 0.625rem;\n\t\t\t\t}\n\t\t\t\t.history-chart {\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tborder: 1px solid var(--border-subtle);\n\t\t\t\t\tborder-radius: var(--radius);\n\t\t\t\t\tpadding: 0.75rem 1rem;\n\t\t\t\t\tmin-width: 0;\n\t\t\t\t}\n\t\t\t\t.history-chart-head {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\tjustify-content: space-between;\n\t\t\t\t\talign-items: baseline;\n\t\t\t\t\tgap: 0.5rem;\n\t\t\t\t\tmargin-bottom: 0.5rem;\n\t\t\t\t}\n\t\t\t\t.history-chart-summary {\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tfont-variant-numeric: tabular-nums;\n\t\t\t\t}\n\t\t\t\t.history-svg { display: block; width: 100%; height: 80px; }\n\t\t\t\t.history-hit { fill: transparent; }\n\t\t\t\t.history-bar { fill: var(--accent); }\n\t\t\t\t.history-bar-err { fill: var(--red); }\n\t\t\t\t.history-svg g:hover .history-bar { fill: var(--text); }\n\n\t\t\t\t.slow-call-args { font-size: 0.6875rem; color: var(--text-muted); word-break: break-all; }\n\n\t\t\t\t/* --- Metrics: two-column row --- */\n\t\t\t\t.metrics-row {\n\t\t\t\t\tdisplay: grid;\n\t\t\t\t\tgrid-template-columns: 1fr 1fr;\n\t\t\t\t\tgap: 0.75rem;\n\t\t\t\t}\n\t\t\t\t.metrics-half { min-width: 0; }\n\n\t\t\t\t/* --- Metrics: data table --- */\n\t\t\t\t.table-wrap { overflow-x: auto; }\n\t\t\t\t.metrics-table {\n\t\t\t\t\twidth: 100%;\n\t\t\t\t\tborder-collapse: collapse;\n\t\t\t\t\tfont-size: 0.8125rem;\n\t\t\t\t}\n\t\t\t\t.metrics-table th {\n\t\t\t\t\ttext-align: left;\n\t\t\t\t\tfont-weight: 500;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tpadding: 0.375rem 0.75rem;\n\t\t\t\t\tborder-bottom: 1px solid var(--border);\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\ttext-transform: uppercase;\n\t\t\t\t\tletter-spacing: 0.04em;\n\t\t\t\t}\n\t\t\t\t.metrics-table td {\n\t\t\t\t\tpadding: 0.375rem 0.75rem;\n\t\t\t\t\tborder-bottom: 1px solid var(--border-subtle);\n\t\t\t\t}\n\t\t\t\t.metrics-table tr:last-child td { border-bottom: none; }\n\t\t\t\t.metrics-table .num {\n\t\t\t\t\ttext-align: right;\n\t\t\t\t\tfont-variant-numeric: tabular-nums;\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t\tfont-size: 0.75rem;\n\t\t\t\t}\n\t\t\t\t.metrics-table .latency { color: var(--text-secondary); }\n\t\t\t\t.metrics-table .has-errors { color: var(--red); }\n\t\t\t\t.integration-name-cell { text-transform: capitalize; }\n\t\t\t\t.integration-name-cell a {\n\t\t\t\t\tcolor: var(--accent);\n\t\t\t\t\ttext-decoration: none;\n\t\t\t\t}\n\t\t\t\t.integration-name-cell a:hover { text-decoration: underline; }\n\n\t\t\t\t/* --- Metrics: top tools --- */\n\t\t\t\t.top-tools-list {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\tflex-direction: column;\n\t\t\t\t\tgap: 0.25rem;\n\t\t\t\t}\n\t\t\t\t.top-tool-item {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tgap: 0.5rem;\n\t\t\t\t\tpadding: 0.375rem 0.625rem;\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tborder: 1px solid var(--border-subtle);\n\t\t\t\t\tborder-radius: 4px;\n\t\t\t\t\tfont-size: 0.8125rem;\n\t\t\t\t}\n\t\t\t\t.top-tool-rank {\n\t\t\t\t\twidth: 18px;\n\t\t\t\t\theight: 18px;\n\t\t\t\t\tbackground: var(--accent-dim);\n\t\t\t\t\tcolor: var(--accent);\n\t\t\t\t\tborder-radius: 3px;\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tjustify-content: center;\n\t\t\t\t\tfont-size: 0.625rem;\n\t\t\t\t\tfont-weight: 600;\n\t\t\t\t\tflex-shrink: 0;\n\t\t\t\t}\n\t\t\t\t.top-tool-name {\n\t\t\t\t\tflex: 1;\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\toverflow: hidden;\n\t\t\t\t\ttext-overflow: ellipsis;\n\t\t\t\t\twhite-space: nowrap;\n\t\t\t\t\tcolor: var(--text-secondary);\n\t\t\t\t}\n\t\t\t\t.top-tool-calls {\n\t\t\t\t\tfont-variant-numeric: tabular-nums;\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t}\n\n\t\t\t\t/* --- Metrics: efficiency --- */\n\t\t\t\t.efficiency-grid {\n\t\t\t\t\tdisplay: grid;\n\t\t\t\t\tgrid-template-columns: 1fr 1fr;\n\t\t\t\t\tgap: 0.375rem;\n\t\t\t\t}\n\t\t\t\t.efficiency-item {\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tborder: 1px solid var(--border-subtle);\n\t\t\t\t\tborder-radius: 4px;\n\t\t\t\t\tpadding: 0.625rem;\n\t\t\t\t}\n\t\t\t\t.efficiency-value {\n\t\t\t\t\tfont-size: 1rem;\n\t\t\t\t\tfont-weight: 700;\n\t\t\t\t\tfont-variant-numeric: tabular-nums;\n\t\t\t\t\tletter-spacing: -0.01em;\n\t\t\t\t\tdisplay: block;\n\t\t\t\t}\n\t\t\t\t.efficiency-label {\n\t\t\t\t\tfont-size: 0.625rem;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tdisplay: block;\n\t\t\t\t}\n\n\t\t\t\t/* --- Savings hero: headline value-prop card --- */\n\t\t\t\t.savings-hero {\n\t\t\t\t\tbackground: linear-gradient(135deg,\n\t\t\t\t\t\toklch(22% 0.04 250) 0%,\n\t\t\t\t\t\toklch(18% 0.025 250) 100%);\n\t\t\t\t\tborder: 1px solid var(--accent-dim);\n\t\t\t\t\tborder-radius: var(--radius);\n\t\t\t\t\tpadding: 1.25rem 1.5rem;\n\t\t\t\t\tmargin-bottom: 1.5rem;\n\t\t\t\t\tposition: relative;\n\t\t\t\t\toverflow: hidden;\n\t\t\t\t}\n\t\t\t\t.savings-hero::before {\n\t\t\t\t\tcontent: \"\";\n\t\t\t\t\tposition: absolute;\n\t\t\t\t\ttop: -40%;\n\t\t\t\t\tright: -10%;\n\t\t\t\t\twidth: 320px;\n\t\t\t\t\theight: 320px;\n\t\t\t\t\tbackground: radial-gradient(circle, var(--accent-dim) 0%, transparent 70%);\n\t\t\t\t\tpointer-events: none;\n\t\t\t\t}\n\t\t\t\t.savings-hero-head {\n\t\t\t\t\tposition: relative;\n\t\t\t\t\tmargin-bottom: 1rem;\n\t\t\t\t}\n\t\t\t\t.savings-hero-label {\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\ttext-transform: uppercase;\n\t\t\t\t\tletter-spacing: 0.08em;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tfont-weight: 500;\n\t\t\t\t}\n\t\t\t\t.savings-hero-value {\n\t\t\t\t\tfont-size: 2.5rem;\n\t\t\t\t\tfont-weight: 700;\n\t\t\t\t\tfont-variant-numeric: tabular-nums;\n\t\t\t\t\tletter-spacing: -0.03em;\n\t\t\t\t\tcolor: var(--text);\n\t\t\t\t\tline-height: 1.1;\n\t\t\t\t\tmargin-top: 0.25rem;\n\t\t\t\t}\n\t\t\t\t.savings-hero-unit {\n\t\t\t\t\tfont-size: 1rem;\n\t\t\t\t\tfont-weight: 500;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tmargin-left: 0.375rem;\n\t\t\t\t\tletter-spacing: 0;\n\t\t\t\t}\n\t\t\t\t.savings-hero-sub {\n\t\t\t\t\tfont-size: 0.8125rem;\n\t\t\t\t\tcolor: var(--text-secondary);\n\t\t\t\t\tmargin-top: 0.25rem;\n\t\t\t\t}\n\t\t\t\t.savings-hero-dollars {\n\t\t\t\t\tcolor: var(--green);\n\t\t\t\t\tfont-weight: 500;\n\t\t\t\t}\n\t\t\t\t.savings-buckets {\n\t\t\t\t\tposition: relative;\n\t\t\t\t\tdisplay: grid;\n\t\t\t\t\tgrid-template-columns: repeat(4, 1fr);\n\t\t\t\t\tgap: 0.625rem;\n\t\t\t\t}\n\t\t\t\t.savings-bucket {\n\t\t\t\t\tbackground: var(--surface);\n\t\t\t\t\tborder: 1px solid var(--border-subtle);\n\t\t\t\t\tborder-radius: 6px;\n\t\t\t\t\tpadding: 0.75rem 0.875rem;\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\tflex-direction: column;\n\t\t\t\t\tgap: 0.25rem;\n\t\t\t\t}\n\t\t\t\t.savings-bucket-head {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\tjustify-content: space-between;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tgap: 0.5rem;\n\t\t\t\t}\n\t\t\t\t.savings-bucket-label {\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tcolor: var(--text-secondary);\n\t\t\t\t\tfont-weight: 500;\n\t\t\t\t\ttext-transform: uppercase;\n\t\t\t\t\tletter-spacing: 0.04em;\n\t\t\t\t}\n\t\t\t\t.savings-bucket-pct {\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tfont-variant-numeric: tabular-nums;\n\t\t\t\t\tcolor: var(--accent);\n\t\t\t\t\tbackground: var(--accent-dim);\n\t\t\t\t\tpadding: 1px 6px;\n\t\t\t\t\tborder-radius: 3px;\n\t\t\t\t\tfont-weight: 600;\n\t\t\t\t}\n\t\t\t\t.savings-bucket-value {\n\t\t\t\t\tfont-size: 1.25rem;\n\t\t\t\t\tfont-weight: 700;\n\t\t\t\t\tfont-variant-numeric: tabular-nums;\n\t\t\t\t\tletter-spacing: -0.02em;\n\t\t\t\t\tcolor: var(--text);\n\t\t\t\t}\n\t\t\t\t.savings-bucket-unit {\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tfont-weight: 500;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tmargin-left: 0.25rem;\n\t\t\t\t}\n\t\t\t\t.savings-bucket-meta {\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tfont-variant-numeric: tabular-nums;\n\t\t\t\t}\n\t\t\t\t.savings-bucket-blurb {\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tline-height: 1.4;\n\t\t\t\t\tmargin-top: 0.125rem;\n\t\t\t\t}\n\t\t\t\t.savings-hero-foot {\n\t\t\t\t\tposition: relative;\n\t\t\t\t\tfont-size: 0.625rem;\n\t\t\t\t\tcolor: var(--text-muted);\n\t\t\t\t\tmargin-top: 0.875rem;\n\t\t\t\t\tpadding-top: 0.625rem;\n\t\t\t\t\tborder-top: 1px solid var(--border-subtle);\n\t\t\t\t}\n\n\t\t\t\t/* --- Slack/setup-specific --- */\n\t\t\t\t.slack-desc { color: var(--text-secondary); font-size: 0.8125rem; line-height: 1.6; }\n\t\t\t\t.slack-desc code { background: var(--bg); padding: 2px 6px; border-radius: 3px; font-size: 0.75rem; font-family: var(--font-mono); }\n\t\t\t\t.slack-steps { margin-top: 0.75rem; display: flex; flex-direction: column; gap: 0.75rem; }\n\t\t\t\t.slack-step { display: flex; gap: 0.625rem; align-items: flex-start; }\n\t\t\t\t.slack-step-num {\n\t\t\t\t\tflex-shrink: 0;\n\t\t\t\t\twidth: 24px; height: 24px;\n\t\t\t\t\tbackground: var(--accent);\n\t\t\t\t\tcolor: oklch(100% 0 0);\n\t\t\t\t\tborder-radius: 50%;\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\talign-items: center;\n\t\t\t\t\tjustify-content: center;\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tfont-weight: 600;\n\t\t\t\t}\n\t\t\t\t.slack-step-detail { color: var(--text-secondary); font-size: 0.8125rem; margin-top: 0.125rem; }\n\t\t\t\t.slack-step-detail kbd {\n\t\t\t\t\tbackground: var(--bg);\n\t\t\t\t\tborder: 1px solid var(--border);\n\t\t\t\t\tborder-radius: 3px;\n\t\t\t\t\tpadding: 1px 5px;\n\t\t\t\t\tfont-size: 0.6875rem;\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t}\n\t\t\t\t.slack-link { color: var(--accent); text-decoration: none; }\n\t\t\t\t.slack-link:hover { text-decoration: underline; }\n\t\t\t\t.slack-code-block {\n\t\t\t\t\tbackground: var(--bg);\n\t\t\t\t\tborder: 1px solid var(--border-subtle);\n\t\t\t\t\tborder-radius: var(--radius);\n\t\t\t\t\tpadding: 0.625rem;\n\t\t\t\t\tmargin-top: 0.375rem;\n\t\t\t\t\tposition: relative;\n\t\t\t\t}\n\t\t\t\t.slack-code-block pre { overflow-x: auto; margin: 0; }\n\t\t\t\t.slack-code-block code { font-size: 0.6875rem; font-family: var(--font-mono); color: var(--text-muted); white-space: pre-wrap; word-break: break-all; }\n\t\t\t\t.slack-code-block .btn { position: absolute; top: 0.375rem; right: 0.375rem; }\n\t\t\t\t.slack-textarea {\n\t\t\t\t\tfont-family: var(--font-mono);\n\t\t\t\t\tfont-size: 0.8125rem;\n\t\t\t\t\tresize: vertical;\n\t\t\t\t\tmin-height: 56px;\n\t\t\t\t}\n\t\t\t\t.slack-token-meta {\n\t\t\t\t\tdisplay: flex;\n\t\t\t\t\tgap: 1.25rem;\n\t\t\t\t\tfont-size: 0.8125rem;\n\t\t\t\t\tcolor: var(--text-secondary);\n\t\t\t\t}\n\t\t\t\t.slack-token-meta strong { color: var(--text); font-weight: 600; }\n\n\t\t\t\t/* --- Spinner --- */\n\t\t\t\t.spinner {\n\t\t\t\t\tdisplay: inline-block;\n\t\t\t\t\twidth: 12px;\n\t\t\t\t\theight: 12px;\n\t\t\t\t\tborder: 2px solid var(--border);\n\t\t\t\t\tborder-top-color: var(--accent);\n\t\t\t\t\tborder-radius: 50%;\n\t\t\t\t\tanimation: spin 0.6s linear infinite;\n\t\t\t\t\tvertical-align: middle;\n\t\t\t\t}\n\t\t\t\t@keyframes spin { to { transform: rotate(360deg); } }\n\n\t\t\t\t/* --- Responsive --- */\n\t\t\t\t@media (max-width: 768px) {\n\t\t\t\t\t.sidebar { width: 180px; }\n\t\t\t\t\t.main { padding: 1.5rem; }\n\t\t\t\t\t.metrics-grid { grid-template-columns: repeat(2, 1fr); }\n\t\t\t\t\t.metrics-row { grid-template-columns: 1fr; }\n\t\t\t\t\t.history-grid { grid-template-columns: 1fr; }\n\t\t\t\t\t.stats-grid { grid-template-columns: repeat(2, 1fr); }\n\t\t\t\t\t.integration-grid { grid-template-columns: repeat(2, 1fr); }\n\t\t\t\t\t.savings-buckets { grid-template-columns: repeat(2, 1fr); }\n\t\t\t\t\t.savings-hero { padding: 1rem; }\n\t\t\t\t\t.savings-hero-value { font-size: 2rem; }\n\t\t\t\t}\n\t\t\t\t@media (max-width: 560px) {\n\t\t\t\t\tbody { flex-direction: column; }\n\t\t\t\t\t.sidebar {\n\t\t\t\t\t\twidth: 100%;\n\t\t\t\t\t\tflex-direction: row;\n\t\t\t\t\t\tpadding: 0.5rem 0;\n\t\t\t\t\t\tborder-right: none;\n\t\t\t\t\t\tborder-bottom: 1px solid var(--border-subtle);\n\t\t\t\t\t\toverflow-x: auto;\n\t\t\t\t\t}\n\t\t\t\t\t.sidebar-brand { display: none; }\n\t\t\t\t\t.nav-item {\n\t\t\t\t\t\tborder-left: none;\n\t\t\t\t\t\tborder-bottom: 2px solid transparent;\n\t\t\t\t\t\tpadding: 0.375rem 0.75rem;\n\t\t\t\t\t\twhite-space: nowrap;\n\t\t\t\t\t}\n\t\t\t\t\t.nav-item.active { border-left-color: transparent; border-bottom-color: var(--accent); }\n\t\t\t\t\t.main { padding: 1rem; }\n\t\t\t\t\t.integration-grid { grid-template-columns: 1fr; }\n\t\t\t\t\t.savings-buckets { grid-template-columns: 1fr; }\n\t\t\t\t}\n\t\t\t</style></head><body><nav class=\"sidebar\"><div class=\"sidebar-brand\"><div class=\"sidebar-brand-name\">⚙ Switchboard</div><span class=\"sidebar-brand-sub\">Server Configuration</span></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -91,7 +93,7 @@ func Base(data PageData) templ.Component {
 				var templ_7745c5c3_Var3 templ.SafeURL
 				templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinURLErrs(templ.SafeURL(item.Path))
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 876, Col: 40}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 919, Col: 40}
 				}
 				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
 				if templ_7745c5c3_Err != nil {
@@ -104,7 +106,7 @@ func Base(data PageData) templ.Component {
 				var templ_7745c5c3_Var4 string
 				templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(item.Icon)
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 876, Col: 78}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 919, Col: 78}
 				}
 				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
 				if templ_7745c5c3_Err != nil {
@@ -117,7 +119,7 @@ func Base(data PageData) templ.Component {
 				var templ_7745c5c3_Var5 string
 				templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(item.Label)
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 876, Col: 93}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 919, Col: 93}
 				}
 				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
 				if templ_7745c5c3_Err != nil {
@@ -135,7 +137,7 @@ func Base(data PageData) templ.Component {
 				var templ_7745c5c3_Var6 templ.SafeURL
 				templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinURLErrs(templ.SafeURL(item.Path))
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 878, Col: 40}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 921, Col: 40}
 				}
 				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
 				if templ_7745c5c3_Err != nil {
@@ -148,7 +150,7 @@ func Base(data PageData) templ.Component {
 				var templ_7745c5c3_Var7 string
 				templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinStringErrs(item.Icon)
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 878, Col: 71}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 921, Col: 71}
 				}
 				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
 				if templ_7745c5c3_Err != nil {
@@ -161,7 +163,7 @@ func Base(data PageData) templ.Component {
 				var templ_7745c5c3_Var8 string
 				templ_7745c5c3_Var8, templ_7745c5c3_Err = templ.JoinStringErrs(item.Label)
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 878, Col: 86}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 921, Col: 86}
 				}
 				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var8))
 				if templ_7745c5c3_Err != nil {
@@ -180,7 +182,7 @@ func Base(data PageData) templ.Component {
 		var templ_7745c5c3_Var9 string
 		templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinStringErrs(version.String())
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 881, Col: 51}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 924, Col: 51}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
 		if templ_7745c5c3_Err != nil {
@@ -198,7 +200,7 @@ func Base(data PageData) templ.Component {
 			var templ_7745c5c3_Var10 string
 			templ_7745c5c3_Var10, templ_7745c5c3_Err = templ.JoinStringErrs(data.FlashSuccess)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 885, Col: 57}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 928, Col: 57}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var10))
 			if templ_7745c5c3_Err != nil {
@@ -217,7 +219,7 @@ func Base(data PageData) templ.Component {
 			var templ_7745c5c3_Var11 string
 			templ_7745c5c3_Var11, templ_7745c5c3_Err = templ.JoinStringErrs(data.FlashError)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 888, Col: 53}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/layouts/base.templ`, Line: 931, Col: 53}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var11))
 			if templ_7745c5c3_Err != nil {
@@ -0,0 +1,60 @@
+package pages
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	mcp "github.com/daltoniam/switchboard"
+	"github.com/daltoniam/switchboard/web/templates/layouts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func renderCompaction(t *testing.T, data CompactionData) string {
+	t.Helper()
+	var buf bytes.Buffer
+	page := layouts.PageData{Title: "Compaction", CurrentPath: "/compaction"}
+	require.NoError(t, Compaction(page, data).Render(context.Background(), &buf))
+	return buf.String()
+}
+
+func TestCompaction_EmptyState(t *testing.T) {
+	html := renderCompaction(t, CompactionData{Metrics: &mcp.MetricsSnapshot{}})
+	assert.Contains(t, html, "No compacted responses since startup")
+	assert.NotContains(t, html, "Compacted Tools")
+}
+
+func TestCompaction_RendersToolsFieldsAndFollowUps(t *testing.T) {
+	fields := make([]mcp.FieldAttributionSnapshot, maxFieldRows+3)
+	fields[0] = mcp.FieldAttributionSnapshot{Path: "body", BytesBefore: 4096, BytesDropped: 4096}
+	for i := 1; i < len(fields); i++ {
+		fields[i] = mcp.FieldAttributionSnapshot{Path: "kept_field", BytesBefore: 10, BytesKept: 10}
+	}
+	html := renderCompaction(t, CompactionData{Metrics: &mcp.MetricsSnapshot{
+		CompactionTools: map[string]mcp.CompactionToolSnapshot{
+			"github_list_issues": {Calls: 4, BytesBefore: 8192, BytesAfter: 2048, OverCompactions: 1, Fields: fields},
+		},
+		OverCompactions: []mcp.OverCompactionSnapshot{
+			{Tool: "github_list_issues", FollowUp: "github_get_issue", Kind: mcp.OverCompactionGet, Count: 1},
+		},
+	}})
+
+	assert.Contains(t, html, "Possible Over-Compaction")
+	assert.Contains(t, html, "github_get_issue")
+	assert.Contains(t, html, "get right after list")
+	assert.Contains(t, html, "75%", "saved share")
+	assert.Contains(t, html, "25%", "follow-up rate")
+	assert.Contains(t, html, `id="tool-github_list_issues"`)
+	assert.Contains(t, html, "4.0KB")
+	assert.Contains(t, html, "3 more fields")
+}
+
+func TestSortedCompactionTools_ByBytesDropped(t *testing.T) {
+	got := sortedCompactionTools(map[string]mcp.CompactionToolSnapshot{
+		"small": {BytesBefore: 100, BytesAfter: 90},
+		"big":   {BytesBefore: 1000, BytesAfter: 100},
+		"alpha": {BytesBefore: 100, BytesAfter: 90},
+	})
+	assert.Equal(t, []string{"big", "alpha", "small"}, got)
+}
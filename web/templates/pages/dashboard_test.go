@@ -3,6 +3,7 @@ package pages
 import (
 	"bytes"
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -178,3 +179,59 @@ func TestDashboard_HeroIncludesAllBucketsByName(t *testing.T) {
 	// Foot note about token heuristic
 	assert.Contains(t, html, "4 characters per token")
 }
+
+func TestTokenFootnote(t *testing.T) {
+	assert.Equal(t, "Tokens estimated at ~4 characters per token.", tokenFootnote(nil))
+	assert.Contains(t, tokenFootnote(&mcp.TokenMeasurementSnapshot{Tokenizer: "o200k_base"}), "o200k_base measures responses")
+
+	tm := &mcp.TokenMeasurementSnapshot{Tokenizer: "o200k_base", CharsPerToken: 3.21}
+	tm.Samples = 12
+	assert.Equal(t, "Response tokens measured with o200k_base on 12 sampled responses (3.2 characters per token); catalog and script tokens estimated at ~4 characters per token.", tokenFootnote(tm))
+}
+
+func TestHistoryBars(t *testing.T) {
+	h := &mcp.MetricsHistory{
+		Resolution: mcp.HistoryHour,
+		Points: []mcp.HistoryPoint{
+			{Time: time.Now(), Calls: 10, Errors: 5, ErrorRate: 50},
+			{Time: time.Now(), Calls: 0},
+			{Time: time.Now(), Calls: 5},
+		},
+	}
+	bars := callBars(h)
+	require.Len(t, bars, 3)
+	assert.Equal(t, chartHeight, bars[0].H, "the busiest bucket fills the chart")
+	assert.Equal(t, chartHeight/2, bars[0].OverlayH, "errors overlay their share of calls")
+	assert.Equal(t, 0.0, bars[1].H)
+	assert.Equal(t, chartHeight/2, bars[2].H)
+	assert.Equal(t, chartWidth/3, bars[1].X)
+	assert.Contains(t, bars[0].Title, "10 calls, 5 errors (50.0%)")
+
+	assert.Equal(t, "15 calls · 5 errors (33.3%)", callsSummary(h))
+	assert.Equal(t, "no calls", latencySummary(h))
+	for _, b := range latencyBars(h) {
+		assert.Equal(t, 0.0, b.H, "an all-zero series draws no bars")
+	}
+}
+
+func TestHistoryIntegrations(t *testing.T) {
+	h := &mcp.MetricsHistory{Integrations: []mcp.IntegrationName{"github", "slack"}}
+	assert.False(t, historyActive(h))
+	assert.Equal(t, []string{"github", "slack"}, historyIntegrations(h))
+
+	h.Integration = "datadog"
+	assert.True(t, historyActive(h), "a filtered view stays visible while empty")
+	assert.Equal(t, []string{"github", "slack", "datadog"}, historyIntegrations(h))
+}
+
+func TestSlowCallArgs(t *testing.T) {
+	assert.Empty(t, slowCallArgs(nil))
+	assert.Equal(t, `{"q":"x","token":"[redacted]"}`, slowCallArgs(map[string]any{"token": mcp.RedactedArg, "q": "x"}))
+
+	long := slowCallArgs(map[string]any{"body": strings.Repeat("a", 500)})
+	assert.True(t, strings.HasSuffix(long, "…"))
+	assert.Less(t, len(long), 130)
+
+	calls := make([]mcp.SlowCall, maxSlowCallRows+5)
+	assert.Len(t, slowCallRows(calls), maxSlowCallRows)
+}
@@ -10,7 +10,9 @@ import templruntime "github.com/a-h/templ/runtime"
 
 import (
 	"fmt"
+	"time"
 
+	mcp "github.com/daltoniam/switchboard"
 	"github.com/daltoniam/switchboard/web/templates/layouts"
 )
 
@@ -18,6 +20,17 @@ type SettingsData struct {
 	SessionStore        string
 	ShowDollarEstimate  bool
 	DollarsPerMTokInput float64
+	Tokenizer           string
+	Tokenizers          []TokenizerOption
+	MetricsHistoryDays  int
+}
+
+// TokenizerOption is one entry in the token measurement selector.
+// Unavailable options have no vocabulary on this install.
+type TokenizerOption struct {
+	Name      string
+	Label     string
+	Available bool
 }
 
 // formatDollarRate renders a $/MTok value for the settings form. Always shows
@@ -30,6 +43,15 @@ func formatDollarRate(v float64) string {
 	return fmt.Sprintf("%.2f", v)
 }
 
+// formatHistoryDays renders the history retention for the settings form,
+// showing the default when unset.
+func formatHistoryDays(days int) string {
+	if days <= 0 {
+		days = int(mcp.DefaultHistoryRetention / (24 * time.Hour))
+	}
+	return fmt.Sprint(days)
+}
+
 func Settings(page layouts.PageData, data SettingsData) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
@@ -63,32 +85,47 @@ func Settings(page layouts.PageData, data SettingsData) templ.Component {
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<h1 class=\"page-title\">Settings</h1><form method=\"POST\" action=\"/settings\"><div class=\"card\" style=\"margin-bottom: 1rem;\"><div class=\"section-title\" style=\"margin-bottom: 0.75rem;\">Session Storage</div><p style=\"font-size: 0.8125rem; color: var(--text-muted); margin: 0 0 1rem 0;\">Controls where session context and breadcrumb history are stored between requests. Changes take effect on next server restart.</p><div class=\"form-group\"><label class=\"form-label\">Storage Backend</label> <select name=\"session_store\" class=\"form-input\">")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<h1 class=\"page-title\">Settings</h1><form method=\"POST\" action=\"/settings\"><div class=\"card\" style=\"margin-bottom: 1rem;\"><div class=\"section-title\" style=\"margin-bottom: 0.75rem;\">Session Storage</div><p style=\"font-size: 0.8125rem; color: var(--text-muted); margin: 0 0 1rem 0;\">Controls where session context, breadcrumb history and pinned results are stored between requests. Changes take effect on next server restart.</p><div class=\"form-group\"><label class=\"form-label\">Storage Backend</label> <select name=\"session_store\" class=\"form-input\"><option value=\"memory\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if data.SessionStore == "memory" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, " selected")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, ">Memory (ephemeral, lost on restart)</option> <option value=\"file\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 			if data.SessionStore == "file" {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<option value=\"memory\">Memory (ephemeral, lost on restart)</option> <option value=\"file\" selected>File (persisted to ~/.config/switchboard/sessions/)</option>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, " selected")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-			} else {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "<option value=\"memory\" selected>Memory (ephemeral, lost on restart)</option> <option value=\"file\">File (persisted to ~/.config/switchboard/sessions/)</option>")
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, ">File (persisted to ~/.config/switchboard/sessions/)</option> <option value=\"sqlite\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if data.SessionStore == "sqlite" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, " selected")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "</select></div></div><div class=\"card\" style=\"margin-bottom: 1rem;\"><div class=\"section-title\" style=\"margin-bottom: 0.75rem;\">Dashboard — Dollar Estimate</div><p style=\"font-size: 0.8125rem; color: var(--text-muted); margin: 0 0 1rem 0;\">When enabled, the dashboard's \"Context window saved\" card shows an approximate dollar value alongside the token count, based on the input token rate below. Token and dollar figures are estimates only.</p><div class=\"form-group\"><label class=\"form-label\">")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, ">SQLite (persisted to ~/.config/switchboard/sessions.db, with full-text search of past results)</option></select></div></div><div class=\"card\" style=\"margin-bottom: 1rem;\"><div class=\"section-title\" style=\"margin-bottom: 0.75rem;\">Dashboard — Dollar Estimate</div><p style=\"font-size: 0.8125rem; color: var(--text-muted); margin: 0 0 1rem 0;\">When enabled, the dashboard's \"Context window saved\" card shows an approximate dollar value alongside the token count, based on the input token rate below. Token and dollar figures are estimates only.</p><div class=\"form-group\"><label class=\"form-label\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 			if data.ShowDollarEstimate {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "<input type=\"checkbox\" name=\"show_dollar_estimate\" value=\"true\" checked> ")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "<input type=\"checkbox\" name=\"show_dollar_estimate\" value=\"true\" checked> ")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			} else {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "<input type=\"checkbox\" name=\"show_dollar_estimate\" value=\"true\"> ")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "<input type=\"checkbox\" name=\"show_dollar_estimate\" value=\"true\"> ")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
@@ -96,26 +133,106 @@ func Settings(page layouts.PageData, data SettingsData) templ.Component {
 			var templ_7745c5c3_Var3 string
 			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(" ")
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/pages/settings.templ`, Line: 62, Col: 11}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/pages/settings.templ`, Line: 80, Col: 11}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, " Show dollar estimate on dashboard</label></div><div class=\"form-group\"><label class=\"form-label\">Input token price ($ per million tokens)</label> <input type=\"number\" step=\"0.01\" min=\"0\" name=\"dollars_per_mtok_input\" class=\"form-input\" value=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, " Show dollar estimate on dashboard</label></div><div class=\"form-group\"><label class=\"form-label\">Input token price ($ per million tokens)</label> <input type=\"number\" step=\"0.01\" min=\"0\" name=\"dollars_per_mtok_input\" class=\"form-input\" value=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 			var templ_7745c5c3_Var4 string
 			templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(formatDollarRate(data.DollarsPerMTokInput))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/pages/settings.templ`, Line: 68, Col: 145}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/pages/settings.templ`, Line: 86, Col: 145}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "\"><p style=\"font-size: 0.75rem; color: var(--text-muted); margin: 0.5rem 0 0 0;\">Default: $3.00 (Claude Sonnet input tier as of late 2025). Set to your model's input rate for an accurate estimate.</p></div></div><button type=\"submit\" class=\"btn\">Save Settings</button></form>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "\"><p style=\"font-size: 0.75rem; color: var(--text-muted); margin: 0.5rem 0 0 0;\">Default: $3.00 (Claude Sonnet input tier as of late 2025). Set to your model's input rate for an accurate estimate.</p></div></div><div class=\"card\" style=\"margin-bottom: 1rem;\"><div class=\"section-title\" style=\"margin-bottom: 0.75rem;\">Token Measurement</div><p style=\"font-size: 0.8125rem; color: var(--text-muted); margin: 0 0 1rem 0;\">Token figures default to an estimate of ~4 characters per token. Pick a tokenizer to count tokens on one in every 10 compacted or rendered responses, before and after processing; the dashboard then converts response savings at the measured ratio. Applies immediately.</p><div class=\"form-group\"><label class=\"form-label\">Tokenizer</label> <select name=\"tokenizer\" class=\"form-input\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			for _, opt := range data.Tokenizers {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "<option value=\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var5 string
+				templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(opt.Name)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/pages/settings.templ`, Line: 103, Col: 31}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				if data.Tokenizer == opt.Name {
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, " selected")
+					if templ_7745c5c3_Err != nil {
+						return templ_7745c5c3_Err
+					}
+				}
+				if !opt.Available {
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, " disabled")
+					if templ_7745c5c3_Err != nil {
+						return templ_7745c5c3_Err
+					}
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, ">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var6 string
+				templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinStringErrs(opt.Label)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/pages/settings.templ`, Line: 104, Col: 19}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, " ")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				if !opt.Available {
+					var templ_7745c5c3_Var7 string
+					templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinStringErrs(" — vocabulary not installed")
+					if templ_7745c5c3_Err != nil {
+						return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/pages/settings.templ`, Line: 106, Col: 42}
+					}
+					_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
+					if templ_7745c5c3_Err != nil {
+						return templ_7745c5c3_Err
+					}
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, "</option>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "</select><p style=\"font-size: 0.75rem; color: var(--text-muted); margin: 0.5rem 0 0 0;\">Claude's tokenizer is not public, so for Claude models these counts are an approximation too. Vocabularies are embedded at build time or read from ~/.config/switchboard/tokenizers.</p></div></div><div class=\"card\" style=\"margin-bottom: 1rem;\"><div class=\"section-title\" style=\"margin-bottom: 0.75rem;\">Metrics History</div><p style=\"font-size: 0.8125rem; color: var(--text-muted); margin: 0 0 1rem 0;\">Hourly calls, errors, latency and bytes saved per integration and tool are kept on disk in ~/.config/switchboard/metrics-history.json for the dashboard charts and /api/metrics/history. Per-minute history always covers the last 3 hours. Applies immediately.</p><div class=\"form-group\"><label class=\"form-label\">Keep hourly history for (days)</label> <input type=\"number\" step=\"1\" min=\"1\" max=\"90\" name=\"metrics_history_days\" class=\"form-input\" value=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var8 string
+			templ_7745c5c3_Var8, templ_7745c5c3_Err = templ.JoinStringErrs(formatHistoryDays(data.MetricsHistoryDays))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/pages/settings.templ`, Line: 126, Col: 149}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var8))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, "\"></div></div><button type=\"submit\" class=\"btn\">Save Settings</button></form>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
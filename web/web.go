@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -28,7 +29,9 @@ import (
 	slackInt "github.com/daltoniam/switchboard/integrations/slack"
 	xInt "github.com/daltoniam/switchboard/integrations/x"
 	"github.com/daltoniam/switchboard/marketplace"
+	"github.com/daltoniam/switchboard/project"
 	"github.com/daltoniam/switchboard/remotemcp"
+	"github.com/daltoniam/switchboard/tokenizer"
 	wasmmod "github.com/daltoniam/switchboard/wasm"
 	"github.com/daltoniam/switchboard/web/templates/layouts"
 	"github.com/daltoniam/switchboard/web/templates/pages"
@@ -41,6 +44,7 @@ type WebServer struct {
 	health         *healthCache
 	marketplace    *marketplace.Manager
 	wasmLoader     pluginLoader
+	projects       *project.Store
 	onConfigChange func()
 }
 
@@ -50,6 +54,11 @@ func WithConfigChangeHook(fn func()) Option {
 	return func(w *WebServer) { w.onConfigChange = fn }
 }
 
+// WithProjectStore enables the project pages, backed by store.
+func WithProjectStore(store *project.Store) Option {
+	return func(w *WebServer) { w.projects = store }
+}
+
 // New returns a WebServer that provides a browser-based config UI.
 func New(services *mcp.Services, port int, mp *marketplace.Manager, wl *wasmmod.Loader, opts ...Option) *WebServer {
 	ws := &WebServer{
@@ -75,6 +84,7 @@ func (w *WebServer) Handler() http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("GET /", w.handleDashboard)
+	mux.HandleFunc("GET /compaction", w.handleCompaction)
 	mux.HandleFunc("GET /integrations", w.handleIntegrationsList)
 	mux.HandleFunc("GET /integrations/{name}", w.handleIntegrationDetail)
 	mux.HandleFunc("POST /integrations/{name}", w.handleIntegrationSave)
@@ -194,16 +204,27 @@ func (w *WebServer) Handler() http.Handler {
 	mux.HandleFunc("GET /api/health", w.handleHealthAPI)
 	mux.HandleFunc("POST /api/health/refresh", w.handleHealthRefresh)
 	mux.HandleFunc("GET /api/metrics", w.handleMetricsAPI)
+	mux.HandleFunc("GET /api/metrics/history", w.handleMetricsHistoryAPI)
 
 	mux.HandleFunc("GET /settings", w.handleSettings)
 	mux.HandleFunc("POST /settings", w.handleSettingsSave)
 
+	mux.HandleFunc("GET /projects", w.handleProjectsList)
+	mux.HandleFunc("GET /projects/new", w.handleProjectNew)
+	mux.HandleFunc("POST /projects/new", w.handleProjectNewSubmit)
+	mux.HandleFunc("GET /projects/{name}", w.handleProjectDetail)
+	mux.HandleFunc("POST /projects/{name}", w.handleProjectSave)
+	mux.HandleFunc("POST /projects/{name}/delete", w.handleProjectDelete)
+	mux.HandleFunc("POST /api/projects/preview", w.handleProjectPreview)
+
 	mux.HandleFunc("GET /plugins", w.handlePluginMarketplace)
 	mux.HandleFunc("POST /plugins/install", w.handlePluginInstall)
 	mux.HandleFunc("POST /plugins/install-url", w.handlePluginInstallURL)
 	mux.HandleFunc("POST /plugins/upload", w.handlePluginUpload)
 	mux.HandleFunc("POST /plugins/uninstall", w.handlePluginUninstall)
 	mux.HandleFunc("POST /plugins/update", w.handlePluginUpdate)
+	mux.HandleFunc("POST /plugins/approve", w.handlePluginApprove)
+	mux.HandleFunc("POST /plugins/reject", w.handlePluginReject)
 	mux.HandleFunc("POST /plugins/check-updates", w.handlePluginCheckUpdates)
 	mux.HandleFunc("POST /plugins/auto-update", w.handlePluginAutoUpdate)
 	mux.HandleFunc("POST /plugins/add-manifest", w.handlePluginAddManifest)
@@ -294,11 +315,27 @@ func (w *WebServer) handleDashboard(rw http.ResponseWriter, r *http.Request) {
 		snap := w.services.Metrics.SnapshotWithPricing(rate, cfg.ShowDollarEstimate)
 		data.Metrics = &snap
 		data.TopTools = w.services.Metrics.TopTools(5)
+		history := w.services.Metrics.History(mcp.HistoryQuery{
+			Resolution:  mcp.HistoryHour,
+			Window:      24 * time.Hour,
+			Integration: mcp.IntegrationName(r.URL.Query().Get("integration")),
+		})
+		data.History = &history
 	}
 
 	pages.Dashboard(page, data).Render(r.Context(), rw)
 }
 
+func (w *WebServer) handleCompaction(rw http.ResponseWriter, r *http.Request) {
+	page := w.pageData(r, "Compaction", "/compaction")
+	var data pages.CompactionData
+	if w.services.Metrics != nil {
+		snap := w.services.Metrics.Snapshot()
+		data.Metrics = &snap
+	}
+	pages.Compaction(page, data).Render(r.Context(), rw)
+}
+
 func (w *WebServer) handleIntegrationsList(rw http.ResponseWriter, r *http.Request) {
 	summaries := w.integrationSummaries(r.Context())
 
@@ -3289,6 +3326,35 @@ func (w *WebServer) handleMetricsAPI(rw http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(rw).Encode(snap)
 }
 
+// handleMetricsHistoryAPI serves one metrics series over time. Query
+// parameters: resolution (minute|hour), window (a Go duration such as
+// "6h"), integration and tool.
+func (w *WebServer) handleMetricsHistoryAPI(rw http.ResponseWriter, r *http.Request) {
+	if w.services.Metrics == nil {
+		writeJSON(rw, http.StatusServiceUnavailable, map[string]string{"error": "metrics not initialized"})
+		return
+	}
+	params := r.URL.Query()
+	q := mcp.HistoryQuery{
+		Resolution:  params.Get("resolution"),
+		Integration: mcp.IntegrationName(params.Get("integration")),
+		Tool:        mcp.ToolName(params.Get("tool")),
+	}
+	if raw := params.Get("window"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			writeJSON(rw, http.StatusBadRequest, map[string]string{"error": "invalid window: " + err.Error()})
+			return
+		}
+		q.Window = d
+	}
+	if err := mcp.ValidateHistoryQuery(q); err != nil {
+		writeJSON(rw, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(rw, http.StatusOK, w.services.Metrics.History(q))
+}
+
 func (w *WebServer) handleSettings(rw http.ResponseWriter, r *http.Request) {
 	cfg := w.services.Config.Get()
 	page := w.pageData(r, "Settings", "/settings")
@@ -3296,10 +3362,16 @@ func (w *WebServer) handleSettings(rw http.ResponseWriter, r *http.Request) {
 		SessionStore:        cfg.SessionStore,
 		ShowDollarEstimate:  cfg.ShowDollarEstimate,
 		DollarsPerMTokInput: cfg.DollarsPerMTokInput,
+		Tokenizer:           cfg.Tokenizer,
+		Tokenizers:          tokenizerOptions(),
+		MetricsHistoryDays:  cfg.MetricsHistoryDays,
 	}
 	if data.SessionStore == "" {
 		data.SessionStore = "memory"
 	}
+	if data.Tokenizer == "" {
+		data.Tokenizer = tokenizer.Estimate
+	}
 	pages.Settings(page, data).Render(r.Context(), rw)
 }
 
@@ -3309,7 +3381,7 @@ func (w *WebServer) handleSettingsSave(rw http.ResponseWriter, r *http.Request)
 		return
 	}
 	sessionStore := r.FormValue("session_store")
-	if sessionStore != "memory" && sessionStore != "file" {
+	if sessionStore != "memory" && sessionStore != "file" && sessionStore != "sqlite" {
 		sessionStore = "memory"
 	}
 	showDollar := r.FormValue("show_dollar_estimate") == "true"
@@ -3319,17 +3391,53 @@ func (w *WebServer) handleSettingsSave(rw http.ResponseWriter, r *http.Request)
 			dollarsPerMTok = v
 		}
 	}
+	var historyDays int
+	if raw := strings.TrimSpace(r.FormValue("metrics_history_days")); raw != "" {
+		if v, err := strconv.Atoi(raw); err == nil && v >= 0 {
+			historyDays = min(v, int(mcp.MaxHistoryRetention/(24*time.Hour)))
+		}
+	}
+	family := r.FormValue("tokenizer")
+	if family == "" {
+		family = tokenizer.Estimate
+	}
+	if !tokenizer.Available(family) {
+		http.Redirect(rw, r, "/settings?error="+url.QueryEscape("Tokenizer "+family+" is not available on this install."), http.StatusSeeOther)
+		return
+	}
 	cfg := w.services.Config.Get()
 	cfg.SessionStore = sessionStore
 	cfg.ShowDollarEstimate = showDollar
 	cfg.DollarsPerMTokInput = dollarsPerMTok
+	cfg.Tokenizer = family
+	if family == tokenizer.Estimate {
+		cfg.Tokenizer = ""
+	}
+	cfg.MetricsHistoryDays = historyDays
 	if err := w.services.Config.Update(cfg); err != nil {
 		http.Redirect(rw, r, "/settings?error=Failed+to+save:+"+err.Error(), http.StatusSeeOther)
 		return
 	}
+	if w.services.Metrics != nil {
+		w.services.Metrics.SetHistoryRetention(time.Duration(historyDays) * 24 * time.Hour)
+		if err := tokenizer.Configure(w.services.Metrics, family); err != nil {
+			http.Redirect(rw, r, "/settings?error="+url.QueryEscape("Settings saved, but token measurement is off: "+err.Error()), http.StatusSeeOther)
+			return
+		}
+	}
 	http.Redirect(rw, r, "/settings?success=Settings+saved.", http.StatusSeeOther)
 }
 
+// tokenizerOptions lists the byte estimate and every BPE family, marking
+// which vocabularies this install can load.
+func tokenizerOptions() []pages.TokenizerOption {
+	opts := []pages.TokenizerOption{{Name: tokenizer.Estimate, Label: "Estimate (~4 characters per token)", Available: true}}
+	for _, f := range tokenizer.Families {
+		opts = append(opts, pages.TokenizerOption{Name: f.Name, Label: f.Label, Available: tokenizer.Available(f.Name)})
+	}
+	return opts
+}
+
 func (w *WebServer) handleXSetup(rw http.ResponseWriter, r *http.Request) {
 	ic, exists := w.services.Config.GetIntegration("x")
 	hasToken := exists && ic.Credentials["bearer_token"] != ""
@@ -19,6 +19,7 @@ import (
 type pluginLoader interface {
 	LoadPlugin(ctx context.Context, path, nameOverride string) error
 	UnloadPlugin(ctx context.Context, name string) error
+	InspectPlugin(ctx context.Context, path string) (*marketplace.PluginMetadata, error)
 }
 
 func (w *WebServer) handlePluginMarketplace(rw http.ResponseWriter, r *http.Request) {
@@ -40,6 +41,7 @@ func (w *WebServer) handlePluginMarketplace(rw http.ResponseWriter, r *http.Requ
 			URL:     src.URL,
 			Name:    src.Name,
 			Enabled: src.Enabled,
+			Keys:    len(src.PublicKeys),
 		})
 	}
 
@@ -52,6 +54,11 @@ func (w *WebServer) handlePluginMarketplace(rw http.ResponseWriter, r *http.Requ
 			AutoUpdate:    ip.AutoUpdate,
 			LatestVersion: ip.LatestVersion,
 			UpdateAvail:   ip.LatestVersion != "" && ip.LatestVersion != ip.Version,
+			Capabilities:  ip.ApprovedCapabilities,
+			Pending:       ip.PendingCapabilities,
+			NeedsApproval: ip.NeedsApproval,
+			Trust:         ip.Trust,
+			TrustOverride: ip.TrustOverride,
 		}
 		data.Installed = append(data.Installed, entry)
 	}
@@ -75,6 +82,7 @@ func (w *WebServer) handlePluginMarketplace(rw http.ResponseWriter, r *http.Requ
 					Installed:        br.Installed,
 					InstalledVersion: br.InstalledVersion,
 					UpdateAvailable:  br.UpdateAvailable,
+					Verified:         br.Verified,
 				})
 			}
 		}
@@ -107,10 +115,15 @@ func (w *WebServer) handlePluginInstall(rw http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := w.liveLoadPlugin(r.Context(), ip.Path, ""); err != nil {
+	loaded, err := w.reviewAndLoadPlugin(r.Context(), ip)
+	if err != nil {
 		http.Redirect(rw, r, fmt.Sprintf("/plugins?error=Installed+%s+but+load+failed:+%s", urlEncode(ip.Name), urlEncode(err.Error())), http.StatusSeeOther)
 		return
 	}
+	if !loaded {
+		http.Redirect(rw, r, fmt.Sprintf("/plugins?success=Installed+%s.+Approve+its+requested+capabilities+to+load+it.", urlEncode(ip.Name)), http.StatusSeeOther)
+		return
+	}
 	http.Redirect(rw, r, fmt.Sprintf("/plugins?success=Installed+and+loaded+%s@%s.", urlEncode(ip.Name), urlEncode(ip.Version)), http.StatusSeeOther)
 }
 
@@ -138,10 +151,15 @@ func (w *WebServer) handlePluginInstallURL(rw http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if err := w.liveLoadPlugin(r.Context(), ip.Path, ""); err != nil {
+	loaded, err := w.reviewAndLoadPlugin(r.Context(), ip)
+	if err != nil {
 		http.Redirect(rw, r, fmt.Sprintf("/plugins?error=Installed+%s+but+load+failed:+%s", urlEncode(ip.Name), urlEncode(err.Error())), http.StatusSeeOther)
 		return
 	}
+	if !loaded {
+		http.Redirect(rw, r, fmt.Sprintf("/plugins?success=Installed+%s.+Approve+its+requested+capabilities+to+load+it.", urlEncode(ip.Name)), http.StatusSeeOther)
+		return
+	}
 	http.Redirect(rw, r, fmt.Sprintf("/plugins?success=Installed+and+loaded+%s.", urlEncode(ip.Name)), http.StatusSeeOther)
 }
 
@@ -176,10 +194,15 @@ func (w *WebServer) handlePluginUpload(rw http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := w.liveLoadPlugin(r.Context(), ip.Path, ""); err != nil {
+	loaded, err := w.reviewAndLoadPlugin(r.Context(), ip)
+	if err != nil {
 		http.Redirect(rw, r, fmt.Sprintf("/plugins?error=Uploaded+%s+but+load+failed:+%s", urlEncode(ip.Name), urlEncode(err.Error())), http.StatusSeeOther)
 		return
 	}
+	if !loaded {
+		http.Redirect(rw, r, fmt.Sprintf("/plugins?success=Uploaded+%s.+Approve+its+requested+capabilities+to+load+it.", urlEncode(ip.Name)), http.StatusSeeOther)
+		return
+	}
 	http.Redirect(rw, r, fmt.Sprintf("/plugins?success=Uploaded+and+loaded+%s.", urlEncode(ip.Name)), http.StatusSeeOther)
 }
 
@@ -229,13 +252,74 @@ func (w *WebServer) handlePluginUpdate(rw http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := w.liveLoadPlugin(r.Context(), ip.Path, ""); err != nil {
+	loaded, err := w.reviewAndLoadPlugin(r.Context(), ip)
+	if err != nil {
 		http.Redirect(rw, r, fmt.Sprintf("/plugins?error=Updated+%s+but+load+failed:+%s", urlEncode(ip.Name), urlEncode(err.Error())), http.StatusSeeOther)
 		return
 	}
+	if !loaded {
+		http.Redirect(rw, r, fmt.Sprintf("/plugins?success=Updated+%s+to+%s.+It+requests+new+capabilities+that+need+approval+before+it+reloads.", urlEncode(ip.Name), urlEncode(ip.Version)), http.StatusSeeOther)
+		return
+	}
 	http.Redirect(rw, r, fmt.Sprintf("/plugins?success=Updated+and+reloaded+%s+to+%s.", urlEncode(ip.Name), urlEncode(ip.Version)), http.StatusSeeOther)
 }
 
+func (w *WebServer) handlePluginApprove(rw http.ResponseWriter, r *http.Request) {
+	if w.marketplace == nil {
+		http.Redirect(rw, r, "/plugins?error=Marketplace+not+configured", http.StatusSeeOther)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(rw, r, "/plugins?error=Invalid+form+data", http.StatusSeeOther)
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if r.FormValue("trust_unsigned") == "true" {
+		if err := w.marketplace.OverrideTrust(name); err != nil {
+			http.Redirect(rw, r, "/plugins?error=Approve+failed:+"+urlEncode(err.Error()), http.StatusSeeOther)
+			return
+		}
+		log.Printf("Trust overridden for unsigned plugin %q", name)
+	}
+	if err := w.marketplace.ApproveCapabilities(name); err != nil {
+		http.Redirect(rw, r, "/plugins?error=Approve+failed:+"+urlEncode(err.Error()), http.StatusSeeOther)
+		return
+	}
+	log.Printf("Approved capabilities for plugin %q", name)
+
+	for _, ip := range w.marketplace.InstalledPlugins() {
+		if ip.Name != name {
+			continue
+		}
+		if err := w.liveLoadPlugin(r.Context(), ip.Path, ""); err != nil {
+			http.Redirect(rw, r, fmt.Sprintf("/plugins?error=Approved+%s+but+load+failed:+%s", urlEncode(name), urlEncode(err.Error())), http.StatusSeeOther)
+			return
+		}
+	}
+	http.Redirect(rw, r, fmt.Sprintf("/plugins?success=Approved+and+loaded+%s.", urlEncode(name)), http.StatusSeeOther)
+}
+
+func (w *WebServer) handlePluginReject(rw http.ResponseWriter, r *http.Request) {
+	if w.marketplace == nil {
+		http.Redirect(rw, r, "/plugins?error=Marketplace+not+configured", http.StatusSeeOther)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(rw, r, "/plugins?error=Invalid+form+data", http.StatusSeeOther)
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	w.liveUnloadPlugin(r.Context(), name)
+	if err := w.marketplace.UninstallPlugin(name); err != nil {
+		http.Redirect(rw, r, "/plugins?error=Reject+failed:+"+urlEncode(err.Error()), http.StatusSeeOther)
+		return
+	}
+	log.Printf("Rejected capabilities for plugin %q; uninstalled", name)
+	http.Redirect(rw, r, fmt.Sprintf("/plugins?success=Rejected+and+uninstalled+%s.", urlEncode(name)), http.StatusSeeOther)
+}
+
 func (w *WebServer) handlePluginCheckUpdates(rw http.ResponseWriter, r *http.Request) {
 	if w.marketplace == nil {
 		http.Redirect(rw, r, "/plugins?error=Marketplace+not+configured", http.StatusSeeOther)
@@ -306,6 +390,13 @@ func (w *WebServer) handlePluginAddManifest(rw http.ResponseWriter, r *http.Requ
 		Name:    name,
 		Enabled: true,
 	}
+	if key := strings.TrimSpace(r.FormValue("public_key")); key != "" {
+		if _, err := marketplace.ParsePublicKey(key); err != nil {
+			http.Redirect(rw, r, "/plugins?error=Invalid+public+key:+"+urlEncode(err.Error()), http.StatusSeeOther)
+			return
+		}
+		src.PublicKeys = []string{key}
+	}
 	if err := w.marketplace.AddManifestSource(src); err != nil {
 		http.Redirect(rw, r, "/plugins?error="+urlEncode(err.Error()), http.StatusSeeOther)
 		return
@@ -344,6 +435,29 @@ func (w *WebServer) liveLoadPlugin(ctx context.Context, path, nameOverride strin
 	return nil
 }
 
+// reviewAndLoadPlugin checks the capabilities an installed plugin requests
+// against what the user approved and live-loads it only when they match.
+// A false result with a nil error means the plugin awaits approval.
+func (w *WebServer) reviewAndLoadPlugin(ctx context.Context, ip *marketplace.InstalledPlugin) (bool, error) {
+	if w.wasmLoader == nil {
+		return true, nil
+	}
+	meta, err := w.wasmLoader.InspectPlugin(ctx, ip.Path)
+	if err != nil {
+		log.Printf("WARN: inspect plugin %q failed: %v", ip.Path, err)
+		return false, err
+	}
+	approved, err := w.marketplace.ReviewCapabilities(ip.Name, meta.CapabilityList())
+	if err != nil {
+		return false, err
+	}
+	if !approved {
+		log.Printf("Plugin %q requests unapproved capabilities; awaiting approval", ip.Name)
+		return false, nil
+	}
+	return true, w.liveLoadPlugin(ctx, ip.Path, "")
+}
+
 func (w *WebServer) liveUnloadPlugin(ctx context.Context, name string) {
 	if w.wasmLoader == nil {
 		return
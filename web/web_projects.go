@@ -0,0 +1,381 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	mcp "github.com/daltoniam/switchboard"
+	"github.com/daltoniam/switchboard/project"
+	"github.com/daltoniam/switchboard/web/templates/pages"
+)
+
+// defaultProjectTemplate is preselected on the new project page.
+const defaultProjectTemplate = "backend-service"
+
+func (w *WebServer) handleProjectNew(rw http.ResponseWriter, r *http.Request) {
+	page := w.pageData(r, "New Project", "/projects/new")
+	data := w.projectNewData()
+	data.Template = defaultProjectTemplate
+	pages.ProjectNew(page, data).Render(r.Context(), rw)
+}
+
+func (w *WebServer) handleProjectNewSubmit(rw http.ResponseWriter, r *http.Request) {
+	page := w.pageData(r, "New Project", "/projects/new")
+	data := w.projectNewData()
+	if !data.Available {
+		http.Error(rw, "projects are not available", http.StatusNotFound)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(rw, r, "/projects/new?error=Invalid+form+data", http.StatusSeeOther)
+		return
+	}
+	data.Template = r.FormValue("template")
+	data.Name = strings.TrimSpace(r.FormValue("name"))
+	data.Dir = strings.TrimSpace(r.FormValue("dir"))
+
+	def, err := w.scaffoldProject(&data)
+	if err != nil {
+		data.Error = err.Error()
+		rw.WriteHeader(http.StatusBadRequest)
+		pages.ProjectNew(page, data).Render(r.Context(), rw)
+		return
+	}
+
+	if r.FormValue("action") != "create" {
+		preview, _ := json.MarshalIndent(def, "", "  ")
+		data.Preview = string(preview)
+		pages.ProjectNew(page, data).Render(r.Context(), rw)
+		return
+	}
+
+	if err := w.projects.Create(def); err != nil {
+		data.Error = err.Error()
+		rw.WriteHeader(http.StatusConflict)
+		pages.ProjectNew(page, data).Render(r.Context(), rw)
+		return
+	}
+	msg := fmt.Sprintf("Project %s created.", def.Name)
+	http.Redirect(rw, r, "/projects/"+def.Name+"?success="+url.QueryEscape(msg), http.StatusSeeOther)
+}
+
+// scaffoldProject builds a definition from the submitted form, recording
+// what was detected about the repository in data.RepoNote.
+func (w *WebServer) scaffoldProject(data *pages.ProjectNewData) (*project.Definition, error) {
+	tmpl, ok := project.FindTemplate(data.Template)
+	if !ok {
+		return nil, fmt.Errorf("choose a template")
+	}
+
+	opts := project.ScaffoldOptions{
+		Name:    data.Name,
+		Enabled: w.services.Config.EnabledIntegrations(),
+	}
+	if data.Dir != "" {
+		repo, err := project.DetectRepo(data.Dir)
+		if err != nil {
+			data.RepoNote = err.Error()
+		} else {
+			opts.Repo = repo
+			data.RepoNote = "Detected " + repo.Root
+			if repo.Branch != "" {
+				data.RepoNote += " on branch " + repo.Branch
+			}
+			if repo.RemoteURL != "" {
+				data.RepoNote += " (remote " + repo.RemoteURL + ")"
+			}
+		}
+	}
+	return project.Scaffold(tmpl, opts)
+}
+
+func (w *WebServer) projectNewData() pages.ProjectNewData {
+	data := pages.ProjectNewData{Available: w.projects != nil}
+	for _, t := range project.Templates() {
+		data.Templates = append(data.Templates, pages.ProjectTemplateEntry{
+			Name:         t.Name,
+			Title:        t.Title,
+			Description:  t.Description,
+			Integrations: t.Integrations,
+		})
+	}
+	return data
+}
+
+// maxPreviewTools caps the tool names listed in the scope preview; the
+// summary still reports the full count.
+const maxPreviewTools = 200
+
+func (w *WebServer) handleProjectsList(rw http.ResponseWriter, r *http.Request) {
+	page := w.pageData(r, "Projects", "/projects")
+	data := pages.ProjectsListData{Available: w.projects != nil}
+	if w.projects != nil {
+		names := w.projects.Names()
+		slices.Sort(names)
+		for _, name := range names {
+			def, ok := w.projects.Get(name)
+			if !ok {
+				continue
+			}
+			s := pages.ProjectSummary{
+				Name:         def.Name,
+				Repo:         def.Repo,
+				Branch:       def.Branch,
+				ContextFiles: len(project.AssembleManifest(def, w.projects.ConfigDir())),
+				Endpoint:     w.projectEndpoint(def.Name, ""),
+			}
+			if rule := def.Tools[project.DefaultServerID]; rule != nil {
+				s.AllowRules = len(rule.Allow)
+				s.DenyRules = len(rule.Deny)
+			}
+			if def.Agents != nil {
+				s.Roles = len(def.Agents.Roles)
+			}
+			data.Projects = append(data.Projects, s)
+		}
+	}
+	pages.ProjectsList(page, data).Render(r.Context(), rw)
+}
+
+func (w *WebServer) handleProjectDetail(rw http.ResponseWriter, r *http.Request) {
+	def, ok := w.lookupProject(rw, r)
+	if !ok {
+		return
+	}
+	page := w.pageData(r, def.Name, "/projects")
+	pages.ProjectDetail(page, w.projectDetailData(def)).Render(r.Context(), rw)
+}
+
+func (w *WebServer) handleProjectSave(rw http.ResponseWriter, r *http.Request) {
+	def, ok := w.lookupProject(rw, r)
+	if !ok {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Redirect(rw, r, "/projects/"+def.Name+"?error=Invalid+form+data", http.StatusSeeOther)
+		return
+	}
+
+	fail := func(msg string) {
+		data := w.projectDetailData(def)
+		data.Repo = r.FormValue("repo")
+		data.Branch = r.FormValue("branch")
+		data.Allow = r.FormValue("allow")
+		data.Deny = r.FormValue("deny")
+		data.Defaults = r.FormValue("defaults")
+		data.Error = msg
+		rw.WriteHeader(http.StatusBadRequest)
+		pages.ProjectDetail(w.pageData(r, def.Name, "/projects"), data).Render(r.Context(), rw)
+	}
+
+	rule := &project.ScopeRule{
+		Allow: splitLines(r.FormValue("allow")),
+		Deny:  splitLines(r.FormValue("deny")),
+	}
+	if raw := strings.TrimSpace(r.FormValue("defaults")); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &rule.Defaults); err != nil {
+			fail("Defaults must be a JSON object of tool globs to argument objects: " + err.Error())
+			return
+		}
+	}
+
+	updated := *def
+	updated.Repo = strings.TrimSpace(r.FormValue("repo"))
+	updated.Branch = strings.TrimSpace(r.FormValue("branch"))
+	updated.Tools = make(map[string]*project.ScopeRule, len(def.Tools)+1)
+	for id, rl := range def.Tools {
+		updated.Tools[id] = rl
+	}
+	if len(rule.Allow) == 0 && len(rule.Deny) == 0 && len(rule.Defaults) == 0 {
+		delete(updated.Tools, project.DefaultServerID)
+	} else {
+		updated.Tools[project.DefaultServerID] = rule
+	}
+	if len(updated.Tools) == 0 {
+		updated.Tools = nil
+	}
+
+	patch, err := replacementPatch(def, &updated)
+	if err != nil {
+		fail(err.Error())
+		return
+	}
+	if _, err := w.projects.Update(def.Name, patch); err != nil {
+		fail(err.Error())
+		return
+	}
+	http.Redirect(rw, r, "/projects/"+def.Name+"?success=Project+saved.", http.StatusSeeOther)
+}
+
+func (w *WebServer) handleProjectDelete(rw http.ResponseWriter, r *http.Request) {
+	def, ok := w.lookupProject(rw, r)
+	if !ok {
+		return
+	}
+	if err := w.projects.Delete(def.Name); err != nil {
+		http.Redirect(rw, r, "/projects/"+def.Name+"?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(rw, r, "/projects?success="+url.QueryEscape("Project "+def.Name+" deleted."), http.StatusSeeOther)
+}
+
+// handleProjectPreview reports which enabled tools the submitted allow and
+// deny globs permit, for the live preview on the project page.
+func (w *WebServer) handleProjectPreview(rw http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(rw, "invalid form data", http.StatusBadRequest)
+		return
+	}
+	rule := &project.ScopeRule{
+		Allow: splitLines(r.FormValue("allow")),
+		Deny:  splitLines(r.FormValue("deny")),
+	}
+	matching, total := w.previewScope(rule)
+	shown := matching[:min(len(matching), maxPreviewTools)]
+	writeJSON(rw, http.StatusOK, map[string]any{
+		"matching": len(matching),
+		"total":    total,
+		"tools":    shown,
+	})
+}
+
+func (w *WebServer) lookupProject(rw http.ResponseWriter, r *http.Request) (*project.Definition, bool) {
+	if w.projects == nil {
+		http.Error(rw, "projects are not available", http.StatusNotFound)
+		return nil, false
+	}
+	def, ok := w.projects.Get(r.PathValue("name"))
+	if !ok {
+		http.Error(rw, "project not found", http.StatusNotFound)
+		return nil, false
+	}
+	return def, true
+}
+
+func (w *WebServer) projectDetailData(def *project.Definition) pages.ProjectDetailData {
+	data := pages.ProjectDetailData{
+		Name:     def.Name,
+		Repo:     def.Repo,
+		Branch:   def.Branch,
+		Endpoint: w.projectEndpoint(def.Name, ""),
+	}
+
+	rule := def.Tools[project.DefaultServerID]
+	if rule != nil {
+		data.Allow = strings.Join(rule.Allow, "\n")
+		data.Deny = strings.Join(rule.Deny, "\n")
+		if len(rule.Defaults) > 0 {
+			b, _ := json.MarshalIndent(rule.Defaults, "", "  ")
+			data.Defaults = string(b)
+		}
+	}
+	matching, total := w.previewScope(rule)
+	data.MatchingTotal = len(matching)
+	data.ToolTotal = total
+	data.Matching = matching[:min(len(matching), maxPreviewTools)]
+
+	for _, e := range project.AssembleManifest(def, w.projects.ConfigDir()) {
+		data.Context = append(data.Context, pages.ProjectContextEntry{Path: e.Path, Source: e.Source, Size: e.Size})
+	}
+
+	if def.Agents != nil {
+		roles := make([]string, 0, len(def.Agents.Roles))
+		for name := range def.Agents.Roles {
+			roles = append(roles, name)
+		}
+		slices.Sort(roles)
+		for _, name := range roles {
+			data.Roles = append(data.Roles, pages.ProjectRoleEntry{
+				Name:        name,
+				Description: def.Agents.Roles[name].Description,
+				Endpoint:    w.projectEndpoint(def.Name, name),
+			})
+		}
+	}
+
+	cfg, _ := json.MarshalIndent(map[string]any{
+		"mcpServers": map[string]any{
+			def.Name: map[string]any{"url": data.Endpoint},
+		},
+	}, "", "  ")
+	data.ClientConfig = string(cfg)
+	return data
+}
+
+// previewScope returns the sorted names of enabled tools that rule permits,
+// and the number of enabled tools overall.
+func (w *WebServer) previewScope(rule *project.ScopeRule) (matching []string, total int) {
+	enabled := w.services.Config.EnabledIntegrations()
+	var tools []mcp.ToolDefinition
+	for _, a := range w.services.Registry.All() {
+		if slices.Contains(enabled, a.Name()) {
+			tools = append(tools, a.Tools()...)
+		}
+	}
+	for _, t := range project.FilterTools(tools, rule) {
+		matching = append(matching, string(t.Name))
+	}
+	slices.Sort(matching)
+	return matching, len(tools)
+}
+
+func (w *WebServer) projectEndpoint(name, role string) string {
+	endpoint := fmt.Sprintf("http://localhost:%d/mcp/%s", w.port, name)
+	if role != "" {
+		endpoint += "?role=" + url.QueryEscape(role)
+	}
+	return endpoint
+}
+
+func splitLines(s string) []string {
+	var out []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// replacementPatch returns the JSON merge patch (RFC 7396) that turns from
+// into to. Store.Update merges objects key by key, so fields and nested keys
+// absent from to are nulled out explicitly.
+func replacementPatch(from, to *project.Definition) (json.RawMessage, error) {
+	var a, b map[string]any
+	for _, p := range []struct {
+		def *project.Definition
+		dst *map[string]any
+	}{{from, &a}, {to, &b}} {
+		data, err := json.Marshal(p.def)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, p.dst); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(diffPatch(a, b))
+}
+
+func diffPatch(from, to map[string]any) map[string]any {
+	patch := make(map[string]any)
+	for k := range from {
+		if _, ok := to[k]; !ok {
+			patch[k] = nil
+		}
+	}
+	for k, v := range to {
+		fromObj, fromIsObj := from[k].(map[string]any)
+		toObj, toIsObj := v.(map[string]any)
+		if fromIsObj && toIsObj {
+			patch[k] = diffPatch(fromObj, toObj)
+			continue
+		}
+		patch[k] = v
+	}
+	return patch
+}
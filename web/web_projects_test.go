@@ -0,0 +1,255 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	mcp "github.com/daltoniam/switchboard"
+	"github.com/daltoniam/switchboard/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupProjectWeb(t *testing.T) (*WebServer, *project.Store) {
+	t.Helper()
+	ws, _, cfgService := setupTestWeb()
+	cfgService.cfg.Integrations["github"] = &mcp.IntegrationConfig{Enabled: true}
+	store := project.NewStore(t.TempDir())
+	WithProjectStore(store)(ws)
+	return ws, store
+}
+
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "billing-api")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("ref: refs/heads/main\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "config"), []byte("[remote \"origin\"]\n\turl = git@github.com:acme/billing-api.git\n"), 0600))
+	return dir
+}
+
+func postForm(handler http.Handler, path string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestProjectNew_Page(t *testing.T) {
+	ws, _ := setupProjectWeb(t)
+	rr := httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/projects/new", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, "Incident response")
+	assert.Contains(t, body, "Docs site")
+}
+
+func TestProjectNew_Preview(t *testing.T) {
+	ws, store := setupProjectWeb(t)
+	rr := postForm(ws.Handler(), "/projects/new", url.Values{
+		"template": {"backend-service"},
+		"dir":      {gitRepo(t)},
+		"action":   {"preview"},
+	})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, "on branch main")
+	assert.Contains(t, body, "github_*")
+	assert.Contains(t, body, "acme")
+	assert.Empty(t, store.Names(), "preview does not write")
+}
+
+func TestProjectNew_Create(t *testing.T) {
+	ws, store := setupProjectWeb(t)
+	form := url.Values{
+		"template": {"backend-service"},
+		"dir":      {gitRepo(t)},
+		"action":   {"create"},
+	}
+	rr := postForm(ws.Handler(), "/projects/new", form)
+
+	assert.Equal(t, http.StatusSeeOther, rr.Code)
+	assert.Contains(t, rr.Header().Get("Location"), "success=")
+	def, ok := store.Get("billing-api")
+	require.True(t, ok)
+	assert.Equal(t, "main", def.Branch)
+	assert.Equal(t, []string{"github_*"}, def.Tools[project.DefaultServerID].Allow)
+
+	rr = postForm(ws.Handler(), "/projects/new", form)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Contains(t, rr.Body.String(), "already exists")
+}
+
+func TestProjectNew_InvalidName(t *testing.T) {
+	ws, store := setupProjectWeb(t)
+	rr := postForm(ws.Handler(), "/projects/new", url.Values{
+		"template": {"docs-site"},
+		"name":     {"bad name"},
+		"action":   {"create"},
+	})
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "does not match pattern")
+	assert.Empty(t, store.Names())
+}
+
+func TestProjectNew_NoStore(t *testing.T) {
+	ws, _, _ := setupTestWeb()
+	rr := httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/projects/new", nil))
+	assert.Contains(t, rr.Body.String(), "not available")
+
+	rr = postForm(ws.Handler(), "/projects/new", url.Values{"template": {"docs-site"}})
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func createTestProject(t *testing.T, store *project.Store) {
+	t.Helper()
+	require.NoError(t, store.Create(&project.Definition{
+		Version: "1",
+		Name:    "billing",
+		Branch:  "main",
+		Tools: map[string]*project.ScopeRule{
+			project.DefaultServerID: {
+				Allow:    []string{"testint_*"},
+				Deny:     []string{"testint_delete_*"},
+				Defaults: map[string]map[string]any{"testint_*": {"org": "acme", "team": "core"}},
+			},
+			"other-server": {Allow: []string{"x_*"}},
+		},
+		Agents: &project.AgentsConfig{Roles: map[string]*project.RoleDefinition{
+			"reviewer": {Description: "Reviews changes."},
+		}},
+	}))
+}
+
+func TestProjectsList(t *testing.T) {
+	ws, store := setupProjectWeb(t)
+	createTestProject(t, store)
+
+	rr := httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/projects", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, `href="/projects/billing"`)
+	assert.Contains(t, body, "http://localhost:3847/mcp/billing")
+	assert.Contains(t, body, "1 allow · 1 deny")
+}
+
+func TestProjectDetail(t *testing.T) {
+	ws, store := setupProjectWeb(t)
+	createTestProject(t, store)
+
+	rr := httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/projects/billing", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, "testint_list", "preview lists matching tools")
+	assert.Contains(t, body, "1 of 1 tools match")
+	assert.Contains(t, body, "mcpServers")
+	assert.Contains(t, body, "/mcp/billing?role=reviewer")
+
+	rr = httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/projects/missing", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestProjectSave_ReplacesScope(t *testing.T) {
+	ws, store := setupProjectWeb(t)
+	createTestProject(t, store)
+
+	rr := postForm(ws.Handler(), "/projects/billing", url.Values{
+		"repo":     {""},
+		"branch":   {"release"},
+		"allow":    {"testint_list\n  testint_get_* \n"},
+		"deny":     {""},
+		"defaults": {`{"testint_*": {"org": "globex"}}`},
+	})
+	assert.Equal(t, http.StatusSeeOther, rr.Code)
+
+	def, ok := store.Get("billing")
+	require.True(t, ok)
+	assert.Equal(t, "release", def.Branch)
+	rule := def.Tools[project.DefaultServerID]
+	assert.Equal(t, []string{"testint_list", "testint_get_*"}, rule.Allow)
+	assert.Empty(t, rule.Deny)
+	assert.Equal(t, map[string]any{"org": "globex"}, rule.Defaults["testint_*"], "removed keys are dropped, not merged")
+	assert.Equal(t, []string{"x_*"}, def.Tools["other-server"].Allow, "other servers are untouched")
+	assert.NotNil(t, def.Agents)
+}
+
+func TestProjectSave_ClearScope(t *testing.T) {
+	ws, store := setupProjectWeb(t)
+	createTestProject(t, store)
+
+	rr := postForm(ws.Handler(), "/projects/billing", url.Values{"branch": {"main"}})
+	assert.Equal(t, http.StatusSeeOther, rr.Code)
+
+	def, _ := store.Get("billing")
+	assert.NotContains(t, def.Tools, project.DefaultServerID)
+}
+
+func TestProjectSave_InvalidDefaults(t *testing.T) {
+	ws, store := setupProjectWeb(t)
+	createTestProject(t, store)
+
+	rr := postForm(ws.Handler(), "/projects/billing", url.Values{
+		"allow":    {"testint_*"},
+		"defaults": {`["not", "an", "object"]`},
+	})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Defaults must be a JSON object")
+
+	def, _ := store.Get("billing")
+	assert.Equal(t, "acme", def.Tools[project.DefaultServerID].Defaults["testint_*"]["org"])
+}
+
+func TestProjectDelete(t *testing.T) {
+	ws, store := setupProjectWeb(t)
+	createTestProject(t, store)
+
+	rr := postForm(ws.Handler(), "/projects/billing/delete", nil)
+	assert.Equal(t, http.StatusSeeOther, rr.Code)
+	assert.Contains(t, rr.Header().Get("Location"), "/projects?success=")
+	_, ok := store.Get("billing")
+	assert.False(t, ok)
+}
+
+func TestProjectPreviewAPI(t *testing.T) {
+	ws, _ := setupProjectWeb(t)
+
+	rr := postForm(ws.Handler(), "/api/projects/preview", url.Values{"deny": {"testint_*"}})
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp struct {
+		Matching int      `json:"matching"`
+		Total    int      `json:"total"`
+		Tools    []string `json:"tools"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, 0, resp.Matching)
+	assert.Equal(t, 1, resp.Total)
+
+	rr = postForm(ws.Handler(), "/api/projects/preview", url.Values{"allow": {"testint_l*"}})
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, []string{"testint_list"}, resp.Tools)
+}
+
+func TestDiffPatch(t *testing.T) {
+	from := map[string]any{"a": 1.0, "b": map[string]any{"x": 1.0, "y": 2.0}, "c": "gone"}
+	to := map[string]any{"a": 2.0, "b": map[string]any{"x": 1.0}}
+	assert.Equal(t, map[string]any{
+		"a": 2.0,
+		"b": map[string]any{"x": 1.0, "y": nil},
+		"c": nil,
+	}, diffPatch(from, to))
+}
@@ -3,14 +3,19 @@ package web
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	mcp "github.com/daltoniam/switchboard"
 	"github.com/daltoniam/switchboard/googleoauth"
@@ -339,6 +344,165 @@ func TestMetricsAPI(t *testing.T) {
 	assert.Contains(t, body, "integrations")
 }
 
+func TestMetricsAPI_IncludesCompactionAnalytics(t *testing.T) {
+	ws, _, _ := setupTestWeb()
+	ws.services.Metrics = mcp.NewMetrics()
+	ws.services.Metrics.RecordFieldAttribution("testint_list", map[string]int{"id": 5, "body": 100}, map[string]int{"id": 5})
+	ws.services.Metrics.RecordOverCompaction("testint_list", "testint_get_item", mcp.OverCompactionGet)
+	handler := ws.Handler()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/metrics", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var body struct {
+		CompactionTools map[string]mcp.CompactionToolSnapshot `json:"compaction_tools"`
+		OverCompactions []mcp.OverCompactionSnapshot          `json:"over_compactions"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	require.Contains(t, body.CompactionTools, "testint_list")
+	assert.Equal(t, "body", body.CompactionTools["testint_list"].Fields[0].Path)
+	assert.Equal(t, int64(100), body.CompactionTools["testint_list"].Fields[0].BytesDropped)
+	require.Len(t, body.OverCompactions, 1)
+	assert.Equal(t, mcp.ToolName("testint_get_item"), body.OverCompactions[0].FollowUp)
+}
+
+func TestCompactionPage(t *testing.T) {
+	ws, _, _ := setupTestWeb()
+	ws.services.Metrics = mcp.NewMetrics()
+	ws.services.Metrics.RecordFieldAttribution("testint_list", map[string]int{"body": 100}, nil)
+	handler := ws.Handler()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/compaction", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "testint_list")
+	assert.Contains(t, rr.Body.String(), `href="/compaction"`, "nav links the page")
+}
+
+func TestMetricsHistoryAPI(t *testing.T) {
+	ws, _, _ := setupTestWeb()
+	ws.services.Metrics = mcp.NewMetrics()
+	ws.services.Metrics.RecordExecution("testint", "testint_list", 20*time.Millisecond, true, 0)
+	ws.services.Metrics.RecordExecution("other", "other_get", 20*time.Millisecond, false, 0)
+	handler := ws.Handler()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/metrics/history?resolution=minute&window=5m&integration=testint", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var body mcp.MetricsHistory
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, mcp.HistoryMinute, body.Resolution)
+	assert.Equal(t, mcp.IntegrationName("testint"), body.Integration)
+	require.Len(t, body.Points, 5)
+	var calls, errs int64
+	for _, p := range body.Points {
+		calls += p.Calls
+		errs += p.Errors
+	}
+	assert.Equal(t, int64(1), calls)
+	assert.Equal(t, int64(1), errs)
+	assert.ElementsMatch(t, []mcp.IntegrationName{"testint", "other"}, body.Integrations)
+	assert.Equal(t, []mcp.ToolName{"testint_list"}, body.Tools)
+}
+
+func TestDashboard_SlowCalls(t *testing.T) {
+	ws, _, _ := setupTestWeb()
+	ws.services.Metrics = mcp.NewMetrics()
+	ws.services.Metrics.RecordExecution("testint", "testint_search", 3*time.Second, false, 0)
+	ws.services.Metrics.RecordSlowCall("testint", "testint_search", map[string]any{"query": "needle", "password": "hunter2"}, 3*time.Second, false, 0)
+
+	rr := httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	body := rr.Body.String()
+	assert.Contains(t, body, "Slow Calls (last hour)")
+	assert.Contains(t, body, "testint_search")
+	assert.Contains(t, body, "query")
+	assert.NotContains(t, body, "needle", "argument values are not kept")
+	assert.NotContains(t, body, "hunter2")
+	assert.Contains(t, body, "<th>p95</th>")
+}
+
+func TestMetricsHistoryAPI_BadQuery(t *testing.T) {
+	ws, _, _ := setupTestWeb()
+	handler := ws.Handler()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/metrics/history", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	ws.services.Metrics = mcp.NewMetrics()
+	for _, query := range []string{"window=soon", "resolution=day", "window=-1h"} {
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/metrics/history?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		assert.Contains(t, rr.Body.String(), "error", query)
+	}
+}
+
+func TestDashboard_HistoryCharts(t *testing.T) {
+	ws, _, _ := setupTestWeb()
+	ws.services.Metrics = mcp.NewMetrics()
+	handler := ws.Handler()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	assert.NotContains(t, rr.Body.String(), "Last 24 Hours", "no charts before any calls")
+
+	ws.services.Metrics.RecordExecution("testint", "testint_list", 20*time.Millisecond, true, 0)
+	ws.services.Metrics.RecordExecution("other", "other_get", 20*time.Millisecond, false, 0)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/?integration=testint", nil))
+	body := rr.Body.String()
+	assert.Contains(t, body, "Last 24 Hours")
+	assert.Contains(t, body, "history-bar-err")
+	assert.Contains(t, body, "1 calls · 1 errors (100.0%)", "summary covers only the selected integration")
+	assert.Contains(t, body, `<option value="testint" selected>`)
+}
+
+func postSettings(t *testing.T, ws *WebServer, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/settings", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, req)
+	require.Equal(t, http.StatusSeeOther, rr.Code)
+	return rr
+}
+
+func TestSettingsSave_Tokenizer(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SWITCHBOARD_TOKENIZER_DIR", dir)
+	t.Setenv("HOME", t.TempDir())
+	ws, _, cfgService := setupTestWeb()
+	ws.services.Metrics = mcp.NewMetrics()
+
+	rr := postSettings(t, ws, url.Values{"tokenizer": {"cl100k_base"}})
+	assert.Contains(t, rr.Header().Get("Location"), "error=", "a family without a vocabulary is rejected")
+	assert.Empty(t, cfgService.cfg.Tokenizer)
+
+	var ranks strings.Builder
+	for i := range 256 {
+		fmt.Fprintf(&ranks, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(i)}), i)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cl100k_base.tiktoken"), []byte(ranks.String()), 0o600))
+
+	rr = postSettings(t, ws, url.Values{"tokenizer": {"cl100k_base"}})
+	assert.Contains(t, rr.Header().Get("Location"), "success=")
+	assert.Equal(t, "cl100k_base", cfgService.cfg.Tokenizer)
+	tm := ws.services.Metrics.Snapshot().TokenMeasurement
+	require.NotNil(t, tm, "the tokenizer applies without a restart")
+	assert.Equal(t, "cl100k_base", tm.Tokenizer)
+
+	rr = postSettings(t, ws, url.Values{"tokenizer": {"estimate"}})
+	assert.Contains(t, rr.Header().Get("Location"), "success=")
+	assert.Empty(t, cfgService.cfg.Tokenizer)
+	assert.Nil(t, ws.services.Metrics.Snapshot().TokenMeasurement)
+}
+
 func TestPluginLoadPath_LoadError(t *testing.T) {
 	ws, _, cfgService := setupTestWeb()
 	ws.wasmLoader = wasmmod.NewLoader(nil, nil, cfgService)
@@ -404,10 +568,14 @@ func TestPluginLoadPath_InvalidExtension(t *testing.T) {
 // install/upload/update handlers without spinning up a real wazero runtime.
 type errLoader struct {
 	loadErr error
+	meta    *marketplace.PluginMetadata
 }
 
 func (e *errLoader) LoadPlugin(_ context.Context, _, _ string) error { return e.loadErr }
 func (e *errLoader) UnloadPlugin(_ context.Context, _ string) error  { return nil }
+func (e *errLoader) InspectPlugin(_ context.Context, _ string) (*marketplace.PluginMetadata, error) {
+	return e.meta, nil
+}
 
 // uploadPlugin builds a multipart upload request for POST /plugins/upload.
 func uploadPlugin(t *testing.T, name string, body []byte) *http.Request {
@@ -900,3 +1068,82 @@ func TestGoogleOAuthCallback_NoCodeRedirectsWithError(t *testing.T) {
 	assert.Contains(t, rr.Header().Get("Location"), "/integrations/google/setup")
 	assert.Contains(t, rr.Header().Get("Location"), "error=")
 }
+
+// TestPluginUpload_CapabilitiesNeedApproval verifies a freshly uploaded
+// plugin that requests network access is not loaded until the user approves
+// it, and that approving loads it.
+func TestPluginUpload_CapabilitiesNeedApproval(t *testing.T) {
+	ws, _, _ := setupTestWeb()
+	ws.marketplace = marketplace.NewManager(marketplace.Config{}, t.TempDir(), func(_ marketplace.Config) error { return nil })
+	loader := &errLoader{meta: &marketplace.PluginMetadata{Capabilities: []string{"http"}}}
+	ws.wasmLoader = loader
+
+	rr := httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, uploadPlugin(t, "netty", []byte("fake wasm")))
+	assert.Equal(t, http.StatusSeeOther, rr.Code)
+	assert.Contains(t, rr.Header().Get("Location"), "Approve+its+requested+capabilities")
+
+	installed := ws.marketplace.InstalledPlugins()
+	require.Len(t, installed, 1)
+	assert.True(t, installed[0].NeedsApproval)
+	assert.Equal(t, []string{"network: any public host (http,https)"}, installed[0].PendingCapabilities)
+
+	rr = httptest.NewRecorder()
+	page := httptest.NewRequest("GET", "/plugins", nil)
+	ws.Handler().ServeHTTP(rr, page)
+	assert.Contains(t, rr.Body.String(), "Approval required")
+	assert.Contains(t, rr.Body.String(), "network: any public host")
+
+	form := strings.NewReader("name=netty")
+	req := httptest.NewRequest("POST", "/plugins/approve", form)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr = httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, req)
+	assert.Contains(t, rr.Header().Get("Location"), "success=Approved+and+loaded+netty.")
+	assert.False(t, ws.marketplace.InstalledPlugins()[0].NeedsApproval)
+}
+
+// TestPluginApprove_UnsignedRequiresOverride verifies an unsigned marketplace
+// plugin is only approved when the user also ticks the trust override.
+func TestPluginApprove_UnsignedRequiresOverride(t *testing.T) {
+	ws, _, _ := setupTestWeb()
+	ws.marketplace = marketplace.NewManager(marketplace.Config{InstalledPlugins: []marketplace.InstalledPlugin{
+		{Name: "netty", Path: "/tmp/netty.wasm", Trust: marketplace.TrustUnsigned, NeedsApproval: true},
+	}}, t.TempDir(), func(_ marketplace.Config) error { return nil })
+	ws.wasmLoader = &errLoader{}
+
+	rr := httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/plugins", nil))
+	assert.Contains(t, rr.Body.String(), "Run this unsigned plugin anyway")
+
+	approve := func(body string) string {
+		req := httptest.NewRequest("POST", "/plugins/approve", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		ws.Handler().ServeHTTP(rr, req)
+		return rr.Header().Get("Location")
+	}
+	assert.Contains(t, approve("name=netty"), "error=Approve+failed")
+	assert.True(t, ws.marketplace.InstalledPlugins()[0].NeedsApproval)
+
+	assert.Contains(t, approve("name=netty&trust_unsigned=true"), "success=Approved+and+loaded+netty.")
+	ip := ws.marketplace.InstalledPlugins()[0]
+	assert.True(t, ip.TrustOverride)
+	assert.False(t, ip.NeedsApproval)
+}
+
+func TestSettingsSave_MetricsHistoryDays(t *testing.T) {
+	ws, _, cfgService := setupTestWeb()
+	ws.services.Metrics = mcp.NewMetrics()
+
+	postSettings(t, ws, url.Values{"metrics_history_days": {"30"}})
+	assert.Equal(t, 30, cfgService.cfg.MetricsHistoryDays)
+	assert.Equal(t, 30*24*time.Hour, ws.services.Metrics.HistoryRetention(), "retention applies without a restart")
+
+	postSettings(t, ws, url.Values{"metrics_history_days": {"1000"}})
+	assert.Equal(t, 90, cfgService.cfg.MetricsHistoryDays, "capped at the maximum")
+
+	rr := httptest.NewRecorder()
+	ws.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/settings", nil))
+	assert.Contains(t, rr.Body.String(), `name="metrics_history_days" class="form-input" value="90"`)
+}